@@ -6,9 +6,18 @@ import (
 	"fyne.io/fyne/v2"
 )
 
-//go:embed clock.png clock.ico
+//go:embed clock.png clock.ico clock_*.png
 var assetsFS embed.FS
 
+// TrayState identifies which tray icon variant to show
+type TrayState string
+
+const (
+	TrayStateStopped TrayState = "stopped"
+	TrayStateRunning TrayState = "running"
+	TrayStateIdle    TrayState = "idle"
+)
+
 // GetClockPNG returns the clock.png resource for Fyne
 func GetClockResource() fyne.Resource {
 	data, err := assetsFS.ReadFile("clock.png")
@@ -26,3 +35,14 @@ func GetClockIconResource() fyne.Resource {
 	}
 	return fyne.NewStaticResource("clock.ico", data)
 }
+
+// GetStateIcon returns the clock icon variant for the given tray state,
+// falling back to the base clock icon if the variant is missing
+func GetStateIcon(state TrayState) fyne.Resource {
+	name := "clock_" + string(state) + ".png"
+	data, err := assetsFS.ReadFile(name)
+	if err != nil {
+		return GetClockResource()
+	}
+	return fyne.NewStaticResource(name, data)
+}
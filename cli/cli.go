@@ -0,0 +1,347 @@
+// Package cli implements a headless command-line mode for environments where
+// the GUI can't run.
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/time-tracker/v2/core"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/types"
+	"github.com/time-tracker/v2/services"
+)
+
+const (
+	sessionFileName = "cli_session.json"
+	// stopFileName is created by "stop" to ask the "start" process to stop
+	stopFileName = "cli_stop"
+	// stopPollInterval is how often "start" checks for a stop request
+	stopPollInterval = time.Second
+)
+
+var errNoSession = errors.New("no CLI session is running")
+
+const usage = `Usage: go-tracker -cli <command> [args]
+
+Commands:
+  login <email>    Log in and store the token (password is read from stdin)
+  tasks            List your tasks
+  start <taskID>   Track a task in the foreground until interrupted (Ctrl+C)
+  stop             Stop the session started by "start"
+  status           Show the current CLI session
+  import <file>    Import the previous version's history (SQLite database or CSV)`
+
+// sessionState is written while "start" is running so that "status" and
+// "stop" can find the tracking process
+type sessionState struct {
+	PID       int       `json:"pid"`
+	TaskID    int       `json:"task_id"`
+	TaskName  string    `json:"task_name"`
+	StartTime time.Time `json:"start_time"`
+}
+
+// Run executes a CLI command and returns the process exit code
+func Run(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	var err error
+	switch args[0] {
+	case "login":
+		err = login(args[1:])
+	case "tasks":
+		err = listTasks()
+	case "start":
+		err = start(args[1:])
+	case "stop":
+		err = stop()
+	case "status":
+		err = status()
+	case "import":
+		err = importLegacy(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func login(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: login <email>")
+	}
+	password, err := readPassword("Password: ")
+	if err != nil {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+
+	user, err := services.NewAuthService().Login(args[0], password)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.Token == "" {
+		return errors.New("invalid email or password")
+	}
+	fmt.Printf("Logged in as %s\n", user.Username)
+	return nil
+}
+
+func listTasks() error {
+	tasks, err := core.NewTaskManager().GetTasks()
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		fmt.Println("No tasks found")
+		return nil
+	}
+	for _, task := range tasks {
+		fmt.Printf("%6d  %s (Project: %s)\n", task.ID, task.Name, task.Project.Name)
+	}
+	return nil
+}
+
+func start(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: start <taskID>")
+	}
+	taskID, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid task ID %q", args[0])
+	}
+	if state, err := activeSession(); err == nil {
+		return fmt.Errorf("already tracking %s (pid %d)", state.TaskName, state.PID)
+	}
+
+	taskManager := core.NewTaskManager()
+	tasks, err := taskManager.GetTasks()
+	if err != nil {
+		return err
+	}
+	var task *types.Task
+	for i := range tasks {
+		if tasks[i].ID == taskID {
+			task = &tasks[i]
+			break
+		}
+	}
+	if task == nil {
+		return fmt.Errorf("task %d not found", taskID)
+	}
+
+	screenshotDir, err := config.ScreenshotDir()
+	if err != nil {
+		return err
+	}
+	session := core.NewSession(taskManager, core.NewActivityTracker(screenshotDir, taskManager))
+
+	if err := session.Start(*task); err != nil {
+		return err
+	}
+	if err := session.OpenWorkReport(core.StartDescription(*task)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to open work report: %v\n", err)
+	}
+	if err := writeSessionState(sessionState{
+		PID:       os.Getpid(),
+		TaskID:    task.ID,
+		TaskName:  task.Name,
+		StartTime: time.Now(),
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	defer removeSessionState()
+	takeStopRequest() // Left by a "stop" that raced a previous session's exit
+
+	fmt.Printf("Tracking %s. Press Ctrl+C to stop.\n", task.Name)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	ticker := time.NewTicker(stopPollInterval)
+	defer ticker.Stop()
+	waitForStop(signals, ticker.C)
+
+	elapsed := session.Elapsed()
+	stopErr := session.Stop()
+	if err := session.CloseWorkReport(core.StopDescription(*task)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to close work report: %v\n", err)
+	}
+	if stopErr != nil {
+		return stopErr
+	}
+	fmt.Printf("Stopped %s after %s\n", task.Name, elapsed.Round(time.Second))
+	return nil
+}
+
+// waitForStop blocks until the process is interrupted or "stop" is run. A
+// stop file is used rather than a signal since Windows can't deliver an
+// interrupt to another process.
+func waitForStop(signals <-chan os.Signal, poll <-chan time.Time) {
+	for {
+		select {
+		case <-signals:
+			return
+		case <-poll:
+			if takeStopRequest() {
+				return
+			}
+		}
+	}
+}
+
+func stop() error {
+	state, err := activeSession()
+	if err != nil {
+		return err
+	}
+	path, err := stopRequestPath()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		return fmt.Errorf("failed to request stop: %w", err)
+	}
+	fmt.Printf("Stopping %s\n", state.TaskName)
+	return nil
+}
+
+func status() error {
+	state, err := activeSession()
+	if err != nil {
+		fmt.Println("Not tracking")
+		return nil
+	}
+	fmt.Printf("Tracking %s (ID: %d) for %s (pid %d)\n",
+		state.TaskName, state.TaskID, time.Since(state.StartTime).Round(time.Second), state.PID)
+	return nil
+}
+
+func importLegacy(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: import <file>")
+	}
+	db, err := core.NewDatabase("time_tracker.db")
+	if err != nil {
+		return err
+	}
+	if err := db.Connect(); err != nil {
+		return err
+	}
+	if err := db.Unavailable(); err != nil {
+		return fmt.Errorf("local database unavailable: %w", err)
+	}
+	result, err := db.ImportLegacy(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d activities, skipped %d (%d imported before, %d unreadable)\n",
+		result.Imported, result.Skipped(), result.Duplicates, result.Invalid)
+	return nil
+}
+
+func sessionStatePath() (string, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, sessionFileName), nil
+}
+
+// activeSession returns the running "start" session. A session file left by
+// a process that no longer runs, e.g. after a crash, is removed.
+func activeSession() (*sessionState, error) {
+	state, err := readSessionState()
+	if err != nil {
+		return nil, errNoSession
+	}
+	if !processAlive(state.PID) {
+		removeSessionState()
+		return nil, errNoSession
+	}
+	return state, nil
+}
+
+func readSessionState() (*sessionState, error) {
+	path, err := sessionStatePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var state sessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func writeSessionState(state sessionState) error {
+	path, err := sessionStatePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write session file %s: %w", path, err)
+	}
+	return nil
+}
+
+func removeSessionState() {
+	if path, err := sessionStatePath(); err == nil {
+		os.Remove(path)
+	}
+}
+
+func stopRequestPath() (string, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, stopFileName), nil
+}
+
+// takeStopRequest reports whether "stop" was run, consuming the request
+func takeStopRequest() bool {
+	path, err := stopRequestPath()
+	if err != nil {
+		return false
+	}
+	return os.Remove(path) == nil
+}
+
+// readPassword prompts for a password without echoing it when stdin is a
+// terminal. Piped input is read as is, without its line ending.
+func readPassword(prompt string) (string, error) {
+	fmt.Print(prompt)
+	if restore, err := disableEcho(int(os.Stdin.Fd())); err == nil {
+		defer fmt.Println() // The newline typed wasn't echoed
+		defer restore()
+	}
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		return "", err
+	}
+	// Only the line ending is dropped; spaces may be part of the password
+	return strings.TrimRight(password, "\r\n"), nil
+}
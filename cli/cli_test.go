@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+// exitedPID returns the PID of a process that has run and been reaped
+func exitedPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("failed to run helper process: %v", err)
+	}
+	return cmd.Process.Pid
+}
+
+func TestActiveSession(t *testing.T) {
+	tests := []struct {
+		name      string
+		pid       func(t *testing.T) int
+		wantFound bool
+	}{
+		{"running process", func(*testing.T) int { return os.Getpid() }, true},
+		{"exited process", exitedPID, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(config.HomeEnv, t.TempDir())
+			if err := writeSessionState(sessionState{PID: tt.pid(t), TaskID: 7, TaskName: "Task"}); err != nil {
+				t.Fatal(err)
+			}
+
+			state, err := activeSession()
+			if tt.wantFound {
+				if err != nil || state.TaskID != 7 {
+					t.Fatalf("activeSession() = %+v, %v, want task 7", state, err)
+				}
+				return
+			}
+			if !errors.Is(err, errNoSession) {
+				t.Fatalf("activeSession() error = %v, want %v", err, errNoSession)
+			}
+			if _, err := readSessionState(); err == nil {
+				t.Error("stale session file was not removed")
+			}
+		})
+	}
+}
+
+func TestActiveSessionWithoutFile(t *testing.T) {
+	t.Setenv(config.HomeEnv, t.TempDir())
+	if _, err := activeSession(); !errors.Is(err, errNoSession) {
+		t.Fatalf("activeSession() error = %v, want %v", err, errNoSession)
+	}
+}
+
+func TestStopRequestEndsWait(t *testing.T) {
+	t.Setenv(config.HomeEnv, t.TempDir())
+	if err := writeSessionState(sessionState{PID: os.Getpid(), TaskName: "Task"}); err != nil {
+		t.Fatal(err)
+	}
+
+	poll := make(chan time.Time)
+	done := make(chan struct{})
+	go func() {
+		waitForStop(make(chan os.Signal), poll)
+		close(done)
+	}()
+
+	poll <- time.Now() // No request yet
+	select {
+	case <-done:
+		t.Fatal("waitForStop returned without a stop request")
+	default:
+	}
+
+	if err := stop(); err != nil {
+		t.Fatalf("stop() error = %v", err)
+	}
+	select {
+	case poll <- time.Now():
+	case <-done: // The first poll already saw the request
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("waitForStop didn't return after stop")
+	}
+	if takeStopRequest() {
+		t.Error("stop request was not consumed")
+	}
+}
+
+func TestStopWithoutSession(t *testing.T) {
+	t.Setenv(config.HomeEnv, t.TempDir())
+	if err := stop(); !errors.Is(err, errNoSession) {
+		t.Fatalf("stop() error = %v, want %v", err, errNoSession)
+	}
+}
+
+func TestReadPassword(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"line ending", "secret\n", "secret"},
+		{"windows line ending", "secret\r\n", "secret"},
+		{"spaces kept", "  secret pass \n", "  secret pass "},
+		{"no line ending", "secret", "secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, w, err := os.Pipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			w.WriteString(tt.input)
+			w.Close()
+			stdin := os.Stdin
+			os.Stdin = r
+			defer func() { os.Stdin = stdin }()
+
+			got, err := readPassword("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("readPassword() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+
+package cli
+
+import "errors"
+
+// disableEcho isn't supported on this platform, so input is echoed
+func disableEcho(fd int) (func(), error) {
+	return nil, errors.New("disabling echo is not supported on this platform")
+}
@@ -0,0 +1,21 @@
+//go:build linux || darwin
+
+package cli
+
+import "golang.org/x/sys/unix"
+
+// disableEcho stops the terminal on fd from echoing input and returns a
+// function restoring it. It fails if fd isn't a terminal.
+func disableEcho(fd int) (func(), error) {
+	termios, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil, err
+	}
+	saved := *termios
+	termios.Lflag &^= unix.ECHO
+	termios.Lflag |= unix.ICANON | unix.ISIG
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, termios); err != nil {
+		return nil, err
+	}
+	return func() { unix.IoctlSetTermios(fd, ioctlSetTermios, &saved) }, nil
+}
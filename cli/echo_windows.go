@@ -0,0 +1,17 @@
+package cli
+
+import "golang.org/x/sys/windows"
+
+// disableEcho stops the console on fd from echoing input and returns a
+// function restoring it. It fails if fd isn't a console.
+func disableEcho(fd int) (func(), error) {
+	handle := windows.Handle(fd)
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		return nil, err
+	}
+	if err := windows.SetConsoleMode(handle, mode&^windows.ENABLE_ECHO_INPUT|windows.ENABLE_LINE_INPUT); err != nil {
+		return nil, err
+	}
+	return func() { windows.SetConsoleMode(handle, mode) }, nil
+}
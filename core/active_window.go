@@ -0,0 +1,139 @@
+package core
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/time-tracker/v2/internal/logging"
+)
+
+// ActiveWindow identifies the application and window in the foreground.
+//
+// Querying it needs, per OS:
+//   - macOS: Automation permission for System Events (prompted on first use)
+//   - Windows: nothing extra
+//   - Linux: an X11 session with the xprop tool installed, and xwininfo to
+//     capture only the active window; Wayland is not supported
+type ActiveWindow struct {
+	App   string
+	Title string
+}
+
+// errNoActiveWindow is returned for the bounds of the active window when no window has focus
+var errNoActiveWindow = errors.New("no window has focus")
+
+// GetActiveWindow returns the foreground window
+func GetActiveWindow() (ActiveWindow, error) {
+	window, err := activeWindow()
+	window.App = strings.TrimSpace(window.App)
+	window.Title = strings.TrimSpace(window.Title)
+	return window, err
+}
+
+// focusSampleInterval is how often the foreground application is sampled
+const focusSampleInterval = 30 * time.Second
+
+// maxTopApps bounds how many applications are kept per session
+const maxTopApps = 5
+
+// focusTracker counts which application is in the foreground while tracking
+type focusTracker struct {
+	mu      sync.Mutex
+	samples map[string]int
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	failed  bool // Logged a query failure already this session
+}
+
+func newFocusTracker() *focusTracker {
+	return &focusTracker{samples: make(map[string]int)}
+}
+
+// start samples the foreground application until stop is called
+func (ft *focusTracker) start() {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	if ft.stop != nil {
+		return
+	}
+	ft.stop = make(chan struct{})
+	ft.wg.Add(1)
+	go ft.run(ft.stop)
+}
+
+func (ft *focusTracker) run(stop chan struct{}) {
+	defer ft.wg.Done()
+	defer logging.Recover("focus tracker", nil)
+
+	ticker := time.NewTicker(focusSampleInterval)
+	defer ticker.Stop()
+	for {
+		ft.sample()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sample records the current foreground application
+func (ft *focusTracker) sample() {
+	window, err := GetActiveWindow()
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	if err != nil {
+		if !ft.failed {
+			log.Printf("Failed to get active window: %v", err)
+			ft.failed = true
+		}
+		return
+	}
+	if window.App != "" {
+		ft.samples[window.App]++
+	}
+}
+
+// stopSampling stops sampling, keeping the samples so far
+func (ft *focusTracker) stopSampling() {
+	ft.mu.Lock()
+	stop := ft.stop
+	ft.stop = nil
+	ft.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		ft.wg.Wait()
+	}
+}
+
+// reset clears the samples for a new session
+func (ft *focusTracker) reset() {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	ft.samples = make(map[string]int)
+	ft.failed = false
+}
+
+// topApps returns the most sampled applications, most used first
+func (ft *focusTracker) topApps() []string {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	apps := make([]string, 0, len(ft.samples))
+	for app := range ft.samples {
+		apps = append(apps, app)
+	}
+	sort.Slice(apps, func(i, j int) bool {
+		if ft.samples[apps[i]] != ft.samples[apps[j]] {
+			return ft.samples[apps[i]] > ft.samples[apps[j]]
+		}
+		return apps[i] < apps[j]
+	})
+	if len(apps) > maxTopApps {
+		apps = apps[:maxTopApps]
+	}
+	return apps
+}
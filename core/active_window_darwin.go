@@ -0,0 +1,52 @@
+package core
+
+import (
+	"fmt"
+	"image"
+	"os/exec"
+	"strings"
+)
+
+// frontmostScript prints the frontmost application and its front window title on two lines
+const frontmostScript = `tell application "System Events"
+	set frontApp to first application process whose frontmost is true
+	set appName to name of frontApp
+	set windowTitle to ""
+	try
+		set windowTitle to name of front window of frontApp
+	end try
+end tell
+return appName & linefeed & windowTitle`
+
+// activeWindow asks System Events for the frontmost application. macOS
+// prompts for Automation permission the first time.
+func activeWindow() (ActiveWindow, error) {
+	out, err := exec.Command("osascript", "-e", frontmostScript).Output()
+	if err != nil {
+		return ActiveWindow{}, fmt.Errorf("failed to query frontmost application (check Automation permission): %w", err)
+	}
+	app, title, _ := strings.Cut(strings.TrimRight(string(out), "\n"), "\n")
+	return ActiveWindow{App: app, Title: title}, nil
+}
+
+// frontWindowBoundsScript prints the frontmost window's position and size in points
+const frontWindowBoundsScript = `tell application "System Events"
+	set frontApp to first application process whose frontmost is true
+	set {x, y} to position of front window of frontApp
+	set {w, h} to size of front window of frontApp
+end tell
+return (x as text) & " " & (y as text) & " " & (w as text) & " " & (h as text)`
+
+// activeWindowBounds asks System Events where the frontmost window is, in the
+// points the screenshot package captures in
+func activeWindowBounds() (image.Rectangle, error) {
+	out, err := exec.Command("osascript", "-e", frontWindowBoundsScript).Output()
+	if err != nil {
+		return image.Rectangle{}, fmt.Errorf("failed to query front window bounds (check Automation permission): %w", err)
+	}
+	var x, y, w, h int
+	if _, err := fmt.Sscanf(strings.TrimSpace(string(out)), "%d %d %d %d", &x, &y, &w, &h); err != nil {
+		return image.Rectangle{}, fmt.Errorf("unexpected front window bounds %q: %w", strings.TrimSpace(string(out)), err)
+	}
+	return image.Rect(x, y, x+w, y+h), nil
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"fmt"
+	"image"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	activeWindowIDPattern = regexp.MustCompile(`window id # (0x[0-9a-fA-F]+)`)
+	quotedValuePattern    = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+)
+
+// activeWindowID asks the X server, via xprop, for the focused window's ID,
+// or "" if no window has focus
+func activeWindowID() (string, error) {
+	out, err := exec.Command("xprop", "-root", "_NET_ACTIVE_WINDOW").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to query active window (is xprop installed?): %w", err)
+	}
+	match := activeWindowIDPattern.FindStringSubmatch(string(out))
+	if match == nil || match[1] == "0x0" {
+		return "", nil
+	}
+	return match[1], nil
+}
+
+// activeWindow asks the X server, via xprop, for the focused window's class and title
+func activeWindow() (ActiveWindow, error) {
+	id, err := activeWindowID()
+	if err != nil || id == "" {
+		return ActiveWindow{}, err
+	}
+
+	out, err := exec.Command("xprop", "-id", id, "WM_CLASS", "_NET_WM_NAME").Output()
+	if err != nil {
+		return ActiveWindow{}, fmt.Errorf("failed to query window %s: %w", id, err)
+	}
+	var window ActiveWindow
+	for _, line := range strings.Split(string(out), "\n") {
+		values := quotedValuePattern.FindAllStringSubmatch(line, -1)
+		if len(values) == 0 {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "WM_CLASS"):
+			// WM_CLASS is "instance", "Class"; the class is the application name
+			window.App = values[len(values)-1][1]
+		case strings.HasPrefix(line, "_NET_WM_NAME"):
+			window.Title = values[0][1]
+		}
+	}
+	return window, nil
+}
+
+// xwininfoField matches a numeric line of xwininfo's output, e.g. "Width: 800"
+var xwininfoField = regexp.MustCompile(`(?m)^\s*(Absolute upper-left X|Absolute upper-left Y|Width|Height):\s+(-?\d+)`)
+
+// activeWindowBounds asks the X server, via xwininfo, where the focused window is on screen
+func activeWindowBounds() (image.Rectangle, error) {
+	id, err := activeWindowID()
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	if id == "" {
+		return image.Rectangle{}, errNoActiveWindow
+	}
+	out, err := exec.Command("xwininfo", "-id", id).Output()
+	if err != nil {
+		return image.Rectangle{}, fmt.Errorf("failed to query window %s bounds (is xwininfo installed?): %w", id, err)
+	}
+	fields := map[string]int{}
+	for _, match := range xwininfoField.FindAllStringSubmatch(string(out), -1) {
+		fields[match[1]], _ = strconv.Atoi(match[2])
+	}
+	if len(fields) < 4 {
+		return image.Rectangle{}, fmt.Errorf("unexpected xwininfo output for window %s", id)
+	}
+	x, y := fields["Absolute upper-left X"], fields["Absolute upper-left Y"]
+	return image.Rect(x, y, x+fields["Width"], y+fields["Height"]), nil
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin && !windows
+
+package core
+
+import (
+	"errors"
+	"image"
+)
+
+func activeWindow() (ActiveWindow, error) {
+	return ActiveWindow{}, errors.New("active window tracking is not supported on this platform")
+}
+
+func activeWindowBounds() (image.Rectangle, error) {
+	return image.Rectangle{}, errors.New("active window capture is not supported on this platform")
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	user32                   = windows.NewLazySystemDLL("user32.dll")
+	procGetWindowTextW       = user32.NewProc("GetWindowTextW")
+	procGetWindowTextLengthW = user32.NewProc("GetWindowTextLengthW")
+	procGetWindowRect        = user32.NewProc("GetWindowRect")
+)
+
+// activeWindow returns the foreground window's title and the executable name of its process
+func activeWindow() (ActiveWindow, error) {
+	hwnd := windows.GetForegroundWindow()
+	if hwnd == 0 {
+		return ActiveWindow{}, nil
+	}
+
+	var window ActiveWindow
+	length, _, _ := procGetWindowTextLengthW.Call(uintptr(hwnd))
+	if length > 0 {
+		buf := make([]uint16, length+1)
+		procGetWindowTextW.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+		window.Title = windows.UTF16ToString(buf)
+	}
+
+	var pid uint32
+	if _, err := windows.GetWindowThreadProcessId(hwnd, &pid); err != nil {
+		return window, fmt.Errorf("failed to get foreground process: %w", err)
+	}
+	process, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return window, fmt.Errorf("failed to open foreground process: %w", err)
+	}
+	defer windows.CloseHandle(process)
+
+	buf := make([]uint16, windows.MAX_PATH)
+	size := uint32(len(buf))
+	if err := windows.QueryFullProcessImageName(process, 0, &buf[0], &size); err != nil {
+		return window, fmt.Errorf("failed to get foreground process name: %w", err)
+	}
+	exe := filepath.Base(windows.UTF16ToString(buf[:size]))
+	window.App = strings.TrimSuffix(exe, filepath.Ext(exe))
+	return window, nil
+}
+
+// activeWindowBounds returns the foreground window's rectangle on the virtual screen
+func activeWindowBounds() (image.Rectangle, error) {
+	hwnd := windows.GetForegroundWindow()
+	if hwnd == 0 {
+		return image.Rectangle{}, errNoActiveWindow
+	}
+	var rect struct{ Left, Top, Right, Bottom int32 }
+	if ok, _, err := procGetWindowRect.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&rect))); ok == 0 {
+		return image.Rectangle{}, fmt.Errorf("failed to get foreground window bounds: %w", err)
+	}
+	return image.Rect(int(rect.Left), int(rect.Top), int(rect.Right), int(rect.Bottom)), nil
+}
@@ -0,0 +1,30 @@
+package core
+
+import (
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+// IntervalActivity is the input counted over one interval, e.g. between two screenshots
+type IntervalActivity struct {
+	KeyboardEvents int
+	MouseEvents    int
+	Duration       time.Duration
+}
+
+// ActivityLevel returns how busy the interval was as a percentage of
+// busyEventsPerMinute keyboard and mouse events, capped at 100. It returns -1
+// when there is nothing to measure.
+func ActivityLevel(interval IntervalActivity, busyEventsPerMinute int) int {
+	if interval.Duration <= 0 || busyEventsPerMinute <= 0 {
+		return -1
+	}
+	perMinute := float64(interval.KeyboardEvents+interval.MouseEvents) / interval.Duration.Minutes()
+	return min(100, int(perMinute*100/float64(busyEventsPerMinute)+0.5))
+}
+
+// activityLevel rates interval against the configured busy baseline
+func activityLevel(interval IntervalActivity) int {
+	return ActivityLevel(interval, config.Current().BusyEventsPerMinute)
+}
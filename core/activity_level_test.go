@@ -0,0 +1,30 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActivityLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval IntervalActivity
+		busy     int
+		want     int
+	}{
+		{"half busy", IntervalActivity{KeyboardEvents: 30, MouseEvents: 30, Duration: 2 * time.Minute}, 60, 50},
+		{"rounded", IntervalActivity{KeyboardEvents: 2, Duration: time.Minute}, 3, 67},
+		{"capped at 100", IntervalActivity{MouseEvents: 500, Duration: time.Minute}, 60, 100},
+		{"no input", IntervalActivity{Duration: time.Minute}, 60, 0},
+		{"no duration", IntervalActivity{KeyboardEvents: 10}, 60, -1},
+		{"no baseline", IntervalActivity{KeyboardEvents: 10, Duration: time.Minute}, 0, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ActivityLevel(tt.interval, tt.busy); got != tt.want {
+				t.Errorf("ActivityLevel() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,11 @@
 package core
 
 import (
+	"log"
+	"strings"
 	"time"
+
+	"github.com/time-tracker/v2/internal/config"
 )
 
 type Activity struct {
@@ -9,6 +13,13 @@ type Activity struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// SessionSummary describes a just-finished tracking session
+type SessionSummary struct {
+	Record      ActivityRecord
+	Screenshots int
+	Paused      time.Duration // Left out of Record's duration, e.g. Pomodoro breaks
+}
+
 type ActivityTracker struct {
 	ActiveTasks       []Activity
 	IsTracking        bool
@@ -20,56 +31,205 @@ type ActivityTracker struct {
 	InputMonitor      *InputMonitor
 	screenshotDir     string
 	taskManager       *TaskManager // Added TaskManager field
+
+	keyboardEvents int // Input counted this session, including before any Pause
+	mouseEvents    int
+	idleSeconds    int             // No input for at least the idle threshold, this session
+	allocated      time.Duration   // Share of the session when its time is split with concurrent tasks, 0 for all of it
+	pausedAt       time.Time       // When Pause took effect, zero unless paused
+	paused         time.Duration   // Paused this session before pausedAt, left out of its duration
+	lastSummary    *SessionSummary // Set by StopTracking
+	focus          *focusTracker   // Samples the foreground app when enabled in settings
+	lock           *lockWatcher    // Watches for the screen locking when enabled in settings
+	events         *eventBus
+	historyWarned  bool // EventLocalHistoryUnavailable was sent
 }
 
-// Updated NewActivityTracker to accept TaskManager
+// NewActivityTracker creates a tracker recording to the default database and
+// listening to the system input hook
 func NewActivityTracker(screenshotDir string, taskManager *TaskManager) *ActivityTracker {
+	database, err := NewDatabase("time_tracker.db")
+	if err != nil {
+		log.Printf("Local database unavailable, history won't be saved: %v", err)
+		database = NewMemoryDatabase(err)
+	}
+	return NewActivityTrackerWith(screenshotDir, taskManager, database, NewInputMonitor())
+}
+
+// NewActivityTrackerWith creates a tracker around the given database and input
+// monitor, e.g. a temporary database and a monitor with a stub hook (see
+// InputMonitor.SetHook). The screenshot manager is built on the same database
+// and can be adjusted through ScreenshotManager.SetAfter and SetRandSource.
+func NewActivityTrackerWith(screenshotDir string, taskManager *TaskManager, database *Database, inputMonitor *InputMonitor) *ActivityTracker {
+	events := newEventBus()
+	screenshotManager := NewScreenshotManager(600, taskManager, database)
+	screenshotManager.events = events
+	screenshotManager.input = inputMonitor
+	lock := newLockWatcher(events)
+	screenshotManager.screenLocked = lock.isLocked
 	return &ActivityTracker{
 		ActiveTasks:       []Activity{},
 		IsTracking:        false,
 		CurrentTask:       nil,
 		StartTime:         nil,
 		EndTime:           nil,
-		Database:          NewDatabase("time_tracker.db"),
-		ScreenshotManager: NewScreenshotManager(600, taskManager),
-		InputMonitor:      NewInputMonitor(),
+		Database:          database,
+		ScreenshotManager: screenshotManager,
+		InputMonitor:      inputMonitor,
 		screenshotDir:     screenshotDir,
 		taskManager:       taskManager,
+		focus:             newFocusTracker(),
+		lock:              lock,
+		events:            events,
 	}
 }
 
+// Subscribe returns a channel of tracker events (see TrackerEventType) and a
+// function to end the subscription. Events are never blocked on: if the
+// channel isn't drained, the oldest undelivered events are dropped.
+func (at *ActivityTracker) Subscribe() (<-chan TrackerEvent, func()) {
+	return at.events.subscribe()
+}
+
 func (at *ActivityTracker) StartTracking(taskName string) error {
+	// Connect only fails if not even the in-memory fallback can be opened
 	err := at.Database.Connect()
 	if err != nil {
 		return err
 	}
+	if err := at.Database.Unavailable(); err != nil {
+		at.warnNoHistory(err)
+	}
 	at.IsTracking = true
 	at.CurrentTask = &taskName
 	now := time.Now()
 	at.StartTime = &now
+	at.keyboardEvents = 0
+	at.mouseEvents = 0
+	at.idleSeconds = 0
+	at.allocated = 0
+	at.pausedAt = time.Time{}
+	at.paused = 0
+	at.lastSummary = nil
+	at.focus.reset()
+	at.InputMonitor.ResetInterval()
+	at.ScreenshotManager.consent.reset()
 	at.ScreenshotManager.StartCapture()
+	if settings := config.Current(); settings.InputDebugLog {
+		if err := at.InputMonitor.OpenDebugLog(now, settings.InputDebugKeys); err != nil {
+			log.Printf("Failed to open input debug log: %v", err)
+		}
+	}
 	at.InputMonitor.StartMonitoring()
+	at.startFocusTracking()
+	if config.Current().DetectScreenLock {
+		at.lock.start()
+	}
+	at.events.emit(TrackerEvent{Type: EventTrackingStarted, Task: taskName})
 	return at.trackActivities()
 }
 
+// CaptureSessionStart takes the opening screenshot of a session in start/stop
+// capture mode. Called once the work report is open so the screenshot is uploaded
+func (at *ActivityTracker) CaptureSessionStart() {
+	at.ScreenshotManager.captureAtStart()
+}
+
 func (at *ActivityTracker) StopTracking() error {
+	return at.StopTrackingAt(time.Now())
+}
+
+// StopTrackingAt is StopTracking for a session that ended at end, earlier
+// than now
+func (at *ActivityTracker) StopTrackingAt(end time.Time) error {
+	task := ""
+	if at.CurrentTask != nil {
+		task = *at.CurrentTask
+	}
 	at.IsTracking = false
 	at.CurrentTask = nil
-	now := time.Now()
-	at.EndTime = &now
+	at.EndTime = &end
+	at.endPause(end)
+	at.stopInputMonitoring() // Stop input monitoring first so the counts can be saved
+	if err := at.InputMonitor.CloseDebugLog(); err != nil {
+		log.Printf("Failed to close input debug log: %v", err)
+	}
+	at.focus.stopSampling()
+	at.lock.stopWatching()
 	err := at.trackActivities()
 	if err != nil {
 		return err
 	}
-	err = at.saveCurrentSession()
-	if err != nil {
-		return err
+	if err := at.saveCurrentSession(); err != nil {
+		// Losing the local record mustn't keep the session from stopping
+		log.Printf("Failed to save session to local history: %v", err)
+		at.ActiveTasks = []Activity{}
+		at.warnNoHistory(err)
 	}
 	at.ScreenshotManager.StopCapture()
-	at.InputMonitor.StopMonitoring() // Stop input monitoring when tracking stops
+	at.events.emit(TrackerEvent{Type: EventTrackingStopped, Task: task, Summary: at.lastSummary})
 	return nil
 }
 
+// Pause suspends screenshots and input monitoring without ending the session.
+// The time until Resume isn't counted in the session's duration.
+func (at *ActivityTracker) Pause() {
+	if at.IsTracking && at.pausedAt.IsZero() {
+		at.pausedAt = time.Now()
+	}
+	at.ScreenshotManager.StopCapture()
+	at.stopInputMonitoring()
+	at.focus.stopSampling()
+	at.events.emit(TrackerEvent{Type: EventTrackingPaused})
+}
+
+// startFocusTracking samples the foreground application if the user opted in
+func (at *ActivityTracker) startFocusTracking() {
+	if config.Current().TrackActiveWindow {
+		at.focus.start()
+	}
+}
+
+// stopInputMonitoring stops the input monitor, keeping its event counts for the session
+func (at *ActivityTracker) stopInputMonitoring() {
+	counts := at.InputMonitor.StopMonitoring()
+	at.keyboardEvents += counts["keyboard_event_count"]
+	at.mouseEvents += counts["mouse_event_count"]
+	at.idleSeconds += counts["idle_seconds"]
+}
+
+// LastSummary returns the summary of the most recently stopped session, or
+// nil if it could not be saved
+func (at *ActivityTracker) LastSummary() *SessionSummary {
+	return at.lastSummary
+}
+
+// Idle reports whether the session has had no input for the idle threshold
+func (at *ActivityTracker) Idle() bool {
+	return at.IsTracking && at.InputMonitor.Idle()
+}
+
+// Resume restarts screenshots and input monitoring after Pause
+func (at *ActivityTracker) Resume() {
+	if !at.IsTracking {
+		return
+	}
+	at.endPause(time.Now())
+	at.ScreenshotManager.StartCapture()
+	at.InputMonitor.StartMonitoring()
+	at.startFocusTracking()
+	at.events.emit(TrackerEvent{Type: EventTrackingResumed})
+}
+
+// Reassign moves the running session, and the activity logged so far, to
+// taskName. The start time and input counts carry over.
+func (at *ActivityTracker) Reassign(taskName string) {
+	at.CurrentTask = &taskName
+	for i := range at.ActiveTasks {
+		at.ActiveTasks[i].TaskName = taskName
+	}
+}
+
 func (at *ActivityTracker) GetActiveTasks() []Activity {
 	return at.ActiveTasks
 }
@@ -86,6 +246,16 @@ func (at *ActivityTracker) GetLoggedActivities() []Activity {
 	return at.ActiveTasks
 }
 
+// warnNoHistory tells subscribers, the first time only, that sessions aren't
+// being saved locally
+func (at *ActivityTracker) warnNoHistory(err error) {
+	if at.historyWarned {
+		return
+	}
+	at.historyWarned = true
+	at.events.emit(TrackerEvent{Type: EventLocalHistoryUnavailable, Err: err})
+}
+
 func (at *ActivityTracker) trackActivities() error {
 	if at.CurrentTask != nil {
 		at.LogActivity(*at.CurrentTask)
@@ -93,24 +263,52 @@ func (at *ActivityTracker) trackActivities() error {
 	return nil
 }
 
+// SetAllocatedDuration caps the duration saved for the current session, for
+// when its time is shared with other tasks tracked at the same time
+func (at *ActivityTracker) SetAllocatedDuration(d time.Duration) {
+	at.allocated = d
+}
+
 func (at *ActivityTracker) saveCurrentSession() error {
 	duration := at.calculateSessionDuration()
+	if at.allocated > 0 {
+		duration = min(duration, at.allocated.Seconds())
+	}
 	// Use screenshotDir to save the screenshot
-	screenshotPath, err := at.ScreenshotManager.captureScreenshot()
-	if err != nil {
-		// Allow continuing even if screenshot fails, just log it or handle differently
-		screenshotPath = "" // Or some indicator that screenshot failed
+	screenshotPath := ""
+	if at.ScreenshotManager.capturesOnStop() {
+		path, err := at.ScreenshotManager.captureScreenshot()
+		if err == nil {
+			// Allow continuing even if screenshot fails
+			screenshotPath = path
+		}
+	}
+	topApps := strings.Join(at.focus.topApps(), ", ")
+	workReportID := 0
+	if report := at.taskManager.GetWorkReport(); report != nil {
+		workReportID = report.ID
 	}
-	// Get counts without stopping again
+	local := false
+	if task := at.taskManager.GetActiveTask(); task != nil {
+		local = task.Local
+	}
+	// Idle time counted after a session stopped at an earlier end can't exceed it
+	idle := min(at.idleSeconds, int(duration))
+	level := activityLevel(IntervalActivity{
+		KeyboardEvents: at.keyboardEvents,
+		MouseEvents:    at.mouseEvents,
+		Duration:       time.Duration(duration * float64(time.Second)),
+	})
+	var summary *SessionSummary
 	for _, activity := range at.ActiveTasks {
 		// Ensure StartTime and EndTime are not nil before formatting
 		startTimeStr := ""
 		if at.StartTime != nil {
-			startTimeStr = at.StartTime.Format(time.RFC3339)
+			startTimeStr = at.StartTime.UTC().Format(time.RFC3339)
 		}
 		endTimeStr := ""
 		if at.EndTime != nil {
-			endTimeStr = at.EndTime.Format(time.RFC3339)
+			endTimeStr = at.EndTime.UTC().Format(time.RFC3339)
 		}
 
 		err := at.Database.SaveActivity(
@@ -119,18 +317,55 @@ func (at *ActivityTracker) saveCurrentSession() error {
 			endTimeStr,
 			int(duration),
 			screenshotPath,
-			0, 0)
+			at.keyboardEvents, at.mouseEvents,
+			topApps, workReportID, level, idle, initialSyncState(workReportID, local))
 		if err != nil {
 			return err // Or collect errors and return aggregate
 		}
+		if summary == nil {
+			summary = &SessionSummary{Record: ActivityRecord{
+				Task:               activity.TaskName,
+				DurationSeconds:    int64(duration),
+				ScreenshotPath:     screenshotPath,
+				KeyboardEventCount: int64(at.keyboardEvents),
+				MouseEventCount:    int64(at.mouseEvents),
+				TopApps:            topApps,
+				ActivityLevel:      int64(level),
+				IdleSeconds:        int64(idle),
+			}, Paused: at.paused}
+			if at.StartTime != nil {
+				summary.Record.StartTime = *at.StartTime
+			}
+			summary.Record.EndTime = at.EndTime
+			if at.StartTime != nil && at.EndTime != nil {
+				count, err := at.Database.CountScreenshots(startTimeStr, endTimeStr)
+				if err != nil {
+					log.Printf("Failed to count session screenshots: %v", err)
+				}
+				summary.Screenshots = count
+			}
+		}
 	}
 	at.ActiveTasks = []Activity{} // Clear active tasks after saving
+	at.lastSummary = summary
 	return nil
 }
 
 func (at *ActivityTracker) calculateSessionDuration() float64 {
 	if at.StartTime != nil && at.EndTime != nil {
-		return at.EndTime.Sub(*at.StartTime).Seconds()
+		// Compare wall-clock readings so time spent asleep is counted
+		elapsed := at.EndTime.Round(0).Sub(at.StartTime.Round(0))
+		return max(elapsed-at.paused, 0).Seconds()
 	}
 	return 0.0
 }
+
+// endPause adds the pause in effect, if any, to the session's paused time
+func (at *ActivityTracker) endPause(now time.Time) {
+	if at.pausedAt.IsZero() {
+		return
+	}
+	// A session stopped at an earlier end may have been paused after it
+	at.paused += max(now.Round(0).Sub(at.pausedAt.Round(0)), 0)
+	at.pausedAt = time.Time{}
+}
@@ -0,0 +1,144 @@
+package core
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	hook "github.com/robotn/gohook"
+)
+
+func TestActivityTrackerWithStubs(t *testing.T) {
+	tests := []struct {
+		name      string
+		events    []hook.Event
+		wantKeys  int64
+		wantMouse int64
+	}{
+		{"no input", nil, 0, 0},
+		{"keys and clicks", []hook.Event{
+			{Kind: hook.KeyDown, Keychar: 'a'},
+			{Kind: hook.KeyDown, Keychar: 'b'},
+			{Kind: hook.MouseDown, Button: hook.MouseMap["left"]},
+		}, 2, 1},
+		{"scrolling counts as mouse input", []hook.Event{{Kind: hook.MouseWheel, Rotation: 1, Amount: 3}}, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := make(chan hook.Event)
+			defer close(events)
+			db := newTestDatabase(t)
+			tm := NewTaskManagerWithAPI(newFakeTaskAPI())
+			at := NewActivityTrackerWith(t.TempDir(), tm, db, newStubInputMonitor(events))
+			s := NewSession(tm, at)
+			if err := s.Start(demoTask); err != nil {
+				t.Fatal(err)
+			}
+			for _, ev := range tt.events {
+				events <- ev
+			}
+			waitForInput(t, at.InputMonitor, len(tt.events))
+			if err := s.Stop(); err != nil {
+				t.Fatal(err)
+			}
+
+			summary := at.LastSummary()
+			if summary == nil {
+				t.Fatal("no session summary")
+			}
+			if summary.Record.KeyboardEventCount != tt.wantKeys || summary.Record.MouseEventCount != tt.wantMouse {
+				t.Errorf("counted %d keys, %d mouse events, want %d, %d",
+					summary.Record.KeyboardEventCount, summary.Record.MouseEventCount, tt.wantKeys, tt.wantMouse)
+			}
+			totals, err := db.GetTaskTotals()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(totals) != 1 || totals[0].Sessions != 1 {
+				t.Errorf("injected database has %+v, want one session", totals)
+			}
+		})
+	}
+}
+
+// waitForInput waits until im has recorded n keyboard and mouse events
+func waitForInput(t *testing.T, im *InputMonitor, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		im.mu.Lock()
+		got := len(im.Keystrokes) + len(im.MouseMovements)
+		im.mu.Unlock()
+		if got >= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("recorded %d input events, want %d", got, n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTrackingWithoutLocalHistory(t *testing.T) {
+	tests := []struct {
+		name        string
+		unusable    bool
+		wantWarning bool
+	}{
+		{"database file usable", false, false},
+		{"database file unusable", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := NewDatabase(testDatabaseFile(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.unusable {
+				// A directory where the file should be can't be opened
+				if err := os.MkdirAll(db.dbFile, 0700); err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { os.RemoveAll(db.dbFile) })
+			}
+			t.Cleanup(func() {
+				if db.conn != nil {
+					db.conn.Close() // Frees the shared in-memory database for the next run
+				}
+			})
+			tm := NewTaskManagerWithAPI(newFakeTaskAPI())
+			at := NewActivityTrackerWith(t.TempDir(), tm, db, newStubInputMonitor(nil))
+			events, unsubscribe := at.Subscribe()
+			defer unsubscribe()
+			s := NewSession(tm, at)
+
+			for i := 0; i < 2; i++ {
+				if err := s.Start(demoTask); err != nil {
+					t.Fatal(err)
+				}
+				if err := s.Stop(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if (db.Unavailable() != nil) != tt.unusable {
+				t.Errorf("Unavailable() = %v, want an error %v", db.Unavailable(), tt.unusable)
+			}
+			warnings := 0
+			for len(events) > 0 {
+				if ev := <-events; ev.Type == EventLocalHistoryUnavailable {
+					warnings++
+				}
+			}
+			if want := map[bool]int{true: 1, false: 0}[tt.wantWarning]; warnings != want {
+				t.Errorf("%d history warnings, want %d", warnings, want)
+			}
+			totals, err := db.GetTaskTotals()
+			if err != nil || len(totals) != 1 || totals[0].Sessions != 2 {
+				t.Errorf("GetTaskTotals() = %+v, %v, want two sessions, in memory if need be", totals, err)
+			}
+		})
+	}
+}
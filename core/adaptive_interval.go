@@ -0,0 +1,39 @@
+package core
+
+import (
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+// recordActivityLevel counts captures rated below LowActivityPercent in a row,
+// for the adaptive interval. Unrated captures (level -1) leave the count alone.
+func (sm *ScreenshotManager) recordActivityLevel(level int) {
+	if level < 0 {
+		return
+	}
+	quiet := level < config.Current().LowActivityPercent
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if quiet {
+		sm.quietCount++
+	} else {
+		sm.quietCount = 0
+	}
+}
+
+// adaptiveInterval returns the time between scheduled screenshots after
+// quietCount low-activity captures in a row: base while the user is active,
+// then shortest, doubling with each further quiet capture up to longest.
+// It never returns less than base.
+func adaptiveInterval(base time.Duration, quietCount int, shortest, longest time.Duration) time.Duration {
+	if quietCount == 0 || longest <= base {
+		return base
+	}
+	interval := max(shortest, base)
+	for i := 1; i < quietCount && interval < longest; i++ {
+		interval *= 2
+	}
+	return min(interval, longest)
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+func TestAdaptiveInterval(t *testing.T) {
+	const (
+		base     = 5 * time.Minute
+		shortest = 10 * time.Minute
+		longest  = time.Hour
+	)
+	tests := []struct {
+		name       string
+		base       time.Duration
+		quietCount int
+		want       time.Duration
+	}{
+		{"active", base, 0, base},
+		{"first quiet capture", base, 1, shortest},
+		{"doubling", base, 3, 40 * time.Minute},
+		{"capped", base, 10, longest},
+		{"base above the shortest", 20 * time.Minute, 1, 20 * time.Minute},
+		{"base above the longest", 2 * time.Hour, 5, 2 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := adaptiveInterval(tt.base, tt.quietCount, shortest, longest); got != tt.want {
+				t.Errorf("adaptiveInterval(%s, %d) = %s, want %s", tt.base, tt.quietCount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntervalFollowsActivity(t *testing.T) {
+	serverInterval := 120
+	tests := []struct {
+		name     string
+		adaptive bool
+		policy   *config.ServerPolicy
+		levels   []int
+		want     time.Duration
+	}{
+		{"no captures yet", true, nil, nil, 5 * time.Minute},
+		{"one quiet capture", true, nil, []int{5}, 10 * time.Minute},
+		{"two quiet captures", true, nil, []int{5, 0}, 20 * time.Minute},
+		{"unrated captures ignored", true, nil, []int{5, -1}, 10 * time.Minute},
+		{"activity resets", true, nil, []int{5, 5, 50}, 5 * time.Minute},
+		{"disabled", false, nil, []int{5, 5}, 5 * time.Minute},
+		{"server interval kept", true, &config.ServerPolicy{ScreenshotIntervalSeconds: &serverInterval}, []int{5, 5}, 2 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateSettings(t, func(s *config.Settings) {
+				s.ScreenshotJitterPercent = 0
+				s.AdaptiveScreenshotInterval = tt.adaptive
+				s.LowActivityPercent = 10
+				s.LowActivityMinIntervalMinutes = 10
+				s.LowActivityMaxIntervalMinutes = 60
+			})
+			if err := config.SetServerPolicy(tt.policy); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { config.SetServerPolicy(nil) })
+
+			sm := NewScreenshotManager(300, nil, nil)
+			for _, level := range tt.levels {
+				sm.recordActivityLevel(level)
+			}
+			if got := sm.randomInterval(); got != tt.want {
+				t.Errorf("randomInterval() after levels %v = %s, want %s", tt.levels, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"image"
+	"log"
+
+	"github.com/kbinani/screenshot"
+	"github.com/time-tracker/v2/internal/config"
+)
+
+// captureBounds returns the screen area to capture for scope: the primary
+// display, or the focused window when scope is CaptureScopeActiveWindow and
+// its bounds can be determined
+func captureBounds(scope string) image.Rectangle {
+	primary := screenshot.GetDisplayBounds(0)
+	if scope != config.CaptureScopeActiveWindow {
+		return primary
+	}
+	window, err := activeWindowBounds()
+	if err != nil {
+		log.Printf("Capturing the full screen, the active window's bounds are unknown: %v", err)
+		return primary
+	}
+	displays := make([]image.Rectangle, screenshot.NumActiveDisplays())
+	for i := range displays {
+		displays[i] = screenshot.GetDisplayBounds(i)
+	}
+	bounds, ok := selectWindowBounds(displays, window)
+	if !ok {
+		log.Printf("Capturing the full screen, the active window %v is off screen", window)
+		return primary
+	}
+	return bounds
+}
+
+// selectWindowBounds clips window to the display showing most of it, so a
+// window straddling two displays is captured where it mostly is. It reports
+// false if the window isn't on any display.
+func selectWindowBounds(displays []image.Rectangle, window image.Rectangle) (image.Rectangle, bool) {
+	var best image.Rectangle
+	bestArea := 0
+	for _, display := range displays {
+		visible := window.Intersect(display)
+		if area := visible.Dx() * visible.Dy(); area > bestArea {
+			best, bestArea = visible, area
+		}
+	}
+	return best, bestArea > 0
+}
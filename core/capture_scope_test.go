@@ -0,0 +1,37 @@
+package core
+
+import (
+	"image"
+	"testing"
+)
+
+func TestSelectWindowBounds(t *testing.T) {
+	left := image.Rect(0, 0, 1920, 1080)
+	right := image.Rect(1920, 0, 3840, 1080)
+
+	tests := []struct {
+		name     string
+		displays []image.Rectangle
+		window   image.Rectangle
+		want     image.Rectangle
+		wantOK   bool
+	}{
+		{"on one display", []image.Rectangle{left, right}, image.Rect(100, 100, 900, 700), image.Rect(100, 100, 900, 700), true},
+		{"on the second display", []image.Rectangle{left, right}, image.Rect(2000, 50, 2500, 450), image.Rect(2000, 50, 2500, 450), true},
+		{"straddling, mostly left", []image.Rectangle{left, right}, image.Rect(1500, 0, 2000, 500), image.Rect(1500, 0, 1920, 500), true},
+		{"straddling, mostly right", []image.Rectangle{left, right}, image.Rect(1800, 0, 2400, 500), image.Rect(1920, 0, 2400, 500), true},
+		{"partly off screen", []image.Rectangle{left}, image.Rect(-200, -100, 400, 300), image.Rect(0, 0, 400, 300), true},
+		{"off every display", []image.Rectangle{left, right}, image.Rect(5000, 0, 5500, 500), image.Rectangle{}, false},
+		{"no displays", nil, image.Rect(0, 0, 100, 100), image.Rectangle{}, false},
+		{"empty window", []image.Rectangle{left}, image.Rect(10, 10, 10, 10), image.Rectangle{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := selectWindowBounds(tt.displays, tt.window)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("selectWindowBounds(%v, %v) = %v, %v, want %v, %v", tt.displays, tt.window, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package core
+
+import "github.com/time-tracker/v2/internal/config"
+
+// captureStrategy decides when screenshots are taken automatically during a session
+type captureStrategy struct {
+	scheduled bool // Capture at random intervals while the session runs
+	onStart   bool // Capture once the session's work report is open
+	onStop    bool // Capture when the session stops
+}
+
+// captureStrategies maps each config capture mode to its strategy
+var captureStrategies = map[string]captureStrategy{
+	config.CaptureModeInterval:  {scheduled: true, onStop: true},
+	config.CaptureModeStartStop: {onStart: true, onStop: true},
+	config.CaptureModeManual:    {},
+}
+
+// strategyFor returns the strategy for mode, falling back to interval
+// captures for unknown modes
+func strategyFor(mode string) captureStrategy {
+	if strategy, ok := captureStrategies[mode]; ok {
+		return strategy
+	}
+	return captureStrategies[config.CaptureModeInterval]
+}
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+func TestCaptureModes(t *testing.T) {
+	tests := []struct {
+		mode          string
+		wantScheduled bool
+		wantOnStart   bool
+		wantOnStop    bool
+	}{
+		{config.CaptureModeInterval, true, false, true},
+		{config.CaptureModeStartStop, false, true, true},
+		{config.CaptureModeManual, false, false, false},
+		{"unknown", true, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			updateSettings(t, func(s *config.Settings) { s.ScreenshotCaptureMode = tt.mode })
+			sm := NewScreenshotManager(60, nil, nil)
+			var waits atomic.Int32
+			sm.SetAfter(func(time.Duration) <-chan time.Time {
+				waits.Add(1)
+				return nil // Never fires
+			})
+
+			sm.StartCapture()
+			onStart := sm.strategy.onStart
+			onStop := sm.capturesOnStop()
+			sm.StopCapture() // Waits for the scheduler, which has started waiting if it runs
+
+			if scheduled := waits.Load() > 0; scheduled != tt.wantScheduled {
+				t.Errorf("scheduled captures = %v, want %v", scheduled, tt.wantScheduled)
+			}
+			if onStart != tt.wantOnStart || onStop != tt.wantOnStop {
+				t.Errorf("captures on start, stop = %v, %v, want %v, %v", onStart, onStop, tt.wantOnStart, tt.wantOnStop)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/time-tracker/v2/internal/logging"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// checkpointInterval is how often a running session refreshes its checkpoint
+const checkpointInterval = time.Minute
+
+// SessionCheckpoint records a running session so it can be recovered after a crash
+type SessionCheckpoint struct {
+	TaskID       int
+	TaskName     string
+	StartTime    time.Time
+	WorkReportID int       // 0 until the server report has been opened
+	LastSeen     time.Time // Last time the app was known to be tracking
+	// ActivitySaved is set once tracking stopped and only the server report is left to close
+	ActivitySaved bool
+}
+
+// SaveCheckpoint stores cp, replacing any previous checkpoint
+func (db *Database) SaveCheckpoint(cp SessionCheckpoint) error {
+	query := `
+    INSERT OR REPLACE INTO session_checkpoint (id, task_id, task_name, start_time, work_report_id, last_seen, activity_saved)
+    VALUES (1, ?, ?, ?, ?, ?, ?)`
+	_, err := db.conn.Exec(query, cp.TaskID, cp.TaskName, cp.StartTime.UTC().Format(time.RFC3339),
+		cp.WorkReportID, cp.LastSeen.UTC().Format(time.RFC3339), cp.ActivitySaved)
+	if err != nil {
+		return fmt.Errorf("failed to save session checkpoint: %w", err)
+	}
+	return nil
+}
+
+// LoadCheckpoint returns the stored checkpoint, or nil if there is none
+func (db *Database) LoadCheckpoint() (*SessionCheckpoint, error) {
+	query := `
+    SELECT task_id, task_name, start_time, work_report_id, last_seen, activity_saved
+    FROM session_checkpoint WHERE id = 1`
+	var cp SessionCheckpoint
+	var startTime, lastSeen string
+	err := db.conn.QueryRow(query).Scan(&cp.TaskID, &cp.TaskName, &startTime, &cp.WorkReportID, &lastSeen, &cp.ActivitySaved)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load session checkpoint: %w", err)
+	}
+	if cp.StartTime, err = time.Parse(time.RFC3339, startTime); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint start time %q: %w", startTime, err)
+	}
+	if cp.LastSeen, err = time.Parse(time.RFC3339, lastSeen); err != nil {
+		cp.LastSeen = cp.StartTime
+	}
+	return &cp, nil
+}
+
+// ClearCheckpoint removes the stored checkpoint
+func (db *Database) ClearCheckpoint() error {
+	if _, err := db.conn.Exec("DELETE FROM session_checkpoint"); err != nil {
+		return fmt.Errorf("failed to clear session checkpoint: %w", err)
+	}
+	return nil
+}
+
+// PendingCheckpoint returns the checkpoint of a session that was still
+// running when the app last exited, or nil
+func (s *Session) PendingCheckpoint() (*SessionCheckpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.task != nil {
+		return nil, nil
+	}
+	db := s.ActivityTracker.Database
+	if err := db.Connect(); err != nil {
+		return nil, err
+	}
+	return db.LoadCheckpoint()
+}
+
+// CloseCheckpoint finishes an interrupted session as if it had stopped when
+// last seen: the activity is saved locally, the server report is closed and
+// the checkpoint is removed. All failures are returned together; the
+// checkpoint is then kept, marked once the activity is saved, for a retry.
+func (s *Session) CloseCheckpoint(cp SessionCheckpoint, description string) error {
+	db := s.ActivityTracker.Database
+	if err := db.Connect(); err != nil {
+		return err
+	}
+
+	end := cp.LastSeen
+	if end.Before(cp.StartTime) {
+		end = cp.StartTime
+	}
+	var saveErr error
+	if !cp.ActivitySaved {
+		saveErr = db.SaveActivity(cp.TaskName, cp.StartTime.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339),
+			int(end.Sub(cp.StartTime).Seconds()), "", 0, 0, "", cp.WorkReportID, -1, 0, initialSyncState(cp.WorkReportID, cp.TaskID < 0))
+	}
+
+	var reportErr error
+	if cp.WorkReportID != 0 {
+		reportErr = s.TaskManager.CloseWorkReportByID(cp.WorkReportID, end, description)
+	}
+	if reportErr == nil && cp.WorkReportID != 0 {
+		s.markSynced(cp.WorkReportID)
+	}
+	if saveErr != nil || reportErr != nil {
+		// Keep the checkpoint so closing it can be retried, without saving
+		// the activity again if only the report is left
+		if saveErr == nil && !cp.ActivitySaved {
+			cp.ActivitySaved = true
+			saveErr = db.SaveCheckpoint(cp)
+		}
+		return errors.Join(saveErr, reportErr)
+	}
+	return db.ClearCheckpoint()
+}
+
+// DiscardCheckpoint forgets an interrupted session without recording it
+func (s *Session) DiscardCheckpoint() error {
+	db := s.ActivityTracker.Database
+	if err := db.Connect(); err != nil {
+		return err
+	}
+	return db.ClearCheckpoint()
+}
+
+// writeCheckpoint saves the running session's state. Callers must hold s.mu.
+func (s *Session) writeCheckpoint() {
+	if s.task == nil {
+		return
+	}
+	cp := SessionCheckpoint{
+		TaskID:    s.task.ID,
+		TaskName:  s.task.Name,
+		StartTime: s.startTime,
+		LastSeen:  wallClock(),
+	}
+	if report := s.TaskManager.GetWorkReport(); report != nil {
+		cp.WorkReportID = report.ID
+	}
+	if err := s.ActivityTracker.Database.SaveCheckpoint(cp); err != nil {
+		log.Printf("Failed to checkpoint session: %v", err)
+	}
+}
+
+// checkpointStopped records that tracking for task stopped and the activity
+// was saved. The checkpoint is kept until the server report is closed.
+func (s *Session) checkpointStopped(task types.Task, startTime time.Time) {
+	db := s.ActivityTracker.Database
+	report := s.TaskManager.GetWorkReport()
+	if report == nil {
+		if err := db.ClearCheckpoint(); err != nil {
+			log.Printf("Failed to clear session checkpoint: %v", err)
+		}
+		return
+	}
+	cp := SessionCheckpoint{
+		TaskID:        task.ID,
+		TaskName:      task.Name,
+		StartTime:     startTime,
+		WorkReportID:  report.ID,
+		LastSeen:      wallClock(),
+		ActivitySaved: true,
+	}
+	if err := db.SaveCheckpoint(cp); err != nil {
+		log.Printf("Failed to checkpoint session: %v", err)
+	}
+}
+
+// reportClosed removes the checkpoint if it only remained for workReportID
+func (s *Session) reportClosed(workReportID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	db := s.ActivityTracker.Database
+	cp, err := db.LoadCheckpoint()
+	if err != nil {
+		log.Printf("Failed to load session checkpoint: %v", err)
+		return
+	}
+	// A newer session may already have replaced the checkpoint
+	if cp == nil || !cp.ActivitySaved || cp.WorkReportID != workReportID {
+		return
+	}
+	if err := db.ClearCheckpoint(); err != nil {
+		log.Printf("Failed to clear session checkpoint: %v", err)
+	}
+}
+
+// startCheckpoints refreshes the checkpoint periodically until stopCheckpoints.
+// Callers must hold s.mu.
+func (s *Session) startCheckpoints() {
+	s.writeCheckpoint()
+	if s.checkpointStop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	s.checkpointStop = stop
+	go func() {
+		defer logging.Recover("session checkpoint", nil)
+		ticker := time.NewTicker(checkpointInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.mu.Lock()
+				s.writeCheckpoint()
+				s.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// stopCheckpoints stops the refresh loop. Callers must hold s.mu.
+func (s *Session) stopCheckpoints() {
+	if s.checkpointStop != nil {
+		close(s.checkpointStop)
+		s.checkpointStop = nil
+	}
+}
@@ -0,0 +1,182 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	db := newTestDatabase(t)
+	if cp, err := db.LoadCheckpoint(); err != nil || cp != nil {
+		t.Fatalf("LoadCheckpoint() = %+v, %v before any was saved", cp, err)
+	}
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	want := SessionCheckpoint{TaskID: 7, TaskName: "Design", StartTime: start, WorkReportID: 12, LastSeen: start.Add(time.Hour), ActivitySaved: true}
+	if err := db.SaveCheckpoint(SessionCheckpoint{TaskID: 1, StartTime: start}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveCheckpoint(want); err != nil { // Replaces the first
+		t.Fatal(err)
+	}
+	got, err := db.LoadCheckpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.TaskID != want.TaskID || !got.StartTime.Equal(want.StartTime) || !got.LastSeen.Equal(want.LastSeen) ||
+		got.WorkReportID != want.WorkReportID || !got.ActivitySaved {
+		t.Fatalf("LoadCheckpoint() = %+v, want %+v", got, want)
+	}
+
+	if err := db.ClearCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	if cp, _ := db.LoadCheckpoint(); cp != nil {
+		t.Errorf("checkpoint %+v left after ClearCheckpoint", cp)
+	}
+}
+
+func TestSessionCheckpoints(t *testing.T) {
+	s := newTestSession(t, newFakeTaskAPI())
+	db := s.ActivityTracker.Database
+	if err := s.Start(demoTask); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.OpenWorkReport("start"); err != nil {
+		t.Fatal(err)
+	}
+	reportID := s.TaskManager.GetWorkReport().ID
+
+	steps := []struct {
+		name          string
+		action        func() error
+		wantCP        bool
+		activitySaved bool
+	}{
+		{"tracking", func() error { return nil }, true, false},
+		{"stopped", s.Stop, true, true},
+		{"report closed", func() error { return s.CloseWorkReport("done") }, false, false},
+	}
+	for _, step := range steps {
+		if err := step.action(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		cp, err := db.LoadCheckpoint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (cp != nil) != step.wantCP {
+			t.Fatalf("%s: checkpoint = %+v, want one %v", step.name, cp, step.wantCP)
+		}
+		if cp != nil && (cp.WorkReportID != reportID || cp.ActivitySaved != step.activitySaved || cp.TaskID != demoTask.ID) {
+			t.Errorf("%s: checkpoint = %+v", step.name, cp)
+		}
+	}
+}
+
+func TestCloseCheckpoint(t *testing.T) {
+	tests := []struct {
+		name           string
+		withReport     bool
+		activitySaved  bool
+		wantActivities int
+	}{
+		{"crashed while tracking", true, false, 1},
+		{"crashed before closing the report", true, true, 0},
+		{"crashed before the report opened", false, false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newFakeTaskAPI()
+			s := newTestSession(t, api)
+			db := s.ActivityTracker.Database
+			start := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+			cp := SessionCheckpoint{TaskID: demoTask.ID, TaskName: demoTask.Name, StartTime: start,
+				LastSeen: start.Add(time.Hour), ActivitySaved: tt.activitySaved}
+			if tt.withReport {
+				report, err := api.StartUserTask(demoTask.Project.ID, demoTask.ID, "start", start.Format(time.RFC3339))
+				if err != nil {
+					t.Fatal(err)
+				}
+				cp.WorkReportID = report.ID
+			}
+			if err := db.SaveCheckpoint(cp); err != nil {
+				t.Fatal(err)
+			}
+
+			pending, err := s.PendingCheckpoint()
+			if err != nil || pending == nil {
+				t.Fatalf("PendingCheckpoint() = %+v, %v", pending, err)
+			}
+			if err := s.CloseCheckpoint(*pending, "recovered"); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.withReport {
+				end, ok := api.stoppedAt(cp.WorkReportID)
+				if !ok || !end.Equal(cp.LastSeen) {
+					t.Errorf("report closed at %v (%v), want %v", end, ok, cp.LastSeen)
+				}
+			}
+			records, err := db.GetActivityRecords()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != tt.wantActivities {
+				t.Fatalf("%d activities saved, want %d", len(records), tt.wantActivities)
+			}
+			if len(records) == 1 && records[0].DurationSeconds != 3600 {
+				t.Errorf("saved %ds, want the hour until last seen", records[0].DurationSeconds)
+			}
+			if cp, _ := db.LoadCheckpoint(); cp != nil {
+				t.Errorf("checkpoint %+v kept after closing it", cp)
+			}
+		})
+	}
+}
+
+func TestCloseCheckpointOffline(t *testing.T) {
+	api := newFakeTaskAPI()
+	s := newTestSession(t, api)
+	db := s.ActivityTracker.Database
+	start := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	report, err := api.StartUserTask(demoTask.Project.ID, demoTask.ID, "start", start.Format(time.RFC3339))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp := SessionCheckpoint{TaskID: demoTask.ID, TaskName: demoTask.Name, StartTime: start,
+		LastSeen: start.Add(time.Hour), WorkReportID: report.ID}
+	if err := db.SaveCheckpoint(cp); err != nil {
+		t.Fatal(err)
+	}
+
+	api.stopErr = errors.New("offline")
+	if err := s.CloseCheckpoint(cp, "recovered"); err == nil {
+		t.Fatal("CloseCheckpoint() succeeded with the server offline")
+	}
+	pending, err := s.PendingCheckpoint()
+	if err != nil || pending == nil {
+		t.Fatalf("PendingCheckpoint() = %+v, %v, want it kept for a retry", pending, err)
+	}
+	if !pending.ActivitySaved {
+		t.Error("kept checkpoint doesn't record the saved activity")
+	}
+
+	// Retried once the server is back
+	api.stopErr = nil
+	if err := s.CloseCheckpoint(*pending, "recovered"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := api.stoppedAt(report.ID); !ok {
+		t.Error("report wasn't closed by the retry")
+	}
+	records, err := db.GetActivityRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Errorf("%d activities saved, want 1", len(records))
+	}
+}
@@ -0,0 +1,225 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// ConcurrentTask is a task tracked alongside the session's main task. It has
+// its own work report but no screenshots or input monitoring of its own.
+type ConcurrentTask struct {
+	Task  types.Task
+	Start time.Time
+}
+
+// concurrentStop is a concurrent task stopped with the session whose work
+// report is closed by CloseWorkReport
+type concurrentStop struct {
+	task      types.Task
+	start     time.Time
+	allocated time.Duration
+}
+
+// timeAllocator splits time equally between the tasks tracked at each moment:
+// while n tasks run, each accrues 1/n of the elapsed time
+type timeAllocator struct {
+	last   time.Time
+	shares map[int]time.Duration // Accrued share by task ID
+}
+
+// reset starts allocating afresh with only taskID running
+func (a *timeAllocator) reset(taskID int, now time.Time) {
+	a.last = now
+	a.shares = map[int]time.Duration{taskID: 0}
+}
+
+// advance accrues the time since the last change to the running tasks
+func (a *timeAllocator) advance(now time.Time) {
+	if n := len(a.shares); n > 0 && now.After(a.last) {
+		each := now.Sub(a.last) / time.Duration(n)
+		for id := range a.shares {
+			a.shares[id] += each
+		}
+	}
+	a.last = now
+}
+
+// add starts accruing time for taskID
+func (a *timeAllocator) add(taskID int, now time.Time) {
+	a.advance(now)
+	a.shares[taskID] = 0
+}
+
+// remove stops accruing time for taskID and returns its share
+func (a *timeAllocator) remove(taskID int, now time.Time) time.Duration {
+	a.advance(now)
+	share := a.shares[taskID]
+	delete(a.shares, taskID)
+	return share
+}
+
+// rename moves the share accrued by one task ID to another
+func (a *timeAllocator) rename(from, to int) {
+	share, ok := a.shares[from]
+	if !ok {
+		return
+	}
+	delete(a.shares, from)
+	a.shares[to] = share
+}
+
+// allocated returns the time credited to a task that ran from start to now,
+// given its equal share: the share when time is split, otherwise all of it
+func allocated(start, now time.Time, share time.Duration) time.Duration {
+	if config.Current().TimeAllocation == config.AllocationFull {
+		return now.Sub(start)
+	}
+	return share
+}
+
+// AddTask tracks task alongside the main task, opening a work report for it
+// unless it is local. Concurrent tasks keep running across Switch and stop
+// with the session.
+func (s *Session) AddTask(task types.Task, description string) error {
+	s.mu.Lock()
+	if s.task == nil {
+		s.mu.Unlock()
+		return errors.New("start tracking a task first")
+	}
+	if s.task.ID == task.ID || s.concurrentIndex(task.ID) >= 0 {
+		s.mu.Unlock()
+		return fmt.Errorf("already tracking %s", task.Name)
+	}
+	now := wallClock()
+	s.concurrent = append(s.concurrent, ConcurrentTask{Task: task, Start: now})
+	s.alloc.add(task.ID, now)
+	s.mu.Unlock()
+
+	if task.Local {
+		return nil
+	}
+	if _, err := s.TaskManager.StartConcurrentTask(task, description); err != nil {
+		s.mu.Lock()
+		if i := s.concurrentIndex(task.ID); i >= 0 {
+			s.concurrent = append(s.concurrent[:i], s.concurrent[i+1:]...)
+			s.alloc.remove(task.ID, wallClock())
+		}
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// RemoveTask stops tracking a concurrent task, saving its activity and
+// closing its work report
+func (s *Session) RemoveTask(taskID int, description string) error {
+	s.mu.Lock()
+	i := s.concurrentIndex(taskID)
+	if i < 0 {
+		s.mu.Unlock()
+		return errors.New("that task is not being tracked")
+	}
+	ct := s.concurrent[i]
+	s.concurrent = append(s.concurrent[:i], s.concurrent[i+1:]...)
+	now := wallClock()
+	stop := concurrentStop{task: ct.Task, start: ct.Start, allocated: allocated(ct.Start, now, s.alloc.remove(taskID, now))}
+	s.mu.Unlock()
+
+	s.saveConcurrent(stop, now)
+	return s.closeConcurrent(stop, description)
+}
+
+// ConcurrentTasks returns the tasks tracked alongside the main task
+func (s *Session) ConcurrentTasks() []ConcurrentTask {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ConcurrentTask(nil), s.concurrent...)
+}
+
+// concurrentIndex returns the position of taskID in s.concurrent, or -1.
+// Callers must hold s.mu.
+func (s *Session) concurrentIndex(taskID int) int {
+	for i, ct := range s.concurrent {
+		if ct.Task.ID == taskID {
+			return i
+		}
+	}
+	return -1
+}
+
+// stopConcurrent stops every concurrent task with the session, saving their
+// activities now and leaving their reports for CloseWorkReport. Callers must
+// hold s.mu.
+func (s *Session) stopConcurrent(now time.Time) {
+	for _, ct := range s.concurrent {
+		stop := concurrentStop{task: ct.Task, start: ct.Start, allocated: allocated(ct.Start, now, s.alloc.remove(ct.Task.ID, now))}
+		s.saveConcurrent(stop, now)
+		s.pendingConcurrent = append(s.pendingConcurrent, stop)
+	}
+	s.concurrent = nil
+}
+
+// allocateMain caps the main task's saved duration at its share of the time
+// and remembers how much to take off its work report. Callers must hold s.mu.
+func (s *Session) allocateMain(taskID int, now time.Time) {
+	s.unallocated = 0
+	share := s.alloc.remove(taskID, now)
+	if config.Current().TimeAllocation == config.AllocationFull {
+		return
+	}
+	if elapsed := now.Sub(s.startTime); elapsed-share > time.Second {
+		s.ActivityTracker.SetAllocatedDuration(share)
+		s.unallocated = elapsed - share
+	}
+}
+
+// saveConcurrent records a stopped concurrent task's activity
+func (s *Session) saveConcurrent(stop concurrentStop, now time.Time) {
+	workReportID := 0
+	if report := s.TaskManager.concurrentReport(stop.task.ID); report != nil {
+		workReportID = report.ID
+	}
+	err := s.ActivityTracker.Database.SaveActivity(stop.task.Name,
+		stop.start.UTC().Format(time.RFC3339), now.UTC().Format(time.RFC3339),
+		int(stop.allocated.Seconds()), "", 0, 0, "", workReportID, -1, 0, initialSyncState(workReportID, stop.task.Local))
+	if err != nil {
+		log.Printf("Failed to save activity for %s: %v", stop.task.Name, err)
+	}
+}
+
+// closeConcurrent closes a stopped concurrent task's work report at the end
+// of its allocated time
+func (s *Session) closeConcurrent(stop concurrentStop, description string) error {
+	if stop.task.Local {
+		return nil
+	}
+	report := s.TaskManager.concurrentReport(stop.task.ID)
+	if err := s.TaskManager.StopConcurrentTask(stop.task.ID, description, stop.start.Add(stop.allocated)); err != nil {
+		return err
+	}
+	if report != nil {
+		s.markSynced(report.ID)
+	}
+	return nil
+}
+
+// closePendingConcurrent closes the reports of concurrent tasks stopped with the session
+func (s *Session) closePendingConcurrent(description string) error {
+	s.mu.Lock()
+	pending := s.pendingConcurrent
+	s.pendingConcurrent = nil
+	s.mu.Unlock()
+
+	var errs []error
+	for _, stop := range pending {
+		if err := s.closeConcurrent(stop, description); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", stop.task.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+func TestTimeAllocator(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	tests := []struct {
+		name string
+		run  func(a *timeAllocator) time.Duration
+		want time.Duration
+	}{
+		{"alone", func(a *timeAllocator) time.Duration {
+			return a.remove(1, at(60))
+		}, 60 * time.Minute},
+		{"joined later", func(a *timeAllocator) time.Duration {
+			a.add(2, at(60))
+			return a.remove(2, at(120))
+		}, 30 * time.Minute},
+		{"main task after a split", func(a *timeAllocator) time.Duration {
+			a.add(2, at(60))
+			a.remove(2, at(120))
+			return a.remove(1, at(180))
+		}, 150 * time.Minute},
+		{"three ways", func(a *timeAllocator) time.Duration {
+			a.add(2, at(0))
+			a.add(3, at(0))
+			return a.remove(3, at(90))
+		}, 30 * time.Minute},
+		{"renamed", func(a *timeAllocator) time.Duration {
+			a.add(2, at(0))
+			a.rename(2, 5)
+			return a.remove(5, at(60))
+		}, 30 * time.Minute},
+		{"unknown task", func(a *timeAllocator) time.Duration {
+			return a.remove(9, at(60))
+		}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a timeAllocator
+			a.reset(1, start)
+			if got := tt.run(&a); got != tt.want {
+				t.Errorf("share = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocated(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		allocation string
+		want       time.Duration
+	}{
+		{config.AllocationEqual, 20 * time.Minute},
+		{config.AllocationFull, time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.allocation, func(t *testing.T) {
+			updateSettings(t, func(s *config.Settings) { s.TimeAllocation = tt.allocation })
+			if got := allocated(start, start.Add(time.Hour), 20*time.Minute); got != tt.want {
+				t.Errorf("allocated() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionConcurrentTasks(t *testing.T) {
+	api := newFakeTaskAPI()
+	s := newTestSession(t, api)
+	review := types.Task{ID: 102, Name: "Accessibility review", Project: demoTask.Project}
+	release := types.Task{ID: 202, Name: "Release notes", Project: types.Project{ID: 2, Name: "Mobile App"}}
+
+	if err := s.AddTask(review, "with"); err == nil {
+		t.Error("AddTask() before Start succeeded")
+	}
+	if err := s.Start(demoTask); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.OpenWorkReport("start"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		task    types.Task
+		wantErr bool
+	}{
+		{"alongside the main task", review, false},
+		{"the main task", demoTask, true},
+		{"already alongside", review, true},
+		{"a second one", release, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.AddTask(tt.task, "with"); (err != nil) != tt.wantErr {
+				t.Errorf("AddTask() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+	if got := len(s.ConcurrentTasks()); got != 2 {
+		t.Fatalf("%d concurrent tasks, want 2", got)
+	}
+
+	reviewReport := s.TaskManager.concurrentReport(review.ID)
+	releaseReport := s.TaskManager.concurrentReport(release.ID)
+	if reviewReport == nil || releaseReport == nil {
+		t.Fatal("no work reports were opened for the concurrent tasks")
+	}
+	if err := s.RemoveTask(review.ID, "done"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := api.stoppedAt(reviewReport.ID); !ok {
+		t.Error("RemoveTask() didn't close the task's work report")
+	}
+	if err := s.RemoveTask(review.ID, "done"); err == nil {
+		t.Error("removing a task twice succeeded")
+	}
+
+	// The rest stop with the session and close with its report
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.ConcurrentTasks()) != 0 {
+		t.Error("concurrent tasks kept running after Stop")
+	}
+	if err := s.CloseWorkReport("done"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := api.stoppedAt(releaseReport.ID); !ok {
+		t.Error("CloseWorkReport() didn't close the concurrent task's work report")
+	}
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/time-tracker/v2/internal/logging"
+)
+
+const (
+	healthCheckInterval   = time.Minute      // Between checks while online
+	healthCheckRetryDelay = 15 * time.Second // First retry after a failure, doubled while offline
+	healthCheckMaxDelay   = 5 * time.Minute
+	healthCheckTimeout    = 10 * time.Second
+	// offlineAfterFailures debounces the indicator so one dropped request doesn't flip it
+	offlineAfterFailures = 2
+)
+
+// ConnectivityMonitor periodically pings the backend and reports when it goes
+// offline or comes back
+type ConnectivityMonitor struct {
+	ping func(ctx context.Context) error
+
+	mu       sync.Mutex
+	online   bool
+	stop     chan struct{}
+	onChange func(online bool)
+}
+
+// NewConnectivityMonitor creates a monitor that checks the backend with ping.
+// The backend is assumed online until a check says otherwise.
+func NewConnectivityMonitor(ping func(ctx context.Context) error) *ConnectivityMonitor {
+	return &ConnectivityMonitor{ping: ping, online: true}
+}
+
+// SetChangeCallback registers fn to be called, from the monitoring goroutine,
+// whenever the online state changes
+func (cm *ConnectivityMonitor) SetChangeCallback(fn func(online bool)) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.onChange = fn
+}
+
+// Online reports the last known state
+func (cm *ConnectivityMonitor) Online() bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	return cm.online
+}
+
+// Start begins checking in the background. Calling it again while running does nothing.
+func (cm *ConnectivityMonitor) Start() {
+	cm.mu.Lock()
+	if cm.stop != nil {
+		cm.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	cm.stop = stop
+	cm.mu.Unlock()
+
+	go func() {
+		defer logging.Recover("connectivity monitor", nil)
+		failures := 0
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+			err := cm.ping(ctx)
+			cancel()
+
+			delay := healthCheckInterval
+			if err == nil {
+				failures = 0
+				cm.setOnline(true)
+			} else {
+				failures++
+				if failures == offlineAfterFailures {
+					log.Printf("Backend unreachable: %v", err)
+				}
+				if failures >= offlineAfterFailures {
+					cm.setOnline(false)
+				}
+				delay = min(healthCheckRetryDelay<<(failures-1), healthCheckMaxDelay)
+			}
+
+			select {
+			case <-stop:
+				return
+			case <-time.After(delay):
+			}
+		}
+	}()
+}
+
+// Stop ends the background checks
+func (cm *ConnectivityMonitor) Stop() {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if cm.stop != nil {
+		close(cm.stop)
+		cm.stop = nil
+	}
+}
+
+// setOnline records the state, calling the change callback if it differs
+func (cm *ConnectivityMonitor) setOnline(online bool) {
+	cm.mu.Lock()
+	changed := cm.online != online
+	cm.online = online
+	callback := cm.onChange
+	cm.mu.Unlock()
+
+	if changed && callback != nil {
+		callback(online)
+	}
+}
@@ -2,56 +2,145 @@ package core
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
-	"os"
+	"log"
 	"path/filepath"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/time-tracker/v2/internal/config"
 )
 
 type Database struct {
-	dbFile string
-	conn   *sql.DB
+	dbFile      string
+	conn        *sql.DB
+	unavailable error // Why the file can't be used, once Connect fell back to memory
 }
 
-func NewDatabase(dbFile string) *Database {
+// memoryDSN names the in-memory database used when the file can't be opened.
+// The shared cache keeps it alive across pooled connections.
+const memoryDSN = "file:time_tracker?mode=memory&cache=shared"
+
+func NewDatabase(dbFile string) (*Database, error) {
 	if dbFile == "" {
 		dbFile = "time_tracker.db"
 	}
 
-	var dbDir string
-	if homeDir, err := os.UserHomeDir(); err == nil {
-		dbDir = filepath.Join(homeDir, ".time-tracker")
-	} else {
-		panic(fmt.Sprintf("Failed to determine user home directory: %v", err))
-	}
-	err := os.MkdirAll(dbDir, os.ModePerm)
+	dbDir, err := config.DataDir()
 	if err != nil {
-		panic(fmt.Sprintf("Failed to create database directory: %v", err))
+		return nil, err
 	}
 	return &Database{
 		dbFile: filepath.Join(dbDir, dbFile),
-	}
+	}, nil
 }
 
+// NewMemoryDatabase returns a database kept only in memory, for when the
+// database file can't be used because of reason
+func NewMemoryDatabase(reason error) *Database {
+	return &Database{unavailable: reason}
+}
+
+// Connect opens the database and brings its schema up to date by applying any
+// pending migrations. It is a no-op if the database is already open.
+//
+// If the file can't be opened (e.g. a read-only disk) the database falls back
+// to memory, so tracking goes on without local history; Unavailable says why.
 func (db *Database) Connect() error {
-	conn, err := sql.Open("sqlite3", db.dbFile)
+	if db.conn != nil {
+		return nil
+	}
+	if db.unavailable == nil {
+		err := db.open(db.dbFile)
+		if err == nil {
+			return nil
+		}
+		log.Printf("Local database unavailable, history won't be saved: %v", err)
+		db.unavailable = err
+	}
+	return db.open(memoryDSN)
+}
+
+// open connects to dsn and migrates it, leaving db.conn unset on failure
+func (db *Database) open(dsn string) error {
+	conn, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 	db.conn = conn
 
 	err = db.initDatabase()
+	if err == nil {
+		err = db.migrate()
+	}
 	if err != nil {
+		conn.Close()
+		db.conn = nil
 		return err
 	}
+	return nil
+}
+
+// Unavailable returns why the database file couldn't be used, or nil if it is
+// in use. Data recorded while it is unavailable is lost when the app exits.
+func (db *Database) Unavailable() error {
+	return db.unavailable
+}
+
+// migrateTimesToUTC rewrites timestamps saved with a local UTC offset, as
+// they were before times were stored in UTC, so that text comparisons and
+// sorting in SQL agree. The offset makes the conversion exact.
+func (db *Database) migrateTimesToUTC() error {
+	columns := []struct{ table, column string }{
+		{"activities", "start_time"},
+		{"activities", "end_time"},
+		{"screenshots", "captured_at"},
+	}
+	for _, c := range columns {
+		if err := db.migrateColumnToUTC(c.table, c.column); err != nil {
+			return fmt.Errorf("failed to convert %s.%s to UTC: %w", c.table, c.column, err)
+		}
+	}
+	return nil
+}
 
-	err = db.checkAndUpdateSchema()
+// migrateColumnToUTC converts one column's non-UTC RFC 3339 timestamps to UTC
+func (db *Database) migrateColumnToUTC(table, column string) error {
+	query := fmt.Sprintf("SELECT id, %[1]s FROM %[2]s WHERE %[1]s IS NOT NULL AND %[1]s != '' AND %[1]s NOT LIKE '%%Z'", column, table)
+	rows, err := db.conn.Query(query)
 	if err != nil {
 		return err
 	}
+	converted := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var value string
+		if err := rows.Scan(&id, &value); err != nil {
+			rows.Close()
+			return err
+		}
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			converted[id] = t.UTC().Format(time.RFC3339)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(converted) == 0 {
+		return err
+	}
 
-	return nil
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	update := fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ?", table, column)
+	for id, value := range converted {
+		if _, err := tx.Exec(update, value, id); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 func (db *Database) initDatabase() error {
@@ -64,20 +153,87 @@ func (db *Database) initDatabase() error {
         duration INTEGER,
         screenshot_path TEXT,
         keyboard_event_count INTEGER DEFAULT 0,
-        mouse_event_count INTEGER DEFAULT 0
+        mouse_event_count INTEGER DEFAULT 0,
+        top_apps TEXT DEFAULT '',
+        work_report_id INTEGER DEFAULT 0,
+        description TEXT DEFAULT '',
+        activity_level INTEGER,
+        idle_seconds INTEGER DEFAULT 0,
+        sync_state TEXT DEFAULT 'synced',
+        server TEXT DEFAULT ''
     )`
 	_, err := db.conn.Exec(query)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
+
+	query = `
+    CREATE TABLE IF NOT EXISTS screenshots (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        path TEXT NOT NULL,
+        captured_at TEXT NOT NULL,
+        duplicate INTEGER DEFAULT 0,
+        scale_factor REAL,
+        physical_width INTEGER,
+        physical_height INTEGER,
+        logical_width INTEGER,
+        logical_height INTEGER,
+        work_report_id INTEGER DEFAULT 0,
+        activity_level INTEGER
+    )`
+	_, err = db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to initialize screenshots table: %w", err)
+	}
+
+	// Notes are keyed by path rather than screenshot row, so screenshots
+	// captured before they were recorded can be annotated too
+	query = `
+    CREATE TABLE IF NOT EXISTS screenshot_notes (
+        path TEXT PRIMARY KEY,
+        note TEXT NOT NULL,
+        updated_at TEXT NOT NULL
+    )`
+	_, err = db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to initialize screenshot_notes table: %w", err)
+	}
+
+	// A single row describing the running session, for crash recovery
+	query = `
+    CREATE TABLE IF NOT EXISTS session_checkpoint (
+        id INTEGER PRIMARY KEY CHECK (id = 1),
+        task_id INTEGER NOT NULL,
+        task_name TEXT NOT NULL,
+        start_time TEXT NOT NULL,
+        work_report_id INTEGER DEFAULT 0,
+        last_seen TEXT NOT NULL,
+        activity_saved INTEGER DEFAULT 0
+    )`
+	_, err = db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to initialize session_checkpoint table: %w", err)
+	}
+
+	// Tasks created in the app, tracked without a server work report
+	query = `
+    CREATE TABLE IF NOT EXISTS local_tasks (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        name TEXT NOT NULL UNIQUE,
+        created_at TEXT NOT NULL
+    )`
+	_, err = db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to initialize local_tasks table: %w", err)
+	}
 	return nil
 }
 
-func (db *Database) checkAndUpdateSchema() error {
-	query := "PRAGMA table_info(activities)"
-	rows, err := db.conn.Query(query)
+// tableColumns returns the names of a table's columns
+func (db *Database) tableColumns(table string) (map[string]bool, error) {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
 	if err != nil {
-		return fmt.Errorf("failed to fetch table info: %w", err)
+		return nil, fmt.Errorf("failed to fetch table info: %w", err)
 	}
 	defer rows.Close()
 
@@ -89,47 +245,243 @@ func (db *Database) checkAndUpdateSchema() error {
 		var dfltValue sql.NullString
 		err := rows.Scan(&cid, &name, &ctype, &notnull, &dfltValue, &pk)
 		if err != nil {
-			return fmt.Errorf("failed to scan table info: %w", err)
+			return nil, fmt.Errorf("failed to scan table info: %w", err)
 		}
 		columns[name] = true
 	}
+	return columns, rows.Err()
+}
 
-	if !columns["keyboard_event_count"] {
-		_, err := db.conn.Exec(`
-        ALTER TABLE activities
-        ADD COLUMN keyboard_event_count INTEGER DEFAULT 0
-        `)
-		if err != nil {
-			return fmt.Errorf("failed to add keyboard_event_count column: %w", err)
-		}
+// SaveActivity records a finished activity. activityLevel is a percentage, or
+// -1 if it is unknown; idleSeconds is the part of duration without input.
+// syncState is one of the Sync constants, usually from initialSyncState.
+// The activity is recorded against the active server profile.
+func (db *Database) SaveActivity(task, startTime, endTime string, duration int, screenshotPath string, keyboardEventCount, mouseEventCount int, topApps string, workReportID, activityLevel, idleSeconds int, syncState string) error {
+	query := `
+    INSERT INTO activities (task, start_time, end_time, duration, screenshot_path, keyboard_event_count, mouse_event_count, top_apps, work_report_id, activity_level, idle_seconds, sync_state, server)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := db.conn.Exec(query, task, startTime, endTime, duration, screenshotPath, keyboardEventCount, mouseEventCount, topApps, workReportID, nullActivityLevel(activityLevel), idleSeconds, syncState, activeServer())
+	if err != nil {
+		return fmt.Errorf("failed to save activity: %w", err)
 	}
+	return nil
+}
 
-	if !columns["mouse_event_count"] {
-		_, err := db.conn.Exec(`
-        ALTER TABLE activities
-        ADD COLUMN mouse_event_count INTEGER DEFAULT 0
-        `)
-		if err != nil {
-			return fmt.Errorf("failed to add mouse_event_count column: %w", err)
-		}
+// UpdateActivity changes an activity's task and times, recomputing its duration
+func (db *Database) UpdateActivity(id int64, task string, startTime, endTime time.Time) error {
+	if task == "" {
+		return fmt.Errorf("activity task cannot be empty")
+	}
+	if !endTime.After(startTime) {
+		return fmt.Errorf("activity end time must be after its start time")
+	}
+	query := `
+    UPDATE activities SET task = ?, start_time = ?, end_time = ?, duration = ?
+    WHERE id = ?`
+	result, err := db.conn.Exec(query, task, startTime.UTC().Format(time.RFC3339), endTime.UTC().Format(time.RFC3339),
+		int(endTime.Sub(startTime).Seconds()), id)
+	if err != nil {
+		return fmt.Errorf("failed to update activity: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("activity %d not found", id)
+	}
+	return nil
+}
+
+// UpdateActivityDescription changes an activity's description
+func (db *Database) UpdateActivityDescription(id int64, description string) error {
+	result, err := db.conn.Exec("UPDATE activities SET description = ? WHERE id = ?", description, id)
+	if err != nil {
+		return fmt.Errorf("failed to update activity description: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("activity %d not found", id)
+	}
+	return nil
+}
+
+// SetWorkReportDescription records the description a work report was closed
+// with on the activities saved for it
+func (db *Database) SetWorkReportDescription(workReportID int, description string) error {
+	_, err := db.conn.Exec("UPDATE activities SET description = ? WHERE work_report_id = ?", description, workReportID)
+	if err != nil {
+		return fmt.Errorf("failed to update activity description: %w", err)
 	}
+	return nil
+}
 
+// DeleteActivity removes an activity
+func (db *Database) DeleteActivity(id int64) error {
+	if _, err := db.conn.Exec("DELETE FROM activities WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete activity: %w", err)
+	}
 	return nil
 }
 
-func (db *Database) SaveActivity(task, startTime, endTime string, duration int, screenshotPath string, keyboardEventCount, mouseEventCount int) error {
+// SaveScreenshot records a captured screenshot, whether it was skipped as a
+// duplicate, the display scaling it was captured at, the work report that
+// was open (0 if none) and the activity level since the previous capture (-1 if unknown)
+func (db *Database) SaveScreenshot(path, capturedAt string, duplicate bool, scale ScreenshotScale, workReportID, activityLevel int) error {
 	query := `
-    INSERT INTO activities (task, start_time, end_time, duration, screenshot_path, keyboard_event_count, mouse_event_count)
-    VALUES (?, ?, ?, ?, ?, ?, ?)`
-	_, err := db.conn.Exec(query, task, startTime, endTime, duration, screenshotPath, keyboardEventCount, mouseEventCount)
+    INSERT INTO screenshots (path, captured_at, duplicate, scale_factor, physical_width, physical_height, logical_width, logical_height, work_report_id, activity_level)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := db.conn.Exec(query, path, capturedAt, duplicate, scale.Factor,
+		scale.PhysicalWidth, scale.PhysicalHeight, scale.LogicalWidth, scale.LogicalHeight, workReportID, nullActivityLevel(activityLevel))
 	if err != nil {
-		return fmt.Errorf("failed to save activity: %w", err)
+		return fmt.Errorf("failed to save screenshot: %w", err)
+	}
+	return nil
+}
+
+// GetWorkReportScreenshots returns the paths of the non-duplicate screenshots
+// captured while workReportID was open, oldest first
+func (db *Database) GetWorkReportScreenshots(workReportID int) ([]string, error) {
+	rows, err := db.conn.Query(`
+    SELECT path FROM screenshots
+    WHERE work_report_id = ? AND duplicate = 0
+    ORDER BY captured_at, id`, workReportID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve work report screenshots: %w", err)
+	}
+	defer rows.Close()
+
+	paths := []string{}
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, fmt.Errorf("failed to scan screenshot: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, rows.Err()
+}
+
+// SetScreenshotNote saves the note on the screenshot at path, removing it if
+// note is empty
+func (db *Database) SetScreenshotNote(path, note string) error {
+	var err error
+	if note == "" {
+		_, err = db.conn.Exec("DELETE FROM screenshot_notes WHERE path = ?", path)
+	} else {
+		_, err = db.conn.Exec(`
+    INSERT INTO screenshot_notes (path, note, updated_at) VALUES (?, ?, ?)
+    ON CONFLICT (path) DO UPDATE SET note = excluded.note, updated_at = excluded.updated_at`,
+			path, note, time.Now().UTC().Format(time.RFC3339))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save screenshot note: %w", err)
+	}
+	return nil
+}
+
+// ScreenshotNote returns the note on the screenshot at path, "" if it has none
+func (db *Database) ScreenshotNote(path string) (string, error) {
+	var note string
+	err := db.conn.QueryRow("SELECT note FROM screenshot_notes WHERE path = ?", path).Scan(&note)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load screenshot note: %w", err)
+	}
+	return note, nil
+}
+
+// LoadScreenshotDetails fills in the recorded display scaling, activity level
+// and note of each file, leaving the first two nil for screenshots captured
+// before they were recorded
+func (db *Database) LoadScreenshotDetails(files []ScreenshotFile) error {
+	notes, err := db.screenshotNotes()
+	if err != nil {
+		return err
+	}
+	for i := range files {
+		files[i].Note = notes[files[i].Path]
+	}
+
+	rows, err := db.conn.Query(`
+    SELECT path, scale_factor, physical_width, physical_height, logical_width, logical_height, activity_level
+    FROM screenshots WHERE scale_factor IS NOT NULL OR activity_level IS NOT NULL`)
+	if err != nil {
+		return fmt.Errorf("failed to load screenshot details: %w", err)
+	}
+	defer rows.Close()
+
+	scales := make(map[string]ScreenshotScale)
+	levels := make(map[string]int)
+	for rows.Next() {
+		var path string
+		var factor sql.NullFloat64
+		var physicalWidth, physicalHeight, logicalWidth, logicalHeight, level sql.NullInt64
+		if err := rows.Scan(&path, &factor, &physicalWidth, &physicalHeight, &logicalWidth, &logicalHeight, &level); err != nil {
+			return fmt.Errorf("failed to scan screenshot details: %w", err)
+		}
+		if factor.Valid {
+			scales[path] = ScreenshotScale{
+				Factor:         factor.Float64,
+				PhysicalWidth:  int(physicalWidth.Int64),
+				PhysicalHeight: int(physicalHeight.Int64),
+				LogicalWidth:   int(logicalWidth.Int64),
+				LogicalHeight:  int(logicalHeight.Int64),
+			}
+		}
+		if level.Valid {
+			levels[path] = int(level.Int64)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i := range files {
+		if s, ok := scales[files[i].Path]; ok {
+			files[i].Scale = &s
+		}
+		if l, ok := levels[files[i].Path]; ok {
+			files[i].ActivityLevel = &l
+		}
 	}
 	return nil
 }
 
+// screenshotNotes returns every screenshot note by path
+func (db *Database) screenshotNotes() (map[string]string, error) {
+	rows, err := db.conn.Query("SELECT path, note FROM screenshot_notes")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load screenshot notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := make(map[string]string)
+	for rows.Next() {
+		var path, note string
+		if err := rows.Scan(&path, &note); err != nil {
+			return nil, fmt.Errorf("failed to scan screenshot note: %w", err)
+		}
+		notes[path] = note
+	}
+	return notes, rows.Err()
+}
+
+// nullActivityLevel stores an unknown (negative) activity level as NULL
+func nullActivityLevel(level int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(level), Valid: level >= 0}
+}
+
+// CountScreenshots returns how many screenshots were captured between from and to (RFC 3339)
+func (db *Database) CountScreenshots(from, to string) (int, error) {
+	var count int
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM screenshots WHERE captured_at BETWEEN ? AND ?", from, to).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count screenshots: %w", err)
+	}
+	return count, nil
+}
+
 func (db *Database) GetActivities() ([]map[string]interface{}, error) {
-	query := "SELECT * FROM activities"
+	query := `
+    SELECT id, task, start_time, end_time, duration, screenshot_path, keyboard_event_count, mouse_event_count, top_apps
+    FROM activities`
 	rows, err := db.conn.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve activities: %w", err)
@@ -139,9 +491,9 @@ func (db *Database) GetActivities() ([]map[string]interface{}, error) {
 	var activities []map[string]interface{}
 	for rows.Next() {
 		var id, duration, keyboardEventCount, mouseEventCount sql.NullInt64
-		var task, startTime, endTime, screenshotPath sql.NullString
+		var task, startTime, endTime, screenshotPath, topApps sql.NullString
 
-		err := rows.Scan(&id, &task, &startTime, &endTime, &duration, &screenshotPath, &keyboardEventCount, &mouseEventCount)
+		err := rows.Scan(&id, &task, &startTime, &endTime, &duration, &screenshotPath, &keyboardEventCount, &mouseEventCount, &topApps)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan activity: %w", err)
 		}
@@ -155,6 +507,7 @@ func (db *Database) GetActivities() ([]map[string]interface{}, error) {
 			"screenshot_path":      screenshotPath.String,
 			"keyboard_event_count": keyboardEventCount.Int64,
 			"mouse_event_count":    mouseEventCount.Int64,
+			"top_apps":             topApps.String,
 		}
 		activities = append(activities, activity)
 	}
@@ -168,5 +521,7 @@ func (db *Database) ClearActivities() error {
 	if err != nil {
 		return fmt.Errorf("failed to clear activities: %w", err)
 	}
-	return db.conn.Close()
+	err = db.conn.Close()
+	db.conn = nil
+	return err
 }
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// StartDescription is the work report description sent when tracking task starts
+func StartDescription(task types.Task) string {
+	return descriptionOrDefault(config.Current().StartDescriptionTemplate, config.DefaultStartDescription, task)
+}
+
+// StopDescription is the work report description sent when tracking task stops
+func StopDescription(task types.Task) string {
+	return descriptionOrDefault(config.Current().StopDescriptionTemplate, config.DefaultStopDescription, task)
+}
+
+// descriptionOrDefault formats template, falling back to fallback if the
+// template is unset or resolves to nothing
+func descriptionOrDefault(template, fallback string, task types.Task) string {
+	description := strings.TrimSpace(FormatDescription(template, task, time.Now()))
+	if description == "" {
+		return fallback
+	}
+	return description
+}
+
+// FormatDescription replaces {task}, {id}, {project}, {status}, {date} and
+// {time} in template. Fields the task doesn't have become empty; the date
+// and time are in the display time zone.
+func FormatDescription(template string, task types.Task, now time.Time) string {
+	status := ""
+	if task.Status != nil {
+		status = *task.Status
+	}
+	id := ""
+	if task.ID != 0 {
+		id = strconv.Itoa(task.ID)
+	}
+	now = now.In(config.DisplayLocation())
+	return strings.NewReplacer(
+		"{task}", task.Name,
+		"{id}", id,
+		"{project}", task.Project.Name,
+		"{status}", status,
+		"{date}", now.Format(time.DateOnly),
+		"{time}", now.Format("15:04"),
+	).Replace(template)
+}
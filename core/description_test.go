@@ -0,0 +1,66 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+func TestFormatDescription(t *testing.T) {
+	updateSettings(t, func(s *config.Settings) { s.DisplayTimezone = "UTC" })
+	open := "open"
+	task := types.Task{ID: 7, Name: "Docs", Project: types.Project{Name: "Website"}, Status: &open}
+	now := time.Date(2025, 3, 10, 9, 5, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		template string
+		task     types.Task
+		want     string
+	}{
+		{"every field", "{task} #{id} in {project} ({status}) on {date} at {time}", task, "Docs #7 in Website (open) on 2025-03-10 at 09:05"},
+		{"no placeholders", "Working", task, "Working"},
+		{"missing fields are empty", "{task}#{id}{status}", types.Task{Name: "Errands"}, "Errands#"},
+		{"unknown placeholder kept", "{task} {unknown}", task, "Docs {unknown}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatDescription(tt.template, tt.task, now); got != tt.want {
+				t.Errorf("FormatDescription(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartStopDescription(t *testing.T) {
+	tests := []struct {
+		name      string
+		start     string
+		stop      string
+		wantStart string
+		wantStop  string
+	}{
+		{"defaults", config.DefaultStartDescription, config.DefaultStopDescription, "Started", "Stopped"},
+		{"templates", "Start {task}", "Done with {task}", "Start " + demoTask.Name, "Done with " + demoTask.Name},
+		{"empty falls back", "", "  ", config.DefaultStartDescription, config.DefaultStopDescription},
+		{"blank result falls back", "{status}", "{status}", config.DefaultStartDescription, config.DefaultStopDescription},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateSettings(t, func(s *config.Settings) {
+				s.StartDescriptionTemplate = tt.start
+				s.StopDescriptionTemplate = tt.stop
+			})
+			if got := StartDescription(demoTask); got != tt.wantStart {
+				t.Errorf("StartDescription() = %q, want %q", got, tt.wantStart)
+			}
+			if got := StopDescription(demoTask); got != tt.wantStop {
+				t.Errorf("StopDescription() = %q, want %q", got, tt.wantStop)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// TrackerEventType identifies what happened in a TrackerEvent
+type TrackerEventType string
+
+// Tracker event types. Only the fields noted are set for each type.
+const (
+	// EventTrackingStarted is sent when a session starts. Task is set.
+	EventTrackingStarted TrackerEventType = "tracking_started"
+	// EventTrackingStopped is sent once a session has stopped and been saved.
+	// Task is set, and Summary if the session was saved.
+	EventTrackingStopped TrackerEventType = "tracking_stopped"
+	// EventTrackingPaused is sent when screenshots and input monitoring are
+	// suspended without ending the session (e.g. a Pomodoro break)
+	EventTrackingPaused TrackerEventType = "tracking_paused"
+	// EventTrackingResumed is sent when a paused session resumes
+	EventTrackingResumed TrackerEventType = "tracking_resumed"
+	// EventScreenshotCaptured is sent after a screenshot is saved, before it
+	// is uploaded. ScreenshotPath and Duplicate are set.
+	EventScreenshotCaptured TrackerEventType = "screenshot_captured"
+	// EventCaptureFailed is sent when the screen could not be captured. Err is set.
+	EventCaptureFailed TrackerEventType = "capture_failed"
+	// EventUploadConsentRequested is sent on a session's first upload when
+	// uploads need the user's consent. Answer with ScreenshotManager.SetUploadConsent.
+	EventUploadConsentRequested TrackerEventType = "upload_consent_requested"
+	// EventScreenLocked is sent when the screen locks while tracking
+	EventScreenLocked TrackerEventType = "screen_locked"
+	// EventScreenUnlocked is sent when the screen unlocks, or tracking stops while it is locked
+	EventScreenUnlocked TrackerEventType = "screen_unlocked"
+	// EventLocalHistoryUnavailable is sent, at most once, when sessions can't
+	// be saved to the local database. Tracking and uploads go on. Err is set.
+	EventLocalHistoryUnavailable TrackerEventType = "local_history_unavailable"
+)
+
+// eventBufferSize is how many undelivered events each subscriber can fall
+// behind before the oldest are dropped
+const eventBufferSize = 32
+
+// TrackerEvent describes something that happened in the activity tracker
+type TrackerEvent struct {
+	Type TrackerEventType
+	Time time.Time
+
+	Task           string
+	Summary        *SessionSummary
+	ScreenshotPath string
+	Duplicate      bool
+	Err            error
+}
+
+// eventBus fans events out to subscribers without ever blocking the sender:
+// a subscriber that falls behind loses its oldest events
+type eventBus struct {
+	mu          sync.Mutex
+	subscribers map[chan TrackerEvent]struct{}
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{subscribers: make(map[chan TrackerEvent]struct{})}
+}
+
+// subscribe returns a channel receiving every later event and a function
+// that ends the subscription and closes the channel
+func (b *eventBus) subscribe() (<-chan TrackerEvent, func()) {
+	ch := make(chan TrackerEvent, eventBufferSize)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// emit stamps ev with the current time and sends it to every subscriber
+func (b *eventBus) emit(ev TrackerEvent) {
+	if b == nil {
+		return
+	}
+	ev.Time = time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			// Full: drop the oldest event to make room
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- ev:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package core
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+// ActivityRecord is a typed row of the activities table
+type ActivityRecord struct {
+	ID                 int64      `json:"id"`
+	Task               string     `json:"task"`
+	StartTime          time.Time  `json:"start_time"`
+	EndTime            *time.Time `json:"end_time"`
+	DurationSeconds    int64      `json:"duration_seconds"`
+	ScreenshotPath     string     `json:"screenshot_path"`
+	KeyboardEventCount int64      `json:"keyboard_event_count"`
+	MouseEventCount    int64      `json:"mouse_event_count"`
+	TopApps            string     `json:"top_apps"`       // Most used applications, comma separated
+	WorkReportID       int64      `json:"work_report_id"` // Server work report, 0 if none was open
+	Description        string     `json:"description"`
+	ActivityLevel      int64      `json:"activity_level"` // Input activity as a percentage of the busy baseline, -1 if unknown
+	IdleSeconds        int64      `json:"idle_seconds"`   // Part of the duration without keyboard or mouse input
+	Local              bool       `json:"local"`          // Tracked against a local-only task
+}
+
+// WorkedSeconds is the activity's duration, less idle time unless idle counts as worked
+func (r ActivityRecord) WorkedSeconds() int64 {
+	if config.Current().CountIdleAsWorked {
+		return r.DurationSeconds
+	}
+	return max(0, r.DurationSeconds-r.IdleSeconds)
+}
+
+// TaskTotal aggregates tracked time for one task
+type TaskTotal struct {
+	Task            string `json:"task"`
+	Sessions        int64  `json:"sessions"`
+	DurationSeconds int64  `json:"duration_seconds"`
+	Local           bool   `json:"local"` // A local-only task
+}
+
+// GetActivityRecords returns all activities as typed records, oldest first
+func (db *Database) GetActivityRecords() ([]ActivityRecord, error) {
+	query := `
+    SELECT id, task, start_time, end_time, duration, screenshot_path, keyboard_event_count, mouse_event_count, top_apps, work_report_id, description, activity_level, idle_seconds,
+        task IN (SELECT name FROM local_tasks)
+    FROM activities ORDER BY start_time`
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve activities: %w", err)
+	}
+	defer rows.Close()
+
+	records := []ActivityRecord{}
+	for rows.Next() {
+		var id, duration, keyboardEventCount, mouseEventCount, workReportID, activityLevel, idleSeconds sql.NullInt64
+		var task, startTime, endTime, screenshotPath, topApps, description sql.NullString
+		var local bool
+
+		err := rows.Scan(&id, &task, &startTime, &endTime, &duration, &screenshotPath, &keyboardEventCount, &mouseEventCount, &topApps, &workReportID, &description, &activityLevel, &idleSeconds, &local)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan activity: %w", err)
+		}
+
+		record := ActivityRecord{
+			ID:                 id.Int64,
+			Task:               task.String,
+			DurationSeconds:    duration.Int64,
+			ScreenshotPath:     screenshotPath.String,
+			KeyboardEventCount: keyboardEventCount.Int64,
+			MouseEventCount:    mouseEventCount.Int64,
+			TopApps:            topApps.String,
+			WorkReportID:       workReportID.Int64,
+			Description:        description.String,
+			ActivityLevel:      -1,
+			IdleSeconds:        idleSeconds.Int64,
+			Local:              local,
+		}
+		if activityLevel.Valid {
+			record.ActivityLevel = activityLevel.Int64
+		}
+		if t, err := time.Parse(time.RFC3339, startTime.String); err == nil {
+			record.StartTime = t
+		}
+		if endTime.Valid && endTime.String != "" {
+			if t, err := time.Parse(time.RFC3339, endTime.String); err == nil {
+				record.EndTime = &t
+			}
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// GetActivitiesByDateRange returns the activities that started within [from, to)
+func (db *Database) GetActivitiesByDateRange(from, to time.Time) ([]ActivityRecord, error) {
+	records, err := db.GetActivityRecords()
+	if err != nil {
+		return nil, err
+	}
+	// Stored times may carry different UTC offsets, so compare parsed times
+	// rather than filtering on the text in SQL
+	filtered := []ActivityRecord{}
+	for _, r := range records {
+		if !r.StartTime.Before(from) && r.StartTime.Before(to) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered, nil
+}
+
+// GetTrackedSeconds returns the total tracked seconds for task across the
+// activities that started within [from, to)
+func (db *Database) GetTrackedSeconds(task string, from, to time.Time) (int64, error) {
+	records, err := db.GetActivitiesByDateRange(from, to)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, r := range records {
+		if r.Task == task {
+			total += r.WorkedSeconds()
+		}
+	}
+	return total, nil
+}
+
+// GetTaskTotalsBetween returns the number of sessions and tracked seconds per
+// task, by task name, for the activities that started within [from, to)
+func (db *Database) GetTaskTotalsBetween(from, to time.Time) ([]TaskTotal, error) {
+	records, err := db.GetActivitiesByDateRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	index := map[string]int{}
+	totals := []TaskTotal{}
+	for _, r := range records {
+		i, ok := index[r.Task]
+		if !ok {
+			i = len(totals)
+			index[r.Task] = i
+			totals = append(totals, TaskTotal{Task: r.Task, Local: r.Local})
+		}
+		totals[i].Sessions++
+		totals[i].DurationSeconds += r.WorkedSeconds()
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Task < totals[j].Task })
+	return totals, nil
+}
+
+// GetTaskTotals returns the number of sessions and total tracked seconds per
+// task, less idle time unless idle counts as worked
+func (db *Database) GetTaskTotals() ([]TaskTotal, error) {
+	duration := "duration"
+	if !config.Current().CountIdleAsWorked {
+		duration = "MAX(0, duration - COALESCE(idle_seconds, 0))"
+	}
+	query := `
+    SELECT task, COUNT(*), COALESCE(SUM(` + duration + `), 0), task IN (SELECT name FROM local_tasks)
+    FROM activities GROUP BY task ORDER BY task`
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve task totals: %w", err)
+	}
+	defer rows.Close()
+
+	totals := []TaskTotal{}
+	for rows.Next() {
+		var total TaskTotal
+		if err := rows.Scan(&total.Task, &total.Sessions, &total.DurationSeconds, &total.Local); err != nil {
+			return nil, fmt.Errorf("failed to scan task total: %w", err)
+		}
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
+
+// inDisplayZone converts the records' times to the configured display time
+// zone. Exports keep the UTC offset, so the times stay unambiguous.
+func inDisplayZone(records []ActivityRecord) {
+	loc := config.DisplayLocation()
+	for i := range records {
+		records[i].StartTime = records[i].StartTime.In(loc)
+		if records[i].EndTime != nil {
+			end := records[i].EndTime.In(loc)
+			records[i].EndTime = &end
+		}
+	}
+}
+
+// ExportJSON writes all activities to w as a JSON array of ActivityRecord
+func (db *Database) ExportJSON(w io.Writer) error {
+	records, err := db.GetActivityRecords()
+	if err != nil {
+		return err
+	}
+	inDisplayZone(records)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+// ExportTaskTotalsJSON writes per-task totals to w as a JSON array of TaskTotal
+func (db *Database) ExportTaskTotalsJSON(w io.Writer) error {
+	totals, err := db.GetTaskTotals()
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(totals)
+}
+
+// ExportCSV writes all activities to w as CSV with a header row
+func (db *Database) ExportCSV(w io.Writer) error {
+	records, err := db.GetActivityRecords()
+	if err != nil {
+		return err
+	}
+	inDisplayZone(records)
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"id", "task", "start_time", "end_time", "duration_seconds", "screenshot_path", "keyboard_event_count", "mouse_event_count", "top_apps", "description", "idle_seconds", "local"})
+	for _, r := range records {
+		endTime := ""
+		if r.EndTime != nil {
+			endTime = r.EndTime.Format(time.RFC3339)
+		}
+		writer.Write([]string{
+			strconv.FormatInt(r.ID, 10),
+			r.Task,
+			r.StartTime.Format(time.RFC3339),
+			endTime,
+			strconv.FormatInt(r.DurationSeconds, 10),
+			r.ScreenshotPath,
+			strconv.FormatInt(r.KeyboardEventCount, 10),
+			strconv.FormatInt(r.MouseEventCount, 10),
+			r.TopApps,
+			r.Description,
+			strconv.FormatInt(r.IdleSeconds, 10),
+			strconv.FormatBool(r.Local),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ExportTaskTotalsCSV writes per-task totals to w as CSV with a header row
+func (db *Database) ExportTaskTotalsCSV(w io.Writer) error {
+	totals, err := db.GetTaskTotals()
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"task", "sessions", "duration_seconds", "local"})
+	for _, t := range totals {
+		writer.Write([]string{t.Task, strconv.FormatInt(t.Sessions, 10), strconv.FormatInt(t.DurationSeconds, 10), strconv.FormatBool(t.Local)})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+// saveTestActivity records a session of task starting at start
+func saveTestActivity(t *testing.T, db *Database, task string, start time.Time, duration, idle time.Duration) {
+	t.Helper()
+	end := start.Add(duration)
+	err := db.SaveActivity(task, start.Format(time.RFC3339), end.Format(time.RFC3339), int(duration.Seconds()),
+		"", 10, 20, "", 0, 50, int(idle.Seconds()), SyncUnlinked)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTaskTotals(t *testing.T) {
+	day := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		idleAsWorked bool
+		want         map[string]int64
+	}{
+		{"idle counted", true, map[string]int64{"Design": 3600 + 1800, "Review": 600}},
+		{"idle deducted", false, map[string]int64{"Design": 3000 + 1800, "Review": 600}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateSettings(t, func(s *config.Settings) { s.CountIdleAsWorked = tt.idleAsWorked })
+			db := newTestDatabase(t)
+			saveTestActivity(t, db, "Design", day, time.Hour, 10*time.Minute)
+			saveTestActivity(t, db, "Design", day.Add(2*time.Hour), 30*time.Minute, 0)
+			saveTestActivity(t, db, "Review", day.Add(3*time.Hour), 10*time.Minute, 0)
+			saveTestActivity(t, db, "Review", day.AddDate(0, 0, 1), time.Hour, 0) // Outside the range
+
+			all, err := db.GetTaskTotals()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all) != 2 || all[1].Sessions != 2 {
+				t.Errorf("GetTaskTotals() = %+v, want two tasks with Review in 2 sessions", all)
+			}
+
+			totals, err := db.GetTaskTotalsBetween(day, day.AddDate(0, 0, 1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(totals) != len(tt.want) {
+				t.Fatalf("GetTaskTotalsBetween() = %+v, want %d tasks", totals, len(tt.want))
+			}
+			for _, total := range totals {
+				if total.DurationSeconds != tt.want[total.Task] {
+					t.Errorf("%s = %ds, want %ds", total.Task, total.DurationSeconds, tt.want[total.Task])
+				}
+			}
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	updateSettings(t, func(s *config.Settings) { s.DisplayTimezone = "UTC" })
+	db := newTestDatabase(t)
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	saveTestActivity(t, db, "Design", start, time.Hour, 5*time.Minute)
+	saveTestActivity(t, db, "Review, final", start.Add(2*time.Hour), 10*time.Minute, 0)
+
+	t.Run("JSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := db.ExportJSON(&buf); err != nil {
+			t.Fatal(err)
+		}
+		var records []ActivityRecord
+		if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 2 {
+			t.Fatalf("exported %d records, want 2", len(records))
+		}
+		first := records[0]
+		if first.Task != "Design" || !first.StartTime.Equal(start) || first.DurationSeconds != 3600 || first.IdleSeconds != 300 {
+			t.Errorf("first record = %+v", first)
+		}
+		if first.EndTime == nil || !first.EndTime.Equal(start.Add(time.Hour)) {
+			t.Errorf("end time = %v, want %v", first.EndTime, start.Add(time.Hour))
+		}
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := db.ExportCSV(&buf); err != nil {
+			t.Fatal(err)
+		}
+		rows, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 3 || rows[0][1] != "task" {
+			t.Fatalf("CSV has %d rows, want a header and 2 activities: %q", len(rows), rows)
+		}
+		if rows[2][1] != "Review, final" || rows[1][2] != "2025-03-10T09:00:00Z" {
+			t.Errorf("rows = %q", rows[1:])
+		}
+	})
+
+	t.Run("task totals", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := db.ExportTaskTotalsCSV(&buf); err != nil {
+			t.Fatal(err)
+		}
+		rows, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 3 || rows[1][0] != "Design" || rows[1][1] != "1" {
+			t.Errorf("rows = %q", rows)
+		}
+	})
+}
+
+func TestWorkedSeconds(t *testing.T) {
+	tests := []struct {
+		name         string
+		record       ActivityRecord
+		idleAsWorked bool
+		want         int64
+	}{
+		{"idle deducted", ActivityRecord{DurationSeconds: 600, IdleSeconds: 120}, false, 480},
+		{"idle counted", ActivityRecord{DurationSeconds: 600, IdleSeconds: 120}, true, 600},
+		{"never negative", ActivityRecord{DurationSeconds: 60, IdleSeconds: 120}, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateSettings(t, func(s *config.Settings) { s.CountIdleAsWorked = tt.idleAsWorked })
+			if got := tt.record.WorkedSeconds(); got != tt.want {
+				t.Errorf("WorkedSeconds() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	hook "github.com/robotn/gohook"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/types"
+	"github.com/time-tracker/v2/services"
+)
+
+// fakeTaskAPI is the demo backend, recording the work reports it closes and
+// the screenshots it receives
+type fakeTaskAPI struct {
+	*services.DemoTaskService
+
+	mu         sync.Mutex
+	stopped    map[int]time.Time // End time by work report ID, as closed or corrected
+	uploads    []fakeUpload
+	uploadHold chan struct{} // If set, uploads wait until it is closed
+	stopHold   chan struct{} // If set, closing a report waits until it is closed
+	stopErr    error         // If set, closing a report fails with it
+}
+
+// fakeUpload is a screenshot received by fakeTaskAPI
+type fakeUpload struct {
+	workReportID int
+	path, note   string
+}
+
+func newFakeTaskAPI() *fakeTaskAPI {
+	return &fakeTaskAPI{DemoTaskService: services.NewDemoTaskService(), stopped: map[int]time.Time{}}
+}
+
+func (f *fakeTaskAPI) StopUserTask(workReportID int, endTime string, description *string) (*types.WorkReport, error) {
+	if f.stopHold != nil {
+		<-f.stopHold
+	}
+	if f.stopErr != nil {
+		return nil, f.stopErr
+	}
+	end, err := time.Parse(time.RFC3339, endTime)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	f.stopped[workReportID] = end
+	f.mu.Unlock()
+	return f.DemoTaskService.StopUserTask(workReportID, endTime, description)
+}
+
+func (f *fakeTaskAPI) UpdateWorkReport(workReportID int, task *types.Task, startTime, endTime string) (*types.WorkReport, error) {
+	end, err := time.Parse(time.RFC3339, endTime)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	f.stopped[workReportID] = end
+	f.mu.Unlock()
+	return f.DemoTaskService.UpdateWorkReport(workReportID, task, startTime, endTime)
+}
+
+func (f *fakeTaskAPI) UploadScreenshot(workReportID int, filePath, note string) error {
+	if f.uploadHold != nil {
+		<-f.uploadHold
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.uploads = append(f.uploads, fakeUpload{workReportID, filePath, note})
+	return nil
+}
+
+// uploaded returns the screenshots received so far
+func (f *fakeTaskAPI) uploaded() []fakeUpload {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeUpload(nil), f.uploads...)
+}
+
+// stoppedAt returns when the work report was closed, and whether it was
+func (f *fakeTaskAPI) stoppedAt(workReportID int) (time.Time, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	end, ok := f.stopped[workReportID]
+	return end, ok
+}
+
+// newStubInputMonitor returns a monitor whose hook starts at once and
+// delivers the events sent on events
+func newStubInputMonitor(events chan hook.Event) *InputMonitor {
+	im := NewInputMonitor()
+	im.SetHook(func() chan hook.Event {
+		ch := make(chan hook.Event, 16)
+		ch <- hook.Event{Kind: hook.HookEnabled}
+		if events != nil {
+			go func() {
+				for ev := range events {
+					ch <- ev
+				}
+			}()
+		}
+		return ch
+	}, func() {})
+	return im
+}
+
+// testDatabaseFile names a database file after the test, removing any left
+// by an earlier run with -count
+func testDatabaseFile(t *testing.T) string {
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name()) + ".db"
+	if dir, err := config.DataDir(); err == nil {
+		os.RemoveAll(filepath.Join(dir, name))
+	}
+	return name
+}
+
+// newTestDatabase returns a connected, empty database of its own for the test
+func newTestDatabase(t *testing.T) *Database {
+	t.Helper()
+	db, err := NewDatabase(testDatabaseFile(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// newTestSession returns a session on api with a database of its own and no
+// real input hook
+func newTestSession(t *testing.T, api services.TaskAPI) *Session {
+	t.Helper()
+	tm := NewTaskManagerWithAPI(api)
+	at := NewActivityTrackerWith(t.TempDir(), tm, newTestDatabase(t), newStubInputMonitor(nil))
+	return NewSession(tm, at)
+}
+
+// updateSettings applies fn to the settings for the rest of the test
+func updateSettings(t *testing.T, fn func(s *config.Settings)) {
+	t.Helper()
+	saved := config.Current()
+	if err := config.Update(fn); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		config.Update(func(s *config.Settings) { *s = saved })
+	})
+}
+
+// demoTask is one of the demo backend's tasks
+var demoTask = types.Task{ID: 101, Name: "Landing page layout", Project: types.Project{ID: 1, Name: "Website Redesign"}}
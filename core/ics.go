@@ -0,0 +1,61 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const icsTimeFormat = "20060102T150405Z"
+
+// WriteICS writes completed activities as an iCalendar file with one VEVENT
+// per activity. Times are written in UTC so calendar apps place them
+// correctly regardless of the zone they were recorded in. Activities without
+// an end time are skipped.
+func WriteICS(w io.Writer, records []ActivityRecord) error {
+	bw := bufio.NewWriter(w)
+	now := time.Now().UTC().Format(icsTimeFormat)
+
+	writeLine(bw, "BEGIN:VCALENDAR")
+	writeLine(bw, "VERSION:2.0")
+	writeLine(bw, "PRODID:-//Time Tracker//Activities//EN")
+	writeLine(bw, "CALSCALE:GREGORIAN")
+	for _, r := range records {
+		if r.EndTime == nil {
+			continue
+		}
+		writeLine(bw, "BEGIN:VEVENT")
+		writeLine(bw, fmt.Sprintf("UID:activity-%d-%d@time-tracker", r.ID, r.StartTime.Unix()))
+		writeLine(bw, "DTSTAMP:"+now)
+		writeLine(bw, "DTSTART:"+r.StartTime.UTC().Format(icsTimeFormat))
+		writeLine(bw, "DTEND:"+r.EndTime.UTC().Format(icsTimeFormat))
+		writeLine(bw, "SUMMARY:"+escapeICSText(r.Task))
+		writeLine(bw, "END:VEVENT")
+	}
+	writeLine(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
+
+// escapeICSText escapes characters with special meaning in iCalendar text values
+func escapeICSText(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", "")
+	return replacer.Replace(s)
+}
+
+// writeLine writes a content line terminated by CRLF, folding it at 75 octets
+// as required by RFC 5545
+func writeLine(w *bufio.Writer, line string) {
+	const limit = 75
+	for len(line) > limit {
+		// Don't split a multi-byte UTF-8 sequence
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+	w.WriteString(line + "\r\n")
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"image"
+	"math/bits"
+)
+
+// differenceHash computes a 64-bit perceptual "dHash" of img: the image is
+// reduced to a 9x8 grayscale grid and each bit records whether a cell is
+// brighter than its right-hand neighbour. Similar images yield hashes with a
+// small Hamming distance.
+func differenceHash(img image.Image) uint64 {
+	const w, h = 9, 8
+	bounds := img.Bounds()
+	var gray [h][w]uint32
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// Sample the centre of each grid cell
+			px := bounds.Min.X + (2*x+1)*bounds.Dx()/(2*w)
+			py := bounds.Min.Y + (2*y+1)*bounds.Dy()/(2*h)
+			r, g, b, _ := img.At(px, py).RGBA()
+			gray[y][x] = (299*r + 587*g + 114*b) / 1000
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w-1; x++ {
+			hash <<= 1
+			if gray[y][x] > gray[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// hammingDistance returns the number of differing bits between two hashes
+func hammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
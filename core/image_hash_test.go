@@ -0,0 +1,68 @@
+package core
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// gradient returns a w x h image whose brightness rises from left to right,
+// or falls if reversed, with noise added to one pixel column
+func gradient(w, h int, reversed bool, noise uint8) image.Image {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(x * 255 / w)
+			if reversed {
+				v = 255 - v
+			}
+			if x == w/2 {
+				v += noise
+			}
+			img.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	return img
+}
+
+func TestHammingDistance(t *testing.T) {
+	tests := []struct {
+		a, b uint64
+		want int
+	}{
+		{0, 0, 0},
+		{0b1011, 0b0001, 2},
+		{0, ^uint64(0), 64},
+	}
+	for _, tt := range tests {
+		if got := hammingDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("hammingDistance(%b, %b) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestIsDuplicate(t *testing.T) {
+	base := gradient(160, 90, false, 0)
+	tests := []struct {
+		name string
+		next image.Image
+		want bool
+	}{
+		{"identical", gradient(160, 90, false, 0), true},
+		{"resized", gradient(320, 180, false, 0), true},
+		{"slight noise", gradient(160, 90, false, 1), true},
+		{"different content", gradient(160, 90, true, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := NewScreenshotManager(60, nil, nil)
+			if sm.isDuplicate(base, 4) {
+				t.Fatal("the first capture of a session counted as a duplicate")
+			}
+			if got := sm.isDuplicate(tt.next, 4); got != tt.want {
+				t.Errorf("isDuplicate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
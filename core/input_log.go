@@ -0,0 +1,120 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/time-tracker/v2/internal/logging"
+)
+
+// MaxInputLogBytes caps each session's input log; later events are dropped
+const MaxInputLogBytes = 10 << 20
+
+// inputLogRecord is one line of an input log
+type inputLogRecord struct {
+	Time   time.Time `json:"time"`
+	Type   string    `json:"type"`             // "press", "click" or "scroll"
+	Key    string    `json:"key,omitempty"`    // Only when keys are recorded
+	Button string    `json:"button,omitempty"` // For clicks
+	Scroll int       `json:"scroll,omitempty"` // Lines scrolled, up is positive
+}
+
+// inputLogLine serializes ev as one JSON line. The key pressed is left out
+// unless keepKeys is set, so the log shows only that a key was pressed.
+func inputLogLine(ev InputEvent, keepKeys bool) ([]byte, error) {
+	record := inputLogRecord{
+		Time:   ev.Timestamp.UTC(),
+		Type:   ev.EventType,
+		Button: ev.Button,
+		Scroll: ev.Scroll[1],
+	}
+	if keepKeys {
+		record.Key = ev.Key
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// inputLog writes a session's raw input events to a JSON Lines file in the
+// log folder, for checking that input is counted correctly
+type inputLog struct {
+	path     string
+	file     *os.File
+	w        *bufio.Writer
+	size     int64
+	keepKeys bool
+	full     bool // Reached MaxInputLogBytes
+}
+
+// InputLogDir returns the folder input logs are written to, creating it if needed
+func InputLogDir() (string, error) {
+	logDir, err := logging.Dir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(logDir, "input")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create input log directory %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// openInputLog creates the input log for a session starting at start
+func openInputLog(start time.Time, keepKeys bool) (*inputLog, error) {
+	dir, err := InputLogDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("input_%s.jsonl", start.Format("20060102_150405")))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create input log %s: %w", path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat input log %s: %w", path, err)
+	}
+	return &inputLog{path: path, file: file, w: bufio.NewWriter(file), size: info.Size(), keepKeys: keepKeys}, nil
+}
+
+// write appends ev, dropping it once the log is full
+func (l *inputLog) write(ev InputEvent) {
+	if l.full {
+		return
+	}
+	line, err := inputLogLine(ev, l.keepKeys)
+	if err != nil {
+		log.Printf("Failed to serialize input event: %v", err)
+		return
+	}
+	if l.size+int64(len(line)) > MaxInputLogBytes {
+		l.full = true
+		log.Printf("Input log %s reached %d bytes, not recording further events this session", l.path, MaxInputLogBytes)
+		return
+	}
+	if _, err := l.w.Write(line); err != nil {
+		l.full = true
+		log.Printf("Failed to write input log %s: %v", l.path, err)
+		return
+	}
+	l.size += int64(len(line))
+}
+
+// close flushes and closes the file
+func (l *inputLog) close() error {
+	flushErr := l.w.Flush()
+	closeErr := l.file.Close()
+	if flushErr != nil {
+		return fmt.Errorf("failed to write input log %s: %w", l.path, flushErr)
+	}
+	return closeErr
+}
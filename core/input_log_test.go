@@ -0,0 +1,148 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	hook "github.com/robotn/gohook"
+)
+
+func TestInputLogLine(t *testing.T) {
+	at := time.Date(2024, 3, 1, 9, 30, 0, 0, time.FixedZone("CET", 3600))
+
+	tests := []struct {
+		name     string
+		ev       InputEvent
+		keepKeys bool
+		want     string
+	}{
+		{"key hidden", InputEvent{EventType: "press", Key: "a", Timestamp: at}, false,
+			`{"time":"2024-03-01T08:30:00Z","type":"press"}`},
+		{"key kept", InputEvent{EventType: "press", Key: "a", Timestamp: at}, true,
+			`{"time":"2024-03-01T08:30:00Z","type":"press","key":"a"}`},
+		{"click", InputEvent{EventType: "click", Button: "left", Pressed: true, Timestamp: at}, false,
+			`{"time":"2024-03-01T08:30:00Z","type":"click","button":"left"}`},
+		{"scroll down", InputEvent{EventType: "scroll", Scroll: [2]int{0, -3}, Timestamp: at}, false,
+			`{"time":"2024-03-01T08:30:00Z","type":"scroll","scroll":-3}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, err := inputLogLine(tt.ev, tt.keepKeys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(line); got != tt.want+"\n" {
+				t.Errorf("inputLogLine() = %q, want %q", got, tt.want+"\n")
+			}
+		})
+	}
+}
+
+// readInputLog returns the records of the input log for a session starting
+// at start, removing the file so a repeated run starts afresh
+func readInputLog(t *testing.T, start time.Time) []inputLogRecord {
+	t.Helper()
+	dir, err := InputLogDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "input_"+start.Format("20060102_150405")+".jsonl")
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(path)
+	defer f.Close()
+	var records []inputLogRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record inputLogRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("bad line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestDebugLog(t *testing.T) {
+	events := []hook.Event{
+		{Kind: hook.KeyDown, Keychar: 'x'},
+		{Kind: hook.MouseDown, Button: hook.MouseMap["right"]},
+		{Kind: hook.MouseWheel, Rotation: -1, Amount: 2},
+	}
+
+	tests := []struct {
+		name     string
+		keepKeys bool
+		start    time.Time
+		want     []inputLogRecord
+	}{
+		{"keys hidden", false, time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local), []inputLogRecord{
+			{Type: "press"}, {Type: "click", Button: "right"}, {Type: "scroll", Scroll: 2},
+		}},
+		{"keys kept", true, time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local), []inputLogRecord{
+			{Type: "press", Key: "x"}, {Type: "click", Button: "right"}, {Type: "scroll", Scroll: 2},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			im := NewInputMonitor()
+			im.IsMonitoring = true
+			if err := im.OpenDebugLog(tt.start, tt.keepKeys); err != nil {
+				t.Fatal(err)
+			}
+			for _, ev := range events {
+				im.record(ev)
+			}
+			if err := im.CloseDebugLog(); err != nil {
+				t.Fatal(err)
+			}
+			im.record(hook.Event{Kind: hook.KeyDown, Keychar: 'y'}) // Not logged once closed
+
+			got := readInputLog(t, tt.start)
+			if len(got) != len(tt.want) {
+				t.Fatalf("%d records, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, record := range got {
+				if record.Time.IsZero() {
+					t.Errorf("record %d has no time", i)
+				}
+				record.Time = time.Time{}
+				if record != tt.want[i] {
+					t.Errorf("record %d = %+v, want %+v", i, record, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestInputLogFull(t *testing.T) {
+	start := time.Date(2024, 3, 1, 11, 0, 0, 0, time.Local)
+	l, err := openInputLog(start, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := InputEvent{EventType: "press", Timestamp: start}
+	l.write(ev)
+	l.size = MaxInputLogBytes - 1 // As if the log were nearly full
+	l.write(ev)
+	l.size = 0
+	l.write(ev) // Still dropped, the log stays full for the session
+	if err := l.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !l.full {
+		t.Error("log not marked full")
+	}
+	if got := readInputLog(t, start); len(got) != 1 {
+		t.Errorf("%d records, want only the one written before the log filled up", len(got))
+	}
+}
@@ -1,13 +1,24 @@
 package core
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"sync"
 	"time"
 
 	hook "github.com/robotn/gohook"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/logging"
 )
 
+// hookStartTimeout is how long the input hook may take to report that it is running
+const hookStartTimeout = 5 * time.Second
+
+// ErrInputHookUnavailable means the system input hook did not start, usually
+// because the app lacks input monitoring permissions or there is no display
+var ErrInputHookUnavailable = errors.New("keyboard and mouse monitoring could not be started")
+
 type InputEvent struct {
 	EventType string    // "press", "click", "scroll"
 	Key       string    // Key pressed (for keyboard events)
@@ -23,6 +34,35 @@ type InputMonitor struct {
 	MouseMovements []InputEvent
 	IsMonitoring   bool
 	mu             sync.Mutex
+
+	// Interval bookkeeping for SnapshotInterval
+	intervalStart time.Time
+	intervalKeys  int // len(Keystrokes) at the last snapshot
+	intervalMouse int // len(MouseMovements) at the last snapshot
+	carriedKeys   int // Counted this interval before the data was last cleared
+	carriedMouse  int
+
+	lastInput time.Time     // Last keyboard or mouse event, or when monitoring started
+	idle      time.Duration // Idle stretches since the last StopMonitoring
+
+	debugLog *inputLog // Raw events of this session, while OpenDebugLog is in effect
+
+	stop chan struct{} // Closed by StopMonitoring to end the running hook goroutine
+	done chan struct{} // Closed once that goroutine has ended the hook
+
+	onStartFailure func(err error)
+	startHook      func() chan hook.Event // hook.Start unless replaced
+	endHook        func()                 // hook.End unless replaced
+}
+
+// SetHook replaces the functions that start and end the system input hook
+// (hook.Start and hook.End by default), so events can be fed in without a
+// real keyboard or mouse. Call it before StartMonitoring.
+func (im *InputMonitor) SetHook(start func() chan hook.Event, end func()) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	im.startHook = start
+	im.endHook = end
 }
 
 func NewInputMonitor() *InputMonitor {
@@ -30,9 +70,19 @@ func NewInputMonitor() *InputMonitor {
 		Keystrokes:     []InputEvent{},
 		MouseMovements: []InputEvent{},
 		IsMonitoring:   false,
+		startHook:      hook.Start,
+		endHook:        hook.End,
 	}
 }
 
+// SetStartFailureCallback registers fn to be called, from the monitoring
+// goroutine, when the input hook fails to start. Tracking continues without input counts.
+func (im *InputMonitor) SetStartFailureCallback(fn func(err error)) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	im.onStartFailure = fn
+}
+
 func (im *InputMonitor) StartMonitoring() {
 	im.mu.Lock()
 
@@ -42,83 +92,196 @@ func (im *InputMonitor) StartMonitoring() {
 	}
 
 	im.IsMonitoring = true
+	im.lastInput = time.Now()
+	if im.intervalStart.IsZero() {
+		im.intervalStart = time.Now()
+	}
+	previous := im.done
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	im.stop = stop
+	im.done = done
+	startHook, endHook := im.startHook, im.endHook
 	im.mu.Unlock() // Unlock before starting the long-running hook
 
 	// Start event monitoring in a separate goroutine
 	go func() {
-		evChan := hook.Start()
-		defer hook.End()
-
-		for {
+		defer close(done)
+		// If the hook loop dies, mark monitoring as stopped so it can be restarted
+		defer logging.Recover("input monitor", func() {
 			im.mu.Lock()
-			isMonitoring := im.IsMonitoring
+			im.IsMonitoring = false
 			im.mu.Unlock()
+		})
+		// The hook is process-wide, so the previous session must have ended it
+		// before it is started again
+		if previous != nil {
+			<-previous
+		}
+		select {
+		case <-stop:
+			return // Stopped again before the previous hook finished
+		default:
+		}
 
-			if !isMonitoring {
-				break // Exit goroutine if monitoring stopped
-			}
+		evChan := startHook()
+		defer endHook()
+		if !im.waitForHook(evChan, stop) {
+			return
+		}
 
+		for {
 			select {
-			case ev := <-evChan:
-				im.mu.Lock()
-				if !im.IsMonitoring { // Double check after receiving event
-					im.mu.Unlock()
-					break
+			case ev, ok := <-evChan:
+				if !ok {
+					return
 				}
-				switch ev.Kind {
-				case hook.KeyDown, hook.KeyHold:
-					keyStr := fmt.Sprintf("%c", ev.Keychar) // Convert rune to string
-					// You might want more sophisticated key mapping here
-					// For special keys, ev.Rawcode and ev.Keycode might be useful
-					inputEvent := InputEvent{
-						EventType: "press",
-						Key:       keyStr,
-						Timestamp: time.Now(),
-					}
-					im.Keystrokes = append(im.Keystrokes, inputEvent)
-				case hook.MouseDown:
-					var button string
-					switch ev.Button {
-					case hook.MouseMap["left"]:
-						button = "left"
-					case hook.MouseMap["right"]:
-						button = "right"
-					case hook.MouseMap["middle"]:
-						button = "middle"
-					default:
-						button = "other"
-					}
-					inputEvent := InputEvent{
-						EventType: "click",
-						Button:    button,
-						Pressed:   true, // gohook only provides MouseDown, not Up
-						Timestamp: time.Now(),
-					}
-					im.MouseMovements = append(im.MouseMovements, inputEvent)
-				case hook.MouseWheel:
-					// ev.Rotation > 0 is wheel down, < 0 is wheel up
-					// ev.Amount seems to indicate lines scrolled
-					var scrollY int
-					if ev.Rotation > 0 {
-						scrollY = -int(ev.Amount) // Down
-					} else {
-						scrollY = int(ev.Amount) // Up
-					}
-					inputEvent := InputEvent{
-						EventType: "scroll",
-						Scroll:    [2]int{0, scrollY},
-						Timestamp: time.Now(),
-					}
-					im.MouseMovements = append(im.MouseMovements, inputEvent)
-				}
-				im.mu.Unlock()
-			case <-time.After(100 * time.Millisecond): // Check periodically if monitoring stopped
-				continue
+				im.record(ev)
+			case <-stop:
+				return
 			}
 		}
 	}()
 }
 
+// waitForHook waits until the hook reports that it is running or delivers
+// any event. If it doesn't within hookStartTimeout, monitoring is marked as
+// stopped and the start failure callback is notified.
+func (im *InputMonitor) waitForHook(evChan chan hook.Event, stop <-chan struct{}) bool {
+	select {
+	case ev, ok := <-evChan:
+		if !ok {
+			im.hookFailed()
+			return false
+		}
+		if ev.Kind != hook.HookEnabled {
+			// Input is already arriving, so the hook is running
+			im.record(ev)
+		}
+		return true
+	case <-time.After(hookStartTimeout):
+		im.hookFailed()
+		return false
+	case <-stop: // Give up quietly, monitoring was stopped meanwhile
+		return false
+	}
+}
+
+// hookFailed marks monitoring as stopped after the hook failed to start
+func (im *InputMonitor) hookFailed() {
+	im.mu.Lock()
+	wasMonitoring := im.IsMonitoring
+	im.IsMonitoring = false
+	onStartFailure := im.onStartFailure
+	im.mu.Unlock()
+	if !wasMonitoring {
+		return // Stopped while starting, nothing to report
+	}
+	log.Printf("Input monitoring unavailable, continuing without input counts: %v", ErrInputHookUnavailable)
+	if onStartFailure != nil {
+		onStartFailure(ErrInputHookUnavailable)
+	}
+}
+
+// record stores a keyboard or mouse event while monitoring
+func (im *InputMonitor) record(ev hook.Event) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	if !im.IsMonitoring { // Double check after receiving event
+		return
+	}
+	switch ev.Kind {
+	case hook.KeyDown, hook.KeyHold, hook.MouseDown, hook.MouseWheel:
+		im.addIdleUntil(time.Now())
+	}
+	switch ev.Kind {
+	case hook.KeyDown, hook.KeyHold:
+		keyStr := fmt.Sprintf("%c", ev.Keychar) // Convert rune to string
+		// You might want more sophisticated key mapping here
+		// For special keys, ev.Rawcode and ev.Keycode might be useful
+		inputEvent := InputEvent{
+			EventType: "press",
+			Key:       keyStr,
+			Timestamp: time.Now(),
+		}
+		im.Keystrokes = append(im.Keystrokes, inputEvent)
+		im.logEvent(inputEvent)
+	case hook.MouseDown:
+		var button string
+		switch ev.Button {
+		case hook.MouseMap["left"]:
+			button = "left"
+		case hook.MouseMap["right"]:
+			button = "right"
+		case hook.MouseMap["middle"]:
+			button = "middle"
+		default:
+			button = "other"
+		}
+		inputEvent := InputEvent{
+			EventType: "click",
+			Button:    button,
+			Pressed:   true, // gohook only provides MouseDown, not Up
+			Timestamp: time.Now(),
+		}
+		im.MouseMovements = append(im.MouseMovements, inputEvent)
+		im.logEvent(inputEvent)
+	case hook.MouseWheel:
+		// ev.Rotation > 0 is wheel down, < 0 is wheel up
+		// ev.Amount seems to indicate lines scrolled
+		var scrollY int
+		if ev.Rotation > 0 {
+			scrollY = -int(ev.Amount) // Down
+		} else {
+			scrollY = int(ev.Amount) // Up
+		}
+		inputEvent := InputEvent{
+			EventType: "scroll",
+			Scroll:    [2]int{0, scrollY},
+			Timestamp: time.Now(),
+		}
+		im.MouseMovements = append(im.MouseMovements, inputEvent)
+		im.logEvent(inputEvent)
+	}
+}
+
+// logEvent writes ev to the debug log, if one is open. Callers must hold im.mu.
+func (im *InputMonitor) logEvent(ev InputEvent) {
+	if im.debugLog != nil {
+		im.debugLog.write(ev)
+	}
+}
+
+// OpenDebugLog starts writing the raw events of a session starting at start
+// to a file in InputLogDir, without the keys typed unless keepKeys is set.
+// Any log already open is closed first.
+func (im *InputMonitor) OpenDebugLog(start time.Time, keepKeys bool) error {
+	l, err := openInputLog(start, keepKeys)
+	if err != nil {
+		return err
+	}
+	im.mu.Lock()
+	previous := im.debugLog
+	im.debugLog = l
+	im.mu.Unlock()
+	if previous != nil {
+		return previous.close()
+	}
+	return nil
+}
+
+// CloseDebugLog stops writing raw events, if OpenDebugLog was called
+func (im *InputMonitor) CloseDebugLog() error {
+	im.mu.Lock()
+	l := im.debugLog
+	im.debugLog = nil
+	im.mu.Unlock()
+	if l == nil {
+		return nil
+	}
+	return l.close()
+}
+
 func (im *InputMonitor) StopMonitoring() map[string]int {
 	im.mu.Lock()
 	defer im.mu.Unlock()
@@ -131,22 +294,81 @@ func (im *InputMonitor) StopMonitoring() map[string]int {
 	}
 
 	im.IsMonitoring = false
-	// hook.End() is called in the goroutine when IsMonitoring becomes false
+	close(im.stop) // The goroutine then calls hook.End()
 
+	// The time since the last input counts too if it was long enough
+	im.addIdleUntil(time.Now())
 	eventCounts := map[string]int{
 		"keyboard_event_count": len(im.Keystrokes),
 		"mouse_event_count":    len(im.MouseMovements),
+		"idle_seconds":         int(im.idle.Seconds()),
 	}
+	im.idle = 0
 
-	// Clear data after stopping
+	// Clear data after stopping, keeping this interval's counts
+	im.carriedKeys += len(im.Keystrokes) - im.intervalKeys
+	im.carriedMouse += len(im.MouseMovements) - im.intervalMouse
 	im.ClearData()
 
 	return eventCounts
 }
 
+// Idle reports whether monitoring has had no keyboard or mouse input for the
+// idle threshold. It is false while the hook isn't running.
+func (im *InputMonitor) Idle() bool {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	threshold := time.Duration(config.Current().IdleThresholdMinutes) * time.Minute
+	return im.IsMonitoring && threshold > 0 && !im.lastInput.IsZero() && time.Since(im.lastInput) >= threshold
+}
+
+// addIdleUntil counts the time from the last input to now as idle if it
+// reached the configured threshold. Callers must hold im.mu.
+func (im *InputMonitor) addIdleUntil(now time.Time) {
+	threshold := time.Duration(config.Current().IdleThresholdMinutes) * time.Minute
+	if gap := now.Sub(im.lastInput); threshold > 0 && !im.lastInput.IsZero() && gap >= threshold {
+		im.idle += gap
+	}
+	im.lastInput = now
+}
+
 func (im *InputMonitor) ClearData() {
 	im.Keystrokes = []InputEvent{}
 	im.MouseMovements = []InputEvent{}
+	im.intervalKeys = 0
+	im.intervalMouse = 0
+}
+
+// SnapshotInterval returns the input counted since the previous snapshot (or
+// since monitoring first started) and begins a new interval
+func (im *InputMonitor) SnapshotInterval() IntervalActivity {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	now := time.Now()
+	interval := IntervalActivity{
+		KeyboardEvents: im.carriedKeys + len(im.Keystrokes) - im.intervalKeys,
+		MouseEvents:    im.carriedMouse + len(im.MouseMovements) - im.intervalMouse,
+	}
+	if !im.intervalStart.IsZero() {
+		interval.Duration = now.Sub(im.intervalStart)
+	}
+	im.intervalStart = now
+	im.intervalKeys = len(im.Keystrokes)
+	im.intervalMouse = len(im.MouseMovements)
+	im.carriedKeys = 0
+	im.carriedMouse = 0
+	return interval
+}
+
+// ResetInterval discards the current interval, e.g. when a new session starts
+func (im *InputMonitor) ResetInterval() {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	im.intervalStart = time.Time{}
+	im.intervalKeys = len(im.Keystrokes)
+	im.intervalMouse = len(im.MouseMovements)
+	im.carriedKeys = 0
+	im.carriedMouse = 0
 }
 
 func (im *InputMonitor) GetKeystrokes() []InputEvent {
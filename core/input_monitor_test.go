@@ -0,0 +1,200 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	hook "github.com/robotn/gohook"
+	"github.com/time-tracker/v2/internal/config"
+)
+
+func TestInputMonitorIdle(t *testing.T) {
+	tests := []struct {
+		name       string
+		monitoring bool
+		threshold  int
+		sinceInput time.Duration
+		want       bool
+	}{
+		{"recent input", true, 5, time.Minute, false},
+		{"no input for the threshold", true, 5, 6 * time.Minute, true},
+		{"idle detection off", true, 0, time.Hour, false},
+		{"not monitoring", false, 5, time.Hour, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateSettings(t, func(s *config.Settings) { s.IdleThresholdMinutes = tt.threshold })
+			im := NewInputMonitor()
+			im.IsMonitoring = tt.monitoring
+			im.lastInput = time.Now().Add(-tt.sinceInput)
+			if got := im.Idle(); got != tt.want {
+				t.Errorf("Idle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnapshotInterval(t *testing.T) {
+	im := NewInputMonitor()
+	input := func(keys, mouse int) {
+		for i := 0; i < keys; i++ {
+			im.Keystrokes = append(im.Keystrokes, InputEvent{})
+		}
+		for i := 0; i < mouse; i++ {
+			im.MouseMovements = append(im.MouseMovements, InputEvent{})
+		}
+	}
+
+	tests := []struct {
+		name      string
+		before    func()
+		wantKeys  int
+		wantMouse int
+	}{
+		{"first interval", func() { input(3, 2) }, 3, 2},
+		{"only new input", func() { input(1, 0) }, 1, 0},
+		{"reset discards input", func() { input(4, 4); im.ResetInterval() }, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.before()
+			got := im.SnapshotInterval()
+			if got.KeyboardEvents != tt.wantKeys || got.MouseEvents != tt.wantMouse {
+				t.Errorf("SnapshotInterval() = %d keys, %d mouse, want %d, %d", got.KeyboardEvents, got.MouseEvents, tt.wantKeys, tt.wantMouse)
+			}
+		})
+	}
+}
+
+func TestWaitForHook(t *testing.T) {
+	tests := []struct {
+		name        string
+		events      []hook.Event
+		closed      bool
+		wantRunning bool
+		wantKeys    int
+	}{
+		{"hook enabled", []hook.Event{{Kind: hook.HookEnabled}}, false, true, 0},
+		{"input before the enabled event", []hook.Event{{Kind: hook.KeyDown, Keychar: 'a'}}, false, true, 1},
+		{"hook failed to start", nil, true, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			im := NewInputMonitor()
+			im.IsMonitoring = true
+			var failure error
+			im.SetStartFailureCallback(func(err error) { failure = err })
+
+			evChan := make(chan hook.Event, len(tt.events))
+			for _, ev := range tt.events {
+				evChan <- ev
+			}
+			if tt.closed {
+				close(evChan)
+			}
+
+			if got := im.waitForHook(evChan, make(chan struct{})); got != tt.wantRunning {
+				t.Errorf("waitForHook() = %v, want %v", got, tt.wantRunning)
+			}
+			if im.IsMonitoring != tt.wantRunning {
+				t.Errorf("IsMonitoring = %v, want %v", im.IsMonitoring, tt.wantRunning)
+			}
+			if wantFailure := !tt.wantRunning; (failure != nil) != wantFailure || (wantFailure && !errors.Is(failure, ErrInputHookUnavailable)) {
+				t.Errorf("start failure = %v, want failure %v", failure, wantFailure)
+			}
+			if len(im.Keystrokes) != tt.wantKeys {
+				t.Errorf("%d keystrokes recorded, want %d", len(im.Keystrokes), tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestRestartEndsPreviousHook(t *testing.T) {
+	tests := []struct {
+		name     string
+		restarts int
+	}{
+		{"restart once", 1},
+		{"restart repeatedly", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			running, overlaps, started := 0, 0, 0
+			im := NewInputMonitor()
+			im.SetHook(func() chan hook.Event {
+				mu.Lock()
+				defer mu.Unlock()
+				running++
+				started++
+				if running > 1 {
+					overlaps++
+				}
+				ch := make(chan hook.Event, 1)
+				ch <- hook.Event{Kind: hook.HookEnabled}
+				return ch
+			}, func() {
+				time.Sleep(10 * time.Millisecond) // A slow teardown
+				mu.Lock()
+				running--
+				mu.Unlock()
+			})
+
+			im.StartMonitoring()
+			for i := 0; i < tt.restarts; i++ {
+				im.StopMonitoring()
+				im.StartMonitoring()
+			}
+			im.StopMonitoring()
+			im.mu.Lock()
+			done := im.done
+			im.mu.Unlock()
+			<-done
+
+			mu.Lock()
+			defer mu.Unlock()
+			if overlaps != 0 {
+				t.Errorf("hook started %d times while the previous one was still running", overlaps)
+			}
+			if running != 0 || started > tt.restarts+1 {
+				t.Errorf("%d hooks still running after %d starts", running, started)
+			}
+		})
+	}
+}
+
+func TestAddIdleUntil(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		gaps      []time.Duration
+		want      time.Duration
+	}{
+		{"short gaps aren't idle", 5, []time.Duration{time.Minute, 4 * time.Minute}, 0},
+		{"whole gap counts once over the threshold", 5, []time.Duration{time.Minute, 6 * time.Minute}, 6 * time.Minute},
+		{"gaps add up", 5, []time.Duration{10 * time.Minute, 5 * time.Minute}, 15 * time.Minute},
+		{"idle detection off", 0, []time.Duration{time.Hour}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateSettings(t, func(s *config.Settings) { s.IdleThresholdMinutes = tt.threshold })
+			im := NewInputMonitor()
+			now := time.Now()
+			im.lastInput = now
+			for _, gap := range tt.gaps {
+				now = now.Add(gap)
+				im.addIdleUntil(now)
+			}
+			if im.idle != tt.want {
+				t.Errorf("idle = %s, want %s", im.idle, tt.want)
+			}
+		})
+	}
+}
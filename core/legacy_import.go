@@ -0,0 +1,326 @@
+package core
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The previous, Python version of the app kept its history in a SQLite
+// database with an activities table, which it could also export as CSV with
+// the same columns:
+//
+//	task             TEXT     Task name (required)
+//	start_time       TEXT     ISO 8601, local time unless it has a UTC offset (required)
+//	end_time         TEXT     ISO 8601, empty while a session was running
+//	duration         REAL     Seconds; derived from the times if empty
+//	screenshot_path  TEXT     Last screenshot of the session
+//	keyboard_events  INTEGER  Not recorded by early releases
+//	mouse_events     INTEGER  Not recorded by early releases
+//
+// Other columns are ignored. This app's own column names are accepted too, so
+// one of its CSV exports can be imported the same way.
+
+// ErrNotLegacyHistory is returned by ImportLegacy for a file without the
+// legacy activities columns
+var ErrNotLegacyHistory = errors.New("not a history file from the previous version: it has no task and start_time columns")
+
+// legacyFields maps the accepted column names to the field they fill
+var legacyFields = map[string]string{
+	"task":                 "task",
+	"task_name":            "task",
+	"start_time":           "start_time",
+	"end_time":             "end_time",
+	"duration":             "duration",
+	"duration_seconds":     "duration",
+	"screenshot_path":      "screenshot_path",
+	"keyboard_events":      "keyboard",
+	"keyboard_event_count": "keyboard",
+	"mouse_events":         "mouse",
+	"mouse_event_count":    "mouse",
+}
+
+// legacyTimeLayouts are the timestamp formats Python's isoformat and str wrote
+var legacyTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999",
+	"2006-01-02 15:04:05.999999Z07:00",
+	"2006-01-02 15:04:05.999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+}
+
+// LegacyImportResult counts the rows ImportLegacy read
+type LegacyImportResult struct {
+	Imported   int
+	Duplicates int // Skipped as an activity with the same task and start was already saved
+	Invalid    int // Skipped as they lack a task or have unreadable times or counts
+}
+
+// Skipped returns how many rows weren't imported
+func (r LegacyImportResult) Skipped() int {
+	return r.Duplicates + r.Invalid
+}
+
+// legacyActivity is a legacy row in this app's terms
+type legacyActivity struct {
+	task           string
+	start, end     time.Time
+	duration       int
+	screenshotPath string
+	keyboard       int
+	mouse          int
+}
+
+// ImportLegacy adds the activities in the previous version's history file at
+// path, a SQLite database or, if its name ends in .csv, a CSV export. Rows
+// already imported are skipped, so importing the same file twice is harmless.
+// Imported activities are never synced with the server.
+func (db *Database) ImportLegacy(path string) (LegacyImportResult, error) {
+	var rows []map[string]string
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		rows, err = readLegacyCSV(path)
+	} else {
+		rows, err = readLegacyDatabase(path)
+	}
+	if err != nil {
+		return LegacyImportResult{}, err
+	}
+	return db.importLegacyRows(rows)
+}
+
+// readLegacyCSV reads a CSV export's rows by field
+func readLegacyCSV(path string) ([]map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff") // Excel's byte order mark
+	}
+	fields, err := legacyHeader(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		row := map[string]string{}
+		for i, value := range record {
+			if i < len(fields) && fields[i] != "" {
+				row[fields[i]] = value
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// readLegacyDatabase reads the activities table's rows by field, opening the
+// database read-only
+func readLegacyDatabase(path string) ([]map[string]string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	conn, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer conn.Close()
+
+	result, err := conn.Query("SELECT * FROM activities")
+	if err != nil {
+		return nil, fmt.Errorf("%w (%v)", ErrNotLegacyHistory, err)
+	}
+	defer result.Close()
+	columns, err := result.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read activities columns: %w", err)
+	}
+	fields, err := legacyHeader(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]string
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for result.Next() {
+		if err := result.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan legacy activity: %w", err)
+		}
+		row := map[string]string{}
+		for i, value := range values {
+			if fields[i] != "" && value.Valid {
+				row[fields[i]] = value.String
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, result.Err()
+}
+
+// legacyHeader returns the field each column fills, "" for ignored columns
+func legacyHeader(columns []string) ([]string, error) {
+	fields := make([]string, len(columns))
+	found := map[string]bool{}
+	for i, column := range columns {
+		field := legacyFields[strings.ToLower(strings.TrimSpace(column))]
+		if found[field] {
+			field = "" // Keep the first of two columns for the same field
+		}
+		fields[i] = field
+		found[field] = true
+	}
+	if !found["task"] || !found["start_time"] {
+		return nil, ErrNotLegacyHistory
+	}
+	return fields, nil
+}
+
+// parseLegacyRow turns a legacy row into an activity. A missing duration is
+// derived from the times and a missing end from the duration; missing event
+// counts are zero.
+func parseLegacyRow(row map[string]string) (legacyActivity, error) {
+	a := legacyActivity{
+		task:           strings.TrimSpace(row["task"]),
+		screenshotPath: strings.TrimSpace(row["screenshot_path"]),
+	}
+	if a.task == "" {
+		return a, errors.New("no task")
+	}
+	var err error
+	if a.start, err = parseLegacyTime(row["start_time"]); err != nil {
+		return a, fmt.Errorf("start_time: %w", err)
+	}
+	if end := strings.TrimSpace(row["end_time"]); end != "" {
+		if a.end, err = parseLegacyTime(end); err != nil {
+			return a, fmt.Errorf("end_time: %w", err)
+		}
+		if a.end.Before(a.start) {
+			return a, errors.New("ends before it starts")
+		}
+	}
+
+	duration, hasDuration, err := parseLegacyNumber(row["duration"])
+	switch {
+	case err != nil:
+		return a, fmt.Errorf("duration: %w", err)
+	case hasDuration:
+		a.duration = duration
+		if a.end.IsZero() {
+			a.end = a.start.Add(time.Duration(duration) * time.Second)
+		}
+	case !a.end.IsZero():
+		a.duration = int(a.end.Sub(a.start).Seconds())
+	default:
+		return a, errors.New("neither an end_time nor a duration")
+	}
+
+	if a.keyboard, _, err = parseLegacyNumber(row["keyboard"]); err != nil {
+		return a, fmt.Errorf("keyboard events: %w", err)
+	}
+	if a.mouse, _, err = parseLegacyNumber(row["mouse"]); err != nil {
+		return a, fmt.Errorf("mouse events: %w", err)
+	}
+	return a, nil
+}
+
+// parseLegacyTime parses a Python timestamp, taking times without a UTC
+// offset as local time
+func parseLegacyTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, errors.New("missing")
+	}
+	for _, layout := range legacyTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unreadable time %q", s)
+}
+
+// parseLegacyNumber parses a non-negative count or number of seconds, which
+// Python may have written as a float. Empty values report false.
+func parseLegacyNumber(s string) (int, bool, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 0 || math.IsInf(f, 0) || math.IsNaN(f) {
+		return 0, false, fmt.Errorf("invalid number %q", s)
+	}
+	return int(math.Round(f)), true, nil
+}
+
+// importLegacyRows saves the readable rows that aren't saved already, all in
+// one transaction
+func (db *Database) importLegacyRows(rows []map[string]string) (LegacyImportResult, error) {
+	var result LegacyImportResult
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return result, fmt.Errorf("failed to start import: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i, row := range rows {
+		a, err := parseLegacyRow(row)
+		if err != nil {
+			log.Printf("Not importing legacy row %d: %v", i+1, err)
+			result.Invalid++
+			continue
+		}
+		start := a.start.UTC().Format(time.RFC3339)
+		var exists bool
+		err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM activities WHERE task = ? AND start_time = ?)", a.task, start).Scan(&exists)
+		if err != nil {
+			return LegacyImportResult{}, fmt.Errorf("failed to check for imported activities: %w", err)
+		}
+		if exists {
+			result.Duplicates++
+			continue
+		}
+		_, err = tx.Exec(`
+    INSERT INTO activities (task, start_time, end_time, duration, screenshot_path, keyboard_event_count, mouse_event_count, sync_state)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			a.task, start, a.end.UTC().Format(time.RFC3339), a.duration, a.screenshotPath, a.keyboard, a.mouse, SyncImported)
+		if err != nil {
+			return LegacyImportResult{}, fmt.Errorf("failed to import activity: %w", err)
+		}
+		result.Imported++
+	}
+	if err := tx.Commit(); err != nil {
+		return LegacyImportResult{}, fmt.Errorf("failed to save imported activities: %w", err)
+	}
+	log.Printf("Imported %d legacy activities, skipped %d (%d already imported)", result.Imported, result.Skipped(), result.Duplicates)
+	return result, nil
+}
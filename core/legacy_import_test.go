@@ -0,0 +1,230 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseLegacyTime(t *testing.T) {
+	local := time.Date(2023, 5, 4, 9, 30, 15, 0, time.Local)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2023-05-04T09:30:15", local, false},
+		{"2023-05-04T09:30:15.250000", local.Add(250 * time.Millisecond), false},
+		{"2023-05-04 09:30:15", local, false},
+		{"2023-05-04T09:30:15+02:00", time.Date(2023, 5, 4, 7, 30, 15, 0, time.UTC), false},
+		{"2023-05-04 09:30:15+02:00", time.Date(2023, 5, 4, 7, 30, 15, 0, time.UTC), false},
+		{"2023-05-04T09:30", local.Add(-15 * time.Second), false},
+		{" 2023-05-04 09:30 ", local.Add(-15 * time.Second), false},
+		{"", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseLegacyTime(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLegacyTime(%q) error = %v, want an error %v", tt.in, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseLegacyTime(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLegacyRow(t *testing.T) {
+	start := time.Date(2023, 5, 4, 9, 0, 0, 0, time.Local)
+	tests := []struct {
+		name    string
+		row     map[string]string
+		want    legacyActivity
+		wantErr bool
+	}{
+		{"complete", map[string]string{
+			"task": " Docs ", "start_time": "2023-05-04T09:00:00", "end_time": "2023-05-04T10:00:00",
+			"duration": "3600.0", "screenshot_path": "shot.png", "keyboard": "12", "mouse": "3.0",
+		}, legacyActivity{task: "Docs", start: start, end: start.Add(time.Hour), duration: 3600, screenshotPath: "shot.png", keyboard: 12, mouse: 3}, false},
+		{"duration from the times", map[string]string{"task": "Docs", "start_time": "2023-05-04T09:00:00", "end_time": "2023-05-04T09:30:00"},
+			legacyActivity{task: "Docs", start: start, end: start.Add(30 * time.Minute), duration: 1800}, false},
+		{"end from the duration", map[string]string{"task": "Docs", "start_time": "2023-05-04T09:00:00", "duration": "90.4"},
+			legacyActivity{task: "Docs", start: start, end: start.Add(90 * time.Second), duration: 90}, false},
+		{"no task", map[string]string{"task": " ", "start_time": "2023-05-04T09:00:00", "duration": "60"}, legacyActivity{}, true},
+		{"unreadable start", map[string]string{"task": "Docs", "start_time": "soon", "duration": "60"}, legacyActivity{}, true},
+		{"unreadable end", map[string]string{"task": "Docs", "start_time": "2023-05-04T09:00:00", "end_time": "later"}, legacyActivity{}, true},
+		{"ends before it starts", map[string]string{"task": "Docs", "start_time": "2023-05-04T09:00:00", "end_time": "2023-05-04T08:00:00"}, legacyActivity{}, true},
+		{"still running", map[string]string{"task": "Docs", "start_time": "2023-05-04T09:00:00"}, legacyActivity{}, true},
+		{"negative duration", map[string]string{"task": "Docs", "start_time": "2023-05-04T09:00:00", "duration": "-5"}, legacyActivity{}, true},
+		{"bad count", map[string]string{"task": "Docs", "start_time": "2023-05-04T09:00:00", "duration": "60", "mouse": "many"}, legacyActivity{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLegacyRow(tt.row)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLegacyRow() error = %v, want an error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.task != tt.want.task || !got.start.Equal(tt.want.start) || !got.end.Equal(tt.want.end) ||
+				got.duration != tt.want.duration || got.screenshotPath != tt.want.screenshotPath ||
+				got.keyboard != tt.want.keyboard || got.mouse != tt.want.mouse {
+				t.Errorf("parseLegacyRow() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLegacyHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []string
+		want    []string
+		wantErr bool
+	}{
+		{"legacy names", []string{"id", "task", "start_time", "end_time", "keyboard_events"}, []string{"", "task", "start_time", "end_time", "keyboard"}, false},
+		{"export names", []string{"Task_Name", " start_time ", "duration_seconds"}, []string{"task", "start_time", "duration"}, false},
+		{"first of two columns kept", []string{"task", "task_name", "start_time"}, []string{"task", "", "start_time"}, false},
+		{"no start", []string{"task", "end_time"}, nil, true},
+		{"no task", []string{"start_time", "duration"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := legacyHeader(tt.columns)
+			if tt.wantErr {
+				if !errors.Is(err, ErrNotLegacyHistory) {
+					t.Errorf("legacyHeader(%q) error = %v, want %v", tt.columns, err, ErrNotLegacyHistory)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("legacyHeader(%q) = %q, want %q", tt.columns, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("legacyHeader(%q) = %q, want %q", tt.columns, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+// writeLegacyDatabase creates a legacy SQLite history at path by running statements
+func writeLegacyDatabase(t *testing.T, path string, statements ...string) {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for _, statement := range statements {
+		if _, err := conn.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestImportLegacy(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		write func(t *testing.T, path string)
+	}{
+		{"CSV export", "history.csv", func(t *testing.T, path string) {
+			csv := "\ufefftask,start_time,end_time,duration,screenshot_path,keyboard_events,mouse_events\n" +
+				"Docs,2023-05-04T09:00:00,2023-05-04T10:00:00,3600.0,shot.png,12,3\n" +
+				"Review,2023-05-04 11:00:00,,1800,,,\n" +
+				",2023-05-04T12:00:00,,60,,,\n"
+			if err := os.WriteFile(path, []byte(csv), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"SQLite database", "time_tracker.db", func(t *testing.T, path string) {
+			writeLegacyDatabase(t, path,
+				`CREATE TABLE activities (id INTEGER PRIMARY KEY, task TEXT, start_time TEXT, end_time TEXT, duration REAL, screenshot_path TEXT)`,
+				`INSERT INTO activities (task, start_time, end_time, duration, screenshot_path) VALUES
+					('Docs', '2023-05-04T09:00:00', '2023-05-04T10:00:00', 3600.0, 'shot.png'),
+					('Review', '2023-05-04 11:00:00', NULL, 1800, NULL),
+					(NULL, '2023-05-04T12:00:00', NULL, 60, NULL)`)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			tt.write(t, path)
+			db := newTestDatabase(t)
+
+			result, err := db.ImportLegacy(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != (LegacyImportResult{Imported: 2, Invalid: 1}) {
+				t.Errorf("first ImportLegacy() = %+v, want 2 imported and 1 invalid", result)
+			}
+			result, err = db.ImportLegacy(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != (LegacyImportResult{Duplicates: 2, Invalid: 1}) || result.Skipped() != 3 {
+				t.Errorf("second ImportLegacy() = %+v, want the 2 imported rows skipped as duplicates", result)
+			}
+
+			var imported, unsynced int
+			if err := db.conn.QueryRow("SELECT COUNT(*) FROM activities WHERE sync_state = ?", SyncImported).Scan(&imported); err != nil {
+				t.Fatal(err)
+			}
+			if unsynced, err = db.CountUnsynced(); err != nil {
+				t.Fatal(err)
+			}
+			if imported != 2 || unsynced != 0 {
+				t.Errorf("%d activities imported and %d to sync, want 2 and none", imported, unsynced)
+			}
+		})
+	}
+}
+
+func TestImportLegacyNotHistory(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "other.csv")
+	if err := os.WriteFile(csvPath, []byte("name,when\nDocs,today\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	noTable := filepath.Join(dir, "other.db")
+	writeLegacyDatabase(t, noTable, `CREATE TABLE notes (text TEXT)`)
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"CSV without the columns", csvPath},
+		{"database without activities", noTable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDatabase(t)
+			if _, err := db.ImportLegacy(tt.path); !errors.Is(err, ErrNotLegacyHistory) {
+				t.Errorf("ImportLegacy() error = %v, want %v", err, ErrNotLegacyHistory)
+			}
+		})
+	}
+
+	db := newTestDatabase(t)
+	if _, err := db.ImportLegacy(filepath.Join(dir, "missing.db")); err == nil || errors.Is(err, ErrNotLegacyHistory) {
+		t.Errorf("ImportLegacy() of a missing file error = %v, want a failure to open it", err)
+	}
+}
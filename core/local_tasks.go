@@ -0,0 +1,65 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// ErrLocalTaskExists is returned when creating a local task whose name is taken
+var ErrLocalTaskExists = errors.New("a local task with that name already exists")
+
+// localProject is the project shown for local tasks
+var localProject = types.Project{Name: "Local"}
+
+// localTask builds the task for a local_tasks row. IDs are negated so they
+// never collide with server task IDs.
+func localTask(id int, name string) types.Task {
+	return types.Task{ID: -id, Name: name, Project: localProject, Local: true}
+}
+
+// CreateLocalTask stores a new local-only task named name
+func (db *Database) CreateLocalTask(name string) (types.Task, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return types.Task{}, errors.New("the task name is empty")
+	}
+	result, err := db.conn.Exec("INSERT INTO local_tasks (name, created_at) VALUES (?, ?)",
+		name, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return types.Task{}, ErrLocalTaskExists
+		}
+		return types.Task{}, fmt.Errorf("failed to create local task: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return types.Task{}, fmt.Errorf("failed to create local task: %w", err)
+	}
+	return localTask(int(id), name), nil
+}
+
+// GetLocalTasks returns the local-only tasks, by name
+func (db *Database) GetLocalTasks() ([]types.Task, error) {
+	rows, err := db.conn.Query("SELECT id, name FROM local_tasks ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve local tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []types.Task{}
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan local task: %w", err)
+		}
+		tasks = append(tasks, localTask(id, name))
+	}
+	return tasks, rows.Err()
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+// TestMain keeps every test's settings, database and screenshots in a
+// temporary data directory, and off the display and session bus
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "time-tracker-core")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Setenv(config.HomeEnv, home)
+	err = config.Update(func(s *config.Settings) {
+		s.DetectScreenLock = false
+		s.TrackActiveWindow = false
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
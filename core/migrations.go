@@ -0,0 +1,123 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// migration is one step bringing an older database up to date. Steps must be
+// idempotent: databases created before schema versioning may already have
+// some of their changes, and a step interrupted by a crash is run again.
+type migration struct {
+	name  string
+	apply func(db *Database) error
+}
+
+// migrations are applied in order. A database's schema version is the number
+// of steps applied, so new steps are only ever appended.
+var migrations = []migration{
+	{"add activity input, app and report columns", func(db *Database) error {
+		return db.addMissingColumns("activities", "keyboard_event_count INTEGER DEFAULT 0",
+			"mouse_event_count INTEGER DEFAULT 0", "top_apps TEXT DEFAULT ''", "work_report_id INTEGER DEFAULT 0")
+	}},
+	{"add activity description", func(db *Database) error {
+		return db.addMissingColumns("activities", "description TEXT DEFAULT ''")
+	}},
+	{"add activity level and idle time", func(db *Database) error {
+		return db.addMissingColumns("activities", "activity_level INTEGER", "idle_seconds INTEGER DEFAULT 0")
+	}},
+	{"add screenshot display, report and activity columns", func(db *Database) error {
+		return db.addMissingColumns("screenshots", "scale_factor REAL", "physical_width INTEGER", "physical_height INTEGER",
+			"logical_width INTEGER", "logical_height INTEGER", "work_report_id INTEGER DEFAULT 0", "activity_level INTEGER")
+	}},
+	{"store times in UTC", (*Database).migrateTimesToUTC},
+	{"add activity sync state", func(db *Database) error {
+		return db.addMissingColumns("activities", "sync_state TEXT DEFAULT 'synced'")
+	}},
+	{"add activity server", (*Database).migrateActivityServer},
+}
+
+// migrate applies the migrations newer than the database's schema version,
+// recording the version after each one
+func (db *Database) migrate() error {
+	_, err := db.conn.Exec(`
+    CREATE TABLE IF NOT EXISTS schema_version (
+        id INTEGER PRIMARY KEY CHECK (id = 1),
+        version INTEGER NOT NULL
+    )`)
+	if err != nil {
+		return fmt.Errorf("failed to initialize schema_version table: %w", err)
+	}
+	version, err := db.schemaVersion()
+	if err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		// Written by a newer release; its extra columns are ignored
+		log.Printf("Database schema version %d is newer than this release supports (%d)", version, len(migrations))
+		return nil
+	}
+	for i := version; i < len(migrations); i++ {
+		step := migrations[i]
+		if err := step.apply(db); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", i+1, step.name, err)
+		}
+		_, err := db.conn.Exec(`
+        INSERT INTO schema_version (id, version) VALUES (1, ?)
+        ON CONFLICT(id) DO UPDATE SET version = excluded.version`, i+1)
+		if err != nil {
+			return fmt.Errorf("failed to record schema version %d: %w", i+1, err)
+		}
+		log.Printf("Applied database migration %d: %s", i+1, step.name)
+	}
+	return nil
+}
+
+// schemaVersion returns the number of migrations applied, 0 for a database
+// that predates schema versioning
+func (db *Database) schemaVersion() (int, error) {
+	var version int
+	err := db.conn.QueryRow("SELECT version FROM schema_version WHERE id = 1").Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// addMissingColumns adds each column definition, such as "idle_seconds
+// INTEGER DEFAULT 0", that table doesn't have yet
+func (db *Database) addMissingColumns(table string, definitions ...string) error {
+	columns, err := db.tableColumns(table)
+	if err != nil {
+		return err
+	}
+	for _, definition := range definitions {
+		name, _, _ := strings.Cut(definition, " ")
+		if columns[name] {
+			continue
+		}
+		if _, err := db.conn.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, definition)); err != nil {
+			return fmt.Errorf("failed to add %s column: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// migrateActivityServer records which server each activity's work report
+// belongs to. Earlier activities are put down to the server in use now, as
+// the one they were most likely tracked against.
+func (db *Database) migrateActivityServer() error {
+	if err := db.addMissingColumns("activities", "server TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if _, err := db.conn.Exec("UPDATE activities SET server = ? WHERE server = ''", activeServer()); err != nil {
+		return fmt.Errorf("failed to record activity servers: %w", err)
+	}
+	return nil
+}
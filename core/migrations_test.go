@@ -0,0 +1,123 @@
+package core
+
+import (
+	"database/sql"
+	"testing"
+)
+
+// legacySchema is the activities and screenshots tables as created before
+// schema versioning, with a local-time timestamp
+const legacySchema = `
+CREATE TABLE activities (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task TEXT NOT NULL,
+    start_time TEXT NOT NULL,
+    end_time TEXT,
+    duration INTEGER,
+    screenshot_path TEXT
+);
+CREATE TABLE screenshots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    path TEXT NOT NULL,
+    captured_at TEXT NOT NULL,
+    duplicate INTEGER DEFAULT 0
+);
+INSERT INTO activities (task, start_time, end_time, duration) VALUES
+    ('Design', '2025-03-10T10:00:00+01:00', '2025-03-10T11:00:00+01:00', 3600);`
+
+// migratedToVersion2 is legacySchema after the first two migrations
+const migratedToVersion2 = legacySchema + `
+ALTER TABLE activities ADD COLUMN keyboard_event_count INTEGER DEFAULT 0;
+ALTER TABLE activities ADD COLUMN mouse_event_count INTEGER DEFAULT 0;
+ALTER TABLE activities ADD COLUMN top_apps TEXT DEFAULT '';
+ALTER TABLE activities ADD COLUMN work_report_id INTEGER DEFAULT 0;
+ALTER TABLE activities ADD COLUMN description TEXT DEFAULT '';`
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   string // Run on the database file before connecting
+		version int    // Recorded before connecting, if not 0
+		want    int
+	}{
+		{"new database", "", 0, len(migrations)},
+		{"predates versioning", legacySchema, 0, len(migrations)},
+		{"partly migrated", migratedToVersion2, 2, len(migrations)},
+		{"newer release", "", len(migrations) + 3, len(migrations) + 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := NewDatabase(testDatabaseFile(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.setup != "" || tt.version != 0 {
+				prepare(t, db.dbFile, tt.setup, tt.version)
+			}
+
+			if err := db.Connect(); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { db.conn.Close() })
+			if err := db.Unavailable(); err != nil {
+				t.Fatalf("fell back to memory: %v", err)
+			}
+			version, err := db.schemaVersion()
+			if err != nil || version != tt.want {
+				t.Fatalf("schema version = %d, %v, want %d", version, err, tt.want)
+			}
+			if err := db.migrate(); err != nil {
+				t.Errorf("migrating again: %v", err)
+			}
+
+			columns, err := db.tableColumns("activities")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, column := range []string{"keyboard_event_count", "description", "idle_seconds", "sync_state", "server"} {
+				if !columns[column] {
+					t.Errorf("activities has no %s column", column)
+				}
+			}
+			if tt.setup != "" {
+				var start string
+				if err := db.conn.QueryRow("SELECT start_time FROM activities").Scan(&start); err != nil {
+					t.Fatal(err)
+				}
+				if start != "2025-03-10T09:00:00Z" {
+					t.Errorf("start_time = %s, want it in UTC", start)
+				}
+				var server string
+				if err := db.conn.QueryRow("SELECT server FROM activities").Scan(&server); err != nil {
+					t.Fatal(err)
+				}
+				if server != activeServer() {
+					t.Errorf("server = %q, want the active profile's %q", server, activeServer())
+				}
+			}
+		})
+	}
+}
+
+// prepare runs setup on the database file at path and records version
+func prepare(t *testing.T, path, setup string, version int) {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if setup != "" {
+		if _, err := conn.Exec(setup); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if version != 0 {
+		_, err := conn.Exec(`CREATE TABLE schema_version (id INTEGER PRIMARY KEY CHECK (id = 1), version INTEGER NOT NULL);
+            INSERT INTO schema_version (id, version) VALUES (1, ?)`, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// Reassign moves the running session to task without stopping it: the time
+// tracked so far, the logged activity and the next screenshots all go to
+// task. The server side is moved separately with ReassignWorkReport. To keep
+// the time so far on the current task instead, use Switch.
+func (s *Session) Reassign(task types.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.task == nil {
+		return errors.New("not tracking")
+	}
+	if s.task.ID == task.ID {
+		return fmt.Errorf("already tracking %s", task.Name)
+	}
+	if s.concurrentIndex(task.ID) >= 0 {
+		return fmt.Errorf("%s is already being tracked alongside", task.Name)
+	}
+	s.ActivityTracker.Reassign(task.Name)
+	s.alloc.rename(s.task.ID, task.ID)
+	s.task = &task
+	if task.Local {
+		s.TaskManager.SetActiveTask(task)
+	}
+	s.writeCheckpoint()
+	return nil
+}
+
+// ReassignWorkReport follows a Reassign on the server: the open work report is
+// closed at its start, so it holds no time, and a report for the new task is
+// opened from the same start. Screenshots already uploaded stay with the old
+// report. If closing the old report fails it stays attached to the session,
+// to be closed when it stops, and no new report is opened. CloseWorkReport
+// waits for the handover, and the new report isn't opened if the session
+// stopped or was reassigned again meanwhile.
+func (s *Session) ReassignWorkReport(stopDescription, startDescription string) error {
+	s.reportMu.Lock()
+	defer s.reportMu.Unlock()
+
+	s.mu.Lock()
+	task := s.task
+	start := s.startTime
+	s.mu.Unlock()
+	if task == nil {
+		return errors.New("no active session")
+	}
+
+	if report := s.TaskManager.GetWorkReport(); report != nil {
+		if report.StartTime != nil {
+			start = *report.StartTime
+		}
+		if _, err := s.TaskManager.UserStopTaskAt(stopDescription, start); err != nil {
+			return fmt.Errorf("failed to close the previous work report: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	current := s.task
+	s.mu.Unlock()
+	if current != task {
+		return fmt.Errorf("tracking %s ended before its work report was opened", task.Name)
+	}
+	var openErr error
+	if !task.Local {
+		_, openErr = s.TaskManager.UserStartTaskAt(task.Project.ID, *task, startDescription, start)
+	}
+	s.mu.Lock()
+	s.writeCheckpoint()
+	s.mu.Unlock()
+	return openErr
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/internal/types"
+)
+
+func TestReassign(t *testing.T) {
+	review := types.Task{ID: 102, Name: "Accessibility review", Project: demoTask.Project}
+	release := types.Task{ID: 202, Name: "Release notes", Project: types.Project{ID: 2, Name: "Mobile App"}}
+
+	tests := []struct {
+		name    string
+		start   bool
+		to      types.Task
+		wantErr bool
+	}{
+		{"not tracking", false, review, true},
+		{"same task", true, demoTask, true},
+		{"tracked alongside", true, release, true},
+		{"another task", true, review, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSession(t, newFakeTaskAPI())
+			if tt.start {
+				if err := s.Start(demoTask); err != nil {
+					t.Fatal(err)
+				}
+				if err := s.AddTask(release, "with"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := s.Reassign(tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Reassign() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := *s.ActivityTracker.CurrentTask; got != tt.to.Name {
+				t.Errorf("tracking %q, want %q", got, tt.to.Name)
+			}
+			for _, activity := range s.ActivityTracker.GetActiveTasks() {
+				if activity.TaskName != tt.to.Name {
+					t.Errorf("activity logged on %q, want %q", activity.TaskName, tt.to.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestReassignWorkReport(t *testing.T) {
+	review := types.Task{ID: 102, Name: "Accessibility review", Project: demoTask.Project}
+	api := newFakeTaskAPI()
+	s := newTestSession(t, api)
+	if err := s.Start(demoTask); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.OpenWorkReport("start"); err != nil {
+		t.Fatal(err)
+	}
+	old := s.TaskManager.GetWorkReport()
+
+	if err := s.Reassign(review); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReassignWorkReport("moved", "moved here"); err != nil {
+		t.Fatal(err)
+	}
+
+	end, ok := api.stoppedAt(old.ID)
+	if !ok || !end.Equal(*old.StartTime) {
+		t.Errorf("old report closed at %s (%v), want at its start %s", end, ok, old.StartTime)
+	}
+	current := s.TaskManager.GetWorkReport()
+	if current == nil || current.ID == old.ID || current.Task.ID != review.ID {
+		t.Fatalf("open report = %+v, want a new one for %s", current, review.Name)
+	}
+	if !current.StartTime.Equal(*old.StartTime) {
+		t.Errorf("new report starts at %s, want %s", current.StartTime, old.StartTime)
+	}
+}
+
+func TestReassignWorkReportCloseFails(t *testing.T) {
+	review := types.Task{ID: 102, Name: "Accessibility review", Project: demoTask.Project}
+	api := newFakeTaskAPI()
+	s := newTestSession(t, api)
+	if err := s.Start(demoTask); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.OpenWorkReport("start"); err != nil {
+		t.Fatal(err)
+	}
+	old := s.TaskManager.GetWorkReport()
+	if err := s.Reassign(review); err != nil {
+		t.Fatal(err)
+	}
+
+	api.stopErr = errors.New("offline")
+	if err := s.ReassignWorkReport("moved", "moved here"); err == nil {
+		t.Fatal("ReassignWorkReport() succeeded without closing the old report")
+	}
+	// The old report is kept so stopping the session closes it
+	if current := s.TaskManager.GetWorkReport(); current == nil || current.ID != old.ID {
+		t.Fatalf("open report = %+v, want the old one (%d) kept", current, old.ID)
+	}
+
+	api.stopErr = nil
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CloseWorkReport("done"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := api.stoppedAt(old.ID); !ok {
+		t.Error("old report wasn't closed when the session stopped")
+	}
+}
+
+func TestReassignWorkReportAfterStop(t *testing.T) {
+	review := types.Task{ID: 102, Name: "Accessibility review", Project: demoTask.Project}
+	api := newFakeTaskAPI()
+	s := newTestSession(t, api)
+	if err := s.Start(demoTask); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.OpenWorkReport("start"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reassign(review); err != nil {
+		t.Fatal(err)
+	}
+
+	api.stopHold = make(chan struct{})
+	handover := make(chan error, 1)
+	go func() { handover <- s.ReassignWorkReport("moved", "moved here") }()
+	time.Sleep(100 * time.Millisecond) // The old report is being closed
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	close(api.stopHold)
+
+	if err := <-handover; err == nil {
+		t.Error("ReassignWorkReport() succeeded after the session stopped")
+	}
+	if report := s.TaskManager.GetWorkReport(); report != nil {
+		t.Errorf("report %d opened after the session stopped", report.ID)
+	}
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+// pixelSize returns the pixelation block size for a blur level, or 0 when off
+func pixelSize(level string) int {
+	switch level {
+	case config.BlurLight:
+		return 8
+	case config.BlurHeavy:
+		return 24
+	default:
+		return 0
+	}
+}
+
+// redactImage pixelates img according to the blur level so on-screen text is
+// unreadable. It returns img unchanged when blurring is off.
+func redactImage(img image.Image, level string) image.Image {
+	block := pixelSize(level)
+	if block == 0 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	out := image.NewRGBA(bounds)
+	for by := bounds.Min.Y; by < bounds.Max.Y; by += block {
+		for bx := bounds.Min.X; bx < bounds.Max.X; bx += block {
+			cell := image.Rect(bx, by, bx+block, by+block).Intersect(bounds)
+
+			// Average the cell's pixels
+			var r, g, b, a, n uint64
+			for y := cell.Min.Y; y < cell.Max.Y; y++ {
+				for x := cell.Min.X; x < cell.Max.X; x++ {
+					cr, cg, cb, ca := img.At(x, y).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					b += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			avg := color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			}
+			for y := cell.Min.Y; y < cell.Max.Y; y++ {
+				for x := cell.Min.X; x < cell.Max.X; x++ {
+					out.Set(x, y, avg)
+				}
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// UploadResult is the outcome of uploading one screenshot
+type UploadResult struct {
+	Path string
+	Err  error // nil if the upload succeeded
+}
+
+// ReuploadScreenshots uploads the locally stored screenshots captured while
+// workReportID was open to that report, oldest first. progress, if not nil, is
+// called after each file with the number done so far and the total. Files
+// that no longer exist are reported as failed rather than stopping the batch.
+func (s *Session) ReuploadScreenshots(workReportID int, progress func(done, total int)) ([]UploadResult, error) {
+	if workReportID == 0 {
+		return nil, ErrNoActiveWorkReport
+	}
+	paths, err := s.ActivityTracker.Database.GetWorkReportScreenshots(workReportID)
+	if err != nil {
+		return nil, err
+	}
+
+	sm := s.ActivityTracker.ScreenshotManager
+	results := make([]UploadResult, 0, len(paths))
+	for i, path := range paths {
+		release := sm.holdForUpload(path)
+		err := s.TaskManager.uploadExisting(workReportID, path)
+		release()
+		if err != nil {
+			log.Printf("Failed to re-upload screenshot %s: %v", path, err)
+		}
+		results = append(results, UploadResult{Path: path, Err: err})
+		if progress != nil {
+			progress(i+1, len(paths))
+		}
+	}
+	return results, nil
+}
+
+// uploadExisting uploads filePath to workReportID if the file still exists
+func (tm *TaskManager) uploadExisting(workReportID int, filePath string) error {
+	if _, err := os.Stat(filePath); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("screenshot file no longer exists")
+		}
+		return err
+	}
+	return tm.uploadTo(workReportID, filePath)
+}
+
+// holdForUpload keeps path from being deleted, by Cleanup or DeleteRange,
+// until the returned func is called. A hold already taken by the capture's own
+// upload is left for that upload to release.
+func (sm *ScreenshotManager) holdForUpload(path string) func() {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.uploading[path] {
+		return func() {}
+	}
+	sm.uploading[path] = true
+	return func() {
+		sm.mu.Lock()
+		delete(sm.uploading, path)
+		sm.mu.Unlock()
+	}
+}
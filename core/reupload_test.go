@@ -0,0 +1,53 @@
+package core
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+func TestReuploadKeepsFileUntilSent(t *testing.T) {
+	dir := t.TempDir()
+	updateSettings(t, func(s *config.Settings) { s.ScreenshotDir = dir })
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	path := writeScreenshot(t, dir, old, ".png", 100, old)
+
+	api := newFakeTaskAPI()
+	api.uploadHold = make(chan struct{})
+	s := newTestSession(t, api)
+	const reportID = 42
+	if err := s.ActivityTracker.Database.SaveScreenshot(path, old.UTC().Format(time.RFC3339), false, ScreenshotScale{}, reportID, -1); err != nil {
+		t.Fatal(err)
+	}
+	sm := s.ActivityTracker.ScreenshotManager
+
+	done := make(chan []UploadResult, 1)
+	go func() {
+		results, err := s.ReuploadScreenshots(reportID, nil)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- results
+	}()
+	time.Sleep(100 * time.Millisecond) // The upload is in flight
+
+	if deleted, _, err := sm.Cleanup(24*time.Hour, 0); err != nil || deleted != 0 {
+		t.Errorf("Cleanup() during the upload = %d, %v, want 0 deleted", deleted, err)
+	}
+	if err := sm.DeleteScreenshot(path); err == nil {
+		t.Error("DeleteScreenshot() succeeded during the upload")
+	}
+	close(api.uploadHold)
+	if results := <-done; len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("ReuploadScreenshots() = %+v, want one success", results)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("screenshot deleted during the upload: %v", err)
+	}
+
+	if err := sm.DeleteScreenshot(path); err != nil {
+		t.Errorf("DeleteScreenshot() after the upload = %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/time-tracker/v2/internal/logging"
+)
+
+// ErrScreenLocked is returned instead of capturing the lock screen
+var ErrScreenLocked = errors.New("screen is locked")
+
+// ScreenLocked reports whether the user's session is locked.
+//
+// Detection needs, per OS:
+//   - macOS: nothing extra (reads the session state via ioreg)
+//   - Windows: nothing extra
+//   - Linux: systemd-logind with loginctl; desktops that don't report
+//     LockedHint always read as unlocked
+func ScreenLocked() (bool, error) {
+	return screenLocked()
+}
+
+// lockPollInterval is how often the lock state is checked while tracking
+const lockPollInterval = 5 * time.Second
+
+// lockWatcher polls the lock state while tracking and emits
+// EventScreenLocked and EventScreenUnlocked when it changes
+type lockWatcher struct {
+	mu     sync.Mutex
+	locked bool
+	stop   chan struct{}
+	wg     sync.WaitGroup
+	failed bool // Logged a query failure already this session
+	events *eventBus
+}
+
+func newLockWatcher(events *eventBus) *lockWatcher {
+	return &lockWatcher{events: events}
+}
+
+// start polls the lock state until stopWatching is called
+func (lw *lockWatcher) start() {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if lw.stop != nil {
+		return
+	}
+	lw.locked = false
+	lw.failed = false
+	lw.stop = make(chan struct{})
+	lw.wg.Add(1)
+	go lw.run(lw.stop)
+}
+
+func (lw *lockWatcher) run(stop chan struct{}) {
+	defer lw.wg.Done()
+	defer logging.Recover("screen lock watcher", nil)
+
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+	for {
+		lw.poll()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll checks the lock state and emits an event if it changed
+func (lw *lockWatcher) poll() {
+	locked, err := ScreenLocked()
+	lw.mu.Lock()
+	if err != nil {
+		if !lw.failed {
+			log.Printf("Failed to check whether the screen is locked: %v", err)
+			lw.failed = true
+		}
+		lw.mu.Unlock()
+		return
+	}
+	changed := locked != lw.locked
+	lw.locked = locked
+	lw.mu.Unlock()
+	if !changed {
+		return
+	}
+	if locked {
+		log.Println("Screen locked")
+		lw.events.emit(TrackerEvent{Type: EventScreenLocked})
+	} else {
+		log.Println("Screen unlocked")
+		lw.events.emit(TrackerEvent{Type: EventScreenUnlocked})
+	}
+}
+
+// isLocked reports the last polled lock state
+func (lw *lockWatcher) isLocked() bool {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.stop != nil && lw.locked
+}
+
+// stopWatching stops polling. An unlock event is sent if the screen was
+// locked, so listeners don't stay in the locked state.
+func (lw *lockWatcher) stopWatching() {
+	lw.mu.Lock()
+	stop := lw.stop
+	lw.stop = nil
+	wasLocked := lw.locked
+	lw.locked = false
+	lw.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	lw.wg.Wait()
+	if wasLocked {
+		lw.events.emit(TrackerEvent{Type: EventScreenUnlocked})
+	}
+}
@@ -0,0 +1,17 @@
+package core
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// screenLocked reads the CGSSessionScreenIsLocked flag, which is only present
+// in the IORegistry root while the screen is locked
+func screenLocked() (bool, error) {
+	out, err := exec.Command("ioreg", "-n", "Root", "-d1").Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to query session lock state: %w", err)
+	}
+	return strings.Contains(string(out), `"CGSSessionScreenIsLocked"=Yes`), nil
+}
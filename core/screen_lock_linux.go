@@ -0,0 +1,21 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// screenLocked asks systemd-logind whether the current session is locked
+func screenLocked() (bool, error) {
+	session := os.Getenv("XDG_SESSION_ID")
+	if session == "" {
+		session = "self"
+	}
+	out, err := exec.Command("loginctl", "show-session", session, "--property=LockedHint", "--value").Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to query session lock state (is loginctl installed?): %w", err)
+	}
+	return strings.TrimSpace(string(out)) == "yes", nil
+}
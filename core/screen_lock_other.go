@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !windows
+
+package core
+
+import "errors"
+
+func screenLocked() (bool, error) {
+	return false, errors.New("screen lock detection is not supported on this platform")
+}
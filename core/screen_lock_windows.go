@@ -0,0 +1,20 @@
+package core
+
+// desktopSwitchDesktop is the DESKTOP_SWITCHDESKTOP access right
+const desktopSwitchDesktop = 0x0100
+
+var (
+	procOpenInputDesktop = user32.NewProc("OpenInputDesktop")
+	procCloseDesktop     = user32.NewProc("CloseDesktop")
+)
+
+// screenLocked reports the session as locked when the input desktop can't be
+// opened, which happens while the secure Winlogon desktop is showing
+func screenLocked() (bool, error) {
+	desktop, _, _ := procOpenInputDesktop.Call(0, 0, desktopSwitchDesktop)
+	if desktop == 0 {
+		return true, nil
+	}
+	procCloseDesktop.Call(desktop)
+	return false, nil
+}
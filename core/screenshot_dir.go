@@ -0,0 +1,75 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MoveScreenshots moves the screenshot files in from into to, copying them
+// when a rename isn't possible (e.g. across drives). It returns how many were moved.
+func MoveScreenshots(from, to string) (int, error) {
+	if filepath.Clean(from) == filepath.Clean(to) {
+		return 0, nil
+	}
+	entries, err := os.ReadDir(from)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read screenshot directory: %w", err)
+	}
+	if err := os.MkdirAll(to, os.ModePerm); err != nil {
+		return 0, fmt.Errorf("failed to create screenshot directory %s: %w", to, err)
+	}
+
+	moved := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), "screenshot_") {
+			continue
+		}
+		src := filepath.Join(from, entry.Name())
+		dst := filepath.Join(to, entry.Name())
+		if _, err := os.Stat(dst); err == nil {
+			log.Printf("Not moving screenshot %s: %s already exists", src, dst)
+			continue
+		}
+		if err := moveFile(src, dst); err != nil {
+			return moved, fmt.Errorf("failed to move screenshot %s: %w", src, err)
+		}
+		moved++
+	}
+	return moved, nil
+}
+
+// moveFile renames src to dst, falling back to copy and delete
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/time-tracker/v2/internal/config"
+	"golang.org/x/image/draw"
+)
+
+// screenshotExtensions are the file extensions screenshots may be saved with
+var screenshotExtensions = []string{".png", ".jpg", ".webp"}
+
+// screenshotExtension returns the file extension for a screenshot format
+func screenshotExtension(format string) string {
+	switch format {
+	case config.FormatJPEG:
+		return ".jpg"
+	case config.FormatWebP:
+		return ".webp"
+	}
+	return ".png"
+}
+
+// encodeScreenshot writes img to w in format. quality (1-100) applies to JPEG and WebP.
+func encodeScreenshot(w io.Writer, img image.Image, format string, quality int) error {
+	switch format {
+	case config.FormatJPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case config.FormatWebP:
+		return encodeWebP(w, img, quality)
+	case config.FormatPNG, "":
+		return png.Encode(w, img)
+	}
+	return fmt.Errorf("unsupported screenshot format %q", format)
+}
+
+// downscale shrinks img so neither side exceeds maxDimension, keeping the
+// aspect ratio. Smaller images, or a maxDimension of 0, are returned unchanged.
+func downscale(img image.Image, maxDimension int) image.Image {
+	bounds := img.Bounds()
+	longest := max(bounds.Dx(), bounds.Dy())
+	if maxDimension <= 0 || longest <= maxDimension {
+		return img
+	}
+	width := max(1, bounds.Dx()*maxDimension/longest)
+	height := max(1, bounds.Dy()*maxDimension/longest)
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+	return scaled
+}
@@ -0,0 +1,113 @@
+package core
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/time-tracker/v2/internal/config"
+	"golang.org/x/image/webp"
+)
+
+func TestScreenshotExtension(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{config.FormatPNG, ".png"},
+		{config.FormatJPEG, ".jpg"},
+		{config.FormatWebP, ".webp"},
+		{"", ".png"},
+	}
+	for _, tt := range tests {
+		if got := screenshotExtension(tt.format); got != tt.want {
+			t.Errorf("screenshotExtension(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeScreenshot(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+
+	tests := []struct {
+		name       string
+		format     string
+		quality    int
+		wantFormat string
+		wantErr    bool
+	}{
+		{"png", config.FormatPNG, 0, "png", false},
+		{"default is png", "", 0, "png", false},
+		{"jpeg", config.FormatJPEG, 85, "jpeg", false},
+		{"webp", config.FormatWebP, 85, "webp", false},
+		{"unsupported", "gif", 0, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := encodeScreenshot(&buf, img, tt.format, tt.quality)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("encodeScreenshot() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			_, format, err := image.DecodeConfig(&buf)
+			if err != nil || format != tt.wantFormat {
+				t.Errorf("encoded as %q (%v), want %q", format, err, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestEncodeWebPSubImage(t *testing.T) {
+	// A region of a larger capture, as the active window scope produces
+	full := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	draw.Draw(full, image.Rect(16, 16, 48, 40), image.NewUniform(color.RGBA{R: 255, A: 255}), image.Point{}, draw.Src)
+	region := full.SubImage(image.Rect(16, 16, 48, 40))
+
+	var buf bytes.Buffer
+	if err := encodeScreenshot(&buf, region, config.FormatWebP, 100); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := webp.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.Bounds(); got.Dx() != 32 || got.Dy() != 24 {
+		t.Fatalf("decoded %dx%d, want 32x24", got.Dx(), got.Dy())
+	}
+	if r, g, _, _ := decoded.At(16, 12).RGBA(); r>>8 < 200 || g>>8 > 60 {
+		t.Errorf("centre pixel has red %d, green %d, want the red region", r>>8, g>>8)
+	}
+}
+
+func TestDownscale(t *testing.T) {
+	tests := []struct {
+		name         string
+		width        int
+		height       int
+		maxDimension int
+		wantWidth    int
+		wantHeight   int
+	}{
+		{"landscape", 400, 200, 100, 100, 50},
+		{"portrait", 200, 400, 100, 50, 100},
+		{"already small", 80, 40, 100, 80, 40},
+		{"exactly the limit", 100, 100, 100, 100, 100},
+		{"no limit", 400, 200, 0, 400, 200},
+		{"thin side kept at one pixel", 1000, 1, 100, 100, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := downscale(image.NewRGBA(image.Rect(0, 0, tt.width, tt.height)), tt.maxDimension).Bounds()
+			if got.Dx() != tt.wantWidth || got.Dy() != tt.wantHeight {
+				t.Errorf("downscale() = %dx%d, want %dx%d", got.Dx(), got.Dy(), tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
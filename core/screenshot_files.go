@@ -0,0 +1,164 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+const (
+	screenshotPrefix     = "screenshot_"
+	screenshotTimeLayout = "20060102_150405"
+)
+
+// ScreenshotFile is a screenshot on disk
+type ScreenshotFile struct {
+	Path  string
+	Time  time.Time // When it was captured
+	Size  int64
+	Scale *ScreenshotScale // Set by Database.LoadScreenshotDetails if recorded
+	// ActivityLevel is the input activity (0-100%) since the previous capture,
+	// set by Database.LoadScreenshotDetails if recorded
+	ActivityLevel *int
+	Note          string // Set by Database.LoadScreenshotDetails
+}
+
+// ScreenshotScale describes the display a screenshot was captured on. On
+// HiDPI displays the physical pixel size is a multiple (Factor) of the
+// display's logical size.
+type ScreenshotScale struct {
+	Factor         float64
+	PhysicalWidth  int
+	PhysicalHeight int
+	LogicalWidth   int
+	LogicalHeight  int
+}
+
+// newScreenshotScale compares a capture's pixel bounds with the display's logical bounds
+func newScreenshotScale(physical, logical image.Rectangle) ScreenshotScale {
+	scale := ScreenshotScale{
+		Factor:         1,
+		PhysicalWidth:  physical.Dx(),
+		PhysicalHeight: physical.Dy(),
+		LogicalWidth:   logical.Dx(),
+		LogicalHeight:  logical.Dy(),
+	}
+	if logical.Dx() > 0 {
+		scale.Factor = float64(physical.Dx()) / float64(logical.Dx())
+	}
+	return scale
+}
+
+// ParseScreenshotTime extracts the capture time from a screenshot file name
+// such as screenshot_20250102_150405.png. Anything after the timestamp (a
+// counter, a different extension) is ignored. Times are in the local zone.
+func ParseScreenshotTime(name string) (time.Time, bool) {
+	rest, ok := strings.CutPrefix(filepath.Base(name), screenshotPrefix)
+	if !ok || len(rest) < len(screenshotTimeLayout) {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(screenshotTimeLayout, rest[:len(screenshotTimeLayout)], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// ListScreenshots returns the screenshots in the screenshot directory, newest
+// first. Files whose name has no timestamp fall back to their modification time.
+func ListScreenshots() ([]ScreenshotFile, error) {
+	screenshotDir, err := config.ScreenshotDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(screenshotDir)
+	if os.IsNotExist(err) {
+		// Deleted since ScreenshotDir created it; the next capture recreates it
+		return []ScreenshotFile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read screenshot directory: %w", err)
+	}
+
+	screenshots := []ScreenshotFile{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), screenshotPrefix) || !slices.Contains(screenshotExtensions, filepath.Ext(entry.Name())) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		captured, ok := ParseScreenshotTime(entry.Name())
+		if !ok {
+			captured = info.ModTime()
+		}
+		screenshots = append(screenshots, ScreenshotFile{
+			Path: filepath.Join(screenshotDir, entry.Name()),
+			Time: captured,
+			Size: info.Size(),
+		})
+	}
+	sort.Slice(screenshots, func(i, j int) bool {
+		return screenshots[i].Time.After(screenshots[j].Time)
+	})
+	return screenshots, nil
+}
+
+// DeleteScreenshot removes a screenshot file unless it is still being uploaded
+func (sm *ScreenshotManager) DeleteScreenshot(path string) error {
+	sm.mu.Lock()
+	uploading := sm.uploading[path]
+	sm.mu.Unlock()
+	if uploading {
+		return errors.New("the screenshot is still being uploaded")
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to delete screenshot: %w", err)
+	}
+	sm.forgetNote(path)
+	return nil
+}
+
+// forgetNote removes the note on a deleted screenshot
+func (sm *ScreenshotManager) forgetNote(path string) {
+	if sm.database == nil {
+		return
+	}
+	if err := sm.database.SetScreenshotNote(path, ""); err != nil {
+		log.Printf("Failed to remove note on deleted screenshot %s: %v", path, err)
+	}
+}
+
+// DeleteRange removes the screenshots captured before the cutoff, by the
+// timestamp in their file names, skipping any still being uploaded. It returns
+// the number of files deleted and the bytes freed.
+func (sm *ScreenshotManager) DeleteRange(before time.Time) (int, int64, error) {
+	screenshots, err := ListScreenshots()
+	if err != nil {
+		return 0, 0, err
+	}
+	deleted := 0
+	var freed int64
+	for _, s := range screenshots {
+		if !s.Time.Before(before) {
+			continue
+		}
+		if err := sm.DeleteScreenshot(s.Path); err != nil {
+			log.Printf("Not deleting screenshot %s: %v", s.Path, err)
+			continue
+		}
+		deleted++
+		freed += s.Size
+	}
+	return deleted, freed, nil
+}
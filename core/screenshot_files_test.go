@@ -0,0 +1,167 @@
+package core
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+func TestScreenshotDirDeleted(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(sm *ScreenshotManager) (int, error)
+	}{
+		{"ListScreenshots", func(*ScreenshotManager) (int, error) {
+			files, err := ListScreenshots()
+			return len(files), err
+		}},
+		{"Cleanup", func(sm *ScreenshotManager) (int, error) {
+			deleted, _, err := sm.Cleanup(time.Hour, 1)
+			return deleted, err
+		}},
+		{"DeleteRange", func(sm *ScreenshotManager) (int, error) {
+			deleted, _, err := sm.DeleteRange(time.Now())
+			return deleted, err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			updateSettings(t, func(s *config.Settings) { s.ScreenshotDir = dir })
+			writeScreenshot(t, dir, time.Now().Add(-48*time.Hour), ".png", 100, time.Now())
+			if err := os.RemoveAll(dir); err != nil {
+				t.Fatal(err)
+			}
+
+			n, err := tt.run(NewScreenshotManager(60, nil, nil))
+			if err != nil || n != 0 {
+				t.Fatalf("%s() = %d, %v, want 0 and no error", tt.name, n, err)
+			}
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				t.Errorf("screenshot directory wasn't recreated: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseScreenshotTime(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   time.Time
+		wantOK bool
+	}{
+		{"screenshot_20250102_150405.png", time.Date(2025, 1, 2, 15, 4, 5, 0, time.Local), true},
+		{"/some/dir/screenshot_20250102_150405.jpg", time.Date(2025, 1, 2, 15, 4, 5, 0, time.Local), true},
+		{"screenshot_20250102_150405_2.png", time.Date(2025, 1, 2, 15, 4, 5, 0, time.Local), true},
+		{"screenshot_2025.png", time.Time{}, false},
+		{"screenshot_20251302_150405.png", time.Time{}, false}, // Month 13
+		{"photo_20250102_150405.png", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseScreenshotTime(tt.name)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("ParseScreenshotTime() = %s, %v, want %s, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDeleteRange(t *testing.T) {
+	cutoff := time.Date(2025, 3, 10, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name        string
+		uploading   []string
+		wantDeleted []string
+	}{
+		{"before the cutoff", nil, []string{"week before", "day before"}},
+		{"skips uploads", []string{"day before"}, []string{"week before"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			updateSettings(t, func(s *config.Settings) { s.ScreenshotDir = dir })
+			files := map[string]string{
+				"week before": writeScreenshot(t, dir, cutoff.AddDate(0, 0, -7), ".png", 100, cutoff),
+				"day before":  writeScreenshot(t, dir, cutoff.Add(-time.Second), ".jpg", 100, cutoff),
+				"at cutoff":   writeScreenshot(t, dir, cutoff, ".png", 100, cutoff),
+				"after":       writeScreenshot(t, dir, cutoff.AddDate(0, 0, 1), ".png", 100, cutoff.AddDate(0, 0, -30)),
+			}
+
+			sm := NewScreenshotManager(60, nil, nil)
+			for _, name := range tt.uploading {
+				sm.uploading[files[name]] = true
+			}
+			deleted, freed, err := sm.DeleteRange(cutoff)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if deleted != len(tt.wantDeleted) || freed != int64(100*len(tt.wantDeleted)) {
+				t.Errorf("DeleteRange() = %d files, %d bytes, want %d files", deleted, freed, len(tt.wantDeleted))
+			}
+			for name, path := range files {
+				_, err := os.Stat(path)
+				if gone := os.IsNotExist(err); gone != slices.Contains(tt.wantDeleted, name) {
+					t.Errorf("%s deleted = %v, want %v", name, gone, !gone)
+				}
+			}
+		})
+	}
+}
+
+func TestScreenshotNotes(t *testing.T) {
+	tests := []struct {
+		name  string
+		notes []string // Set in turn
+		want  string
+	}{
+		{"none", nil, ""},
+		{"set", []string{"Reviewing the design"}, "Reviewing the design"},
+		{"replaced", []string{"first", "second"}, "second"},
+		{"cleared", []string{"first", ""}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			updateSettings(t, func(s *config.Settings) { s.ScreenshotDir = dir })
+			path := writeScreenshot(t, dir, time.Date(2025, 3, 10, 9, 0, 0, 0, time.Local), ".png", 100, time.Now())
+			db := newTestDatabase(t)
+
+			for _, note := range tt.notes {
+				if err := db.SetScreenshotNote(path, note); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got, err := db.ScreenshotNote(path); err != nil || got != tt.want {
+				t.Errorf("ScreenshotNote() = %q, %v, want %q", got, err, tt.want)
+			}
+			files, err := ListScreenshots()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := db.LoadScreenshotDetails(files); err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != 1 || files[0].Note != tt.want {
+				t.Errorf("LoadScreenshotDetails() = %+v, want the note %q", files, tt.want)
+			}
+
+			// Deleting the screenshot takes its note with it
+			sm := NewScreenshotManager(60, nil, db)
+			if err := sm.DeleteScreenshot(path); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := db.ScreenshotNote(path); err != nil || got != "" {
+				t.Errorf("ScreenshotNote() after deletion = %q, %v, want none", got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package core
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+func TestJitteredInterval(t *testing.T) {
+	const interval = 10 * time.Minute
+	tests := []struct {
+		name    string
+		percent int
+		rand    float64
+		want    time.Duration
+	}{
+		{"no jitter", 0, 0.9, interval},
+		{"negative treated as none", -5, 0.9, interval},
+		{"shortest", 20, 0, 8 * time.Minute},
+		{"middle", 20, 0.5, interval},
+		{"longest", 20, 0.75, 11 * time.Minute},
+		{"clamped to the maximum", MaxScreenshotJitterPercent + 50, 0, interval - interval*MaxScreenshotJitterPercent/100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := jitteredInterval(interval, tt.percent, func() float64 { return tt.rand })
+			if got != tt.want {
+				t.Errorf("jitteredInterval(%s, %d) = %s, want %s", interval, tt.percent, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRandSourceMakesIntervalsReproducible(t *testing.T) {
+	updateSettings(t, func(s *config.Settings) {
+		s.ScreenshotJitterPercent = 20
+		s.AdaptiveScreenshotInterval = false
+	})
+	intervals := func() []time.Duration {
+		sm := NewScreenshotManager(600, nil, nil)
+		sm.SetRandSource(rand.New(rand.NewSource(42)))
+		var got []time.Duration
+		for i := 0; i < 5; i++ {
+			got = append(got, sm.randomInterval())
+		}
+		return got
+	}
+
+	first, second := intervals(), intervals()
+	if !slices.Equal(first, second) {
+		t.Errorf("intervals with the same seed differ: %v and %v", first, second)
+	}
+	for _, d := range first {
+		if d < 480*time.Second || d > 720*time.Second {
+			t.Errorf("interval %s outside 600s ± 20%%", d)
+		}
+	}
+}
+
+func TestSchedulerWaitsWithInjectedAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		grace int
+		want  func(seeded *ScreenshotManager) time.Duration
+	}{
+		{"random interval", 0, func(seeded *ScreenshotManager) time.Duration { return seeded.randomInterval() }},
+		{"grace period first", 45, func(*ScreenshotManager) time.Duration { return 45 * time.Second }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateSettings(t, func(s *config.Settings) {
+				s.ScreenshotGraceSeconds = tt.grace
+				s.AdaptiveScreenshotInterval = false
+			})
+			waits := make(chan time.Duration, 1)
+			sm := NewScreenshotManager(600, nil, nil)
+			sm.SetRandSource(rand.New(rand.NewSource(7)))
+			sm.SetAfter(func(d time.Duration) <-chan time.Time {
+				waits <- d
+				return make(chan time.Time) // Never fires, so nothing is captured
+			})
+			sm.stopChan = make(chan struct{})
+			sm.wg.Add(1)
+			go sm.scheduleRandomCapture()
+
+			got := <-waits
+			close(sm.stopChan)
+			sm.wg.Wait()
+
+			seeded := NewScreenshotManager(600, nil, nil)
+			seeded.SetRandSource(rand.New(rand.NewSource(7)))
+			if want := tt.want(seeded); got != want {
+				t.Errorf("first wait = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestGracePeriodDelaysOnlyFirstCapture(t *testing.T) {
+	tests := []struct {
+		name  string
+		grace int
+	}{
+		{"no grace period", 0},
+		{"grace period", 45},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateSettings(t, func(s *config.Settings) {
+				s.ScreenshotGraceSeconds = tt.grace
+				s.ScreenshotJitterPercent = 0
+				s.AdaptiveScreenshotInterval = false
+			})
+			waits := make(chan time.Duration, 3)
+			calls := 0
+			sm := NewScreenshotManager(600, nil, nil)
+			sm.screenLocked = func() bool { return true } // Skip the captures themselves
+			sm.SetAfter(func(d time.Duration) <-chan time.Time {
+				calls++ // Only the scheduler goroutine calls it
+				fire := make(chan time.Time, 1)
+				if calls < cap(waits) {
+					fire <- time.Now()
+				}
+				waits <- d
+				return fire
+			})
+			sm.stopChan = make(chan struct{})
+			sm.wg.Add(1)
+			go sm.scheduleRandomCapture()
+
+			got := []time.Duration{<-waits, <-waits, <-waits}
+			close(sm.stopChan)
+			sm.wg.Wait()
+
+			interval := sm.randomInterval()
+			want := []time.Duration{interval, interval, interval}
+			if tt.grace > 0 {
+				want[0] = time.Duration(tt.grace) * time.Second
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("waits = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSchedulerSurvivesCapturePanic(t *testing.T) {
+	updateSettings(t, func(s *config.Settings) {
+		s.ScreenshotCaptureMode = config.CaptureModeInterval
+		s.ScreenshotGraceSeconds = 0
+	})
+	sm := NewScreenshotManager(60, nil, nil)
+	fire := make(chan time.Time)
+	sm.SetAfter(func(time.Duration) <-chan time.Time { return fire })
+	captures := make(chan int, 2)
+	calls := 0
+	sm.grab = func() (string, error) {
+		calls++
+		captures <- calls
+		if calls == 1 {
+			panic("capture failed")
+		}
+		return "shot.png", nil
+	}
+	sm.StartCapture()
+	defer sm.StopCapture()
+
+	for want := 1; want <= 2; want++ {
+		select {
+		case fire <- time.Now():
+		case <-time.After(2 * time.Second):
+			t.Fatalf("scheduler stopped waiting for capture %d", want)
+		}
+		select {
+		case got := <-captures:
+			if got != want {
+				t.Fatalf("capture %d ran, want %d", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("capture %d didn't run after the panic", want)
+		}
+	}
+}
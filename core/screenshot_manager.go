@@ -1,8 +1,10 @@
 package core
 
 import (
+	"errors"
 	"fmt"
-	"image/png"
+	"image"
+	"log"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -10,31 +12,60 @@ import (
 	"time"
 
 	"github.com/kbinani/screenshot"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/logging"
 )
 
+// MaxCaptureFailures is how many consecutive capture failures are tolerated
+// before captures are suspended for the rest of the session
+const MaxCaptureFailures = 3
+
+// MaxScreenshotJitterPercent bounds the configurable interval randomization
+const MaxScreenshotJitterPercent = 90
+
 type ScreenshotManager struct {
-	interval      time.Duration
-	isActive      bool
-	screenshotDir string
-	stopChan      chan struct{}
-	wg            sync.WaitGroup
-	mu            sync.Mutex
-	taskManager   *TaskManager // Added TaskManager reference
-}
+	interval    time.Duration
+	isActive    bool
+	stopChan    chan struct{}
+	wg          sync.WaitGroup
+	mu          sync.Mutex
+	taskManager *TaskManager // Added TaskManager reference
+	database    *Database
+	lastHash    *uint64         // Perceptual hash of the previous capture in this session
+	uploading   map[string]bool // Screenshots being uploaded, removed once the upload ends
+
+	captureFailures  int  // Consecutive screen capture failures
+	capturesDisabled bool // Set after MaxCaptureFailures; cleared by StartCapture
+	onCaptureFailure func(failures int, err error)
+
+	retentionStop chan struct{} // Closed to end the StartRetention loop
+
+	events *eventBus     // Set by the owning ActivityTracker, may be nil
+	input  *InputMonitor // Set by the owning ActivityTracker to rate activity between captures, may be nil
 
-func NewScreenshotManager(intervalSeconds int, taskManager *TaskManager) *ScreenshotManager {
-	// Seed the random number generator (important for randomInterval)
-	rand.Seed(time.Now().UnixNano())
+	screenLocked func() bool // Set by the owning ActivityTracker; scheduled captures are skipped while it reports true
 
-	homeDir, _ := os.UserHomeDir()
-	screenshotDir := filepath.Join(homeDir, ".time-tracker", "screenshots")
-	os.MkdirAll(screenshotDir, os.ModePerm)
+	strategy   captureStrategy // Chosen from the capture mode by StartCapture
+	quietCount int             // Consecutive captures rated below LowActivityPercent, for the adaptive interval
+	consent    uploadConsent   // Whether this session's screenshots may be uploaded
 
+	rng   *rand.Rand                           // Picks capture intervals; guarded by mu
+	after func(time.Duration) <-chan time.Time // Waits between captures; time.After unless replaced
+	grab  func() (string, error)               // Takes the scheduled captures; captureScreenshot if nil
+}
+
+func NewScreenshotManager(intervalSeconds int, taskManager *TaskManager, database *Database) *ScreenshotManager {
+	if taskManager != nil && database != nil {
+		taskManager.notes = database
+	}
 	return &ScreenshotManager{
-		interval:      time.Duration(intervalSeconds) * time.Second,
-		isActive:      false,
-		screenshotDir: screenshotDir,
-		taskManager:   taskManager,
+		interval:    time.Duration(intervalSeconds) * time.Second,
+		isActive:    false,
+		taskManager: taskManager,
+		database:    database,
+		uploading:   make(map[string]bool),
+		rng:         rand.New(rand.NewSource(time.Now().UnixNano())),
+		after:       time.After,
 		// stopChan is initialized in StartCapture
 	}
 }
@@ -47,9 +78,16 @@ func (sm *ScreenshotManager) StartCapture() {
 	}
 
 	sm.isActive = true
+	sm.captureFailures = 0
+	sm.capturesDisabled = false
+	sm.lastHash = nil // Don't compare against the previous session
+	sm.quietCount = 0
 	sm.stopChan = make(chan struct{}) // Initialize channel here
-	sm.wg.Add(1)
-	go sm.scheduleRandomCapture()
+	sm.strategy = strategyFor(config.Current().ScreenshotCaptureMode)
+	if sm.strategy.scheduled {
+		sm.wg.Add(1)
+		go sm.scheduleRandomCapture()
+	}
 	sm.mu.Unlock()
 }
 
@@ -72,72 +110,300 @@ func (sm *ScreenshotManager) StopCapture() {
 	sm.isActive = false // Mark as inactive
 	sm.mu.Unlock()      // Unlock BEFORE waiting to prevent deadlock
 
+	// An unanswered upload question lapses with the session
+	sm.consent.expire()
+
 	sm.wg.Wait() // Wait for the goroutine to finish
 }
 
+// CaptureNow captures, saves and uploads a screenshot immediately without
+// disturbing the random schedule. Only allowed while a work report is open
+func (sm *ScreenshotManager) CaptureNow() (string, error) {
+	sm.mu.Lock()
+	active := sm.isActive
+	sm.mu.Unlock()
+	if !active {
+		return "", errors.New("screenshots are not being captured")
+	}
+	if sm.taskManager == nil || sm.taskManager.GetWorkReport() == nil {
+		return "", ErrNoActiveWorkReport
+	}
+	// Deliberate captures are always uploaded, even if the screen hasn't changed
+	return sm.capture(false)
+}
+
 func (sm *ScreenshotManager) captureScreenshot() (string, error) {
-	bounds := screenshot.GetDisplayBounds(0)
+	if sm.screenLocked != nil && sm.screenLocked() {
+		return "", ErrScreenLocked
+	}
+	return sm.capture(config.Current().SkipDuplicateScreenshots)
+}
+
+// capture grabs, redacts, saves and uploads the primary display, or the
+// active window if so configured, skipping the upload of near-duplicates
+// when skipDuplicates is set
+func (sm *ScreenshotManager) capture(skipDuplicates bool) (string, error) {
+	bounds := captureBounds(config.Current().ScreenshotScope)
 	img, err := screenshot.CaptureRect(bounds)
 	if err != nil {
-		return "", fmt.Errorf("failed to capture screenshot: %w", err)
+		err = fmt.Errorf("failed to capture screenshot: %w", err)
+		sm.events.emit(TrackerEvent{Type: EventCaptureFailed, Err: err})
+		sm.recordCaptureFailure(err)
+		return "", err
 	}
+	sm.mu.Lock()
+	sm.captureFailures = 0
+	sm.mu.Unlock()
 
+	// Redact before anything touches disk so neither the thumbnail nor the
+	// upload ever sees the raw capture
+	settings := config.Current()
+	redacted := redactImage(img, settings.ScreenshotBlur)
+	duplicate := sm.isDuplicate(redacted, settings.DuplicateThreshold) && skipDuplicates
+	scale := newScreenshotScale(img.Bounds(), bounds)
+	if settings.ScreenshotResolution == config.ResolutionLogical {
+		redacted = downscale(redacted, max(bounds.Dx(), bounds.Dy()))
+	}
+	redacted = downscale(redacted, settings.ScreenshotMaxDimension)
+	if settings.Watermark {
+		// Stamped last so the saved file and the upload, read back from it, match
+		task := ""
+		if sm.taskManager != nil {
+			if active := sm.taskManager.GetActiveTask(); active != nil {
+				task = active.Name
+			}
+		}
+		redacted = watermarkImage(redacted, watermarkText(settings, time.Now(), task), settings.WatermarkPosition, settings.WatermarkOpacity)
+	}
+
+	screenshotDir, err := config.ScreenshotDir()
+	if err != nil {
+		return "", err
+	}
 	timestamp := time.Now().Format("20060102_150405")
-	filename := fmt.Sprintf("screenshot_%s.png", timestamp)
-	filepath := filepath.Join(sm.screenshotDir, filename)
+	filename := fmt.Sprintf("screenshot_%s%s", timestamp, screenshotExtension(settings.ScreenshotFormat))
+	filepath := filepath.Join(screenshotDir, filename)
 
 	file, err := os.Create(filepath)
+	if os.IsNotExist(err) {
+		// The folder was deleted after ScreenshotDir checked it; recreate it once
+		log.Printf("Screenshot directory %s disappeared, recreating it", screenshotDir)
+		if mkErr := os.MkdirAll(screenshotDir, os.ModePerm); mkErr == nil {
+			file, err = os.Create(filepath)
+		}
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to create screenshot file: %w", err)
 	}
 	defer file.Close()
 
-	err = png.Encode(file, img)
+	err = encodeScreenshot(file, redacted, settings.ScreenshotFormat, settings.ScreenshotQuality)
 	if err != nil {
 		return "", fmt.Errorf("failed to save screenshot: %w", err)
 	}
 
-	// Upload the screenshot if task manager is available
-	if sm.taskManager != nil {
-		success, err := sm.taskManager.UploadScreenshot(filepath)
+	if sm.database != nil {
+		workReportID := 0
+		if sm.taskManager != nil {
+			if report := sm.taskManager.GetWorkReport(); report != nil {
+				workReportID = report.ID
+			}
+		}
+		level := -1
+		if sm.input != nil {
+			level = activityLevel(sm.input.SnapshotInterval())
+		}
+		sm.recordActivityLevel(level)
+		if err := sm.database.SaveScreenshot(filepath, time.Now().UTC().Format(time.RFC3339), duplicate, scale, workReportID, level); err != nil {
+			log.Printf("Failed to record screenshot: %v", err)
+		}
+	}
+
+	sm.events.emit(TrackerEvent{Type: EventScreenshotCaptured, ScreenshotPath: filepath, Duplicate: duplicate})
+
+	// Upload the screenshot in the background if task manager is available,
+	// so a slow or throttled upload never delays the next capture
+	if duplicate {
+		log.Printf("Screenshot %s is nearly identical to the previous one, skipping upload", filename)
+	} else if sm.taskManager != nil {
+		sm.mu.Lock()
+		sm.uploading[filepath] = true
+		sm.mu.Unlock()
+		// Consent is awaited by the upload, never here, so stopping isn't held up
+		err := sm.taskManager.queueScreenshotUpload(filepath, sm.uploadAllowed, func(err error) {
+			sm.mu.Lock()
+			delete(sm.uploading, filepath)
+			sm.mu.Unlock()
+			if errors.Is(err, errUploadDeclined) {
+				log.Printf("Keeping screenshot %s local: uploads were declined for this session", filename)
+			} else if err != nil {
+				log.Printf("Failed to upload screenshot: %v", err)
+			}
+		})
 		if err != nil {
-			fmt.Printf("Failed to upload screenshot: %v\n", err)
-		} else if !success {
-			fmt.Printf("Screenshot upload was not successful\n")
+			sm.mu.Lock()
+			delete(sm.uploading, filepath)
+			sm.mu.Unlock()
+			// Without an open report (e.g. it is still being created) the
+			// screenshot is simply kept locally
+			if !errors.Is(err, ErrNoActiveWorkReport) {
+				log.Printf("Failed to upload screenshot: %v", err)
+			}
 		}
 	}
 
 	return filepath, nil
 }
 
+// isDuplicate reports whether img is within threshold bits of the previous
+// capture's perceptual hash, and remembers img's hash for the next comparison
+func (sm *ScreenshotManager) isDuplicate(img image.Image, threshold int) bool {
+	hash := differenceHash(img)
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	duplicate := sm.lastHash != nil && hammingDistance(*sm.lastHash, hash) <= threshold
+	sm.lastHash = &hash
+	return duplicate
+}
+
 func (sm *ScreenshotManager) scheduleRandomCapture() {
 	defer sm.wg.Done() // Ensure Done is called when goroutine exits
 
-	// Use NewTimer for better resource management in loops
-	timer := time.NewTimer(sm.randomInterval())
-	defer timer.Stop() // Ensure timer resources are cleaned up on exit
-
+	// The first capture waits out the grace period, if one is set, so the
+	// user can arrange their windows after starting
+	wait := sm.randomInterval()
+	if grace := config.Current().ScreenshotGraceSeconds; grace > 0 {
+		wait = time.Duration(grace) * time.Second
+	}
 	for {
 		select {
 		case <-sm.stopChan:
 			// Stop signal received, exit the loop
 			return
-		case <-timer.C:
+		case <-sm.after(wait):
 			// Timer fired, capture screenshot
 			// No need to check sm.isActive here, stopChan handles termination
-			_, err := sm.captureScreenshot()
-			if err != nil {
-				// Consider using a logger here instead of fmt.Printf
-				fmt.Printf("Error capturing screenshot: %s\n", err)
+			sm.captureSafely()
+			if sm.CapturesDisabled() {
+				log.Printf("Suspending screenshots after %d consecutive capture failures", MaxCaptureFailures)
+				return
 			}
-			// Reset the timer for the next random interval
-			timer.Reset(sm.randomInterval())
+			wait = sm.randomInterval()
 		}
 	}
 }
 
+// SetCaptureFailureCallback registers fn to be called, from the capturing
+// goroutine, after every failed screen capture with the consecutive failure count
+func (sm *ScreenshotManager) SetCaptureFailureCallback(fn func(failures int, err error)) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.onCaptureFailure = fn
+}
+
+// CapturesDisabled reports whether captures were suspended after repeated failures
+func (sm *ScreenshotManager) CapturesDisabled() bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.capturesDisabled
+}
+
+// recordCaptureFailure counts a failed capture, suspending captures once the
+// failures look permanent (e.g. a missing screen recording permission)
+func (sm *ScreenshotManager) recordCaptureFailure(err error) {
+	sm.mu.Lock()
+	sm.captureFailures++
+	failures := sm.captureFailures
+	if failures >= MaxCaptureFailures {
+		sm.capturesDisabled = true
+	}
+	callback := sm.onCaptureFailure
+	sm.mu.Unlock()
+
+	if callback != nil {
+		callback(failures, err)
+	}
+}
+
+// captureSafely captures a screenshot, recovering from any panic so a single
+// bad capture doesn't stop the scheduler
+func (sm *ScreenshotManager) captureSafely() {
+	defer logging.Recover("screenshot capture", nil)
+	grab := sm.grab
+	if grab == nil {
+		grab = sm.captureScreenshot
+	}
+	_, err := grab()
+	if errors.Is(err, ErrScreenLocked) {
+		log.Println("Skipping screenshot: the screen is locked")
+		return
+	}
+	if err != nil {
+		log.Printf("Error capturing screenshot: %s", err)
+	}
+}
+
+// captureAtStart takes the session's opening screenshot when the capture
+// strategy asks for one
+func (sm *ScreenshotManager) captureAtStart() {
+	sm.mu.Lock()
+	capture := sm.isActive && sm.strategy.onStart
+	sm.mu.Unlock()
+	if capture {
+		sm.captureSafely()
+	}
+}
+
+// capturesOnStop reports whether the capture strategy takes a screenshot when
+// the session stops
+func (sm *ScreenshotManager) capturesOnStop() bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.strategy.onStop
+}
+
+// randomInterval returns the delay before the next capture: the base interval,
+// or the server's, lengthened while activity is low if the adaptive interval
+// is on and varied by up to the configured jitter percentage either way
 func (sm *ScreenshotManager) randomInterval() time.Duration {
-	min := float64(sm.interval) * 0.8
-	max := float64(sm.interval) * 1.2
-	return time.Duration(min + rand.Float64()*(max-min))
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	settings := config.Current()
+	interval, mandated := screenshotInterval(sm.interval)
+	if settings.AdaptiveScreenshotInterval && !mandated {
+		interval = adaptiveInterval(interval, sm.quietCount,
+			time.Duration(settings.LowActivityMinIntervalMinutes)*time.Minute,
+			time.Duration(settings.LowActivityMaxIntervalMinutes)*time.Minute)
+	}
+	return jitteredInterval(interval, settings.ScreenshotJitterPercent, sm.rng.Float64)
+}
+
+// SetRandSource replaces the source used to pick capture intervals, e.g. with
+// a fixed seed for reproducible timing. Call it before StartCapture.
+func (sm *ScreenshotManager) SetRandSource(rng *rand.Rand) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.rng = rng
+}
+
+// SetAfter replaces the function used to wait between captures (time.After by
+// default), so the scheduler can be driven without real delays. Call it before StartCapture.
+func (sm *ScreenshotManager) SetAfter(after func(time.Duration) <-chan time.Time) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.after = after
+}
+
+// jitteredInterval returns a random duration in [interval*(1-p), interval*(1+p)]
+// for p = jitterPercent/100, clamped to [0, MaxScreenshotJitterPercent].
+// randFloat returns values in [0, 1), like rand.Float64.
+func jitteredInterval(interval time.Duration, jitterPercent int, randFloat func() float64) time.Duration {
+	jitterPercent = max(0, min(jitterPercent, MaxScreenshotJitterPercent))
+	if jitterPercent == 0 {
+		return interval
+	}
+	spread := float64(interval) * float64(jitterPercent) / 100
+	offset := (randFloat()*2 - 1) * spread
+	return time.Duration(float64(interval) + offset)
 }
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"log"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/logging"
+)
+
+const retentionInterval = time.Hour
+
+// Cleanup deletes screenshots older than maxAge and then, oldest first, any
+// more needed to bring the folder under maxBytes. A zero maxAge or maxBytes
+// disables that rule. Like DeleteRange, it sees the files ListScreenshots
+// lists, aged by the timestamp in their names. Screenshots that are still
+// being uploaded are kept. It returns the number of files deleted and the
+// bytes freed.
+func (sm *ScreenshotManager) Cleanup(maxAge time.Duration, maxBytes int64) (int, int64, error) {
+	files, err := ListScreenshots()
+	if err != nil {
+		return 0, 0, err
+	}
+	slices.Reverse(files) // Oldest first
+	var totalBytes int64
+	for _, file := range files {
+		totalBytes += file.Size
+	}
+
+	sm.mu.Lock()
+	uploading := make(map[string]bool, len(sm.uploading))
+	for path := range sm.uploading {
+		uploading[path] = true
+	}
+	sm.mu.Unlock()
+
+	deleted := 0
+	var freed int64
+	cutoff := time.Now().Add(-maxAge)
+	for _, file := range files {
+		tooOld := maxAge > 0 && file.Time.Before(cutoff)
+		overCap := maxBytes > 0 && totalBytes > maxBytes
+		if !tooOld && !overCap {
+			// Files are oldest first, so nothing later can match either rule
+			break
+		}
+		if uploading[file.Path] {
+			continue
+		}
+		if err := os.Remove(file.Path); err != nil {
+			log.Printf("Failed to delete screenshot %s: %v", file.Path, err)
+			continue
+		}
+		sm.forgetNote(file.Path)
+		deleted++
+		freed += file.Size
+		totalBytes -= file.Size
+	}
+	return deleted, freed, nil
+}
+
+// StartRetention applies the retention settings now and then hourly until
+// StopRetention is called
+func (sm *ScreenshotManager) StartRetention() {
+	sm.mu.Lock()
+	if sm.retentionStop != nil {
+		sm.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	sm.retentionStop = stop
+	sm.mu.Unlock()
+
+	go func() {
+		defer logging.Recover("screenshot retention", nil)
+		for {
+			settings := config.Current()
+			maxAge := time.Duration(settings.ScreenshotRetentionDays) * 24 * time.Hour
+			maxBytes := int64(settings.ScreenshotMaxSizeMB) * 1024 * 1024
+			if maxAge > 0 || maxBytes > 0 {
+				deleted, freed, err := sm.Cleanup(maxAge, maxBytes)
+				if err != nil {
+					log.Printf("Screenshot cleanup failed: %v", err)
+				} else if deleted > 0 {
+					log.Printf("Screenshot cleanup removed %d files (%d bytes)", deleted, freed)
+				}
+			}
+			select {
+			case <-stop:
+				return
+			case <-time.After(retentionInterval):
+			}
+		}
+	}()
+}
+
+// StopRetention stops the cleanup started by StartRetention
+func (sm *ScreenshotManager) StopRetention() {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.retentionStop != nil {
+		close(sm.retentionStop)
+		sm.retentionStop = nil
+	}
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+// writeScreenshot creates a screenshot file in dir named for when it was
+// captured, with the given size and modification time
+func writeScreenshot(t *testing.T, dir string, captured time.Time, ext string, size int, modTime time.Time) string {
+	t.Helper()
+	path := filepath.Join(dir, screenshotPrefix+captured.Format(screenshotTimeLayout)+ext)
+	if err := os.WriteFile(path, make([]byte, size), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCleanup(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name        string
+		maxAge      time.Duration
+		maxBytes    int64
+		wantDeleted []string
+	}{
+		{"by age from the file name", 7 * day, 0, []string{"old", "old copied"}},
+		{"by size, oldest first", 0, 150, []string{"old", "old copied"}},
+		{"no rules", 0, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			updateSettings(t, func(s *config.Settings) { s.ScreenshotDir = dir })
+			files := map[string]string{
+				"old":          writeScreenshot(t, dir, now.Add(-10*day), ".png", 100, now.Add(-10*day)),
+				"old copied":   writeScreenshot(t, dir, now.Add(-9*day), ".png", 100, now), // Copied back today
+				"recent":       writeScreenshot(t, dir, now.Add(-time.Hour), ".png", 100, now.Add(-20*day)),
+				"not an image": writeScreenshot(t, dir, now.Add(-30*day), ".txt", 100, now.Add(-30*day)),
+			}
+
+			sm := NewScreenshotManager(60, nil, nil)
+			deleted, freed, err := sm.Cleanup(tt.maxAge, tt.maxBytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if deleted != len(tt.wantDeleted) || freed != int64(100*len(tt.wantDeleted)) {
+				t.Errorf("Cleanup() = %d files, %d bytes, want %d files", deleted, freed, len(tt.wantDeleted))
+			}
+			for name, path := range files {
+				_, err := os.Stat(path)
+				if gone := os.IsNotExist(err); gone != slices.Contains(tt.wantDeleted, name) {
+					t.Errorf("%s deleted = %v, want %v", name, gone, !gone)
+				}
+			}
+		})
+	}
+}
+
+func TestCleanupKeepsUploading(t *testing.T) {
+	dir := t.TempDir()
+	updateSettings(t, func(s *config.Settings) { s.ScreenshotDir = dir })
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	path := writeScreenshot(t, dir, old, ".png", 100, old)
+
+	sm := NewScreenshotManager(60, nil, nil)
+	sm.uploading[path] = true
+	if deleted, _, err := sm.Cleanup(24*time.Hour, 0); err != nil || deleted != 0 {
+		t.Fatalf("Cleanup() = %d, %v, want 0 deleted", deleted, err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("uploading screenshot was deleted: %v", err)
+	}
+}
+
+func TestRetentionStartStop(t *testing.T) {
+	dir := t.TempDir()
+	updateSettings(t, func(s *config.Settings) {
+		s.ScreenshotDir = dir
+		s.ScreenshotRetentionDays = 1
+	})
+	old := time.Now().Add(-3 * 24 * time.Hour)
+	path := writeScreenshot(t, dir, old, ".png", 100, old)
+
+	sm := NewScreenshotManager(60, nil, nil)
+	sm.StartRetention()
+	sm.StartRetention() // Already running
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("retention didn't remove the expired screenshot")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	sm.StopRetention()
+	sm.StopRetention() // Already stopped
+}
@@ -0,0 +1,40 @@
+package core
+
+// #cgo pkg-config: libwebp
+// #include <stdlib.h>
+// #include <webp/encode.h>
+import "C"
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+	"unsafe"
+)
+
+// encodeWebP writes img to w as a lossy WebP of the given quality (1-100),
+// using libwebp as Go has no WebP encoder of its own
+func encodeWebP(w io.Writer, img image.Image, quality int) error {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return errors.New("cannot encode an empty screenshot as WebP")
+	}
+	// libwebp takes non-premultiplied RGBA
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok || nrgba.Rect.Min != (image.Point{}) {
+		nrgba = image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+	}
+
+	var output *C.uint8_t
+	size := C.WebPEncodeRGBA((*C.uint8_t)(unsafe.Pointer(&nrgba.Pix[0])),
+		C.int(bounds.Dx()), C.int(bounds.Dy()), C.int(nrgba.Stride), C.float(quality), &output)
+	if size == 0 {
+		// Also the case for images over WebP's 16383 pixel limit
+		return errors.New("libwebp failed to encode the screenshot")
+	}
+	defer C.WebPFree(unsafe.Pointer(output))
+	_, err := w.Write(unsafe.Slice((*byte)(unsafe.Pointer(output)), int(size)))
+	return err
+}
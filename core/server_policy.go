@@ -0,0 +1,39 @@
+package core
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/services"
+)
+
+// RefreshServerPolicy fetches the server's policy and applies it over the
+// local settings. If the server has no config endpoint the local settings
+// apply unchanged; if it can't be reached the cached policy stays in force.
+func (tm *TaskManager) RefreshServerPolicy() error {
+	policy, err := tm.taskService.GetServerConfig()
+	if errors.Is(err, services.ErrServerConfigUnsupported) {
+		if config.CurrentServerPolicy() != nil {
+			log.Println("Server no longer provides a config; using local settings")
+		}
+		return config.SetServerPolicy(nil)
+	}
+	if err != nil {
+		return err
+	}
+	if policy.Mandates() {
+		log.Println("Applying settings mandated by the server")
+	}
+	return config.SetServerPolicy(policy)
+}
+
+// screenshotInterval returns the server-mandated interval between scheduled
+// screenshots and true, or fallback and false if the server doesn't set one
+func screenshotInterval(fallback time.Duration) (time.Duration, bool) {
+	if policy := config.CurrentServerPolicy(); policy != nil && policy.ScreenshotIntervalSeconds != nil {
+		return time.Duration(*policy.ScreenshotIntervalSeconds) * time.Second, true
+	}
+	return fallback, false
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// StatusConflict is how the server's record of what the user is tracking
+// differs from this app's
+type StatusConflict int
+
+const (
+	// StatusInSync means the server and this app agree
+	StatusInSync StatusConflict = iota
+	// StatusOpenElsewhere means the server has a work report open that this
+	// app isn't tracking, e.g. one started on another device or left by a crash
+	StatusOpenElsewhere
+	// StatusClosedElsewhere means this app is tracking into work reports the
+	// server no longer has open, e.g. after stopping on another device
+	StatusClosedElsewhere
+)
+
+// ServerStatus is the server's current status reconciled with local tracking
+type ServerStatus struct {
+	Report   *types.WorkReport // Open on the server, nil if none
+	Conflict StatusConflict
+}
+
+// CheckServerStatus asks the server what the user is tracking and compares it
+// with the work reports open here
+func (tm *TaskManager) CheckServerStatus() (ServerStatus, error) {
+	status, err := tm.taskService.GetCurrentStatus()
+	if err != nil {
+		return ServerStatus{}, err
+	}
+	return reconcileStatus(status.Report, tm.openReportIDs()), nil
+}
+
+// reconcileStatus compares the server's open report with the IDs of the
+// reports open locally. The server reports a single open report, so any one
+// of several concurrent local reports matching it counts as in sync.
+func reconcileStatus(open *types.WorkReport, local map[int]bool) ServerStatus {
+	status := ServerStatus{Report: open}
+	switch {
+	case open != nil && !local[open.ID]:
+		status.Conflict = StatusOpenElsewhere
+	case open == nil && len(local) > 0:
+		status.Conflict = StatusClosedElsewhere
+	}
+	return status
+}
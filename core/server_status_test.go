@@ -0,0 +1,79 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/internal/types"
+)
+
+func TestReconcileStatus(t *testing.T) {
+	open := &types.WorkReport{ID: 5}
+	tests := []struct {
+		name  string
+		open  *types.WorkReport
+		local map[int]bool
+		want  StatusConflict
+	}{
+		{"nothing tracked", nil, map[int]bool{}, StatusInSync},
+		{"same report", open, map[int]bool{5: true}, StatusInSync},
+		{"one of several concurrent reports", open, map[int]bool{4: true, 5: true}, StatusInSync},
+		{"open elsewhere, idle here", open, map[int]bool{}, StatusOpenElsewhere},
+		{"open elsewhere, tracking here", open, map[int]bool{4: true}, StatusOpenElsewhere},
+		{"closed elsewhere", nil, map[int]bool{4: true}, StatusClosedElsewhere},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reconcileStatus(tt.open, tt.local)
+			if got.Conflict != tt.want || got.Report != tt.open {
+				t.Errorf("reconcileStatus() = %+v, want conflict %v with the server's report", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckServerStatus(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, tm *TaskManager, api *fakeTaskAPI)
+		want  StatusConflict
+	}{
+		{"idle everywhere", func(*testing.T, *TaskManager, *fakeTaskAPI) {}, StatusInSync},
+		{"tracking here", func(t *testing.T, tm *TaskManager, api *fakeTaskAPI) {
+			if _, err := tm.UserStartTask(demoTask.Project.ID, demoTask, "start"); err != nil {
+				t.Fatal(err)
+			}
+		}, StatusInSync},
+		{"started on another device", func(t *testing.T, tm *TaskManager, api *fakeTaskAPI) {
+			if _, err := api.StartUserTask(demoTask.Project.ID, demoTask.ID, "elsewhere", start); err != nil {
+				t.Fatal(err)
+			}
+		}, StatusOpenElsewhere},
+		{"stopped on another device", func(t *testing.T, tm *TaskManager, api *fakeTaskAPI) {
+			if _, err := tm.UserStartTask(demoTask.Project.ID, demoTask, "start"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := api.StopUserTask(tm.GetWorkReport().ID, start, nil); err != nil {
+				t.Fatal(err)
+			}
+		}, StatusClosedElsewhere},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newFakeTaskAPI()
+			tm := newTestTaskManager(t, api)
+			tt.setup(t, tm, api)
+
+			status, err := tm.CheckServerStatus()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status.Conflict != tt.want {
+				t.Errorf("CheckServerStatus() = %+v, want conflict %v", status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// ErrSwitchStopped is returned by Switch when neither the new task nor the
+// previous one could be tracked. Tracking has then stopped as if by Stop, and
+// the previous work report is left for CloseWorkReport.
+var ErrSwitchStopped = errors.New("tracking stopped")
+
+// Session coordinates a tracking session across the ActivityTracker (local
+// activity, screenshots and input) and the TaskManager (server work report),
+// so that any front end can drive tracking without duplicating the steps.
+type Session struct {
+	TaskManager     *TaskManager
+	ActivityTracker *ActivityTracker
+
+	mu             sync.Mutex
+	task           *types.Task
+	startTime      time.Time
+	checkpointStop chan struct{} // Ends the checkpoint refresh loop
+	idleToDeduct   time.Duration // Idle time of the last stopped session, for CloseWorkReport
+	stoppedAt      time.Time     // When the last session stopped, for CloseWorkReport
+	pausedToDeduct time.Duration // Paused time of the last stopped session, for CloseWorkReport
+	stoppedLocal   bool          // The last stopped session was for a local task, so it has no report to close
+
+	concurrent        []ConcurrentTask // Tracked alongside task
+	alloc             timeAllocator    // Splits time between task and the concurrent tasks
+	unallocated       time.Duration    // Time of the last stopped session allocated to other tasks, for CloseWorkReport
+	pendingConcurrent []concurrentStop // Concurrent tasks stopped with the session, for CloseWorkReport
+
+	syncMu   sync.Mutex // Serializes Sync
+	reportMu sync.Mutex // Serializes CloseWorkReport with work report handovers, so a stop waits for one in progress
+}
+
+// NewSession creates a session controller for the given managers
+func NewSession(taskManager *TaskManager, activityTracker *ActivityTracker) *Session {
+	return &Session{
+		TaskManager:     taskManager,
+		ActivityTracker: activityTracker,
+	}
+}
+
+// Start begins local tracking for task. The server work report is opened
+// separately with OpenWorkReport so callers can choose to do it asynchronously.
+func (s *Session) Start(task types.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.task != nil {
+		return errors.New("already tracking " + s.task.Name)
+	}
+	if err := s.ActivityTracker.StartTracking(task.Name); err != nil {
+		return err
+	}
+	s.TaskManager.SetActiveTask(task)
+	s.task = &task
+	s.startTime = wallClock()
+	s.alloc.reset(task.ID, s.startTime)
+	s.startCheckpoints()
+	return nil
+}
+
+// OpenWorkReport creates the server-side work report for the active task
+func (s *Session) OpenWorkReport(description string) error {
+	s.mu.Lock()
+	task := s.task
+	s.mu.Unlock()
+
+	if task == nil {
+		return errors.New("no active session")
+	}
+	if task.Local {
+		// Local tasks are tracked without a server report
+		s.ActivityTracker.CaptureSessionStart()
+		return nil
+	}
+	_, err := s.TaskManager.UserStartTask(task.Project.ID, *task, description)
+	if err == nil {
+		// Record the report ID so a crash doesn't leave it open on the server
+		s.mu.Lock()
+		s.writeCheckpoint()
+		s.mu.Unlock()
+		s.ActivityTracker.CaptureSessionStart()
+	}
+	return err
+}
+
+// AdoptWorkReport resumes into report, already open on the server, instead of
+// opening a new one with OpenWorkReport
+func (s *Session) AdoptWorkReport(report types.WorkReport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.task == nil {
+		return errors.New("no active session")
+	}
+	s.TaskManager.AdoptWorkReport(report, *s.task)
+	s.writeCheckpoint()
+	return nil
+}
+
+// Stop ends local tracking and saves the session to the database
+func (s *Session) Stop() error {
+	return s.StopAt(wallClock())
+}
+
+// StopAt is Stop for a session that ended at end rather than now, such as one
+// stopped for running past the maximum session length. The time after end
+// isn't counted, locally or in the work report.
+func (s *Session) StopAt(end time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.task == nil {
+		return errors.New("not tracking")
+	}
+	task := *s.task
+	s.task = nil
+	s.stopCheckpoints()
+	if end.Before(s.startTime) {
+		end = s.startTime
+	}
+	s.stoppedAt = end
+	s.allocateMain(task.ID, end)
+	s.stopConcurrent(end)
+	if err := s.ActivityTracker.StopTrackingAt(end); err != nil {
+		return err
+	}
+	s.recordIdle()
+	s.stoppedLocal = task.Local
+	s.checkpointStopped(task, s.startTime)
+	return nil
+}
+
+// recordIdle remembers the stopped session's idle and paused time for
+// CloseWorkReport. Callers must hold s.mu.
+func (s *Session) recordIdle() {
+	s.idleToDeduct = 0
+	s.pausedToDeduct = 0
+	if summary := s.ActivityTracker.LastSummary(); summary != nil {
+		s.idleToDeduct = time.Duration(summary.Record.IdleSeconds) * time.Second
+		s.pausedToDeduct = summary.Paused
+	}
+}
+
+// CloseWorkReport closes the server-side work report, and those of any
+// concurrent tasks stopped with the session. The report ends when the session
+// stopped, however long the description took to write. Time spent paused,
+// and idle time unless it counts as worked, is taken off the end time so the
+// server records only the time worked; time allocated to concurrent tasks is
+// taken off too.
+func (s *Session) CloseWorkReport(description string) error {
+	s.reportMu.Lock()
+	defer s.reportMu.Unlock()
+	return s.closeWorkReport(description)
+}
+
+// closeWorkReport is CloseWorkReport for callers holding s.reportMu
+func (s *Session) closeWorkReport(description string) error {
+	s.mu.Lock()
+	idle := s.idleToDeduct
+	s.idleToDeduct = 0
+	paused := s.pausedToDeduct
+	s.pausedToDeduct = 0
+	unallocated := s.unallocated
+	s.unallocated = 0
+	local := s.stoppedLocal
+	end := s.stoppedAt
+	s.stoppedAt = time.Time{}
+	s.mu.Unlock()
+	if end.IsZero() {
+		end = time.Now()
+	}
+	end = end.Add(-unallocated - paused)
+	if !config.Current().CountIdleAsWorked {
+		end = end.Add(-idle)
+	}
+	concurrentErr := s.closePendingConcurrent(description)
+
+	report := s.TaskManager.GetWorkReport()
+	if report == nil && local {
+		return concurrentErr
+	}
+	_, err := s.TaskManager.UserStopTaskAt(description, end)
+	if err == nil && report != nil {
+		s.reportClosed(report.ID)
+		s.markSynced(report.ID)
+		if err := s.ActivityTracker.Database.SetWorkReportDescription(report.ID, description); err != nil {
+			log.Printf("Failed to save work report description: %v", err)
+		}
+	}
+	return errors.Join(err, concurrentErr)
+}
+
+// UpdateDescription changes the description of a saved activity and, if it
+// has a work report, of that report on the server. The local description is
+// kept even when the server rejects the change.
+func (s *Session) UpdateDescription(record ActivityRecord, description string) error {
+	if err := s.ActivityTracker.Database.UpdateActivityDescription(record.ID, description); err != nil {
+		return err
+	}
+	if record.WorkReportID == 0 {
+		return nil
+	}
+	return s.TaskManager.UpdateWorkReportDescription(int(record.WorkReportID), description)
+}
+
+// Switch ends the current local session and immediately starts one for task
+// so no time is lost between them. The server side is switched separately
+// with SwitchWorkReport. If task can't be tracked, tracking of the previous
+// task resumes, or stops with ErrSwitchStopped if that fails too.
+func (s *Session) Switch(task types.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.task == nil {
+		return errors.New("not tracking")
+	}
+	if s.concurrentIndex(task.ID) >= 0 {
+		return fmt.Errorf("%s is already being tracked alongside", task.Name)
+	}
+	now := wallClock()
+	s.stoppedAt = now
+	s.allocateMain(s.task.ID, now)
+	if err := s.ActivityTracker.StopTracking(); err != nil {
+		return err
+	}
+	s.recordIdle()
+	s.stoppedLocal = s.task.Local
+	previous := *s.task
+	s.task = nil
+	if err := s.ActivityTracker.StartTracking(task.Name); err != nil {
+		return s.restore(previous, now, fmt.Errorf("failed to start %s: %w", task.Name, err))
+	}
+	s.task = &task
+	s.startTime = wallClock()
+	s.alloc.add(task.ID, s.startTime)
+	s.writeCheckpoint()
+	return nil
+}
+
+// restore resumes tracking previous after Switch failed to start the new
+// task with switchErr. If previous can't be tracked either, the session ends
+// as Stop would have ended it at now. Callers must hold s.mu.
+func (s *Session) restore(previous types.Task, now time.Time, switchErr error) error {
+	if err := s.ActivityTracker.StartTracking(previous.Name); err != nil {
+		log.Printf("Failed to resume %s after a failed switch: %v", previous.Name, err)
+		s.stopCheckpoints()
+		s.stopConcurrent(now)
+		s.checkpointStopped(previous, s.startTime)
+		return fmt.Errorf("%w: %w", ErrSwitchStopped, switchErr)
+	}
+	// The previous report stays open, so there is nothing for CloseWorkReport
+	s.stoppedAt = time.Time{}
+	s.task = &previous
+	s.startTime = wallClock()
+	s.alloc.add(previous.ID, s.startTime)
+	s.writeCheckpoint()
+	return fmt.Errorf("%w, still tracking %s", switchErr, previous.Name)
+}
+
+// SwitchWorkReport closes the open work report and opens one for the task
+// passed to Switch. If the old report can't be closed no new one is opened,
+// so the server never has both open: the old report is let go, for Sync to
+// close from the activity saved with its ID, and the new task's time is synced
+// once it stops.
+func (s *Session) SwitchWorkReport(stopDescription, startDescription string) error {
+	s.reportMu.Lock()
+	defer s.reportMu.Unlock()
+
+	s.mu.Lock()
+	task := s.task
+	s.mu.Unlock()
+	if task == nil {
+		return errors.New("no active session")
+	}
+
+	previous := s.TaskManager.GetWorkReport()
+	closeErr := s.closeWorkReport(stopDescription)
+	s.TaskManager.SetActiveTask(*task)
+	if previous != nil && s.TaskManager.dropWorkReport(previous.ID) {
+		return fmt.Errorf("failed to close the previous work report, so none was opened for %s; syncing will complete both: %w", task.Name, closeErr)
+	}
+
+	s.mu.Lock()
+	current := s.task
+	s.mu.Unlock()
+	if current != task {
+		return errors.Join(closeErr, fmt.Errorf("tracking %s ended before its work report was opened", task.Name))
+	}
+	openErr := s.OpenWorkReport(startDescription)
+	return errors.Join(closeErr, openErr)
+}
+
+// Task returns the task being tracked, or nil when idle
+func (s *Session) Task() *types.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.task == nil {
+		return nil
+	}
+	task := *s.task
+	return &task
+}
+
+// StartedAt returns when the current session started, or the zero time when idle
+func (s *Session) StartedAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.task == nil {
+		return time.Time{}
+	}
+	return s.startTime
+}
+
+// Elapsed returns how long the current session has been running
+func (s *Session) Elapsed() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.task == nil {
+		return 0
+	}
+	return wallClock().Sub(s.startTime)
+}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/internal/types"
+)
+
+func TestCloseWorkReportEndsWhenTrackingStopped(t *testing.T) {
+	api := newFakeTaskAPI()
+	s := newTestSession(t, api)
+	if err := s.Start(demoTask); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.OpenWorkReport("start"); err != nil {
+		t.Fatal(err)
+	}
+	report := s.TaskManager.GetWorkReport()
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	stopped := time.Now()
+
+	time.Sleep(1500 * time.Millisecond) // The user writes a description
+	if err := s.CloseWorkReport("done"); err != nil {
+		t.Fatal(err)
+	}
+
+	end, ok := api.stoppedAt(report.ID)
+	if !ok {
+		t.Fatal("work report wasn't closed")
+	}
+	if end.After(stopped) {
+		t.Errorf("report ended at %s, after tracking stopped at %s", end.Format(time.TimeOnly), stopped.Format(time.TimeOnly))
+	}
+}
+
+func TestPausedTimeIsNotWorked(t *testing.T) {
+	api := newFakeTaskAPI()
+	s := newTestSession(t, api)
+	if err := s.Start(demoTask); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.OpenWorkReport("start"); err != nil {
+		t.Fatal(err)
+	}
+	report := s.TaskManager.GetWorkReport()
+
+	s.ActivityTracker.Pause() // A Pomodoro break
+	time.Sleep(2100 * time.Millisecond)
+	s.ActivityTracker.Resume()
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CloseWorkReport("done"); err != nil {
+		t.Fatal(err)
+	}
+
+	summary := s.ActivityTracker.LastSummary()
+	if summary == nil {
+		t.Fatal("no session summary")
+	}
+	if summary.Paused < 2*time.Second {
+		t.Errorf("paused %s, want at least 2s", summary.Paused)
+	}
+	if summary.Record.DurationSeconds != 0 {
+		t.Errorf("recorded %ds worked, want 0", summary.Record.DurationSeconds)
+	}
+	end, ok := api.stoppedAt(report.ID)
+	if !ok {
+		t.Fatal("work report wasn't closed")
+	}
+	if worked := end.Sub(*report.StartTime); worked > time.Second { // Times are sent to the second
+		t.Errorf("work report spans %s, want the break left out", worked)
+	}
+}
+
+func TestStopAtEarlierEnd(t *testing.T) {
+	api := newFakeTaskAPI()
+	s := newTestSession(t, api)
+	if err := s.Start(demoTask); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.OpenWorkReport("start"); err != nil {
+		t.Fatal(err)
+	}
+	report := s.TaskManager.GetWorkReport()
+	end := s.StartedAt()
+
+	time.Sleep(2100 * time.Millisecond) // Tracking ran on past the maximum
+	if err := s.StopAt(end); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CloseWorkReport("done"); err != nil {
+		t.Fatal(err)
+	}
+
+	summary := s.ActivityTracker.LastSummary()
+	if summary == nil {
+		t.Fatal("no session summary")
+	}
+	if !summary.Record.EndTime.Equal(end) {
+		t.Errorf("activity ended at %s, want %s", summary.Record.EndTime.Format(time.TimeOnly), end.Format(time.TimeOnly))
+	}
+	if summary.Record.DurationSeconds != 0 {
+		t.Errorf("recorded %ds worked, want 0", summary.Record.DurationSeconds)
+	}
+	reported, ok := api.stoppedAt(report.ID)
+	if !ok {
+		t.Fatal("work report wasn't closed")
+	}
+	if worked := reported.Sub(*report.StartTime); worked > time.Second { // Times are sent to the second
+		t.Errorf("work report spans %s, want it to end at %s", worked, end.Format(time.TimeOnly))
+	}
+}
+
+func TestSwitchWorkReportCloseFails(t *testing.T) {
+	review := types.Task{ID: 102, Name: "Accessibility review", Project: demoTask.Project}
+	api := newFakeTaskAPI()
+	s := newTestSession(t, api)
+	if _, err := s.TaskManager.GetTasks(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(demoTask); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.OpenWorkReport("start"); err != nil {
+		t.Fatal(err)
+	}
+	old := s.TaskManager.GetWorkReport()
+	if err := s.Switch(review); err != nil {
+		t.Fatal(err)
+	}
+
+	api.stopErr = errors.New("offline")
+	if err := s.SwitchWorkReport("done", "next"); err == nil {
+		t.Fatal("SwitchWorkReport() succeeded without closing the old report")
+	}
+	if report := s.TaskManager.GetWorkReport(); report != nil {
+		t.Fatalf("report %d open, want none opened while the old one couldn't be closed", report.ID)
+	}
+
+	// Back online, syncing closes the old report from the saved activity
+	api.stopErr = nil
+	if _, err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := api.stoppedAt(old.ID); !ok {
+		t.Error("old report wasn't closed by Sync")
+	}
+}
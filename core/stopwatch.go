@@ -0,0 +1,77 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// wallClock returns the current time without its monotonic reading, so
+// durations between two readings include time the system spent asleep
+func wallClock() time.Time {
+	return time.Now().Round(0)
+}
+
+// Stopwatch measures elapsed wall-clock time across pauses. Elapsed time is
+// derived from timestamps rather than counted ticks, so it stays accurate
+// when ticks are missed or the system sleeps.
+type Stopwatch struct {
+	mu      sync.Mutex
+	now     func() time.Time // Replaceable clock
+	start   time.Time        // Start of the current running segment
+	elapsed time.Duration    // Time accumulated before the current segment
+	running bool
+}
+
+// NewStopwatch returns a stopped stopwatch reading zero
+func NewStopwatch() *Stopwatch {
+	return &Stopwatch{now: wallClock}
+}
+
+// Start resets the stopwatch to zero and starts it
+func (sw *Stopwatch) Start() {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.elapsed = 0
+	sw.start = sw.now()
+	sw.running = true
+}
+
+// Pause stops the stopwatch, keeping the elapsed time
+func (sw *Stopwatch) Pause() {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if !sw.running {
+		return
+	}
+	sw.elapsed += sw.now().Sub(sw.start)
+	sw.running = false
+}
+
+// Resume continues after Pause
+func (sw *Stopwatch) Resume() {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if sw.running {
+		return
+	}
+	sw.start = sw.now()
+	sw.running = true
+}
+
+// Reset stops the stopwatch and sets it back to zero
+func (sw *Stopwatch) Reset() {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.elapsed = 0
+	sw.running = false
+}
+
+// Elapsed returns the total running time
+func (sw *Stopwatch) Elapsed() time.Duration {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if !sw.running {
+		return sw.elapsed
+	}
+	return sw.elapsed + sw.now().Sub(sw.start)
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStopwatch(t *testing.T) {
+	type step struct {
+		advance time.Duration
+		action  func(sw *Stopwatch)
+	}
+	start := (*Stopwatch).Start
+	pause := (*Stopwatch).Pause
+	resume := (*Stopwatch).Resume
+	reset := (*Stopwatch).Reset
+
+	tests := []struct {
+		name  string
+		steps []step
+		want  time.Duration
+	}{
+		{"running", []step{{0, start}, {90 * time.Second, nil}}, 90 * time.Second},
+		{"paused time not counted", []step{{0, start}, {time.Minute, pause}, {time.Hour, resume}, {time.Minute, nil}}, 2 * time.Minute},
+		{"double pause", []step{{0, start}, {time.Minute, pause}, {time.Minute, pause}}, time.Minute},
+		{"resume while running", []step{{0, start}, {time.Minute, resume}, {time.Minute, nil}}, 2 * time.Minute},
+		{"missed ticks and sleep", []step{{0, start}, {3 * time.Hour, nil}}, 3 * time.Hour},
+		{"reset", []step{{0, start}, {time.Minute, reset}, {time.Minute, nil}}, 0},
+		{"restart", []step{{0, start}, {time.Minute, pause}, {0, start}, {time.Second, nil}}, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+			sw := NewStopwatch()
+			sw.now = func() time.Time { return now }
+			for _, s := range tt.steps {
+				now = now.Add(s.advance)
+				if s.action != nil {
+					s.action(sw)
+				}
+			}
+			if got := sw.Elapsed(); got != tt.want {
+				t.Errorf("Elapsed() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWallClockHasNoMonotonicReading(t *testing.T) {
+	now := wallClock()
+	if now != now.Round(0) {
+		t.Error("wallClock() kept its monotonic reading")
+	}
+}
@@ -0,0 +1,303 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// How a saved activity stands against the server. Local activities and
+// server work reports are written separately, so they can drift apart, e.g.
+// when the server is unreachable as a session stops.
+const (
+	SyncSynced   = "synced"   // Its work report is closed on the server
+	SyncPending  = "pending"  // Its work report hasn't been confirmed closed
+	SyncUnlinked = "unlinked" // A server task's activity that never got a work report
+	SyncLocal    = "local"    // A local task's activity, never sent to the server
+	SyncImported = "imported" // Imported from the previous version's history, never sent to the server
+)
+
+// activeServer identifies the server of the active profile, whose work
+// reports the activities saved now belong to. Profiles share one database, and
+// a work report ID means nothing to another profile's server.
+func activeServer() string {
+	return config.ActiveProfile().BaseURL
+}
+
+// initialSyncState is the sync state of an activity as it is saved
+func initialSyncState(workReportID int, local bool) string {
+	switch {
+	case local:
+		return SyncLocal
+	case workReportID == 0:
+		return SyncUnlinked
+	}
+	return SyncPending
+}
+
+// UnsyncedActivity is a saved activity whose work report is pending or missing
+type UnsyncedActivity struct {
+	ID           int64
+	Task         string
+	StartTime    time.Time
+	EndTime      time.Time
+	WorkReportID int
+	Description  string
+	SyncState    string
+	Duration     time.Duration // Tracked time, without pauses or time allocated to other tasks; -1 if unknown
+	Idle         time.Duration // Part of Duration without input
+}
+
+// reportEnd returns when a's work report should end: the start plus the time
+// worked, as CloseWorkReport reckons it, rather than the wall-clock end
+func (a UnsyncedActivity) reportEnd() time.Time {
+	if a.Duration < 0 {
+		return a.EndTime
+	}
+	worked := a.Duration
+	if !config.Current().CountIdleAsWorked {
+		worked -= a.Idle
+	}
+	end := a.StartTime.Add(max(worked, 0))
+	if end.After(a.EndTime) {
+		return a.EndTime
+	}
+	return end
+}
+
+// UnsyncedActivities returns the active server's activities still to be
+// synced, oldest first
+func (db *Database) UnsyncedActivities() ([]UnsyncedActivity, error) {
+	rows, err := db.conn.Query(`
+    SELECT id, task, start_time, COALESCE(end_time, ''), work_report_id, COALESCE(description, ''), sync_state,
+        COALESCE(duration, -1), COALESCE(idle_seconds, 0)
+    FROM activities WHERE sync_state IN (?, ?) AND server = ? ORDER BY start_time`, SyncPending, SyncUnlinked, activeServer())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve unsynced activities: %w", err)
+	}
+	defer rows.Close()
+
+	var activities []UnsyncedActivity
+	for rows.Next() {
+		var a UnsyncedActivity
+		var start, end string
+		var duration, idle int64
+		if err := rows.Scan(&a.ID, &a.Task, &start, &end, &a.WorkReportID, &a.Description, &a.SyncState, &duration, &idle); err != nil {
+			return nil, fmt.Errorf("failed to scan unsynced activity: %w", err)
+		}
+		a.StartTime, _ = time.Parse(time.RFC3339, start)
+		a.EndTime, _ = time.Parse(time.RFC3339, end)
+		a.Duration = time.Duration(duration) * time.Second
+		if duration < 0 {
+			a.Duration = -1
+		}
+		a.Idle = time.Duration(idle) * time.Second
+		activities = append(activities, a)
+	}
+	return activities, rows.Err()
+}
+
+// CountUnsynced returns how many of the active server's activities are still
+// to be synced
+func (db *Database) CountUnsynced() (int, error) {
+	var count int
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM activities WHERE sync_state IN (?, ?) AND server = ?",
+		SyncPending, SyncUnlinked, activeServer()).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unsynced activities: %w", err)
+	}
+	return count, nil
+}
+
+// SetWorkReportSynced marks the activities of a work report closed on the
+// active server as synced
+func (db *Database) SetWorkReportSynced(workReportID int) error {
+	_, err := db.conn.Exec("UPDATE activities SET sync_state = ? WHERE work_report_id = ? AND sync_state = ? AND server = ?",
+		SyncSynced, workReportID, SyncPending, activeServer())
+	if err != nil {
+		return fmt.Errorf("failed to mark work report %d synced: %w", workReportID, err)
+	}
+	return nil
+}
+
+// setActivitySync links an activity to a work report and sets its sync state
+func (db *Database) setActivitySync(id int64, workReportID int, state string) error {
+	_, err := db.conn.Exec("UPDATE activities SET work_report_id = ?, sync_state = ? WHERE id = ?", workReportID, state, id)
+	if err != nil {
+		return fmt.Errorf("failed to update sync state of activity %d: %w", id, err)
+	}
+	return nil
+}
+
+// markSynced records that workReportID was closed on the server
+func (s *Session) markSynced(workReportID int) {
+	if err := s.ActivityTracker.Database.SetWorkReportSynced(workReportID); err != nil {
+		log.Printf("Failed to record sync: %v", err)
+	}
+}
+
+// syncActionKind is how Sync repairs one activity
+type syncActionKind int
+
+const (
+	syncClose  syncActionKind = iota // Close its work report, still open on the server
+	syncUpdate                       // Set its work report's times, which may not have been saved
+	syncCreate                       // Create a closed work report for it
+	syncSkip                         // Leave it for later; reason says why
+)
+
+// syncAction is the repair planned for one activity
+type syncAction struct {
+	kind     syncActionKind
+	activity UnsyncedActivity
+	task     types.Task // For syncCreate
+	reason   string     // For syncSkip
+}
+
+// planSync decides how to bring each unsynced activity in line with the
+// server. open is the server's open work report, if any; reports in inUse
+// belong to the running session and are left alone. Activities without a work
+// report get one if their task can be found, by name, among tasks.
+func planSync(activities []UnsyncedActivity, open *types.WorkReport, inUse map[int]bool, tasks []types.Task) []syncAction {
+	byName := map[string][]types.Task{}
+	for _, task := range tasks {
+		if !task.Local {
+			byName[task.Name] = append(byName[task.Name], task)
+		}
+	}
+
+	actions := make([]syncAction, 0, len(activities))
+	for _, a := range activities {
+		action := syncAction{activity: a}
+		switch {
+		case a.StartTime.IsZero() || a.EndTime.IsZero():
+			action.kind, action.reason = syncSkip, "its times are incomplete"
+		case a.SyncState == SyncPending && inUse[a.WorkReportID]:
+			action.kind, action.reason = syncSkip, "its work report is still being closed"
+		case a.SyncState == SyncPending && open != nil && open.ID == a.WorkReportID:
+			action.kind = syncClose
+		case a.SyncState == SyncPending:
+			action.kind = syncUpdate
+		case len(byName[a.Task]) == 1:
+			action.kind, action.task = syncCreate, byName[a.Task][0]
+		case len(byName[a.Task]) > 1:
+			action.kind, action.reason = syncSkip, "several server tasks have its name"
+		default:
+			action.kind, action.reason = syncSkip, "its task isn't assigned to you on the server"
+		}
+		actions = append(actions, action)
+	}
+	return actions
+}
+
+// unclosedReport returns open if it is neither in use nor the report of any
+// of activities: a report nothing here will close
+func unclosedReport(open *types.WorkReport, activities []UnsyncedActivity, inUse map[int]bool) *types.WorkReport {
+	if open == nil || inUse[open.ID] {
+		return nil
+	}
+	for _, a := range activities {
+		if a.WorkReportID == open.ID {
+			return nil
+		}
+	}
+	return open
+}
+
+// SyncResult describes what Sync did
+type SyncResult struct {
+	Repaired  int // Activities now matched on the server
+	Remaining int // Activities still to be synced
+	// UnclosedReport is a work report open on the server that no local
+	// activity accounts for, e.g. one left by a crash on another machine
+	UnclosedReport *types.WorkReport
+}
+
+// Sync compares the saved activities with the server's work reports and
+// repairs what it can: reports left open are closed at the activity's end,
+// less pauses and idle time as CloseWorkReport would, reports whose close
+// wasn't confirmed get the activity's times, and
+// activities that never got a report get a closed one. Failures are returned
+// together; the activities involved stay unsynced for the next try.
+func (s *Session) Sync() (SyncResult, error) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	var result SyncResult
+	db := s.ActivityTracker.Database
+	if err := db.Connect(); err != nil {
+		return result, err
+	}
+	activities, err := db.UnsyncedActivities()
+	if err != nil {
+		return result, err
+	}
+	open, err := s.TaskManager.GetOpenWorkReport()
+	if err != nil {
+		return result, fmt.Errorf("failed to fetch open work report: %w", err)
+	}
+	inUse := s.TaskManager.openReportIDs()
+	result.UnclosedReport = unclosedReport(open, activities, inUse)
+
+	var errs []error
+	for _, action := range planSync(activities, open, inUse, s.TaskManager.Tasks()) {
+		if err := s.applySync(action); err != nil {
+			errs = append(errs, fmt.Errorf("%s at %s: %w", action.activity.Task, action.activity.StartTime.Local().Format(time.DateTime), err))
+			continue
+		}
+		if action.kind != syncSkip {
+			result.Repaired++
+		}
+	}
+	result.Remaining, err = db.CountUnsynced()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if result.Repaired > 0 {
+		log.Printf("Synced %d activities with the server, %d remain", result.Repaired, result.Remaining)
+	}
+	return result, errors.Join(errs...)
+}
+
+// applySync carries out one planned repair, recording the outcome locally
+func (s *Session) applySync(action syncAction) error {
+	db := s.ActivityTracker.Database
+	a := action.activity
+	// The report ends when the time worked does, as when it was first closed
+	end := a.reportEnd()
+	description := a.Description
+	if description == "" {
+		description = config.DefaultStopDescription
+	}
+
+	switch action.kind {
+	case syncClose:
+		if err := s.TaskManager.CloseWorkReportByID(a.WorkReportID, end, description); err != nil {
+			return err
+		}
+		return db.setActivitySync(a.ID, a.WorkReportID, SyncSynced)
+	case syncUpdate:
+		if err := s.TaskManager.UpdateWorkReport(a.WorkReportID, nil, a.StartTime, end); err != nil {
+			return err
+		}
+		return db.setActivitySync(a.ID, a.WorkReportID, SyncSynced)
+	case syncCreate:
+		report, err := s.TaskManager.CreateClosedWorkReport(action.task, a.StartTime, end, description)
+		if report != nil {
+			state := SyncSynced
+			if err != nil {
+				state = SyncPending // Created but left open; the next Sync closes it
+			}
+			if linkErr := db.setActivitySync(a.ID, report.ID, state); linkErr != nil {
+				err = errors.Join(err, linkErr)
+			}
+		}
+		return err
+	}
+	log.Printf("Not syncing %s at %s: %s", a.Task, a.StartTime.Local().Format(time.DateTime), action.reason)
+	return nil
+}
@@ -0,0 +1,263 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+func TestInitialSyncState(t *testing.T) {
+	tests := []struct {
+		name         string
+		workReportID int
+		local        bool
+		want         string
+	}{
+		{"with a work report", 7, false, SyncPending},
+		{"without a work report", 0, false, SyncUnlinked},
+		{"local task", 0, true, SyncLocal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := initialSyncState(tt.workReportID, tt.local); got != tt.want {
+				t.Errorf("initialSyncState(%d, %v) = %q, want %q", tt.workReportID, tt.local, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanSync(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	design := types.Task{ID: 1, Name: "Design"}
+	tasks := []types.Task{
+		design,
+		{ID: 2, Name: "Review"},
+		{ID: 3, Name: "Review"},
+		{ID: 4, Name: "Notes", Local: true},
+	}
+	open := &types.WorkReport{ID: 20}
+
+	tests := []struct {
+		name     string
+		activity UnsyncedActivity
+		wantKind syncActionKind
+		wantTask types.Task
+	}{
+		{"no end time", UnsyncedActivity{Task: "Design", StartTime: start, WorkReportID: 10, SyncState: SyncPending}, syncSkip, types.Task{}},
+		{"report in use", UnsyncedActivity{Task: "Design", StartTime: start, EndTime: end, WorkReportID: 30, SyncState: SyncPending}, syncSkip, types.Task{}},
+		{"report left open", UnsyncedActivity{Task: "Design", StartTime: start, EndTime: end, WorkReportID: 20, SyncState: SyncPending}, syncClose, types.Task{}},
+		{"close unconfirmed", UnsyncedActivity{Task: "Design", StartTime: start, EndTime: end, WorkReportID: 10, SyncState: SyncPending}, syncUpdate, types.Task{}},
+		{"no report, task found", UnsyncedActivity{Task: "Design", StartTime: start, EndTime: end, SyncState: SyncUnlinked}, syncCreate, design},
+		{"no report, ambiguous task", UnsyncedActivity{Task: "Review", StartTime: start, EndTime: end, SyncState: SyncUnlinked}, syncSkip, types.Task{}},
+		{"no report, local task", UnsyncedActivity{Task: "Notes", StartTime: start, EndTime: end, SyncState: SyncUnlinked}, syncSkip, types.Task{}},
+		{"no report, unknown task", UnsyncedActivity{Task: "Gone", StartTime: start, EndTime: end, SyncState: SyncUnlinked}, syncSkip, types.Task{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actions := planSync([]UnsyncedActivity{tt.activity}, open, map[int]bool{30: true}, tasks)
+			if len(actions) != 1 {
+				t.Fatalf("%d actions, want 1", len(actions))
+			}
+			got := actions[0]
+			if got.kind != tt.wantKind || got.task.ID != tt.wantTask.ID {
+				t.Errorf("action = %v for task %d, want %v for task %d", got.kind, got.task.ID, tt.wantKind, tt.wantTask.ID)
+			}
+			if (got.kind == syncSkip) != (got.reason != "") {
+				t.Errorf("reason = %q for action %v", got.reason, got.kind)
+			}
+		})
+	}
+}
+
+func TestUnclosedReport(t *testing.T) {
+	open := &types.WorkReport{ID: 20}
+	activities := []UnsyncedActivity{{WorkReportID: 10}}
+
+	tests := []struct {
+		name       string
+		open       *types.WorkReport
+		activities []UnsyncedActivity
+		inUse      map[int]bool
+		want       bool
+	}{
+		{"nothing open", nil, activities, nil, false},
+		{"open for the running session", open, activities, map[int]bool{20: true}, false},
+		{"open for a saved activity", open, append(activities, UnsyncedActivity{WorkReportID: 20}), nil, false},
+		{"unaccounted for", open, activities, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unclosedReport(tt.open, tt.activities, tt.inUse); (got != nil) != tt.want {
+				t.Errorf("unclosedReport() = %v, want one: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSync(t *testing.T) {
+	api := newFakeTaskAPI()
+	s := newTestSession(t, api)
+	if _, err := s.TaskManager.GetTasks(); err != nil {
+		t.Fatal(err)
+	}
+	db := s.ActivityTracker.Database
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	save := func(task string, start time.Time, workReportID int, state string) {
+		t.Helper()
+		end := start.Add(time.Hour)
+		err := db.SaveActivity(task, start.Format(time.RFC3339), end.Format(time.RFC3339), 3600,
+			"", 0, 0, "", workReportID, 0, 0, state)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A report whose close was never confirmed
+	report, err := api.StartUserTask(demoTask.Project.ID, demoTask.ID, "", start.Format(time.RFC3339))
+	if err != nil {
+		t.Fatal(err)
+	}
+	save(demoTask.Name, start, report.ID, SyncPending)
+	save("Accessibility review", start.Add(time.Hour), 0, SyncUnlinked)
+	save("Not on the server", start.Add(2*time.Hour), 0, SyncUnlinked)
+	save("Local notes", start.Add(3*time.Hour), 0, SyncLocal)
+
+	result, err := s.Sync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Repaired != 2 || result.Remaining != 1 || result.UnclosedReport != nil {
+		t.Errorf("Sync() = %+v, want 2 repaired and 1 remaining", result)
+	}
+
+	status, err := api.GetCurrentStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Report != nil {
+		t.Errorf("work report %d still open after sync", status.Report.ID)
+	}
+	if _, ok := api.stoppedAt(report.ID + 1); !ok {
+		t.Error("no closed work report created for the unlinked activity")
+	}
+
+	remaining, err := db.UnsyncedActivities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 1 || remaining[0].Task != "Not on the server" {
+		t.Errorf("unsynced after Sync = %+v, want only the unknown task's activity", remaining)
+	}
+
+	if result, err = s.Sync(); err != nil || result.Repaired != 0 || result.Remaining != 1 {
+		t.Errorf("second Sync() = %+v, %v, want nothing more to repair", result, err)
+	}
+}
+
+func TestReportEnd(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+
+	tests := []struct {
+		name      string
+		duration  time.Duration
+		idle      time.Duration
+		countIdle bool
+		want      time.Time
+	}{
+		{"nothing to take off", 2 * time.Hour, 0, false, end},
+		{"paused", 90 * time.Minute, 0, false, start.Add(90 * time.Minute)},
+		{"paused and idle", 90 * time.Minute, 10 * time.Minute, false, start.Add(80 * time.Minute)},
+		{"idle counted as worked", 90 * time.Minute, 10 * time.Minute, true, start.Add(90 * time.Minute)},
+		{"duration unknown", -1, 10 * time.Minute, false, end},
+		{"longer than the session", 3 * time.Hour, 0, false, end},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateSettings(t, func(s *config.Settings) { s.CountIdleAsWorked = tt.countIdle })
+			a := UnsyncedActivity{StartTime: start, EndTime: end, Duration: tt.duration, Idle: tt.idle}
+			if got := a.reportEnd(); !got.Equal(tt.want) {
+				t.Errorf("reportEnd() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncEndsAtTimeWorked(t *testing.T) {
+	updateSettings(t, func(s *config.Settings) { s.CountIdleAsWorked = false })
+	api := newFakeTaskAPI()
+	s := newTestSession(t, api)
+	db := s.ActivityTracker.Database
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	// Two hours on the clock: half an hour paused, then ten minutes idle. The
+	// report was closed but the response lost, so Sync corrects its times.
+	report, err := api.StartUserTask(demoTask.Project.ID, demoTask.ID, "", start.Format(time.RFC3339))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.SaveActivity(demoTask.Name, start.Format(time.RFC3339), start.Add(2*time.Hour).Format(time.RFC3339), 90*60,
+		"", 0, 0, "", report.ID, 0, 10*60, SyncPending)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	end, ok := api.stoppedAt(report.ID)
+	if !ok {
+		t.Fatal("work report wasn't synced")
+	}
+	if want := start.Add(80 * time.Minute); !end.Equal(want) {
+		t.Errorf("work report ends at %s, want %s", end, want)
+	}
+}
+
+func TestSyncOnlyActiveServer(t *testing.T) {
+	updateSettings(t, func(s *config.Settings) {
+		s.ServerProfiles = []config.ServerProfile{{Name: "Staging", BaseURL: "https://staging.example.com"}}
+		s.ActiveProfileName = "Staging"
+	})
+	api := newFakeTaskAPI()
+	s := newTestSession(t, api)
+	db := s.ActivityTracker.Database
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	// Tracked against staging, whose report 1 has nothing to do with production's
+	err := db.SaveActivity(demoTask.Name, start.Format(time.RFC3339), start.Add(time.Hour).Format(time.RFC3339), 3600,
+		"", 0, 0, "", 1, 0, 0, SyncPending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Update(func(s *config.Settings) { s.ActiveProfileName = config.DefaultProfileName }); err != nil {
+		t.Fatal(err)
+	}
+
+	if count, err := db.CountUnsynced(); err != nil || count != 0 {
+		t.Errorf("CountUnsynced() on production = %d, %v, want 0", count, err)
+	}
+	if result, err := s.Sync(); err != nil || result.Repaired != 0 || result.Remaining != 0 {
+		t.Errorf("Sync() on production = %+v, %v, want nothing to sync", result, err)
+	}
+	if _, ok := api.stoppedAt(1); ok {
+		t.Error("production's work report 1 was changed for a staging activity")
+	}
+	if err := db.SetWorkReportSynced(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := config.Update(func(s *config.Settings) { s.ActiveProfileName = "Staging" }); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := db.CountUnsynced(); err != nil || count != 1 {
+		t.Errorf("CountUnsynced() on staging = %d, %v, want 1", count, err)
+	}
+}
@@ -1,34 +1,67 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"os"
+	"log"
 	"path/filepath"
+	"sync"
 	"time"
 
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/logging"
 	"github.com/time-tracker/v2/internal/types"
 	"github.com/time-tracker/v2/services"
 )
 
+// ErrNoActiveWorkReport is returned when an upload is skipped because no work
+// report is open (not started yet, or already closing). It is not a failure.
+var ErrNoActiveWorkReport = errors.New("no open work report")
+
+// TaskManager tracks the user's tasks and the open work report. Its state is
+// touched from the UI thread, the start/stop goroutines and the screenshot
+// scheduler, so every field below mu is guarded by it. The lock is never held
+// across network calls.
 type TaskManager struct {
-	tasks       []types.Task
-	activeTask  *types.Task
-	taskHistory map[int][]map[string]interface{}
-	taskService *services.TaskService
-	workReport  *types.WorkReport
+	taskService services.TaskAPI
+
+	mu                 sync.Mutex
+	tasks              []types.Task
+	projects           []types.Project // Cached by GetProjects
+	noProjectsEndpoint bool            // The server has no projects endpoint; projects come from tasks
+	activeTask         *types.Task
+	taskHistory        map[int][]map[string]interface{}
+	workReport         *types.WorkReport
+	concurrentReports  map[int]*types.WorkReport // Reports open alongside workReport, by task ID
+	stopping           bool                      // Set while the work report is being closed; blocks new uploads
+	uploads            sync.WaitGroup            // In-flight screenshot uploads against workReport
+
+	uploadSlots *uploadLimiter // Shared by every upload so a weak uplink isn't saturated
+	notes       *Database      // Where uploads look up screenshot notes; set by NewScreenshotManager
 }
 
 func NewTaskManager() *TaskManager {
+	return NewTaskManagerWithAPI(services.NewTaskAPI())
+}
+
+// NewTaskManagerWithAPI creates a TaskManager that uses the given backend
+func NewTaskManagerWithAPI(api services.TaskAPI) *TaskManager {
 	return &TaskManager{
 		tasks:       []types.Task{},
 		activeTask:  nil,
 		taskHistory: make(map[int][]map[string]interface{}),
-		taskService: services.NewTaskService(),
+		taskService: api,
+
+		concurrentReports: make(map[int]*types.WorkReport),
+		uploadSlots:       newUploadLimiter(),
 	}
 }
 
 func (tm *TaskManager) AddTask(task types.Task) (bool, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
 	for _, t := range tm.tasks {
 		if t.ID == task.ID {
 			return false, nil
@@ -40,6 +73,9 @@ func (tm *TaskManager) AddTask(task types.Task) (bool, error) {
 }
 
 func (tm *TaskManager) RemoveTask(task types.Task) (bool, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
 	for i, t := range tm.tasks {
 		if t.ID == task.ID {
 			tm.tasks = append(tm.tasks[:i], tm.tasks[i+1:]...)
@@ -52,22 +88,102 @@ func (tm *TaskManager) RemoveTask(task types.Task) (bool, error) {
 	return false, nil
 }
 
+// Ping checks that the backend is reachable
+func (tm *TaskManager) Ping(ctx context.Context) error {
+	return tm.taskService.Ping(ctx)
+}
+
+// WatchToken reloads the API token when the token file changes, calling
+// onChange with the new one; see services.ApiClient.WatchToken
+func (tm *TaskManager) WatchToken(onChange func(token string)) (stop func(), err error) {
+	return tm.taskService.WatchToken(onChange)
+}
+
 func (tm *TaskManager) GetTasks() ([]types.Task, error) {
-	tasks, err := tm.taskService.GetUserTasks()
+	tasks, _, err := tm.GetTasksIfModified()
+	return tasks, err
+}
+
+// GetTasksIfModified fetches the user's tasks like GetTasks, reporting
+// whether the server's list changed since the previous fetch
+func (tm *TaskManager) GetTasksIfModified() ([]types.Task, bool, error) {
+	tasks, modified, err := tm.taskService.GetUserTasksIfModified()
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
 	tm.tasks = tasks
-	return tm.tasks, nil
+	return append([]types.Task(nil), tm.tasks...), modified, nil
+}
+
+// GetProjects fetches the user's projects and caches them. If the server has
+// no projects endpoint, they are derived from the tasks last fetched by
+// GetTasks instead, and the endpoint isn't asked again.
+func (tm *TaskManager) GetProjects() ([]types.Project, error) {
+	tm.mu.Lock()
+	derive := tm.noProjectsEndpoint
+	tm.mu.Unlock()
+
+	var projects []types.Project
+	if !derive {
+		var err error
+		projects, err = tm.taskService.GetProjects()
+		if errors.Is(err, services.ErrProjectsUnsupported) {
+			log.Println("Server has no projects endpoint; listing the projects of assigned tasks")
+			derive = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if derive {
+		tm.noProjectsEndpoint = true
+		projects = ProjectsFromTasks(tm.tasks)
+	}
+	tm.projects = projects
+	return append([]types.Project(nil), tm.projects...), nil
+}
+
+// Projects returns the projects cached by the last GetProjects
+func (tm *TaskManager) Projects() []types.Project {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	return append([]types.Project(nil), tm.projects...)
+}
+
+// ProjectsFromTasks returns the distinct projects embedded in tasks, in the
+// order they first appear
+func ProjectsFromTasks(tasks []types.Task) []types.Project {
+	seen := map[int]bool{}
+	projects := []types.Project{}
+	for _, task := range tasks {
+		if seen[task.Project.ID] {
+			continue
+		}
+		seen[task.Project.ID] = true
+		projects = append(projects, task.Project)
+	}
+	return projects
 }
 
 func (tm *TaskManager) ClearTasks() {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
 	tm.tasks = []types.Task{}
+	tm.projects = nil
 	tm.activeTask = nil
 	tm.taskHistory = make(map[int][]map[string]interface{})
 }
 
 func (tm *TaskManager) SetActiveTask(task types.Task) (bool, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
 	for _, t := range tm.tasks {
 		if t.ID == task.ID {
 			tm.activeTask = &task
@@ -82,6 +198,13 @@ func (tm *TaskManager) SetActiveTask(task types.Task) (bool, error) {
 }
 
 func (tm *TaskManager) StopActiveTask() {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.stopActiveTaskLocked()
+}
+
+// stopActiveTaskLocked closes the active task's local history entry. Callers must hold tm.mu.
+func (tm *TaskManager) stopActiveTaskLocked() {
 	if tm.activeTask != nil {
 		history := tm.taskHistory[tm.activeTask.ID]
 		if len(history) > 0 {
@@ -95,26 +218,62 @@ func (tm *TaskManager) StopActiveTask() {
 }
 
 func (tm *TaskManager) GetActiveTask() *types.Task {
-	return tm.activeTask
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if tm.activeTask == nil {
+		return nil
+	}
+	task := *tm.activeTask
+	return &task
 }
 
+// GetTaskHistory returns a copy of the task's session history
 func (tm *TaskManager) GetTaskHistory(task types.Task) []map[string]interface{} {
-	return tm.taskHistory[task.ID]
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	history := make([]map[string]interface{}, 0, len(tm.taskHistory[task.ID]))
+	for _, session := range tm.taskHistory[task.ID] {
+		entry := make(map[string]interface{}, len(session))
+		for k, v := range session {
+			entry[k] = v
+		}
+		history = append(history, entry)
+	}
+	return history
 }
 
-func (tm *TaskManager) UserStartTask(projectID int, task types.Task, description string) (bool, error) {
-	if tm.activeTask != nil {
-		tm.StopActiveTask()
+// GetWorkReport returns a copy of the open work report, or nil if none is open
+func (tm *TaskManager) GetWorkReport() *types.WorkReport {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if tm.workReport == nil {
+		return nil
 	}
+	report := *tm.workReport
+	return &report
+}
 
-	startTime := time.Now().Format(time.RFC3339)
+func (tm *TaskManager) UserStartTask(projectID int, task types.Task, description string) (bool, error) {
+	return tm.UserStartTaskAt(projectID, task, description, time.Now())
+}
+
+// UserStartTaskAt opens a work report for task starting at start, which may
+// be in the past (e.g. when a session is reassigned to another task)
+func (tm *TaskManager) UserStartTaskAt(projectID int, task types.Task, description string, start time.Time) (bool, error) {
+	tm.StopActiveTask()
+
+	startTime := start.Format(time.RFC3339)
 	workReport, err := tm.taskService.StartUserTask(projectID, task.ID, description, startTime)
 	if err != nil {
 		return false, err
 	}
 
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
 	tm.workReport = workReport
-	if tm.workReport != nil {
+	if workReport != nil {
 		tm.activeTask = &task
 		tm.taskHistory[task.ID] = append(tm.taskHistory[task.ID], map[string]interface{}{
 			"start_time":  startTime,
@@ -127,46 +286,274 @@ func (tm *TaskManager) UserStartTask(projectID int, task types.Task, description
 }
 
 func (tm *TaskManager) UserStopTask(description string) (bool, error) {
-	if tm.workReport == nil || tm.activeTask == nil {
+	return tm.UserStopTaskAt(description, time.Now())
+}
+
+// UserStopTaskAt closes the open work report with the given end time, which
+// is moved up to the report's start if it falls before it
+func (tm *TaskManager) UserStopTaskAt(description string, end time.Time) (bool, error) {
+	tm.mu.Lock()
+	workReport := tm.workReport
+	activeTask := tm.activeTask
+	if workReport == nil || activeTask == nil || tm.stopping {
+		tm.mu.Unlock()
 		return false, errors.New("no active task to stop")
 	}
+	// Refuse new uploads, then let in-flight ones land before the report is closed
+	tm.stopping = true
+	tm.mu.Unlock()
+	tm.uploads.Wait()
+
+	if workReport.StartTime != nil && end.Before(*workReport.StartTime) {
+		end = *workReport.StartTime
+	}
+	endTime := end.Format(time.RFC3339)
+	updatedReport, err := tm.taskService.StopUserTask(workReport.ID, endTime, &description)
 
-	endTime := time.Now().Format(time.RFC3339)
-	updatedReport, err := tm.taskService.StopUserTask(tm.workReport.ID, endTime, &description)
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.stopping = false
 	if err != nil {
 		return false, err
 	}
 
 	if updatedReport != nil {
-		history := tm.taskHistory[tm.activeTask.ID]
-		lastSession := history[len(history)-1]
-		lastSession["end_time"] = endTime
-		lastSession["description"] = &description
+		history := tm.taskHistory[activeTask.ID]
+		if len(history) > 0 {
+			lastSession := history[len(history)-1]
+			lastSession["end_time"] = endTime
+			lastSession["description"] = &description
+		}
 		tm.activeTask = nil
+		tm.workReport = nil
 		return true, nil
 	}
 	return false, nil
 }
 
-// UploadScreenshot uploads a screenshot for a specific work report.
-func (tm *TaskManager) UploadScreenshot(filePath string) (bool, error) {
-	if tm.workReport == nil {
-		return false, nil // Silently skip upload if no active work report
+// StartConcurrentTask opens a work report for task alongside the main one.
+// Screenshots are only uploaded to the main report.
+func (tm *TaskManager) StartConcurrentTask(task types.Task, description string) (*types.WorkReport, error) {
+	tm.mu.Lock()
+	_, open := tm.concurrentReports[task.ID]
+	tm.mu.Unlock()
+	if open {
+		return nil, fmt.Errorf("a work report is already open for %s", task.Name)
 	}
 
-	// Read the file data
-	fileData, err := os.ReadFile(filePath)
+	report, err := tm.taskService.StartUserTask(task.Project.ID, task.ID, description, time.Now().Format(time.RFC3339))
 	if err != nil {
-		return false, fmt.Errorf("failed to read screenshot file: %w", err)
+		return nil, err
 	}
+	if report == nil {
+		return nil, errors.New("the server did not return a work report")
+	}
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.concurrentReports[task.ID] = report
+	copied := *report
+	return &copied, nil
+}
 
-	// Get the filename from the path
-	filename := filepath.Base(filePath)
+// concurrentReport returns a copy of the work report open for taskID by
+// StartConcurrentTask, or nil
+func (tm *TaskManager) concurrentReport(taskID int) *types.WorkReport {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	report := tm.concurrentReports[taskID]
+	if report == nil {
+		return nil
+	}
+	copied := *report
+	return &copied
+}
 
-	// Call the taskService to upload the screenshot
-	err = tm.taskService.UploadScreenshot(tm.workReport.ID, fileData, filename)
+// openReportIDs returns the IDs of the work reports this session has open
+func (tm *TaskManager) openReportIDs() map[int]bool {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	ids := map[int]bool{}
+	if tm.workReport != nil {
+		ids[tm.workReport.ID] = true
+	}
+	for _, report := range tm.concurrentReports {
+		ids[report.ID] = true
+	}
+	return ids
+}
+
+// CreateClosedWorkReport records a finished stretch of work on the server
+// without touching the current work report. If closing the new report fails
+// it is returned along with the error.
+func (tm *TaskManager) CreateClosedWorkReport(task types.Task, start, end time.Time, description string) (*types.WorkReport, error) {
+	report, err := tm.taskService.StartUserTask(task.Project.ID, task.ID, description, start.Format(time.RFC3339))
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	return true, nil
+	if report == nil {
+		return nil, errors.New("the server did not return a work report")
+	}
+	if _, err := tm.taskService.StopUserTask(report.ID, end.Format(time.RFC3339), &description); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// Tasks returns the server's tasks as last fetched by GetTasks
+func (tm *TaskManager) Tasks() []types.Task {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	return append([]types.Task(nil), tm.tasks...)
+}
+
+// StopConcurrentTask closes the work report opened for taskID by
+// StartConcurrentTask. The end time is moved up to the report's start if it
+// falls before it.
+func (tm *TaskManager) StopConcurrentTask(taskID int, description string, end time.Time) error {
+	tm.mu.Lock()
+	report := tm.concurrentReports[taskID]
+	delete(tm.concurrentReports, taskID)
+	tm.mu.Unlock()
+	if report == nil {
+		return errors.New("no work report is open for that task")
+	}
+
+	if report.StartTime != nil && end.Before(*report.StartTime) {
+		end = *report.StartTime
+	}
+	_, err := tm.taskService.StopUserTask(report.ID, end.Format(time.RFC3339), &description)
+	return err
+}
+
+// GetOpenWorkReport asks the server for a work report that was never closed,
+// such as one left open by a crash on this or another machine. It returns nil
+// if there is none.
+func (tm *TaskManager) GetOpenWorkReport() (*types.WorkReport, error) {
+	return tm.taskService.GetOpenWorkReport()
+}
+
+// dropWorkReport lets go of the open work report if it is still reportID,
+// after closing it failed, leaving it open on the server for Sync to close.
+// It reports whether it did.
+func (tm *TaskManager) dropWorkReport(reportID int) bool {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if tm.workReport == nil || tm.workReport.ID != reportID || tm.stopping {
+		return false
+	}
+	tm.workReport = nil
+	return true
+}
+
+// AdoptWorkReport makes report, already open on the server, the open work
+// report for task, so tracking can resume into it instead of opening a new one
+func (tm *TaskManager) AdoptWorkReport(report types.WorkReport, task types.Task) {
+	tm.StopActiveTask()
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.workReport = &report
+	tm.activeTask = &task
+	tm.taskHistory[task.ID] = append(tm.taskHistory[task.ID], map[string]interface{}{
+		"start_time": report.StartTime,
+		"end_time":   nil,
+	})
+}
+
+// CloseWorkReportByID closes a work report left open by an earlier run of the
+// app. It does not touch the current work report.
+func (tm *TaskManager) CloseWorkReportByID(workReportID int, endTime time.Time, description string) error {
+	_, err := tm.taskService.StopUserTask(workReportID, endTime.Format(time.RFC3339), &description)
+	return err
+}
+
+// UpdateWorkReport corrects the times, and optionally the task, of a closed work report
+func (tm *TaskManager) UpdateWorkReport(workReportID int, task *types.Task, startTime, endTime time.Time) error {
+	_, err := tm.taskService.UpdateWorkReport(workReportID, task, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339))
+	return err
+}
+
+// UpdateWorkReportDescription replaces the description of a closed work report
+func (tm *TaskManager) UpdateWorkReportDescription(workReportID int, description string) error {
+	_, err := tm.taskService.UpdateWorkReportDescription(workReportID, description)
+	return err
+}
+
+// UploadScreenshot uploads a screenshot for a specific work report.
+// The report is snapshotted up front so the whole upload targets one report,
+// and uploads are refused with ErrNoActiveWorkReport once the report has started closing.
+func (tm *TaskManager) UploadScreenshot(filePath string) error {
+	workReportID, ok := tm.reserveUpload()
+	if !ok {
+		return ErrNoActiveWorkReport
+	}
+	defer tm.uploads.Done()
+
+	return tm.uploadTo(workReportID, filePath)
+}
+
+// QueueScreenshotUpload registers an upload against the open work report, so
+// closing the report waits for it, and performs it in the background. done,
+// if not nil, is called with the result. It returns ErrNoActiveWorkReport,
+// without calling done, when no report is open.
+func (tm *TaskManager) QueueScreenshotUpload(filePath string, done func(err error)) error {
+	return tm.queueScreenshotUpload(filePath, nil, done)
+}
+
+// queueScreenshotUpload is QueueScreenshotUpload, but first waits in the
+// background for allowed, if not nil, and ends with errUploadDeclined if it
+// returns false
+func (tm *TaskManager) queueScreenshotUpload(filePath string, allowed func() bool, done func(err error)) error {
+	workReportID, ok := tm.reserveUpload()
+	if !ok {
+		return ErrNoActiveWorkReport
+	}
+	go func() {
+		defer tm.uploads.Done()
+		var err error
+		defer func() {
+			if done != nil {
+				done(err)
+			}
+		}()
+		defer logging.Recover("screenshot upload", nil)
+		if allowed != nil && !allowed() {
+			err = errUploadDeclined
+			return
+		}
+		err = tm.uploadTo(workReportID, filePath)
+	}()
+	return nil
+}
+
+// reserveUpload snapshots the open work report's ID and registers an upload
+// against it. The caller must call tm.uploads.Done when the upload ends.
+func (tm *TaskManager) reserveUpload() (int, bool) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if tm.workReport == nil || tm.stopping {
+		return 0, false
+	}
+	tm.uploads.Add(1)
+	return tm.workReport.ID, true
+}
+
+// uploadTo uploads a screenshot once an upload slot is free
+func (tm *TaskManager) uploadTo(workReportID int, filePath string) error {
+	tm.uploadSlots.acquire()
+	defer tm.uploadSlots.release()
+	return tm.taskService.UploadScreenshot(workReportID, filePath, tm.uploadNote(filePath))
+}
+
+// uploadNote returns the note to send with a screenshot, "" if it has none or
+// notes aren't uploaded
+func (tm *TaskManager) uploadNote(filePath string) string {
+	if tm.notes == nil || !config.Current().UploadScreenshotNotes {
+		return ""
+	}
+	note, err := tm.notes.ScreenshotNote(filePath)
+	if err != nil {
+		log.Printf("Uploading %s without its note: %v", filepath.Base(filePath), err)
+	}
+	return note
 }
@@ -0,0 +1,226 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// newTestTaskManager returns a task manager on api with the demo tasks loaded
+func newTestTaskManager(t *testing.T, api *fakeTaskAPI) *TaskManager {
+	t.Helper()
+	tm := NewTaskManagerWithAPI(api)
+	if _, err := tm.GetTasks(); err != nil {
+		t.Fatal(err)
+	}
+	return tm
+}
+
+func TestTaskManagerConcurrentAccess(t *testing.T) {
+	tm := newTestTaskManager(t, newFakeTaskAPI())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				tm.SetActiveTask(demoTask)
+				tm.GetActiveTask()
+				tm.GetTaskHistory(demoTask)
+				tm.Tasks()
+				tm.GetWorkReport()
+				tm.StopActiveTask()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := len(tm.GetTaskHistory(demoTask)); got != 8*20 {
+		t.Errorf("history has %d sessions, want %d", got, 8*20)
+	}
+}
+
+func TestTaskManagerReturnsCopies(t *testing.T) {
+	tm := newTestTaskManager(t, newFakeTaskAPI())
+	if _, err := tm.UserStartTask(demoTask.Project.ID, demoTask, "start"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func()
+		check  func() bool
+	}{
+		{"work report", func() { tm.GetWorkReport().ID = -1 }, func() bool { return tm.GetWorkReport().ID != -1 }},
+		{"active task", func() { tm.GetActiveTask().Name = "changed" }, func() bool { return tm.GetActiveTask().Name == demoTask.Name }},
+		{"history", func() { tm.GetTaskHistory(demoTask)[0]["description"] = "changed" }, func() bool {
+			return tm.GetTaskHistory(demoTask)[0]["description"] == "start"
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mutate()
+			if !tt.check() {
+				t.Error("changing the returned value changed the task manager's state")
+			}
+		})
+	}
+}
+
+func TestStopWaitsForUploads(t *testing.T) {
+	api := newFakeTaskAPI()
+	api.uploadHold = make(chan struct{})
+	tm := newTestTaskManager(t, api)
+	if _, err := tm.UserStartTask(demoTask.Project.ID, demoTask, "start"); err != nil {
+		t.Fatal(err)
+	}
+	reportID := tm.GetWorkReport().ID
+
+	uploaded := make(chan error, 1)
+	if err := tm.QueueScreenshotUpload("shot.png", func(err error) { uploaded <- err }); err != nil {
+		t.Fatal(err)
+	}
+	stopped := make(chan error, 1)
+	go func() {
+		_, err := tm.UserStopTask("done")
+		stopped <- err
+	}()
+
+	select {
+	case err := <-stopped:
+		t.Fatalf("report closed while an upload was in flight: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(api.uploadHold)
+	if err := <-uploaded; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-stopped; err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := api.stoppedAt(reportID); !ok {
+		t.Error("work report wasn't closed")
+	}
+	if got := api.uploaded(); len(got) != 1 || got[0].workReportID != reportID {
+		t.Errorf("uploads = %+v, want one to report %d", got, reportID)
+	}
+}
+
+func TestUploadsRefusedWithoutReport(t *testing.T) {
+	tests := []struct {
+		name   string
+		upload func(tm *TaskManager) error
+	}{
+		{"UploadScreenshot", func(tm *TaskManager) error { return tm.UploadScreenshot("shot.png") }},
+		{"QueueScreenshotUpload", func(tm *TaskManager) error { return tm.QueueScreenshotUpload("shot.png", nil) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newFakeTaskAPI()
+			tm := newTestTaskManager(t, api)
+			if err := tt.upload(tm); !errors.Is(err, ErrNoActiveWorkReport) {
+				t.Errorf("error = %v, want %v", err, ErrNoActiveWorkReport)
+			}
+			if got := api.uploaded(); len(got) != 0 {
+				t.Errorf("uploaded %+v without a report", got)
+			}
+		})
+	}
+}
+
+func TestUserStopTaskAt(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset time.Duration // From the report's start
+		want   time.Duration
+	}{
+		{"after the start", time.Hour, time.Hour},
+		{"before the start is moved up", -time.Hour, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newFakeTaskAPI()
+			tm := newTestTaskManager(t, api)
+			if _, err := tm.UserStartTask(demoTask.Project.ID, demoTask, "start"); err != nil {
+				t.Fatal(err)
+			}
+			report := tm.GetWorkReport()
+			start := *report.StartTime
+
+			if _, err := tm.UserStopTaskAt("stop", start.Add(tt.offset)); err != nil {
+				t.Fatal(err)
+			}
+			end, ok := api.stoppedAt(report.ID)
+			if !ok {
+				t.Fatal("work report wasn't closed")
+			}
+			if got := end.Sub(start); got != tt.want {
+				t.Errorf("closed %s after the start, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// noReportAPI is a backend that accepts new work reports without returning them
+type noReportAPI struct {
+	*fakeTaskAPI
+}
+
+func (noReportAPI) StartUserTask(projectID, taskID int, description string, startTime string) (*types.WorkReport, error) {
+	return nil, nil
+}
+
+func TestCreateClosedWorkReportWithoutReport(t *testing.T) {
+	tm := NewTaskManagerWithAPI(noReportAPI{newFakeTaskAPI()})
+	end := time.Now()
+	report, err := tm.CreateClosedWorkReport(demoTask, end.Add(-time.Hour), end, "done")
+	if err == nil {
+		t.Fatal("CreateClosedWorkReport() succeeded without a work report")
+	}
+	if report != nil {
+		t.Errorf("CreateClosedWorkReport() returned report %+v", report)
+	}
+}
+
+func TestUploadNote(t *testing.T) {
+	tests := []struct {
+		name   string
+		upload bool
+		note   string
+		want   string
+	}{
+		{"uploaded", true, "Reviewing the design", "Reviewing the design"},
+		{"not uploaded", false, "Reviewing the design", ""},
+		{"no note", true, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateSettings(t, func(s *config.Settings) { s.UploadScreenshotNotes = tt.upload })
+			api := newFakeTaskAPI()
+			tm := newTestTaskManager(t, api)
+			db := newTestDatabase(t)
+			NewScreenshotManager(60, tm, db) // Lets uploads look up notes
+			if _, err := tm.UserStartTask(demoTask.Project.ID, demoTask, "start"); err != nil {
+				t.Fatal(err)
+			}
+			if err := db.SetScreenshotNote("shot.png", tt.note); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tm.UploadScreenshot("shot.png"); err != nil {
+				t.Fatal(err)
+			}
+			if got := api.uploaded(); len(got) != 1 || got[0].note != tt.want {
+				t.Errorf("uploaded %+v, want the note %q", got, tt.want)
+			}
+		})
+	}
+}
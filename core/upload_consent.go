@@ -0,0 +1,121 @@
+package core
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+// UploadConsentTimeout is how long the first upload of a session waits for
+// the user to answer before screenshots are kept local
+const UploadConsentTimeout = time.Minute
+
+// errUploadDeclined ends an upload the user didn't agree to
+var errUploadDeclined = errors.New("screenshot uploads were declined")
+
+// uploadConsent remembers, for one session, whether the user agreed to
+// screenshots being uploaded
+type uploadConsent struct {
+	mu      sync.Mutex
+	decided bool
+	allowed bool
+	pending chan struct{} // Closed when the outstanding request is answered
+}
+
+// reset forgets the previous session's answer, releasing anyone still waiting on it
+func (c *uploadConsent) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending != nil && !c.decided {
+		close(c.pending)
+	}
+	c.decided = false
+	c.allowed = false
+	c.pending = nil
+}
+
+// expire declines an outstanding request, releasing anyone waiting on it, as
+// the session it was asked for has stopped
+func (c *uploadConsent) expire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending != nil && !c.decided {
+		close(c.pending)
+		c.decided = true
+		c.allowed = false
+	}
+}
+
+// set records the user's answer for the rest of the session
+func (c *uploadConsent) set(allowed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending != nil && !c.decided {
+		close(c.pending)
+	}
+	c.decided = true
+	c.allowed = allowed
+}
+
+// uploadAllowed reports whether screenshots may be uploaded this session. With
+// ConfirmScreenshotUploads set, the first call sends EventUploadConsentRequested
+// and every caller waits for SetUploadConsent, declining after UploadConsentTimeout
+// or once capturing stops. It blocks, so call it from the upload goroutine.
+func (sm *ScreenshotManager) uploadAllowed() bool {
+	if !config.Current().ConfirmScreenshotUploads {
+		return true
+	}
+	sm.mu.Lock()
+	stop := sm.stopChan
+	sm.mu.Unlock()
+	c := &sm.consent
+	c.mu.Lock()
+	if c.decided {
+		allowed := c.allowed
+		c.mu.Unlock()
+		return allowed
+	}
+	ask := c.pending == nil
+	if ask {
+		c.pending = make(chan struct{})
+	}
+	wait := c.pending
+	c.mu.Unlock()
+
+	if ask {
+		sm.events.emit(TrackerEvent{Type: EventUploadConsentRequested})
+	}
+	select {
+	case <-wait:
+	case <-stop:
+		log.Println("Tracking stopped before uploading screenshots was answered, keeping them local")
+		c.expire()
+	case <-sm.after(UploadConsentTimeout):
+		log.Printf("No answer about uploading screenshots after %s, keeping them local", UploadConsentTimeout)
+		c.mu.Lock()
+		if !c.decided && c.pending == wait {
+			close(wait)
+			c.decided = true
+			c.allowed = false
+		}
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.decided && c.allowed
+}
+
+// SetUploadConsent answers EventUploadConsentRequested for the current
+// session. It may also be called later to change the answer.
+func (sm *ScreenshotManager) SetUploadConsent(allowed bool) {
+	if allowed {
+		log.Println("Screenshots will be uploaded this session")
+	} else {
+		log.Println("Screenshots will be kept local this session")
+	}
+	sm.consent.set(allowed)
+}
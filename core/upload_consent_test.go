@@ -0,0 +1,167 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+func TestUploadAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		confirm bool
+		answer  func(sm *ScreenshotManager) // Called once consent is requested
+		timeout bool
+		want    bool
+		wantAsk bool
+	}{
+		{"no confirmation needed", false, nil, false, true, false},
+		{"allowed", true, func(sm *ScreenshotManager) { sm.SetUploadConsent(true) }, false, true, true},
+		{"declined", true, func(sm *ScreenshotManager) { sm.SetUploadConsent(false) }, false, false, true},
+		{"new session while asking", true, func(sm *ScreenshotManager) { sm.consent.reset() }, false, false, true},
+		{"no answer", true, nil, true, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateSettings(t, func(s *config.Settings) { s.ConfirmScreenshotUploads = tt.confirm })
+			sm := NewScreenshotManager(60, nil, nil)
+			sm.events = newEventBus()
+			events, unsubscribe := sm.events.subscribe()
+			defer unsubscribe()
+			sm.SetAfter(func(time.Duration) <-chan time.Time {
+				fire := make(chan time.Time, 1)
+				if tt.timeout {
+					fire <- time.Now()
+				}
+				return fire
+			})
+
+			result := make(chan bool)
+			go func() { result <- sm.uploadAllowed() }()
+			asked := false
+			for done := false; !done; {
+				select {
+				case ev := <-events:
+					if ev.Type == EventUploadConsentRequested {
+						asked = true
+						if tt.answer != nil {
+							tt.answer(sm)
+						}
+					}
+				case got := <-result:
+					if got != tt.want {
+						t.Errorf("uploadAllowed() = %v, want %v", got, tt.want)
+					}
+					done = true
+				case <-time.After(2 * time.Second):
+					t.Fatal("uploadAllowed() didn't return")
+				}
+			}
+			if asked != tt.wantAsk {
+				t.Errorf("consent requested = %v, want %v", asked, tt.wantAsk)
+			}
+		})
+	}
+}
+
+func TestUploadConsentAskedOncePerSession(t *testing.T) {
+	updateSettings(t, func(s *config.Settings) { s.ConfirmScreenshotUploads = true })
+	sm := NewScreenshotManager(60, nil, nil)
+	sm.SetUploadConsent(false)
+	if sm.uploadAllowed() {
+		t.Error("uploadAllowed() after declining = true")
+	}
+	sm.SetUploadConsent(true) // Changed their mind
+	if !sm.uploadAllowed() {
+		t.Error("uploadAllowed() after allowing = false")
+	}
+	sm.consent.reset()
+	sm.SetAfter(func(time.Duration) <-chan time.Time {
+		fire := make(chan time.Time, 1)
+		fire <- time.Now()
+		return fire
+	})
+	if sm.uploadAllowed() {
+		t.Error("the answer carried over to the next session")
+	}
+}
+
+func TestUploadConsentLapsesOnStop(t *testing.T) {
+	updateSettings(t, func(s *config.Settings) {
+		s.ConfirmScreenshotUploads = true
+		s.ScreenshotCaptureMode = config.CaptureModeInterval
+	})
+	sm := NewScreenshotManager(60, nil, nil)
+	sm.events = newEventBus()
+	events, unsubscribe := sm.events.subscribe()
+	defer unsubscribe()
+	// Neither the schedule nor the consent timeout ever fires
+	sm.SetAfter(func(time.Duration) <-chan time.Time { return make(chan time.Time) })
+	sm.StartCapture()
+
+	result := make(chan bool)
+	go func() { result <- sm.uploadAllowed() }()
+	for ev := range events {
+		if ev.Type == EventUploadConsentRequested {
+			break
+		}
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		sm.StopCapture()
+		close(stopped)
+	}()
+	select {
+	case got := <-result:
+		if got {
+			t.Error("uploadAllowed() = true after tracking stopped unanswered")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("uploadAllowed() still waiting after tracking stopped")
+	}
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("StopCapture() blocked on the unanswered question")
+	}
+}
+
+func TestQueuedUploadWaitsForConsent(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed bool
+		wantErr error
+		wantN   int
+	}{
+		{"allowed", true, nil, 1},
+		{"declined", false, errUploadDeclined, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newFakeTaskAPI()
+			tm := newTestTaskManager(t, api)
+			if _, err := tm.UserStartTask(demoTask.Project.ID, demoTask, "start"); err != nil {
+				t.Fatal(err)
+			}
+			answer := make(chan bool)
+			done := make(chan error, 1)
+			// Queuing returns at once; only the upload waits for the answer
+			err := tm.queueScreenshotUpload("shot.png", func() bool { return <-answer }, func(err error) { done <- err })
+			if err != nil {
+				t.Fatal(err)
+			}
+			answer <- tt.allowed
+			if err := <-done; !errors.Is(err, tt.wantErr) {
+				t.Errorf("upload error = %v, want %v", err, tt.wantErr)
+			}
+			if got := api.uploaded(); len(got) != tt.wantN {
+				t.Errorf("uploaded %d screenshots, want %d", len(got), tt.wantN)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"sync"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+// uploadLimiter caps how many uploads run at once. The limit is read from
+// settings on every acquire, so changes apply to the next upload.
+type uploadLimiter struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	active int
+	limit  func() int
+}
+
+func newUploadLimiter() *uploadLimiter {
+	l := &uploadLimiter{limit: func() int { return config.Current().UploadConcurrency }}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// acquire blocks until an upload slot is free and takes it
+func (l *uploadLimiter) acquire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.active >= max(1, l.limit()) {
+		l.cond.Wait()
+	}
+	l.active++
+}
+
+// release frees a slot taken by acquire
+func (l *uploadLimiter) release() {
+	l.mu.Lock()
+	l.active--
+	l.mu.Unlock()
+	l.cond.Broadcast()
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUploadLimiter(t *testing.T) {
+	tests := []struct {
+		name       string
+		limit      int
+		wantActive int
+	}{
+		{"one at a time", 1, 1},
+		{"three at once", 3, 3},
+		{"zero still allows one", 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newUploadLimiter()
+			l.limit = func() int { return tt.limit }
+
+			var mu sync.Mutex
+			active, peak := 0, 0
+			var wg sync.WaitGroup
+			for i := 0; i < 6; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					l.acquire()
+					defer l.release()
+					mu.Lock()
+					active++
+					peak = max(peak, active)
+					mu.Unlock()
+					time.Sleep(20 * time.Millisecond)
+					mu.Lock()
+					active--
+					mu.Unlock()
+				}()
+			}
+			wg.Wait()
+
+			if peak != tt.wantActive {
+				t.Errorf("%d uploads ran at once, want %d", peak, tt.wantActive)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrChainBroken is returned by Verify when a record doesn't match its hash
+var ErrChainBroken = errors.New("activity log hash chain is broken")
+
+// chainAlgorithm identifies how a verifiable export's hashes are computed
+const chainAlgorithm = "sha256-chain"
+
+// ChainedRecord is an activity with the hash linking it to the records before it
+type ChainedRecord struct {
+	Record ActivityRecord `json:"record"`
+	Hash   string         `json:"hash"` // Hex SHA-256 of the previous hash and the record's canonical bytes
+}
+
+// VerifiableLog is a tamper-evident export of the activities table. Each
+// record's hash covers the previous hash, so changing, removing or reordering
+// any record changes every hash after it and the head.
+type VerifiableLog struct {
+	Algorithm string          `json:"algorithm"`
+	Records   []ChainedRecord `json:"records"`
+	Head      string          `json:"head"` // Hash of the last record, empty when there are none
+}
+
+// chainHash hashes record onto the chain ending in prev. The canonical bytes
+// are the record's JSON encoding with times in UTC, so the hash doesn't depend
+// on the zone the log was exported in.
+func chainHash(prev string, record ActivityRecord) (string, error) {
+	record.StartTime = record.StartTime.UTC()
+	if record.EndTime != nil {
+		end := record.EndTime.UTC()
+		record.EndTime = &end
+	}
+	canonical, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(prev))
+	h.Write(canonical)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ExportVerifiable writes all activities to w as a VerifiableLog
+func (db *Database) ExportVerifiable(w io.Writer) error {
+	records, err := db.GetActivityRecords()
+	if err != nil {
+		return err
+	}
+	out := VerifiableLog{Algorithm: chainAlgorithm, Records: []ChainedRecord{}}
+	for _, r := range records {
+		r.StartTime = r.StartTime.UTC()
+		if r.EndTime != nil {
+			end := r.EndTime.UTC()
+			r.EndTime = &end
+		}
+		hash, err := chainHash(out.Head, r)
+		if err != nil {
+			return err
+		}
+		out.Records = append(out.Records, ChainedRecord{Record: r, Hash: hash})
+		out.Head = hash
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+// Verify reads a VerifiableLog written by ExportVerifiable and recomputes its
+// hash chain, returning the number of records checked. A record that doesn't
+// match its hash returns an error wrapping ErrChainBroken.
+func Verify(r io.Reader) (int, error) {
+	var chain VerifiableLog
+	if err := json.NewDecoder(r).Decode(&chain); err != nil {
+		return 0, fmt.Errorf("failed to read activity log: %w", err)
+	}
+	if chain.Algorithm != chainAlgorithm {
+		return 0, fmt.Errorf("unsupported activity log algorithm %q", chain.Algorithm)
+	}
+	prev := ""
+	for i, chained := range chain.Records {
+		hash, err := chainHash(prev, chained.Record)
+		if err != nil {
+			return i, err
+		}
+		if hash != chained.Hash {
+			return i, fmt.Errorf("%w at record %d (activity %d)", ErrChainBroken, i+1, chained.Record.ID)
+		}
+		prev = hash
+	}
+	if prev != chain.Head {
+		return len(chain.Records), fmt.Errorf("%w: the head doesn't match the last record", ErrChainBroken)
+	}
+	return len(chain.Records), nil
+}
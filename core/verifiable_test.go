@@ -0,0 +1,75 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	db := newTestDatabase(t)
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	saveTestActivity(t, db, "Design", start, time.Hour, 0)
+	saveTestActivity(t, db, "Review", start.Add(2*time.Hour), 10*time.Minute, 0)
+	saveTestActivity(t, db, "Deploy", start.Add(3*time.Hour), 5*time.Minute, 0)
+
+	var buf bytes.Buffer
+	if err := db.ExportVerifiable(&buf); err != nil {
+		t.Fatal(err)
+	}
+	exported := buf.Bytes()
+
+	tests := []struct {
+		name    string
+		tamper  func(l *VerifiableLog)
+		wantN   int
+		wantErr error
+	}{
+		{"untouched", func(l *VerifiableLog) {}, 3, nil},
+		{"record changed", func(l *VerifiableLog) { l.Records[1].Record.DurationSeconds *= 2 }, 1, ErrChainBroken},
+		{"record removed", func(l *VerifiableLog) { l.Records = append(l.Records[:1], l.Records[2:]...) }, 1, ErrChainBroken},
+		{"records reordered", func(l *VerifiableLog) { l.Records[0], l.Records[1] = l.Records[1], l.Records[0] }, 0, ErrChainBroken},
+		{"last record dropped", func(l *VerifiableLog) { l.Records = l.Records[:2] }, 2, ErrChainBroken},
+		{"times moved to another zone", func(l *VerifiableLog) {
+			l.Records[0].Record.StartTime = l.Records[0].Record.StartTime.In(time.FixedZone("UTC+2", 2*60*60))
+		}, 3, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log VerifiableLog
+			if err := json.Unmarshal(exported, &log); err != nil {
+				t.Fatal(err)
+			}
+			tt.tamper(&log)
+			data, err := json.Marshal(log)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			n, err := Verify(bytes.NewReader(data))
+			if tt.wantErr == nil && err != nil || !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if n != tt.wantN {
+				t.Errorf("Verify() checked %d records, want %d", n, tt.wantN)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsOtherLogs(t *testing.T) {
+	tests := map[string]string{
+		"not JSON":          "activities",
+		"unknown algorithm": `{"algorithm": "md5", "records": []}`,
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Verify(bytes.NewReader([]byte(input))); err == nil || errors.Is(err, ErrChainBroken) {
+				t.Errorf("Verify() error = %v, want a read error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"image"
+	"image/color"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// watermarkReferenceHeight is the image height at which the watermark is
+// drawn at the font's native size; taller images scale it up in whole steps
+const watermarkReferenceHeight = 540
+
+// watermarkText builds the watermark for a capture at now: the time, then the
+// computer's user name and the task when enabled
+func watermarkText(settings config.Settings, now time.Time, task string) string {
+	parts := []string{now.In(config.DisplayLocation()).Format("2006-01-02 15:04:05 MST")}
+	if settings.WatermarkUser {
+		if u, err := user.Current(); err == nil {
+			parts = append(parts, u.Username)
+		}
+	}
+	if settings.WatermarkTask && task != "" {
+		parts = append(parts, task)
+	}
+	return strings.Join(parts, " | ")
+}
+
+// renderLabel draws text in white on a dark box at the font's native size
+func renderLabel(text string) *image.RGBA {
+	face := basicfont.Face7x13
+	const padding = 4
+	width := font.MeasureString(face, text).Ceil() + 2*padding
+	height := face.Metrics().Height.Ceil() + 2*padding
+	label := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(label, label.Bounds(), image.NewUniform(color.RGBA{A: 180}), image.Point{}, draw.Src)
+	drawer := &font.Drawer{
+		Dst:  label,
+		Src:  image.White,
+		Face: face,
+		Dot:  fixed.P(padding, padding+face.Metrics().Ascent.Ceil()),
+	}
+	drawer.DrawString(text)
+	return label
+}
+
+// watermarkImage returns a copy of img with text overlaid in the given corner
+// at opacity percent. The label grows with the image so it stays legible on
+// large displays.
+func watermarkImage(img image.Image, text, position string, opacity int) image.Image {
+	bounds := img.Bounds()
+	label := renderLabel(text)
+	scale := max(1, bounds.Dy()/watermarkReferenceHeight)
+	size := image.Rect(0, 0, label.Bounds().Dx()*scale, label.Bounds().Dy()*scale)
+	margin := 8 * scale
+
+	var at image.Point
+	switch position {
+	case config.WatermarkTopLeft:
+		at = image.Pt(bounds.Min.X+margin, bounds.Min.Y+margin)
+	case config.WatermarkTopRight:
+		at = image.Pt(bounds.Max.X-margin-size.Dx(), bounds.Min.Y+margin)
+	case config.WatermarkBottomLeft:
+		at = image.Pt(bounds.Min.X+margin, bounds.Max.Y-margin-size.Dy())
+	default:
+		at = image.Pt(bounds.Max.X-margin-size.Dx(), bounds.Max.Y-margin-size.Dy())
+	}
+
+	out := image.NewRGBA(bounds)
+	draw.Draw(out, bounds, img, bounds.Min, draw.Src)
+	alpha := uint8(255 * min(max(opacity, 0), 100) / 100)
+	draw.NearestNeighbor.Scale(out, size.Add(at), label, label.Bounds(), draw.Over,
+		&draw.Options{SrcMask: image.NewUniform(color.Alpha{A: alpha})})
+	return out
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"os/user"
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+func TestWatermarkText(t *testing.T) {
+	updateSettings(t, func(s *config.Settings) { s.DisplayTimezone = "UTC" })
+	now := time.Date(2025, 3, 10, 9, 5, 30, 0, time.UTC)
+	username := "?"
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+
+	tests := []struct {
+		name     string
+		settings config.Settings
+		task     string
+		want     string
+	}{
+		{"time only", config.Settings{}, "Docs", "2025-03-10 09:05:30 UTC"},
+		{"with task", config.Settings{WatermarkTask: true}, "Docs", "2025-03-10 09:05:30 UTC | Docs"},
+		{"no task to show", config.Settings{WatermarkTask: true}, "", "2025-03-10 09:05:30 UTC"},
+		{"with user and task", config.Settings{WatermarkUser: true, WatermarkTask: true}, "Docs", "2025-03-10 09:05:30 UTC | " + username + " | Docs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := watermarkText(tt.settings, now, tt.task); got != tt.want {
+				t.Errorf("watermarkText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatermarkImage(t *testing.T) {
+	white := color.RGBA{255, 255, 255, 255}
+	img := image.NewRGBA(image.Rect(0, 0, 400, 300))
+	draw.Draw(img, img.Bounds(), image.NewUniform(white), image.Point{}, draw.Src)
+	corners := map[string]image.Point{
+		config.WatermarkTopLeft:     {12, 12},
+		config.WatermarkTopRight:    {387, 12},
+		config.WatermarkBottomLeft:  {12, 287},
+		config.WatermarkBottomRight: {387, 287},
+	}
+
+	tests := []struct {
+		position string
+		want     string // Corner the label is drawn in
+	}{
+		{config.WatermarkTopLeft, config.WatermarkTopLeft},
+		{config.WatermarkTopRight, config.WatermarkTopRight},
+		{config.WatermarkBottomLeft, config.WatermarkBottomLeft},
+		{config.WatermarkBottomRight, config.WatermarkBottomRight},
+		{"", config.WatermarkBottomRight},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			out := watermarkImage(img, "2025-03-10 09:05:30", tt.position, 70)
+			if out.Bounds() != img.Bounds() {
+				t.Fatalf("bounds = %v, want %v", out.Bounds(), img.Bounds())
+			}
+			for corner, p := range corners {
+				marked := out.At(p.X, p.Y) != color.Color(white)
+				if marked != (corner == tt.want) {
+					t.Errorf("%s corner marked = %v, want %v", corner, marked, corner == tt.want)
+				}
+			}
+		})
+	}
+	if img.RGBAAt(12, 12) != white {
+		t.Error("the original image was drawn on")
+	}
+}
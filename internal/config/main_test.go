@@ -0,0 +1,20 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// TestMain keeps every test's settings in a temporary data directory
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "time-tracker-config")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Setenv(HomeEnv, home)
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
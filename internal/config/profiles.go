@@ -0,0 +1,41 @@
+package config
+
+// DefaultProfileName names the built-in production server profile
+const DefaultProfileName = "Production"
+
+// ServerProfile is a named backend the app can log in to. Each profile keeps
+// its own stored token.
+type ServerProfile struct {
+	Name    string `json:"name"`
+	BaseURL string `json:"base_url"`
+}
+
+// Profiles returns the built-in production profile followed by the configured
+// ones. A configured profile named DefaultProfileName replaces the built-in URL.
+func Profiles() []ServerProfile {
+	profiles := []ServerProfile{{Name: DefaultProfileName, BaseURL: API_URL}}
+	for _, p := range Current().ServerProfiles {
+		if p.Name == "" || p.BaseURL == "" {
+			continue
+		}
+		if p.Name == DefaultProfileName {
+			profiles[0].BaseURL = p.BaseURL
+			continue
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles
+}
+
+// ActiveProfile returns the selected server profile, falling back to the
+// production profile if the selected one no longer exists
+func ActiveProfile() ServerProfile {
+	profiles := Profiles()
+	name := Current().ActiveProfileName
+	for _, p := range profiles {
+		if p.Name == name {
+			return p
+		}
+	}
+	return profiles[0]
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+const serverPolicyFileName = "server_policy.json"
+
+// ServerPolicy is what the backend mandates for its users. Unset fields leave
+// the local setting alone; set ones override it in Current.
+type ServerPolicy struct {
+	// ScreenshotIntervalSeconds replaces the built-in average time between scheduled screenshots
+	ScreenshotIntervalSeconds *int `json:"screenshot_interval_seconds,omitempty"`
+	// WebcamRequired set to false stops sending a webcam image with screenshots
+	WebcamRequired *bool `json:"webcam_required,omitempty"`
+
+	ScreenshotCaptureMode    *string `json:"screenshot_capture_mode,omitempty"`
+	ScreenshotBlur           *string `json:"screenshot_blur,omitempty"`
+	ConfirmScreenshotUploads *bool   `json:"confirm_screenshot_uploads,omitempty"`
+	IdleThresholdMinutes     *int    `json:"idle_threshold_minutes,omitempty"`
+	CountIdleAsWorked        *bool   `json:"count_idle_as_worked,omitempty"`
+
+	// Features are server-side feature flags, by name
+	Features map[string]bool `json:"features,omitempty"`
+}
+
+// MinScreenshotIntervalSeconds bounds ServerPolicy.ScreenshotIntervalSeconds
+const MinScreenshotIntervalSeconds = 30
+
+// Sanitize clears values the app can't apply, returning their JSON names
+func (p *ServerPolicy) Sanitize() []string {
+	var dropped []string
+	if p.ScreenshotIntervalSeconds != nil && *p.ScreenshotIntervalSeconds < MinScreenshotIntervalSeconds {
+		p.ScreenshotIntervalSeconds = nil
+		dropped = append(dropped, "screenshot_interval_seconds")
+	}
+	if p.ScreenshotCaptureMode != nil && !slices.Contains([]string{CaptureModeInterval, CaptureModeStartStop, CaptureModeManual}, *p.ScreenshotCaptureMode) {
+		p.ScreenshotCaptureMode = nil
+		dropped = append(dropped, "screenshot_capture_mode")
+	}
+	if p.ScreenshotBlur != nil && !slices.Contains([]string{BlurOff, BlurLight, BlurHeavy}, *p.ScreenshotBlur) {
+		p.ScreenshotBlur = nil
+		dropped = append(dropped, "screenshot_blur")
+	}
+	if p.IdleThresholdMinutes != nil && *p.IdleThresholdMinutes < 0 {
+		p.IdleThresholdMinutes = nil
+		dropped = append(dropped, "idle_threshold_minutes")
+	}
+	return dropped
+}
+
+// apply overrides the settings p mandates
+func (p *ServerPolicy) apply(s *Settings) {
+	if p.ScreenshotCaptureMode != nil {
+		s.ScreenshotCaptureMode = *p.ScreenshotCaptureMode
+	}
+	if p.ScreenshotBlur != nil {
+		s.ScreenshotBlur = *p.ScreenshotBlur
+	}
+	if p.ConfirmScreenshotUploads != nil {
+		s.ConfirmScreenshotUploads = *p.ConfirmScreenshotUploads
+	}
+	if p.IdleThresholdMinutes != nil {
+		s.IdleThresholdMinutes = *p.IdleThresholdMinutes
+	}
+	if p.CountIdleAsWorked != nil {
+		s.CountIdleAsWorked = *p.CountIdleAsWorked
+	}
+}
+
+// restore puts back the stored values of the settings p mandates, so a
+// mandated value is never saved as the user's own
+func (p *ServerPolicy) restore(s *Settings, stored Settings) {
+	if p.ScreenshotCaptureMode != nil {
+		s.ScreenshotCaptureMode = stored.ScreenshotCaptureMode
+	}
+	if p.ScreenshotBlur != nil {
+		s.ScreenshotBlur = stored.ScreenshotBlur
+	}
+	if p.ConfirmScreenshotUploads != nil {
+		s.ConfirmScreenshotUploads = stored.ConfirmScreenshotUploads
+	}
+	if p.IdleThresholdMinutes != nil {
+		s.IdleThresholdMinutes = stored.IdleThresholdMinutes
+	}
+	if p.CountIdleAsWorked != nil {
+		s.CountIdleAsWorked = stored.CountIdleAsWorked
+	}
+}
+
+// Mandates reports whether p overrides any setting
+func (p *ServerPolicy) Mandates() bool {
+	return p != nil && (p.ScreenshotIntervalSeconds != nil || p.WebcamRequired != nil ||
+		p.ScreenshotCaptureMode != nil || p.ScreenshotBlur != nil || p.ConfirmScreenshotUploads != nil ||
+		p.IdleThresholdMinutes != nil || p.CountIdleAsWorked != nil)
+}
+
+// The active profile's server policy, and the profile it was loaded for.
+// Guarded by settingsMu.
+var (
+	policy        *ServerPolicy
+	policyLoaded  bool
+	policyProfile string
+)
+
+func serverPolicyPath() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, serverPolicyFileName), nil
+}
+
+// loadPolicies reads the cached server policies by profile name
+func loadPolicies() map[string]*ServerPolicy {
+	policies := map[string]*ServerPolicy{}
+	path, err := serverPolicyPath()
+	if err != nil {
+		return policies
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return policies
+	}
+	if err := json.Unmarshal(data, &policies); err != nil || policies == nil {
+		return map[string]*ServerPolicy{}
+	}
+	return policies
+}
+
+// activePolicy returns the server policy for s's active profile, loading the
+// cached copy on first use and after a profile switch. Callers must hold settingsMu.
+func activePolicy(s *Settings) *ServerPolicy {
+	if !policyLoaded || policyProfile != s.ActiveProfileName {
+		policy = loadPolicies()[s.ActiveProfileName]
+		policyLoaded = true
+		policyProfile = s.ActiveProfileName
+	}
+	return policy
+}
+
+// CurrentServerPolicy returns the active profile's server policy, or nil if
+// the server mandates nothing
+func CurrentServerPolicy() *ServerPolicy {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	if current == nil {
+		current = load()
+	}
+	p := activePolicy(current)
+	if p == nil {
+		return nil
+	}
+	c := *p
+	return &c
+}
+
+// SetServerPolicy makes p the active profile's server policy and caches it so
+// it applies before the server is reachable again. Nil removes it.
+func SetServerPolicy(p *ServerPolicy) error {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	if current == nil {
+		current = load()
+	}
+	policy = p
+	policyLoaded = true
+	policyProfile = current.ActiveProfileName
+
+	policies := loadPolicies()
+	if p == nil {
+		delete(policies, policyProfile)
+	} else {
+		policies[policyProfile] = p
+	}
+	path, err := serverPolicyPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(policies, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal server policy: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write server policy file %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	interval := func(n int) *int { return &n }
+	text := func(s string) *string { return &s }
+
+	tests := []struct {
+		name        string
+		policy      ServerPolicy
+		wantDropped []string
+	}{
+		{"valid", ServerPolicy{ScreenshotIntervalSeconds: interval(300), ScreenshotCaptureMode: text(CaptureModeManual), ScreenshotBlur: text(BlurHeavy)}, nil},
+		{"interval too short", ServerPolicy{ScreenshotIntervalSeconds: interval(MinScreenshotIntervalSeconds - 1)}, []string{"screenshot_interval_seconds"}},
+		{"unknown capture mode", ServerPolicy{ScreenshotCaptureMode: text("always")}, []string{"screenshot_capture_mode"}},
+		{"unknown blur", ServerPolicy{ScreenshotBlur: text("pixelate")}, []string{"screenshot_blur"}},
+		{"negative idle threshold", ServerPolicy{IdleThresholdMinutes: interval(-1)}, []string{"idle_threshold_minutes"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dropped := tt.policy.Sanitize()
+			if !slices.Equal(dropped, tt.wantDropped) {
+				t.Errorf("Sanitize() dropped %v, want %v", dropped, tt.wantDropped)
+			}
+			if len(tt.wantDropped) > 0 && tt.policy.Mandates() {
+				t.Errorf("policy still mandates settings after dropping them: %+v", tt.policy)
+			}
+		})
+	}
+}
+
+func TestServerPolicyOverridesSettings(t *testing.T) {
+	heavy := BlurHeavy
+	confirm := true
+	t.Cleanup(func() { SetServerPolicy(nil) })
+	if err := Update(func(s *Settings) { s.ScreenshotBlur = BlurLight }); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		policy      *ServerPolicy
+		update      func(s *Settings)
+		wantBlur    string
+		wantConfirm bool
+		wantStored  string // The user's own blur setting
+	}{
+		{"mandated", &ServerPolicy{ScreenshotBlur: &heavy, ConfirmScreenshotUploads: &confirm}, nil, BlurHeavy, true, BlurLight},
+		{"saving other settings keeps the user's own", &ServerPolicy{ScreenshotBlur: &heavy}, func(s *Settings) { s.IdleThresholdMinutes = 7 }, BlurHeavy, false, BlurLight},
+		{"mandated setting can't be changed", &ServerPolicy{ScreenshotBlur: &heavy}, func(s *Settings) { s.ScreenshotBlur = BlurOff }, BlurHeavy, false, BlurLight},
+		{"policy removed", nil, nil, BlurLight, false, BlurLight},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetServerPolicy(tt.policy); err != nil {
+				t.Fatal(err)
+			}
+			if tt.update != nil {
+				if err := Update(tt.update); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s := Current()
+			if s.ScreenshotBlur != tt.wantBlur || s.ConfirmScreenshotUploads != tt.wantConfirm {
+				t.Errorf("Current() blur, confirm = %s, %v, want %s, %v", s.ScreenshotBlur, s.ConfirmScreenshotUploads, tt.wantBlur, tt.wantConfirm)
+			}
+			if stored := load().ScreenshotBlur; stored != tt.wantStored {
+				t.Errorf("saved blur = %s, want %s", stored, tt.wantStored)
+			}
+		})
+	}
+}
+
+func TestServerPolicyCached(t *testing.T) {
+	interval := 120
+	t.Cleanup(func() { SetServerPolicy(nil) })
+	if err := SetServerPolicy(&ServerPolicy{ScreenshotIntervalSeconds: &interval}); err != nil {
+		t.Fatal(err)
+	}
+
+	// As after a restart
+	settingsMu.Lock()
+	policyLoaded = false
+	policy = nil
+	settingsMu.Unlock()
+
+	p := CurrentServerPolicy()
+	if p == nil || p.ScreenshotIntervalSeconds == nil || *p.ScreenshotIntervalSeconds != interval {
+		t.Errorf("CurrentServerPolicy() = %+v, want the cached interval", p)
+	}
+}
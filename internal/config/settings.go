@@ -0,0 +1,432 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const settingsFileName = "settings.json"
+
+// Screenshot blur levels
+const (
+	BlurOff   = "off"
+	BlurLight = "light"
+	BlurHeavy = "heavy"
+)
+
+// Screenshot file formats
+const (
+	FormatPNG  = "png"
+	FormatJPEG = "jpeg"
+	FormatWebP = "webp"
+)
+
+// Screenshot resolutions on HiDPI displays: every captured pixel, or scaled
+// down to the display's logical size
+const (
+	ResolutionPhysical = "physical"
+	ResolutionLogical  = "logical"
+)
+
+// Screenshot capture modes: at random intervals, once when a session starts
+// and once when it stops, or only when requested
+const (
+	CaptureModeInterval  = "interval"
+	CaptureModeStartStop = "start_stop"
+	CaptureModeManual    = "manual"
+)
+
+// What a screenshot covers: the whole primary display, or just the focused window
+const (
+	CaptureScopeFullScreen   = "full_screen"
+	CaptureScopeActiveWindow = "active_window"
+)
+
+// MaxUploadConcurrency bounds UploadConcurrency
+const MaxUploadConcurrency = 8
+
+// DefaultTaskDisplayFormat is how tasks are listed unless TaskDisplayFormat overrides it
+const DefaultTaskDisplayFormat = "{name} (ID: {id}, Project: {project})"
+
+// Work report descriptions used unless the description templates override them
+const (
+	DefaultStartDescription = "Started"
+	DefaultStopDescription  = "Stopped"
+)
+
+// MaxWebcamDimension bounds WebcamWidth and WebcamHeight
+const MaxWebcamDimension = 4096
+
+// Corners a screenshot watermark can be drawn in
+const (
+	WatermarkTopLeft     = "top_left"
+	WatermarkTopRight    = "top_right"
+	WatermarkBottomLeft  = "bottom_left"
+	WatermarkBottomRight = "bottom_right"
+)
+
+// How time is allocated between tasks tracked at the same time: split equally
+// between them, or counted in full for each
+const (
+	AllocationEqual = "equal"
+	AllocationFull  = "full"
+)
+
+// MinWatermarkOpacity is the lowest WatermarkOpacity, so the watermark never disappears
+const MinWatermarkOpacity = 10
+
+// Screenshot JPEG and WebP quality bounds
+const (
+	MinScreenshotQuality = 1
+	MaxScreenshotQuality = 100
+)
+
+// Settings holds the user-configurable preferences persisted to settings.json
+type Settings struct {
+	LogMaxSizeMB  int `json:"log_max_size_mb"`
+	LogMaxBackups int `json:"log_max_backups"`
+
+	// Local API lets other tools on this machine control tracking
+	LocalAPIEnabled bool   `json:"local_api_enabled"`
+	LocalAPIPort    int    `json:"local_api_port"`
+	LocalAPIToken   string `json:"local_api_token"`
+
+	// ServerProfiles are extra backends (e.g. staging) next to the built-in production one
+	ServerProfiles []ServerProfile `json:"server_profiles"`
+	// ActiveProfileName selects the server profile to use (empty for production)
+	ActiveProfileName string `json:"active_profile"`
+
+	// UploadConcurrency is how many screenshot uploads may run at once
+	UploadConcurrency int `json:"upload_concurrency"`
+
+	// ProxyURL overrides HTTP_PROXY/HTTPS_PROXY for backend requests
+	ProxyURL string `json:"proxy_url"`
+	// UserAgent overrides the User-Agent sent with backend requests (empty for
+	// the app name, version and platform)
+	UserAgent string `json:"user_agent"`
+	// InsecureSkipVerify disables TLS certificate checks for self-signed servers
+	InsecureSkipVerify bool `json:"insecure_skip_verify"`
+
+	// ScreenshotBlur pixelates screenshots before they are saved or uploaded
+	ScreenshotBlur string `json:"screenshot_blur"`
+	// SkipDuplicateScreenshots keeps near-identical captures local instead of uploading them
+	SkipDuplicateScreenshots bool `json:"skip_duplicate_screenshots"`
+	// DuplicateThreshold is the maximum number of differing perceptual hash bits (of 64)
+	// for a capture to count as a duplicate of the previous one
+	DuplicateThreshold int `json:"duplicate_threshold"`
+	// ScreenshotRetentionDays deletes screenshots older than this many days (0 keeps them forever)
+	ScreenshotRetentionDays int `json:"screenshot_retention_days"`
+	// ScreenshotMaxSizeMB caps the screenshot folder size, deleting the oldest first (0 for no cap)
+	ScreenshotMaxSizeMB int `json:"screenshot_max_size_mb"`
+	// ScreenshotJitterPercent randomizes each screenshot interval by up to this
+	// percentage either way (0 for a fixed interval)
+	ScreenshotJitterPercent int `json:"screenshot_jitter_percent"`
+	// ConfirmScreenshotUploads asks on each session's first capture whether its
+	// screenshots may be uploaded; declined screenshots are only kept locally
+	ConfirmScreenshotUploads bool `json:"confirm_screenshot_uploads"`
+	// UploadScreenshotNotes sends a screenshot's note, if it has one when it is
+	// uploaded or re-uploaded, along with the image
+	UploadScreenshotNotes bool `json:"upload_screenshot_notes"`
+	// ScreenshotGraceSeconds delays the first scheduled screenshot of a session
+	// by this long (0 waits one random interval, like every later capture)
+	ScreenshotGraceSeconds int `json:"screenshot_grace_seconds"`
+	// AdaptiveScreenshotInterval spaces scheduled screenshots further apart while
+	// activity stays below LowActivityPercent: the interval becomes
+	// LowActivityMinIntervalMinutes after a quiet capture and doubles with each
+	// further one, up to LowActivityMaxIntervalMinutes. Ignored when the server
+	// sets the interval.
+	AdaptiveScreenshotInterval    bool `json:"adaptive_screenshot_interval"`
+	LowActivityPercent            int  `json:"low_activity_percent"`
+	LowActivityMinIntervalMinutes int  `json:"low_activity_min_interval_minutes"`
+	LowActivityMaxIntervalMinutes int  `json:"low_activity_max_interval_minutes"`
+	// ScreenshotPanelRefreshSeconds reloads the recent screenshots panel this
+	// often while tracking, in case a capture's update was missed (0 disables)
+	ScreenshotPanelRefreshSeconds int `json:"screenshot_panel_refresh_seconds"`
+	// ScreenshotFormat is the file format screenshots are saved and uploaded in
+	ScreenshotFormat string `json:"screenshot_format"`
+	// ScreenshotQuality is the JPEG and WebP quality, from MinScreenshotQuality to MaxScreenshotQuality
+	ScreenshotQuality int `json:"screenshot_quality"`
+	// ScreenshotResolution is ResolutionPhysical or ResolutionLogical
+	ScreenshotResolution string `json:"screenshot_resolution"`
+	// ScreenshotMaxDimension downscales screenshots whose longer side exceeds it (0 keeps full size)
+	ScreenshotMaxDimension int `json:"screenshot_max_dimension"`
+	// ScreenshotCaptureMode is CaptureModeInterval, CaptureModeStartStop or CaptureModeManual
+	ScreenshotCaptureMode string `json:"screenshot_capture_mode"`
+	// ScreenshotScope is CaptureScopeFullScreen or CaptureScopeActiveWindow; the
+	// full screen is captured whenever the active window's bounds are unknown
+	ScreenshotScope string `json:"screenshot_scope"`
+	// Watermark stamps the capture time onto screenshots, plus the computer's
+	// user name and the task when WatermarkUser and WatermarkTask are set
+	Watermark     bool `json:"watermark"`
+	WatermarkUser bool `json:"watermark_user"`
+	WatermarkTask bool `json:"watermark_task"`
+	// WatermarkPosition is the corner the watermark is drawn in, e.g. WatermarkBottomRight
+	WatermarkPosition string `json:"watermark_position"`
+	// WatermarkOpacity is the watermark's opacity in percent, from MinWatermarkOpacity to 100
+	WatermarkOpacity int `json:"watermark_opacity"`
+	// ScreenshotDir overrides where screenshots are stored (empty for the data directory)
+	ScreenshotDir string `json:"screenshot_dir"`
+	// BusyEventsPerMinute is the keyboard and mouse event rate rated as 100% activity
+	BusyEventsPerMinute int `json:"busy_events_per_minute"`
+	// InputDebugLog writes each session's raw keyboard and mouse events to a
+	// file in the log folder, for checking the input counts. Only that a key was
+	// pressed is recorded unless InputDebugKeys is set.
+	InputDebugLog  bool `json:"input_debug_log"`
+	InputDebugKeys bool `json:"input_debug_keys"`
+	// WebcamWidth and WebcamHeight size the placeholder webcam image sent with each screenshot
+	WebcamWidth  int `json:"webcam_width"`
+	WebcamHeight int `json:"webcam_height"`
+
+	// GroupTasksByProject adds a project picker in front of the task picker
+	GroupTasksByProject bool `json:"group_tasks_by_project"`
+	// IdleThresholdMinutes is how long without keyboard or mouse input counts as idle (0 disables idle detection)
+	IdleThresholdMinutes int `json:"idle_threshold_minutes"`
+	// CountIdleAsWorked keeps idle time in durations sent to the server and shown in reports
+	CountIdleAsWorked bool `json:"count_idle_as_worked"`
+	// ConcurrentTasks allows tracking further tasks alongside the main one, each with its own work report
+	ConcurrentTasks bool `json:"concurrent_tasks"`
+	// TimeAllocation is AllocationEqual or AllocationFull, for time when several tasks are tracked at once
+	TimeAllocation string `json:"time_allocation"`
+	// MinSessionSeconds ignores manual stops and switches until a session has run this long (0 disables)
+	MinSessionSeconds int `json:"min_session_seconds"`
+	// MaxSessionHours stops a session automatically once it has run this long (0 disables)
+	MaxSessionHours int `json:"max_session_hours"`
+	// TaskDisplayFormat is how tasks are listed in the task pickers, using
+	// {name}, {id}, {project} and {status}
+	TaskDisplayFormat string `json:"task_display_format"`
+	// StartDescriptionTemplate and StopDescriptionTemplate pre-fill work report
+	// descriptions, using {task}, {id}, {project}, {status}, {date} and {time}
+	StartDescriptionTemplate string `json:"start_description_template"`
+	StopDescriptionTemplate  string `json:"stop_description_template"`
+	// RememberLastTask pre-selects LastTaskID after tasks load on startup
+	RememberLastTask bool `json:"remember_last_task"`
+	LastTaskID       int  `json:"last_task_id"`
+	// AutoStartTracking starts tracking the remembered task when the app launches logged in
+	AutoStartTracking bool `json:"auto_start_tracking"`
+	// WindowSizes remembers window sizes by name (WindowTask etc.)
+	WindowSizes map[string]WindowSize `json:"window_sizes"`
+	// DailyGoalMinutes maps a task ID to how many minutes a day should be spent on it
+	DailyGoalMinutes map[int]int `json:"daily_goal_minutes"`
+	// TaskAppearances maps a task ID to its color and icon; unlisted tasks look the default
+	TaskAppearances map[int]TaskAppearance `json:"task_appearances"`
+
+	// TrackActiveWindow samples which application is in the foreground while tracking
+	TrackActiveWindow bool `json:"track_active_window"`
+	// DetectScreenLock skips screenshots while the screen is locked
+	DetectScreenLock bool `json:"detect_screen_lock"`
+	// PauseWhenLocked also pauses the timer while the screen is locked (needs DetectScreenLock)
+	PauseWhenLocked bool `json:"pause_when_locked"`
+
+	// DisplayTimezone is the IANA time zone (e.g. Europe/Berlin) times are
+	// shown and exported in; empty for the system zone
+	DisplayTimezone string `json:"display_timezone"`
+
+	// ShowStopSummary shows a session summary, with an editable stop description, when the timer stops
+	ShowStopSummary bool `json:"show_stop_summary"`
+
+	// Pomodoro mode durations, and whether tracking pauses during breaks
+	PomodoroFocusMinutes int  `json:"pomodoro_focus_minutes"`
+	PomodoroBreakMinutes int  `json:"pomodoro_break_minutes"`
+	PomodoroAutoPause    bool `json:"pomodoro_auto_pause"`
+}
+
+// DefaultSettings returns the settings used when no settings file exists
+func DefaultSettings() Settings {
+	return Settings{
+		LogMaxSizeMB:                  5,
+		LogMaxBackups:                 3,
+		LocalAPIPort:                  8765,
+		ScreenshotBlur:                BlurOff,
+		SkipDuplicateScreenshots:      true,
+		DuplicateThreshold:            4,
+		ScreenshotJitterPercent:       20,
+		UploadConcurrency:             2,
+		ScreenshotFormat:              FormatPNG,
+		ScreenshotQuality:             85,
+		ScreenshotResolution:          ResolutionPhysical,
+		ScreenshotCaptureMode:         CaptureModeInterval,
+		ScreenshotScope:               CaptureScopeFullScreen,
+		WatermarkPosition:             WatermarkBottomRight,
+		WatermarkOpacity:              70,
+		BusyEventsPerMinute:           120,
+		LowActivityPercent:            10,
+		LowActivityMinIntervalMinutes: 10,
+		LowActivityMaxIntervalMinutes: 60,
+		WebcamWidth:                   100,
+		WebcamHeight:                  100,
+		IdleThresholdMinutes:          5,
+		CountIdleAsWorked:             true,
+		TimeAllocation:                AllocationEqual,
+		MinSessionSeconds:             10,
+		TaskDisplayFormat:             DefaultTaskDisplayFormat,
+		StartDescriptionTemplate:      DefaultStartDescription,
+		StopDescriptionTemplate:       DefaultStopDescription,
+		RememberLastTask:              true,
+		DetectScreenLock:              true,
+		ShowStopSummary:               true,
+		PomodoroFocusMinutes:          25,
+		PomodoroBreakMinutes:          5,
+	}
+}
+
+var (
+	settingsMu sync.Mutex
+	current    *Settings
+)
+
+// HomeEnv names a directory to keep all application data in instead of
+// ~/.time-tracker, for portable installs or separate setups side by side.
+// Passing -home on the command line does the same. The token, settings,
+// database, logs and default screenshot folder all move with it.
+const HomeEnv = "TIME_TRACKER_HOME"
+
+// DataDir returns the application data directory, creating it if needed
+func DataDir() (string, error) {
+	dir, err := dataDirPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create data directory %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// dataDirPath returns HomeEnv's directory, made absolute, or ~/.time-tracker
+func dataDirPath() (string, error) {
+	if dir := strings.TrimSpace(os.Getenv(HomeEnv)); dir != "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s %q: %w", HomeEnv, dir, err)
+		}
+		return abs, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".time-tracker"), nil
+}
+
+// ScreenshotDir returns the configured screenshot directory, creating it if needed
+func ScreenshotDir() (string, error) {
+	dir := Current().ScreenshotDir
+	if dir == "" {
+		dataDir, err := DataDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(dataDir, "screenshots")
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", fmt.Errorf("failed to create screenshot directory %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// CheckWritableDir creates dir if needed and verifies files can be written to it
+func CheckWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	f, err := os.CreateTemp(dir, ".write-test-*")
+	if err != nil {
+		return fmt.Errorf("directory %s is not writable: %w", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// SettingsPath returns the path of the settings file
+func SettingsPath() (string, error) {
+	return settingsPath()
+}
+
+func settingsPath() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, settingsFileName), nil
+}
+
+// load reads the settings file, falling back to defaults for missing values.
+// Callers must hold settingsMu.
+func load() *Settings {
+	s := DefaultSettings()
+	path, err := settingsPath()
+	if err != nil {
+		return &s
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return &s
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		// A corrupt file should not prevent the app from starting
+		d := DefaultSettings()
+		return &d
+	}
+	return &s
+}
+
+// Current returns a copy of the current settings, loading them on first use.
+// Settings the server policy mandates have the mandated values.
+func Current() Settings {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	if current == nil {
+		current = load()
+	}
+	s := current.clone()
+	if p := activePolicy(current); p != nil {
+		p.apply(&s)
+	}
+	return s
+}
+
+// clone returns a copy of s that shares none of its maps and slices
+func (s *Settings) clone() Settings {
+	c := *s
+	c.DailyGoalMinutes = maps.Clone(s.DailyGoalMinutes)
+	c.TaskAppearances = maps.Clone(s.TaskAppearances)
+	c.ServerProfiles = slices.Clone(s.ServerProfiles)
+	c.WindowSizes = maps.Clone(s.WindowSizes)
+	return c
+}
+
+// Update applies fn to the current settings and persists the result. Changes
+// to settings the server policy mandates are discarded. If the settings can't
+// be saved, the error is returned and the current settings are left as they were.
+func Update(fn func(s *Settings)) error {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	if current == nil {
+		current = load()
+	}
+	updated := current.clone()
+	fn(&updated)
+	if p := activePolicy(current); p != nil {
+		p.restore(&updated, *current)
+	}
+
+	path, err := settingsPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(&updated, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write settings file %s: %w", path, err)
+	}
+	current = &updated
+	return nil
+}
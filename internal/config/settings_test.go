@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDataDir(t *testing.T) {
+	userHome := t.TempDir()
+	custom := filepath.Join(t.TempDir(), "portable")
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	relative, err := filepath.Rel(cwd, custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		env  string
+		want string
+	}{
+		{"default", "", filepath.Join(userHome, ".time-tracker")},
+		{"blank", "  ", filepath.Join(userHome, ".time-tracker")},
+		{"absolute", custom, custom},
+		{"padded", " " + custom + " ", custom},
+		{"relative", relative, custom},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", userHome)
+			t.Setenv(HomeEnv, tt.env)
+
+			got, err := DataDir()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("DataDir() = %s, want %s", got, tt.want)
+			}
+			info, err := os.Stat(got)
+			if err != nil || !info.IsDir() || info.Mode().Perm() != 0700 {
+				t.Errorf("data directory not created private: %v, %v", info, err)
+			}
+		})
+	}
+}
+
+func TestUpdateNotSaved(t *testing.T) {
+	if err := Update(func(s *Settings) {
+		s.IdleThresholdMinutes = 5
+		s.DailyGoalMinutes = map[int]int{7: 60}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := DataDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A directory where the settings file goes makes saving fail
+	path := filepath.Join(dir, settingsFileName)
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(path, 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(path) })
+
+	err = Update(func(s *Settings) {
+		s.IdleThresholdMinutes = 9
+		s.DailyGoalMinutes[7] = 90
+	})
+	if err == nil {
+		t.Fatal("Update() succeeded without saving")
+	}
+	s := Current()
+	if s.IdleThresholdMinutes != 5 || s.DailyGoalMinutes[7] != 60 {
+		t.Errorf("after a failed Update, idle threshold = %d and goal = %d, want 5 and 60 as saved", s.IdleThresholdMinutes, s.DailyGoalMinutes[7])
+	}
+}
@@ -0,0 +1,10 @@
+package config
+
+// TaskAppearance is how a task is shown in the task pickers and status.
+// The zero value is the default appearance.
+type TaskAppearance struct {
+	// Color is a "#rrggbb" color, empty for none
+	Color string `json:"color,omitempty"`
+	// Icon is an emoji or short symbol shown before the task's name
+	Icon string `json:"icon,omitempty"`
+}
@@ -0,0 +1,18 @@
+package config
+
+import "time"
+
+// DisplayLocation returns the time zone times are shown and entered in: the
+// configured DisplayTimezone, or the system zone if it is unset or unknown.
+// Times are always stored in UTC.
+func DisplayLocation() *time.Location {
+	name := Current().DisplayTimezone
+	if name == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
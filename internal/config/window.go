@@ -0,0 +1,14 @@
+package config
+
+// WindowSize is a window's saved content size
+type WindowSize struct {
+	Width  float32 `json:"width"`
+	Height float32 `json:"height"`
+}
+
+// Windows whose size is remembered across launches
+const (
+	WindowTask    = "task"
+	WindowHistory = "history"
+	WindowGallery = "gallery"
+)
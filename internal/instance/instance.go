@@ -0,0 +1,141 @@
+// Package instance keeps the app to one running instance per data directory.
+//
+// The running instance listens on a socket in the data directory. A later
+// launch finds it there, hands over what it was launched for (a
+// timetracker:// link, or nothing to just bring the window forward) and
+// exits, so two instances never track, hook input or write the database at
+// the same time. Each message is one line; the reply is "ok" or
+// "error: <message>".
+package instance
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/logging"
+)
+
+// ErrRunning is returned by Acquire when another instance holds the data directory
+var ErrRunning = errors.New("another instance is running")
+
+// socketName is the running instance's socket in the data directory
+const socketName = "instance.sock"
+
+// timeout bounds each exchange with the running instance
+const timeout = 5 * time.Second
+
+// Instance is the running instance's claim on the data directory
+type Instance struct {
+	listener net.Listener
+}
+
+// socketPath returns where the running instance listens
+func socketPath() (string, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, socketName), nil
+}
+
+// Acquire makes this process the running instance. It returns ErrRunning if
+// another instance already is; a socket left by one that crashed is replaced.
+func Acquire() (*Instance, error) {
+	path, err := socketPath()
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		conn, dialErr := net.DialTimeout("unix", path, timeout)
+		if dialErr == nil {
+			conn.Close()
+			return nil, ErrRunning
+		}
+		// Nobody answers, so the socket is stale
+		if removeErr := os.Remove(path); removeErr != nil && !os.IsNotExist(removeErr) {
+			return nil, fmt.Errorf("failed to claim %s: %w", path, err)
+		}
+		if listener, err = net.Listen("unix", path); err != nil {
+			return nil, fmt.Errorf("failed to claim %s: %w", path, err)
+		}
+	}
+	return &Instance{listener: listener}, nil
+}
+
+// Serve calls handle, in the background, with each message forwarded by a
+// later launch, replying with its error. It returns at once.
+func (i *Instance) Serve(handle func(message string) error) {
+	go func() {
+		for {
+			conn, err := i.listener.Accept()
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			if err != nil {
+				log.Printf("Instance socket stopped: %v", err)
+				return
+			}
+			go i.answer(conn, handle)
+		}
+	}()
+}
+
+// answer reads one message from conn and replies with handle's result
+func (i *Instance) answer(conn net.Conn, handle func(message string) error) {
+	defer conn.Close()
+	defer logging.Recover("instance message", nil)
+	conn.SetDeadline(time.Now().Add(timeout))
+	message, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		// Acquire checking whether this instance runs
+		return
+	}
+	reply := "ok"
+	if err := handle(strings.TrimSuffix(message, "\n")); err != nil {
+		reply = "error: " + err.Error()
+	}
+	fmt.Fprintln(conn, reply)
+}
+
+// Close gives up the data directory, removing the socket
+func (i *Instance) Close() error {
+	return i.listener.Close()
+}
+
+// Forward hands message to the running instance and returns its error, if any
+func Forward(message string) error {
+	if strings.Contains(message, "\n") {
+		return errors.New("message must be a single line")
+	}
+	path, err := socketPath()
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("unix", path, timeout)
+	if err != nil {
+		return fmt.Errorf("failed to reach the running instance: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := fmt.Fprintln(conn, message); err != nil {
+		return fmt.Errorf("failed to reach the running instance: %w", err)
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("no answer from the running instance: %w", err)
+	}
+	reply = strings.TrimSuffix(reply, "\n")
+	if message, ok := strings.CutPrefix(reply, "error: "); ok {
+		return errors.New(message)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package instance
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+// acquire makes the test the running instance of a data directory of its own
+func acquire(t *testing.T) *Instance {
+	t.Helper()
+	t.Setenv(config.HomeEnv, t.TempDir())
+	i, err := Acquire()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { i.Close() })
+	return i
+}
+
+func TestSecondInstance(t *testing.T) {
+	acquire(t)
+	if _, err := Acquire(); !errors.Is(err, ErrRunning) {
+		t.Errorf("second Acquire() = %v, want %v", err, ErrRunning)
+	}
+}
+
+func TestForward(t *testing.T) {
+	i := acquire(t)
+	received := make(chan string, 2)
+	i.Serve(func(message string) error {
+		received <- message
+		if message == "bad" {
+			return errors.New("unsupported link")
+		}
+		return nil
+	})
+
+	tests := []struct {
+		message string
+		wantErr string
+	}{
+		{"timetracker://start?task=7", ""},
+		{"", ""}, // Launched without a link
+		{"bad", "unsupported link"},
+	}
+	for _, tt := range tests {
+		err := Forward(tt.message)
+		if (err == nil) != (tt.wantErr == "") || (err != nil && err.Error() != tt.wantErr) {
+			t.Errorf("Forward(%q) = %v, want %q", tt.message, err, tt.wantErr)
+		}
+		if got := <-received; got != tt.message {
+			t.Errorf("received %q, want %q", got, tt.message)
+		}
+	}
+}
+
+func TestStaleSocket(t *testing.T) {
+	i := acquire(t)
+	// As if the instance had crashed, leaving its socket behind
+	i.listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	i.Close()
+
+	again, err := Acquire()
+	if err != nil {
+		t.Fatalf("Acquire() after a crash = %v", err)
+	}
+	again.Close()
+}
+
+func TestForwardWithoutInstance(t *testing.T) {
+	t.Setenv(config.HomeEnv, t.TempDir())
+	if err := Forward("timetracker://start?task=7"); err == nil {
+		t.Error("Forward() succeeded with no running instance")
+	}
+}
@@ -0,0 +1,93 @@
+package localapi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// TaskTotal is the time tracked on one task within a period
+type TaskTotal struct {
+	Task           string `json:"task"`
+	Sessions       int64  `json:"sessions"`
+	TrackedSeconds int64  `json:"tracked_seconds"`
+	Local          bool   `json:"local"` // A local-only task, unknown to the server
+}
+
+// Metrics is returned by GET /metrics
+type Metrics struct {
+	Status Status      `json:"status"`
+	Today  []TaskTotal `json:"today"`
+	Week   []TaskTotal `json:"week"`
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	metrics, err := s.controller.Metrics()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		writeJSON(w, http.StatusOK, metrics)
+	case "prometheus":
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.WriteHeader(http.StatusOK)
+		if err := writePrometheus(w, metrics); err != nil {
+			log.Printf("Failed to write local API response: %v", err)
+		}
+	default:
+		writeError(w, http.StatusBadRequest, errors.New(`format must be "json" or "prometheus"`))
+	}
+}
+
+// writePrometheus writes metrics in the Prometheus text exposition format
+func writePrometheus(w io.Writer, m Metrics) error {
+	var b strings.Builder
+	b.WriteString("# HELP timetracker_tracking Whether a session is being tracked.\n")
+	b.WriteString("# TYPE timetracker_tracking gauge\n")
+	tracking, task := 0, ""
+	if m.Status.Tracking {
+		tracking = 1
+	}
+	if m.Status.Task != nil {
+		task = m.Status.Task.Name
+	}
+	fmt.Fprintf(&b, "timetracker_tracking{task=\"%s\"} %d\n", escapeLabel(task), tracking)
+	b.WriteString("# HELP timetracker_session_elapsed_seconds Tracked time of the current session.\n")
+	b.WriteString("# TYPE timetracker_session_elapsed_seconds gauge\n")
+	fmt.Fprintf(&b, "timetracker_session_elapsed_seconds %d\n", m.Status.ElapsedSeconds)
+
+	periods := []struct {
+		name   string
+		totals []TaskTotal
+	}{{"today", m.Today}, {"week", m.Week}}
+	b.WriteString("# HELP timetracker_tracked_seconds Time tracked per task in the period.\n")
+	b.WriteString("# TYPE timetracker_tracked_seconds gauge\n")
+	for _, p := range periods {
+		for _, t := range p.totals {
+			fmt.Fprintf(&b, "timetracker_tracked_seconds{period=\"%s\",task=\"%s\"} %d\n", p.name, escapeLabel(t.Task), t.TrackedSeconds)
+		}
+	}
+	b.WriteString("# HELP timetracker_sessions Sessions per task in the period.\n")
+	b.WriteString("# TYPE timetracker_sessions gauge\n")
+	for _, p := range periods {
+		for _, t := range p.totals {
+			fmt.Fprintf(&b, "timetracker_sessions{period=\"%s\",task=\"%s\"} %d\n", p.name, escapeLabel(t.Task), t.Sessions)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeLabel escapes a Prometheus label value
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
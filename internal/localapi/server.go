@@ -0,0 +1,188 @@
+// Package localapi provides an opt-in HTTP API, bound to localhost only, that
+// lets editors and shell scripts drive the tracker.
+//
+// Every request must carry the local token from settings as
+// "Authorization: Bearer <token>". Endpoints:
+//
+//	GET  /tasks   -> [Task, ...] (same JSON shape as the backend's tasks)
+//	GET  /status  -> Status
+//	POST /start   <- {"task_id": 123}  -> Status
+//	POST /stop    -> Status
+//	GET  /metrics -> Metrics, or Prometheus text with ?format=prometheus
+//
+// Status is {"tracking": bool, "task": Task|null, "elapsed_seconds": int}.
+// Metrics is {"status": Status, "today": [TaskTotal, ...], "week": [TaskTotal, ...]},
+// where TaskTotal is {"task": name, "sessions": int, "tracked_seconds": int, "local": bool}.
+// Today and the week (starting Monday) are in the configured display time zone.
+// Errors are returned as {"error": "message"} with a non-2xx status code.
+package localapi
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// Status describes the current tracking state
+type Status struct {
+	Tracking       bool        `json:"tracking"`
+	Task           *types.Task `json:"task"`
+	ElapsedSeconds int         `json:"elapsed_seconds"`
+}
+
+// StartRequest is the body accepted by POST /start
+type StartRequest struct {
+	TaskID int `json:"task_id"`
+}
+
+// Controller is implemented by whatever owns the tracking session
+type Controller interface {
+	Tasks() ([]types.Task, error)
+	Start(taskID int) error
+	Stop() error
+	Status() Status
+	Metrics() (Metrics, error)
+}
+
+// Server serves the local API
+type Server struct {
+	controller Controller
+	token      string
+	httpServer *http.Server
+}
+
+// NewServer creates a server listening on 127.0.0.1:port
+func NewServer(controller Controller, port int, token string) *Server {
+	s := &Server{
+		controller: controller,
+		token:      token,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tasks", s.handleTasks)
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/start", s.handleStart)
+	mux.HandleFunc("/stop", s.handleStop)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	s.httpServer = &http.Server{
+		Addr:              fmt.Sprintf("127.0.0.1:%d", port),
+		Handler:           s.authenticate(mux),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s
+}
+
+// Start begins listening in the background
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.httpServer.Addr, err)
+	}
+	go func() {
+		if err := s.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Local API server stopped: %v", err)
+		}
+	}()
+	log.Printf("Local API listening on %s", s.httpServer.Addr)
+	return nil
+}
+
+// Shutdown stops the server
+func (s *Server) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return s.httpServer.Shutdown(ctx)
+}
+
+// authenticate rejects requests without the expected bearer token
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if s.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("invalid or missing token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	tasks, err := s.controller.Tasks()
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	writeJSON(w, http.StatusOK, s.controller.Status())
+}
+
+func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	var req StartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.TaskID == 0 {
+		writeError(w, http.StatusBadRequest, errors.New(`body must be {"task_id": <id>}`))
+		return
+	}
+	if err := s.controller.Start(req.TaskID); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.controller.Status())
+}
+
+func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	if err := s.controller.Stop(); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.controller.Status())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to write local API response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// GenerateToken returns a random token suitable for authenticating local clients
+func GenerateToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,109 @@
+package localapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/time-tracker/v2/internal/types"
+)
+
+const testToken = "local-token"
+
+// fakeController serves fixed tasks and metrics
+type fakeController struct {
+	metrics    Metrics
+	metricsErr error
+}
+
+func (c *fakeController) Tasks() ([]types.Task, error) { return nil, nil }
+func (c *fakeController) Start(taskID int) error       { return nil }
+func (c *fakeController) Stop() error                  { return nil }
+func (c *fakeController) Status() Status               { return c.metrics.Status }
+func (c *fakeController) Metrics() (Metrics, error)    { return c.metrics, c.metricsErr }
+
+// serve sends a request with testToken to a server for controller and returns the response
+func serve(t *testing.T, controller Controller, method, target string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rec := httptest.NewRecorder()
+	NewServer(controller, 0, testToken).httpServer.Handler.ServeHTTP(rec, req)
+	return rec.Result()
+}
+
+func TestMetrics(t *testing.T) {
+	controller := &fakeController{metrics: Metrics{
+		Status: Status{Tracking: true, Task: &types.Task{ID: 7, Name: `Docs "v2"`}, ElapsedSeconds: 90},
+		Today:  []TaskTotal{{Task: `Docs "v2"`, Sessions: 2, TrackedSeconds: 600}},
+		Week:   []TaskTotal{{Task: `Docs "v2"`, Sessions: 5, TrackedSeconds: 3600}, {Task: "Errands", Sessions: 1, TrackedSeconds: 60, Local: true}},
+	}}
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		wantStatus  int
+		wantType    string
+		wantContain []string
+	}{
+		{"JSON by default", "GET", "/metrics", http.StatusOK, "application/json", []string{`"tracked_seconds":3600`, `"local":true`}},
+		{"JSON", "GET", "/metrics?format=json", http.StatusOK, "application/json", []string{`"elapsed_seconds":90`}},
+		{"Prometheus", "GET", "/metrics?format=prometheus", http.StatusOK, "text/plain; version=0.0.4", []string{
+			`timetracker_tracking{task="Docs \"v2\""} 1`,
+			"timetracker_session_elapsed_seconds 90",
+			`timetracker_tracked_seconds{period="today",task="Docs \"v2\""} 600`,
+			`timetracker_tracked_seconds{period="week",task="Errands"} 60`,
+			`timetracker_sessions{period="week",task="Docs \"v2\""} 5`,
+		}},
+		{"unknown format", "GET", "/metrics?format=xml", http.StatusBadRequest, "application/json", []string{`"error"`}},
+		{"wrong method", "POST", "/metrics", http.StatusMethodNotAllowed, "application/json", []string{`"error"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := serve(t, controller, tt.method, tt.target)
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := resp.Header.Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			for _, want := range tt.wantContain {
+				if !strings.Contains(string(body), want) {
+					t.Errorf("body doesn't contain %s:\n%s", want, body)
+				}
+			}
+		})
+	}
+}
+
+func TestMetricsError(t *testing.T) {
+	resp := serve(t, &fakeController{metricsErr: errors.New("database is locked")}, "GET", "/metrics")
+	var body map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusInternalServerError || body["error"] != "database is locked" {
+		t.Errorf("response = %d %v, want 500 with the error", resp.StatusCode, body)
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	tests := map[string]string{
+		"plain":      "plain",
+		`say "hi"`:   `say \"hi\"`,
+		`back\slash`: `back\\slash`,
+		"two\nlines": `two\nlines`,
+	}
+	for value, want := range tests {
+		if got := escapeLabel(value); got != want {
+			t.Errorf("escapeLabel(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+const logFileName = "app.log"
+
+// RotatingWriter is an io.Writer that writes to a file and rotates it once
+// it grows beyond maxBytes, keeping at most maxBackups old files
+// (app.log.1 being the most recent).
+type RotatingWriter struct {
+	mu         sync.Mutex
+	path       string
+	maxBytes   int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewRotatingWriter opens (or creates) the log file at path for appending
+func NewRotatingWriter(path string, maxBytes int64, maxBackups int) (*RotatingWriter, error) {
+	w := &RotatingWriter{
+		path:       path,
+		maxBytes:   maxBytes,
+		maxBackups: maxBackups,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotatingWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file %s: %w", w.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file %s: %w", w.path, err)
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// rotate shifts app.log.N -> app.log.N+1, dropping the oldest, and reopens a fresh file
+func (w *RotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	if w.maxBackups <= 0 {
+		os.Remove(w.path)
+	} else {
+		os.Remove(fmt.Sprintf("%s.%d", w.path, w.maxBackups))
+		for i := w.maxBackups - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1))
+		}
+		os.Rename(w.path, w.path+".1")
+	}
+	return w.open()
+}
+
+// Write implements io.Writer
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.maxBytes > 0 && w.size+int64(len(p)) > w.maxBytes && w.size > 0 {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close closes the underlying log file
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// Dir returns the directory holding the log files, creating it if needed
+func Dir() (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(dataDir, "logs")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create log directory %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// Path returns the path of the current log file
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, logFileName), nil
+}
+
+// Setup redirects the standard logger to a rotating log file sized according
+// to the current settings, and to stderr when there is one. The returned
+// closer should be closed on exit.
+func Setup() (io.Closer, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	settings := config.Current()
+	w, err := NewRotatingWriter(
+		filepath.Join(dir, logFileName),
+		int64(settings.LogMaxSizeMB)*1024*1024,
+		settings.LogMaxBackups,
+	)
+	if err != nil {
+		return nil, err
+	}
+	log.SetOutput(logOutput(w, os.Stderr))
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	return w, nil
+}
+
+// logOutput writes to file, and to stderr if it is a valid handle. Windows
+// GUI builds (-H=windowsgui) have none, and a failing writer would stop
+// io.MultiWriter before the file, so stderr errors are ignored.
+func logOutput(file io.Writer, stderr *os.File) io.Writer {
+	if stderr == nil {
+		return file
+	}
+	if _, err := stderr.Stat(); err != nil {
+		return file
+	}
+	return io.MultiWriter(file, bestEffortWriter{stderr})
+}
+
+// bestEffortWriter reports every write as successful, whatever w returns
+type bestEffortWriter struct {
+	w io.Writer
+}
+
+func (b bestEffortWriter) Write(p []byte) (int, error) {
+	b.w.Write(p)
+	return len(p), nil
+}
+
+// LogPanic should be deferred at the top of main. It records a panic and its
+// stack trace in the log before letting the crash continue.
+func LogPanic() {
+	if r := recover(); r != nil {
+		log.Printf("PANIC: %v\n%s", r, debug.Stack())
+		panic(r)
+	}
+}
+
+// Recover should be deferred at the top of background goroutines. It logs a
+// panic with its stack trace instead of letting it crash the app, then calls
+// onPanic (if non-nil) so the caller can restore a consistent state.
+func Recover(name string, onPanic func()) {
+	if r := recover(); r != nil {
+		log.Printf("PANIC in %s: %v\n%s", name, r, debug.Stack())
+		if onPanic != nil {
+			onPanic()
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingWriterRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), logFileName)
+	w, err := NewRotatingWriter(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path, "third\n"},
+		{path + ".1", "second\n"},
+		{path + ".2", "first\n"},
+	}
+	for _, tt := range tests {
+		got, err := os.ReadFile(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s = %q, want %q", filepath.Base(tt.path), got, tt.want)
+		}
+	}
+}
+
+func TestLogOutputReachesFileWithoutStderr(t *testing.T) {
+	closed, err := os.CreateTemp(t.TempDir(), "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close() // Writes now fail, like a GUI build's missing console
+
+	tests := []struct {
+		name   string
+		stderr *os.File
+	}{
+		{"no stderr", nil},
+		{"invalid stderr", closed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var file strings.Builder
+			if _, err := logOutput(&file, tt.stderr).Write([]byte("logged\n")); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if file.String() != "logged\n" {
+				t.Errorf("file got %q, want %q", file.String(), "logged\n")
+			}
+		})
+	}
+}
+
+func TestLogOutputIgnoresStderrErrors(t *testing.T) {
+	stderr, err := os.CreateTemp(t.TempDir(), "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stderr.Close()
+
+	var file strings.Builder
+	out := logOutput(&file, stderr)
+	stderr.Close() // Valid when checked, failing by the time it is written
+	if _, err := out.Write([]byte("logged\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if file.String() != "logged\n" {
+		t.Errorf("file got %q, want %q", file.String(), "logged\n")
+	}
+}
+
+func TestRecover(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      func()
+		wantPanic bool
+	}{
+		{"panic", func() { panic("boom") }, true},
+		{"no panic", func() {}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log.SetOutput(&buf)
+			defer log.SetOutput(os.Stderr)
+
+			called := false
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				defer Recover("test goroutine", func() { called = true })
+				tt.body()
+			}()
+			<-done
+
+			if called != tt.wantPanic {
+				t.Errorf("onPanic called = %v, want %v", called, tt.wantPanic)
+			}
+			if logged := strings.Contains(buf.String(), "PANIC in test goroutine: boom"); logged != tt.wantPanic {
+				t.Errorf("panic logged = %v, want %v: %q", logged, tt.wantPanic, buf.String())
+			}
+		})
+	}
+}
+
+func TestRecoverWithoutCallback(t *testing.T) {
+	log.SetOutput(&bytes.Buffer{})
+	defer log.SetOutput(os.Stderr)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer Recover("test goroutine", nil)
+		panic("boom")
+	}()
+	<-done // Reaching here means the panic didn't crash the test binary
+}
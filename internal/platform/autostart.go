@@ -0,0 +1,62 @@
+// Package platform holds the OS-specific integration, such as launching the
+// app at login. Each OS implements the unexported hooks in its own file.
+package platform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// appName is used for autostart entries and labels
+const appName = "Time Tracker"
+
+// AutostartEnabled reports whether the app is registered to start at login
+func AutostartEnabled() (bool, error) {
+	path, err := registeredPath()
+	if err != nil {
+		return false, err
+	}
+	return path != "", nil
+}
+
+// SetAutostart registers the running binary to start at login, or removes the registration
+func SetAutostart(enabled bool) error {
+	if !enabled {
+		return disableAutostart()
+	}
+	exe, err := executablePath()
+	if err != nil {
+		return err
+	}
+	return enableAutostart(exe)
+}
+
+// SyncAutostart points an existing autostart entry at the running binary,
+// so the app still launches at login after it has been moved or updated
+func SyncAutostart() error {
+	path, err := registeredPath()
+	if err != nil || path == "" {
+		return err
+	}
+	exe, err := executablePath()
+	if err != nil {
+		return err
+	}
+	if path == exe {
+		return nil
+	}
+	return enableAutostart(exe)
+}
+
+// executablePath returns the resolved absolute path of the running binary
+func executablePath() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return exe, nil
+}
@@ -0,0 +1,84 @@
+package platform
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const launchAgentLabel = "com.time-tracker.app"
+
+var programPattern = regexp.MustCompile(`<key>ProgramArguments</key>\s*<array>\s*<string>([^<]*)</string>`)
+
+// launchAgentFile returns the LaunchAgent plist path
+func launchAgentFile() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, "Library", "LaunchAgents", launchAgentLabel+".plist"), nil
+}
+
+func enableAutostart(exe string) error {
+	path, err := launchAgentFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create LaunchAgents directory: %w", err)
+	}
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(exe))
+	plist := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>%s</string>
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+</dict>
+</plist>
+`, launchAgentLabel, escaped.String())
+	if err := os.WriteFile(path, []byte(plist), 0644); err != nil {
+		return fmt.Errorf("failed to write LaunchAgent %s: %w", path, err)
+	}
+	return nil
+}
+
+func disableAutostart() error {
+	path, err := launchAgentFile()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove LaunchAgent %s: %w", path, err)
+	}
+	return nil
+}
+
+func registeredPath() (string, error) {
+	path, err := launchAgentFile()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read LaunchAgent %s: %w", path, err)
+	}
+	match := programPattern.FindSubmatch(data)
+	if match == nil {
+		return "", nil
+	}
+	r := strings.NewReplacer("&lt;", "<", "&gt;", ">", "&quot;", `"`, "&#34;", `"`, "&#39;", "'", "&#x9;", "\t", "&#xA;", "\n", "&#xD;", "\r", "&amp;", "&")
+	return r.Replace(string(match[1])), nil
+}
@@ -0,0 +1,82 @@
+package platform
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// autostartFile returns the XDG autostart .desktop entry path
+func autostartFile() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(configDir, "autostart", "time-tracker.desktop"), nil
+}
+
+func enableAutostart(exe string) error {
+	path, err := autostartFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create autostart directory: %w", err)
+	}
+	entry := fmt.Sprintf("[Desktop Entry]\nType=Application\nName=%s\nExec=%s\nX-GNOME-Autostart-enabled=true\n",
+		appName, quoteExec(exe))
+	if err := os.WriteFile(path, []byte(entry), 0644); err != nil {
+		return fmt.Errorf("failed to write autostart entry %s: %w", path, err)
+	}
+	return nil
+}
+
+func disableAutostart() error {
+	path, err := autostartFile()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove autostart entry %s: %w", path, err)
+	}
+	return nil
+}
+
+func registeredPath() (string, error) {
+	path, err := autostartFile()
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read autostart entry %s: %w", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if exec, ok := strings.CutPrefix(scanner.Text(), "Exec="); ok {
+			return unquoteExec(exec), nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// quoteExec quotes a path for a .desktop Exec key
+func quoteExec(path string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", `$`, `\$`)
+	return `"` + r.Replace(path) + `"`
+}
+
+// unquoteExec reverses quoteExec, returning unquoted values unchanged
+func unquoteExec(exec string) string {
+	if len(exec) < 2 || exec[0] != '"' || exec[len(exec)-1] != '"' {
+		return exec
+	}
+	r := strings.NewReplacer(`\\`, `\`, `\"`, `"`, "\\`", "`", `\$`, `$`)
+	return r.Replace(exec[1 : len(exec)-1])
+}
@@ -0,0 +1,17 @@
+//go:build !linux && !darwin && !windows
+
+package platform
+
+import "errors"
+
+func enableAutostart(exe string) error {
+	return errors.New("starting on login is not supported on this platform")
+}
+
+func disableAutostart() error {
+	return nil
+}
+
+func registeredPath() (string, error) {
+	return "", nil
+}
@@ -0,0 +1,53 @@
+package platform
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+const runKeyPath = `Software\Microsoft\Windows\CurrentVersion\Run`
+
+func enableAutostart(exe string) error {
+	key, _, err := registry.CreateKey(registry.CURRENT_USER, runKeyPath, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("failed to open Run registry key: %w", err)
+	}
+	defer key.Close()
+	if err := key.SetStringValue(appName, `"`+exe+`"`); err != nil {
+		return fmt.Errorf("failed to write Run registry value: %w", err)
+	}
+	return nil
+}
+
+func disableAutostart() error {
+	key, err := registry.OpenKey(registry.CURRENT_USER, runKeyPath, registry.SET_VALUE)
+	if err == registry.ErrNotExist {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to open Run registry key: %w", err)
+	}
+	defer key.Close()
+	if err := key.DeleteValue(appName); err != nil && err != registry.ErrNotExist {
+		return fmt.Errorf("failed to delete Run registry value: %w", err)
+	}
+	return nil
+}
+
+func registeredPath() (string, error) {
+	key, err := registry.OpenKey(registry.CURRENT_USER, runKeyPath, registry.QUERY_VALUE)
+	if err == registry.ErrNotExist {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to open Run registry key: %w", err)
+	}
+	defer key.Close()
+	value, _, err := key.GetStringValue(appName)
+	if err == registry.ErrNotExist {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read Run registry value: %w", err)
+	}
+	return strings.Trim(value, `"`), nil
+}
@@ -0,0 +1,20 @@
+package platform
+
+import "errors"
+
+// URLScheme is the custom URL scheme the app handles, e.g. timetracker://start?task=123
+const URLScheme = "timetracker"
+
+// ErrURLSchemeUnsupported is returned by RegisterURLScheme where the scheme
+// can't be registered at runtime
+var ErrURLSchemeUnsupported = errors.New("registering the URL scheme is not supported on this platform")
+
+// RegisterURLScheme registers the running binary as the handler for URLScheme
+// links, so opening one launches the app with the URL as its argument
+func RegisterURLScheme() error {
+	exe, err := executablePath()
+	if err != nil {
+		return err
+	}
+	return registerURLScheme(exe)
+}
@@ -0,0 +1,38 @@
+package platform
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// urlSchemeDesktopFile is the .desktop entry that handles URLScheme links
+const urlSchemeDesktopFile = "time-tracker-url.desktop"
+
+func registerURLScheme(exe string) error {
+	dataDir := os.Getenv("XDG_DATA_HOME")
+	if dataDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		dataDir = filepath.Join(homeDir, ".local", "share")
+	}
+	path := filepath.Join(dataDir, "applications", urlSchemeDesktopFile)
+	entry := fmt.Sprintf("[Desktop Entry]\nType=Application\nName=%s\nExec=%s %%u\nMimeType=x-scheme-handler/%s;\nNoDisplay=true\n",
+		appName, quoteExec(exe), URLScheme)
+	if existing, err := os.ReadFile(path); err == nil && string(existing) == entry {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create applications directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(entry), 0644); err != nil {
+		return fmt.Errorf("failed to write URL handler entry %s: %w", path, err)
+	}
+	if out, err := exec.Command("xdg-mime", "default", urlSchemeDesktopFile, "x-scheme-handler/"+URLScheme).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to register URL handler with xdg-mime: %w: %s", err, out)
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !windows
+
+package platform
+
+// On macOS the scheme is declared by CFBundleURLTypes in the app bundle's
+// Info.plist, and links are delivered as Apple events rather than arguments
+func registerURLScheme(exe string) error {
+	return ErrURLSchemeUnsupported
+}
@@ -0,0 +1,32 @@
+package platform
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+func registerURLScheme(exe string) error {
+	keyPath := `Software\Classes\` + URLScheme
+	key, _, err := registry.CreateKey(registry.CURRENT_USER, keyPath, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("failed to open URL scheme registry key: %w", err)
+	}
+	defer key.Close()
+	if err := key.SetStringValue("", "URL:"+appName); err != nil {
+		return fmt.Errorf("failed to write URL scheme registry value: %w", err)
+	}
+	if err := key.SetStringValue("URL Protocol", ""); err != nil {
+		return fmt.Errorf("failed to write URL scheme registry value: %w", err)
+	}
+
+	command, _, err := registry.CreateKey(registry.CURRENT_USER, keyPath+`\shell\open\command`, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("failed to open URL scheme command registry key: %w", err)
+	}
+	defer command.Close()
+	if err := command.SetStringValue("", `"`+exe+`" "%1"`); err != nil {
+		return fmt.Errorf("failed to write URL scheme command registry value: %w", err)
+	}
+	return nil
+}
@@ -49,6 +49,9 @@ type Task struct {
 	Project     Project `json:"project"`
 	Description *string `json:"description,omitempty"`
 	Status      *string `json:"status,omitempty"`
+	// Local marks a task created in the app rather than on the server; it has
+	// a negative ID and never gets a work report
+	Local bool `json:"local,omitempty"`
 }
 
 // WorkReport represents a work report based on task_types.py WorkReport dataclass
@@ -0,0 +1,38 @@
+// Package version identifies the running build. Release builds set the
+// variables with -ldflags, e.g.
+//
+//	go build -ldflags "-X github.com/time-tracker/v2/internal/version.Version=1.4.0 -X github.com/time-tracker/v2/internal/version.Commit=$(git rev-parse --short HEAD)"
+package version
+
+import "runtime/debug"
+
+var (
+	// Version is the release version, "dev" for local builds
+	Version = "dev"
+	// Commit is the source revision the binary was built from
+	Commit = ""
+)
+
+// BuildCommit returns Commit, falling back to the VCS revision Go embeds in
+// builds made from a checkout ("unknown" if neither is available)
+func BuildCommit() string {
+	if Commit != "" {
+		return Commit
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" && s.Value != "" {
+				if len(s.Value) > 12 {
+					return s.Value[:12]
+				}
+				return s.Value
+			}
+		}
+	}
+	return "unknown"
+}
+
+// String describes the build, e.g. "1.4.0 (a1b2c3d)"
+func String() string {
+	return Version + " (" + BuildCommit() + ")"
+}
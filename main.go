@@ -1,81 +1,94 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
+	// Bundled so display time zones resolve on systems without a zone database (Windows)
+	_ "time/tzdata"
 
-	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"github.com/time-tracker/v2/assets"
+	"github.com/time-tracker/v2/cli"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/instance"
+	"github.com/time-tracker/v2/internal/logging"
+	"github.com/time-tracker/v2/internal/platform"
+	"github.com/time-tracker/v2/internal/version"
 	"github.com/time-tracker/v2/services"
 	"github.com/time-tracker/v2/ui"
 )
 
-const tokenFileName = ".token"
+func main() {
+	cliMode := flag.Bool("cli", false, "run a command without the GUI (see -cli help)")
+	demoMode := flag.Bool("demo", false, "run without a backend, using sample tasks (same as "+services.DemoEnv+"=1)")
+	homeDir := flag.String("home", "", "keep all data in this directory instead of ~/.time-tracker (same as "+config.HomeEnv+")")
+	showVersion := flag.Bool("version", false, "print the version and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(version.String())
+		return
+	}
+	// Before anything locates the data directory, logging included
+	if *homeDir != "" {
+		os.Setenv(config.HomeEnv, *homeDir)
+	}
 
-// getTokenFilePath returns the path to the token file within a dedicated config directory.
-func getTokenFilePath() (string, error) {
-	homeDir, err := os.UserHomeDir()
+	// Set up file logging before anything else so startup problems are captured
+	logCloser, err := logging.Setup()
 	if err != nil {
-		return "", fmt.Errorf("failed to get user home directory: %w", err)
+		log.Printf("Failed to set up file logging: %v", err)
+	} else {
+		defer logCloser.Close()
 	}
-	configDir := filepath.Join(homeDir, ".time-tracker")
-	// Ensure the directory exists
-	if err := os.MkdirAll(configDir, 0700); err != nil {
-		return "", fmt.Errorf("failed to create config directory %s: %w", configDir, err)
+	defer logging.LogPanic()
+
+	log.Printf("Time Tracker %s", version.String())
+	if dir, err := config.DataDir(); err == nil {
+		log.Printf("Data directory: %s", dir)
+	}
+	if *demoMode {
+		os.Setenv(services.DemoEnv, "1")
+	}
+	if services.DemoMode() {
+		log.Println("Demo mode: no requests are sent to the server")
+	}
+	if *cliMode {
+		code := cli.Run(flag.Args())
+		if logCloser != nil {
+			logCloser.Close()
+		}
+		os.Exit(code)
 	}
-	return filepath.Join(configDir, tokenFileName), nil
-}
 
-// checkTokenExists checks if the token file exists.
-func checkTokenExists() bool {
-	tokenPath, err := getTokenFilePath()
-	if err != nil {
-		log.Printf("Error getting token file path: %v", err)
-		return false // Assume no token if path fails
-	}
-	_, err = os.Stat(tokenPath)
-	if os.IsNotExist(err) {
-		log.Println("Token file does not exist.")
-		return false
-	} else if err != nil {
-		log.Printf("Error checking token file %s: %v", tokenPath, err)
-		return false // Assume no token on error
-	}
-	log.Println("Token file found.")
-	return true
-}
+	// Opening a timetracker:// link passes it as an argument
+	rawLink, _ := ui.FindDeepLink(flag.Args())
 
-// saveToken saves the token to the designated file.
-func saveToken(token string) error {
-	tokenPath, err := getTokenFilePath()
-	if err != nil {
-		return fmt.Errorf("failed to get token file path for saving: %w", err)
+	// Only one instance may run per data directory; a later launch hands its
+	// link over to the running one and exits
+	inst, err := instance.Acquire()
+	if errors.Is(err, instance.ErrRunning) {
+		forwardLaunch(rawLink)
+		return
 	}
-	// Write the token, overwriting the file if it exists.
-	// Set permissions to be readable/writable only by the user.
-	err = os.WriteFile(tokenPath, []byte(token), 0600)
 	if err != nil {
-		return fmt.Errorf("failed to write token file %s: %w", tokenPath, err)
+		log.Printf("Failed to check for a running instance: %v", err)
+	} else {
+		defer inst.Close()
 	}
-	log.Printf("Token saved successfully to %s", tokenPath)
-	return nil
-}
 
-// showTaskWindow creates and displays the main task window.
-func showTaskWindow(a fyne.App) {
-	log.Println("Showing Task Window...")
-	// We pass the app instance to the task window constructor
-	taskUI := ui.NewTaskWindow(a)
-	// The Run method of TaskWindowUI likely calls a.Run() or manages its own window showing.
-	// If NewTaskWindow just creates the window, we need to show it.
-	// Let's assume NewTaskWindow prepares it and we just need to show the window.
-	taskUI.Win.Show()
-}
+	var deepLink *ui.DeepLink
+	if rawLink != "" {
+		link, err := ui.ParseDeepLink(rawLink)
+		if err != nil {
+			log.Printf("Ignoring link: %v", err)
+		} else {
+			deepLink = link
+		}
+	}
 
-func main() {
 	// Initialize the Fyne application
 	myApp := app.New()
 
@@ -87,44 +100,35 @@ func main() {
 		myApp.SetIcon(iconResource)
 	}
 
-	// Initialize the authentication service
-	// Assuming NewAuthService() exists and is correctly implemented
-	authSvc := services.NewAuthService() // You might need to pass config here
-
-	// Check if the token exists
-	if checkTokenExists() {
-		// Token exists, show the main task window directly
-		log.Println("Token exists, launching main application.")
-		showTaskWindow(myApp)
-	} else {
-		// Token does not exist, show the login window
-		log.Println("Token does not exist, launching login window.")
+	// Keep the login entry valid if the binary has been moved or updated
+	if err := platform.SyncAutostart(); err != nil {
+		log.Printf("Failed to update autostart entry: %v", err)
+	}
+	if err := platform.RegisterURLScheme(); err != nil && !errors.Is(err, platform.ErrURLSchemeUnsupported) {
+		log.Printf("Failed to register %s:// links: %v", platform.URLScheme, err)
+	}
 
-		// Define the callback function for successful login
-		onLoginSuccess := func(token string) {
-			log.Println("Login successful, proceeding to main application.")
-			// Save the received token
-			err := saveToken(token)
-			if err != nil {
-				log.Printf("FATAL: Failed to save token: %v", err)
-				// Decide how to handle this - maybe show an error dialog?
-				// For now, we log fatally, but a real app might retry or show UI error.
-				// We might not want to proceed without saving the token.
-				// However, for now, let's proceed to show the task window anyway.
-				// Consider adding dialog.ShowError(err, currentWindow) if possible.
-			}
-			// Show the main task window
-			showTaskWindow(myApp)
-		}
+	tokenStore, err := services.NewTokenStore()
+	if err != nil {
+		log.Panicf("Failed to locate token file: %v", err)
+	}
 
-		// Create and show the login window, passing the app, service, and success callback
-		// The login window will close itself upon successful login via the callback.
-		loginWin := ui.NewLoginWindow(myApp, authSvc, onLoginSuccess)
-		loginWin.Show()
+	// The coordinator decides between the login and task windows, and switches
+	// between them on logout, until the application exits
+	coordinator := ui.NewAppCoordinator(myApp, services.NewAuthService(), tokenStore)
+	coordinator.SetDeepLink(deepLink)
+	if inst != nil {
+		inst.Serve(coordinator.Relaunch)
 	}
+	coordinator.Run()
+}
 
-	// Start the Fyne application event loop.
-	// This will block until the application exits (e.g., user quits from tray or closes last window if not configured otherwise).
-	myApp.Run()
-	log.Println("Application has exited.")
+// forwardLaunch hands this launch's link, "" if it has none, to the running
+// instance, which brings its window forward
+func forwardLaunch(rawLink string) {
+	if err := instance.Forward(rawLink); err != nil {
+		log.Printf("Time Tracker is already running but didn't take over this launch: %v", err)
+		return
+	}
+	log.Println("Time Tracker is already running; handed this launch over to it")
 }
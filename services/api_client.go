@@ -2,69 +2,229 @@ package services
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+// ErrUnauthorized is returned when the server rejects the stored token
+var ErrUnauthorized = errors.New("unauthorized")
+
+// ErrInvalidCredentials is returned when the server rejects a login's email or password
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// ErrNetworkUnreachable wraps transport failures, such as DNS errors, refused
+// connections, proxy failures and timeouts, where no response was received
+var ErrNetworkUnreachable = errors.New("cannot reach the server")
+
+// Login requests time out sooner than the user would give up on the button,
+// and are retried at most once, only when no usable response was received
+const (
+	loginTimeout    = 15 * time.Second
+	loginAttempts   = 2
+	loginRetryDelay = time.Second
 )
 
 type ApiClient struct {
-	BaseURL string
-	Token   string
+	BaseURL    string
+	httpClient *http.Client
+	tokenStore *TokenStore
+
+	mu    sync.Mutex
+	token string                    // Reloaded from the token file by WatchToken
+	cache map[string]cachedResponse // GetConditional responses by endpoint, for the current token
 }
 
 func NewApiClient(baseURL string) *ApiClient {
-	homeDir, err := os.UserHomeDir()
+	return NewApiClientWithHTTPClient(baseURL, newHTTPClient(config.Current()))
+}
+
+// NewApiClientWithHTTPClient creates a client that sends every request through
+// httpClient, so tests can point it at an httptest server or a stub RoundTripper
+func NewApiClientWithHTTPClient(baseURL string, httpClient *http.Client) *ApiClient {
+	tokenStore, err := NewTokenStore()
 	if err != nil {
-		println("Unable to determine user home directory:", err)
-		return &ApiClient{}
+		log.Printf("Unable to locate token file: %v", err)
+		return &ApiClient{BaseURL: baseURL, httpClient: httpClient}
 	}
-	tokenPath := filepath.Join(homeDir, ".time-tracker", ".token")
-	token := ""
-	if data, err := os.ReadFile(tokenPath); err == nil {
-		token = string(data)
-	} else {
-		println("Token file not found. Please login again.")
+	token, err := tokenStore.Load()
+	if err != nil {
+		log.Println("Token file not found. Please login again.")
 	}
 
 	return &ApiClient{
-		BaseURL: baseURL,
-		Token:   token,
+		BaseURL:    baseURL,
+		token:      token,
+		httpClient: httpClient,
+		tokenStore: tokenStore,
 	}
 }
 
+// Token returns the token sent with requests
+func (c *ApiClient) Token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+// SetToken replaces the token sent with requests
+func (c *ApiClient) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if token != c.token {
+		c.cache = nil // Responses were for the previous user
+	}
+	c.token = token
+}
+
+// WatchToken reloads the token whenever the token file changes, e.g. after a
+// login in another instance, and clears it when the file is removed. onChange,
+// if not nil, is then called with the new token. Call stop to end watching.
+func (c *ApiClient) WatchToken(onChange func(token string)) (stop func(), err error) {
+	if c.tokenStore == nil {
+		return nil, errors.New("no token file to watch")
+	}
+	return c.tokenStore.Watch(func(token string) {
+		c.SetToken(token)
+		if onChange != nil {
+			onChange(token)
+		}
+	})
+}
+
+// newHTTPClient builds the client used for all backend requests. Proxies from
+// HTTP_PROXY/HTTPS_PROXY are honoured unless a proxy URL is set in settings.
+func newHTTPClient(settings config.Settings) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if settings.ProxyURL != "" {
+		proxyURL, err := url.Parse(settings.ProxyURL)
+		if err != nil {
+			log.Printf("Ignoring invalid proxy URL %q: %v", settings.ProxyURL, err)
+		} else {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+	if settings.InsecureSkipVerify {
+		log.Println("WARNING: TLS certificate verification is disabled")
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &http.Client{Transport: transport}
+}
+
+// Login posts the credentials in payload and stores the returned token. It
+// returns ErrInvalidCredentials if the server rejects them and wraps
+// ErrNetworkUnreachable if the server could not be reached.
 func (c *ApiClient) Login(payload map[string]interface{}) (map[string]interface{}, error) {
-	response, err := c.CallAPI("/api/login", "POST", payload)
+	var response map[string]interface{}
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		response, retry, err = c.loginOnce(payload)
+		if err == nil || !retry || attempt == loginAttempts {
+			break
+		}
+		log.Printf("Login attempt %d of %d failed, retrying in %s: %v", attempt, loginAttempts, loginRetryDelay, err)
+		time.Sleep(loginRetryDelay)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	if token, ok := response["token"].(string); ok {
-		c.Token = token
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return nil, errors.New("unable to determine user home directory")
+		c.SetToken(token)
+		if c.tokenStore != nil {
+			if err := c.tokenStore.Save(token); err != nil {
+				return nil, err
+			}
 		}
-		tokenDir := filepath.Join(homeDir, ".time-tracker")
-		os.MkdirAll(tokenDir, os.ModePerm)
-		tokenPath := filepath.Join(tokenDir, ".token")
-		os.WriteFile(tokenPath, []byte(token), os.ModePerm)
 	}
 
 	return response, nil
 }
 
+// loginOnce makes a single login request, reporting whether a failure is
+// worth retrying
+func (c *ApiClient) loginOnce(payload map[string]interface{}) (map[string]interface{}, bool, error) {
+	req, err := c.prepareRequest("POST", "/api/login", payload)
+	if err != nil {
+		return nil, false, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), loginTimeout)
+	defer cancel()
+
+	resp, err := c.do(req.WithContext(ctx))
+	if err != nil {
+		return nil, true, fmt.Errorf("%w: %w", ErrNetworkUnreachable, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, false, ErrInvalidCredentials
+	case resp.StatusCode >= 500:
+		return nil, true, newAPIError(resp)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, false, newAPIError(resp)
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		if ctx.Err() != nil {
+			return nil, true, fmt.Errorf("%w: %w", ErrNetworkUnreachable, err)
+		}
+		return nil, false, fmt.Errorf("invalid login response: %w", err)
+	}
+	return result, false, nil
+}
+
+// handleUnauthorized forgets the rejected token, in memory and on disk, and
+// returns the error callers should report
+func (c *ApiClient) handleUnauthorized() error {
+	log.Println("Unauthorized. Removing token file.")
+	c.SetToken("")
+	if c.tokenStore != nil {
+		if err := c.tokenStore.Remove(); err != nil {
+			log.Printf("Failed to remove token file: %v", err)
+		}
+	}
+	return ErrUnauthorized
+}
+
+// Ping checks that the backend is reachable with a lightweight authenticated
+// GET. Any HTTP response below 500 counts as reachable; unlike other calls a
+// rejected token is not forgotten here.
+func (c *ApiClient) Ping(ctx context.Context) error {
+	req, err := c.prepareRequest("GET", "/api/tasks/user", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return newAPIError(resp)
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
 // prepareRequest creates a new HTTP request with proper headers for JSON data
 func (c *ApiClient) prepareRequest(method, endpoint string, data map[string]interface{}) (*http.Request, error) {
-	url := c.BaseURL + endpoint
-
 	var body io.Reader
-	contentType := "application/json"
-
 	if data != nil {
 		jsonData, jsonErr := json.Marshal(data)
 		if jsonErr != nil {
@@ -72,21 +232,12 @@ func (c *ApiClient) prepareRequest(method, endpoint string, data map[string]inte
 		}
 		body = bytes.NewBuffer(jsonData)
 	}
-
-	req, err := http.NewRequest(method, url, body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	if c.Token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.Token)
-	}
-	req.Header.Set("Content-Type", contentType)
-
-	return req, nil
+	return c.prepareRequestWithBody(method, endpoint, body, "application/json")
 }
 
-// prepareRequestWithBody creates a new HTTP request with a custom body and content type
+// prepareRequestWithBody creates a new HTTP request with a custom body and
+// content type. Every request to the API is built here, so all of them carry
+// the token, a request ID and the User-Agent.
 func (c *ApiClient) prepareRequestWithBody(method, endpoint string, body io.Reader, contentType string) (*http.Request, error) {
 	url := c.BaseURL + endpoint
 
@@ -95,9 +246,11 @@ func (c *ApiClient) prepareRequestWithBody(method, endpoint string, body io.Read
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	if c.Token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.Token)
+	if token := c.Token(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
 	}
+	setRequestID(req)
+	setUserAgent(req)
 	if contentType != "" {
 		req.Header.Set("Content-Type", contentType)
 	}
@@ -106,49 +259,23 @@ func (c *ApiClient) prepareRequestWithBody(method, endpoint string, body io.Read
 }
 
 func (c *ApiClient) CallAPI(endpoint, method string, data map[string]interface{}) (map[string]interface{}, error) {
-	url := c.BaseURL + endpoint
-
-	var req *http.Request
-	var err error
-
-	if data != nil {
-		jsonData, _ := json.Marshal(data)
-		req, err = http.NewRequest(method, url, bytes.NewBuffer(jsonData))
-	} else {
-		req, err = http.NewRequest(method, url, nil)
-	}
-
+	req, err := c.prepareRequest(method, endpoint, data)
 	if err != nil {
 		return nil, err
 	}
 
-	if c.Token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.Token)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusUnauthorized {
-		println("Unauthorized. Removing token file.")
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return nil, errors.New("unable to determine user home directory")
-		}
-		tokenPath := filepath.Join(homeDir, ".time-tracker", ".token")
-		os.Remove(tokenPath)
-		c.Token = ""
-		return nil, errors.New("unauthorized")
+		return nil, c.handleUnauthorized()
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, errors.New("API call failed with status: " + resp.Status)
+		return nil, newAPIError(resp)
 	}
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -165,8 +292,6 @@ func (c *ApiClient) CallAPI(endpoint, method string, data map[string]interface{}
 
 // UploadFile sends a file using multipart/form-data
 func (c *ApiClient) UploadFile(endpoint, method, fieldName, fileName string, fileData []byte) (map[string]interface{}, error) {
-	url := c.BaseURL + endpoint
-
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 
@@ -184,38 +309,23 @@ func (c *ApiClient) UploadFile(endpoint, method, fieldName, fileName string, fil
 		return nil, fmt.Errorf("failed to close multipart writer: %w", err)
 	}
 
-	req, err := http.NewRequest(method, url, body)
+	req, err := c.prepareRequestWithBody(method, endpoint, body, writer.FormDataContentType())
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	if c.Token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.Token)
+		return nil, err
 	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusUnauthorized {
-		println("Unauthorized. Removing token file.")
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return nil, errors.New("unable to determine user home directory")
-		}
-		tokenPath := filepath.Join(homeDir, ".time-tracker", ".token")
-		os.Remove(tokenPath)
-		c.Token = ""
-		return nil, errors.New("unauthorized")
+		return nil, c.handleUnauthorized()
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API call failed with status: %s, body: %s", resp.Status, string(respBody))
+		return nil, newAPIError(resp)
 	}
 
 	respBody, err := io.ReadAll(resp.Body)
@@ -235,49 +345,23 @@ func (c *ApiClient) UploadFile(endpoint, method, fieldName, fileName string, fil
 
 // CallAPIForArray makes an API call and expects a JSON array response
 func (c *ApiClient) CallAPIForArray(endpoint, method string, data map[string]interface{}) ([]interface{}, error) {
-	url := c.BaseURL + endpoint
-
-	var req *http.Request
-	var err error
-
-	if data != nil {
-		jsonData, _ := json.Marshal(data)
-		req, err = http.NewRequest(method, url, bytes.NewBuffer(jsonData))
-	} else {
-		req, err = http.NewRequest(method, url, nil)
-	}
-
+	req, err := c.prepareRequest(method, endpoint, data)
 	if err != nil {
 		return nil, err
 	}
 
-	if c.Token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.Token)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusUnauthorized {
-		println("Unauthorized. Removing token file.")
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return nil, errors.New("unable to determine user home directory")
-		}
-		tokenPath := filepath.Join(homeDir, ".time-tracker", ".token")
-		os.Remove(tokenPath)
-		c.Token = ""
-		return nil, errors.New("unauthorized")
+		return nil, c.handleUnauthorized()
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, errors.New("API call failed with status: " + resp.Status)
+		return nil, newAPIError(resp)
 	}
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
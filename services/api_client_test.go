@@ -0,0 +1,312 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+const testToken = "secret-token"
+
+// newTestClient returns a client logged in with testToken, in a data
+// directory of its own, that sends its requests to handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*ApiClient, *TokenStore) {
+	t.Helper()
+	t.Setenv(config.HomeEnv, t.TempDir())
+	store, err := NewTokenStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(testToken); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewApiClientWithHTTPClient(server.URL, server.Client()), store
+}
+
+// checkHeaders reports headers every API request should carry but req lacks
+func checkHeaders(t *testing.T, req *http.Request) {
+	t.Helper()
+	if got := req.Header.Get("Authorization"); got != "Bearer "+testToken {
+		t.Errorf("Authorization = %q, want the bearer token", got)
+	}
+	if req.Header.Get(requestIDHeader) == "" {
+		t.Errorf("no %s header", requestIDHeader)
+	}
+	if got := req.Header.Get("User-Agent"); got != userAgent() {
+		t.Errorf("User-Agent = %q, want %q", got, userAgent())
+	}
+}
+
+func TestCallAPI(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		data     map[string]interface{}
+		wantBody string
+	}{
+		{"GET without data", "GET", nil, ""},
+		{"POST with data", "POST", map[string]interface{}{"task_id": 7}, `{"task_id":7}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				checkHeaders(t, r)
+				if r.Method != tt.method || r.URL.Path != "/api/reports" {
+					t.Errorf("request = %s %s, want %s /api/reports", r.Method, r.URL.Path, tt.method)
+				}
+				if got := r.Header.Get("Content-Type"); got != "application/json" {
+					t.Errorf("Content-Type = %q", got)
+				}
+				if body, _ := io.ReadAll(r.Body); string(body) != tt.wantBody {
+					t.Errorf("body = %q, want %q", body, tt.wantBody)
+				}
+				w.Write([]byte(`{"id": 42}`))
+			})
+
+			result, err := client.CallAPI("/api/reports", tt.method, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result["id"] != float64(42) {
+				t.Errorf("result = %v, want id 42", result)
+			}
+		})
+	}
+}
+
+func TestCallAPIForArray(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		checkHeaders(t, r)
+		w.Write([]byte(`[{"id": 1}, {"id": 2}]`))
+	})
+
+	result, err := client.CallAPIForArray("/api/tasks/user", "GET", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 {
+		t.Errorf("got %d items, want 2", len(result))
+	}
+}
+
+func TestUploadFile(t *testing.T) {
+	data := []byte("png data")
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		checkHeaders(t, r)
+		file, header, err := r.FormFile("screenshot")
+		if err != nil {
+			t.Errorf("no screenshot field: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		got, _ := io.ReadAll(file)
+		if header.Filename != "shot.png" || string(got) != string(data) {
+			t.Errorf("uploaded %q with %q, want shot.png with %q", header.Filename, got, data)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true})
+	})
+
+	result, err := client.UploadFile("/api/screenshots", "POST", "screenshot", "shot.png", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result["ok"] != true {
+		t.Errorf("result = %v", result)
+	}
+}
+
+func TestServerErrorIsAPIError(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "broken", http.StatusInternalServerError)
+	})
+
+	_, err := client.CallAPI("/api/reports", "GET", nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError || apiErr.Body != "broken" {
+		t.Fatalf("CallAPI() error = %v, want a 500 APIError", err)
+	}
+	if apiErr.RequestID == "" {
+		t.Error("APIError has no request ID")
+	}
+	if client.Token() != testToken {
+		t.Error("token was cleared after a server error")
+	}
+}
+
+func TestUnauthorizedForgetsToken(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(c *ApiClient) error
+	}{
+		{"CallAPI", func(c *ApiClient) error {
+			_, err := c.CallAPI("/api/reports", "GET", nil)
+			return err
+		}},
+		{"CallAPIForArray", func(c *ApiClient) error {
+			_, err := c.CallAPIForArray("/api/tasks/user", "GET", nil)
+			return err
+		}},
+		{"UploadFile", func(c *ApiClient) error {
+			_, err := c.UploadFile("/api/screenshots", "POST", "screenshot", "shot.png", []byte("png"))
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, store := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			})
+
+			if err := tt.call(client); !errors.Is(err, ErrUnauthorized) {
+				t.Fatalf("error = %v, want %v", err, ErrUnauthorized)
+			}
+			if token := client.Token(); token != "" {
+				t.Errorf("token = %q after 401, want it cleared", token)
+			}
+			if _, err := os.Stat(store.path); !os.IsNotExist(err) {
+				t.Errorf("token file still exists after 401: %v", err)
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int // Returned in turn, then 200 with a token
+		wantAttempts int
+		wantErr      error
+		wantStatus   int // Of the APIError, if one is wanted
+	}{
+		{"first attempt", nil, 1, nil, 0},
+		{"bad credentials", []int{http.StatusUnauthorized}, 1, ErrInvalidCredentials, 0},
+		{"forbidden", []int{http.StatusForbidden}, 1, ErrInvalidCredentials, 0},
+		{"retried after a server error", []int{http.StatusBadGateway}, 2, nil, 0},
+		{"server errors twice", []int{http.StatusBadGateway, http.StatusBadGateway}, 2, nil, http.StatusBadGateway},
+		{"client error not retried", []int{http.StatusBadRequest}, 1, nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			client, store := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				if r.URL.Path != "/api/login" || r.Method != "POST" {
+					t.Errorf("request = %s %s", r.Method, r.URL.Path)
+				}
+				if attempts <= len(tt.statuses) {
+					w.WriteHeader(tt.statuses[attempts-1])
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]string{"token": "new-token"})
+			})
+
+			_, err := client.Login(map[string]interface{}{"email": "a@example.com", "password": "pw"})
+			if attempts != tt.wantAttempts {
+				t.Errorf("%d attempts, want %d", attempts, tt.wantAttempts)
+			}
+			var apiErr *APIError
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Login() error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantStatus != 0:
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantStatus {
+					t.Errorf("Login() error = %v, want a %d APIError", err, tt.wantStatus)
+				}
+			case err != nil:
+				t.Fatalf("Login() error = %v", err)
+			default:
+				if token, _ := store.Load(); client.Token() != "new-token" || token != "new-token" {
+					t.Errorf("token = %q, stored %q, want new-token", client.Token(), token)
+				}
+			}
+		})
+	}
+}
+
+func TestLoginUnreachable(t *testing.T) {
+	t.Setenv(config.HomeEnv, t.TempDir())
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close() // Connections are now refused
+
+	client := NewApiClientWithHTTPClient(server.URL, server.Client())
+	_, err := client.Login(map[string]interface{}{"email": "a@example.com", "password": "pw"})
+	if !errors.Is(err, ErrNetworkUnreachable) {
+		t.Errorf("Login() error = %v, want %v", err, ErrNetworkUnreachable)
+	}
+}
+
+func TestWatchToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(store *TokenStore) error
+		want   string
+	}{
+		{"login elsewhere", func(store *TokenStore) error { return store.Save("other-token") }, "other-token"},
+		{"logout elsewhere", func(store *TokenStore) error { return store.Remove() }, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := make(chan string, 1)
+			client, store := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				sent <- r.Header.Get("Authorization")
+				w.Write([]byte(`{}`))
+			})
+
+			changes := make(chan string, 4)
+			stop, err := client.WatchToken(func(token string) { changes <- token })
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer stop()
+
+			if err := tt.change(store); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case got := <-changes:
+				if got != tt.want {
+					t.Fatalf("onChange(%q), want %q", got, tt.want)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("no change seen")
+			}
+			if got := client.Token(); got != tt.want {
+				t.Errorf("Token() = %q, want %q", got, tt.want)
+			}
+
+			if _, err := client.CallAPI("/api/reports", "GET", nil); err != nil {
+				t.Fatal(err)
+			}
+			want := ""
+			if tt.want != "" {
+				want = "Bearer " + tt.want
+			}
+			if got := <-sent; got != want {
+				t.Errorf("Authorization = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestWatchTokenWithoutTokenFile(t *testing.T) {
+	client := &ApiClient{}
+	if _, err := client.WatchToken(nil); err == nil {
+		t.Error("WatchToken succeeded without a token file")
+	}
+}
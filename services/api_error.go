@@ -0,0 +1,72 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBodyBytes bounds how much of an error response is kept
+const maxErrorBodyBytes = 2048
+
+// APIError is returned for non-2xx responses so callers can branch on the status code
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       string
+	RequestID  string // The X-Request-ID sent with the request, if any
+}
+
+func (e *APIError) Error() string {
+	msg := "API call failed with status: " + e.Status
+	if e.Body != "" {
+		msg += ", body: " + e.Body
+	}
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (request ID %s)", e.RequestID)
+	}
+	return msg
+}
+
+// ServerMessage returns the human-readable message from the response body if
+// the server sent one (as "error", "detail" or "message"), otherwise the raw body
+func (e *APIError) ServerMessage() string {
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(e.Body), &body); err == nil {
+		for _, key := range []string{"error", "detail", "message"} {
+			if msg, ok := body[key].(string); ok && msg != "" {
+				return msg
+			}
+		}
+	}
+	if e.Body != "" {
+		return e.Body
+	}
+	return e.Status
+}
+
+// UserMessage is ServerMessage followed by the request ID, for errors shown
+// to users who may need to report them
+func (e *APIError) UserMessage() string {
+	if e.RequestID == "" {
+		return e.ServerMessage()
+	}
+	return fmt.Sprintf("%s (request ID %s)", e.ServerMessage(), e.RequestID)
+}
+
+// newAPIError builds an APIError from a response, reading at most
+// maxErrorBodyBytes of the body
+func newAPIError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       strings.TrimSpace(string(body)),
+	}
+	if resp.Request != nil {
+		apiErr.RequestID = resp.Request.Header.Get(requestIDHeader)
+	}
+	return apiErr
+}
@@ -10,12 +10,19 @@ type AuthService struct {
 	apiClient *ApiClient
 }
 
-// NewAuthService creates a new instance of AuthService
+// NewAuthService creates a new instance of AuthService for the active server
+// profile, or one accepting any credentials in demo mode
 func NewAuthService() auth.Service {
-	// Provide a default BaseURL for the ApiClient
-	// TODO: Make this configurable
+	if DemoMode() {
+		return demoAuthService{}
+	}
+	return NewAuthServiceWithClient(NewApiClient(config.ActiveProfile().BaseURL))
+}
+
+// NewAuthServiceWithClient creates an AuthService that uses the given ApiClient
+func NewAuthServiceWithClient(apiClient *ApiClient) auth.Service {
 	return &AuthService{
-		apiClient: NewApiClient(config.API_URL),
+		apiClient: apiClient,
 	}
 }
 
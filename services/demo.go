@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/time-tracker/v2/internal/auth"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// DemoEnv enables demo mode when set to a true value such as "1". Passing
+// -demo on the command line does the same.
+//
+// Demo mode runs the app without a backend, for evaluating it or developing
+// the UI: any email and password log in, a few sample tasks are listed, and
+// work reports and screenshot uploads are accepted without leaving the
+// machine. Activities and screenshots are still recorded locally.
+const DemoEnv = "TIMETRACKER_DEMO"
+
+// DemoMode reports whether demo mode is enabled
+func DemoMode() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(DemoEnv))
+	return enabled
+}
+
+// demoToken is the token handed out by demo logins
+const demoToken = "demo"
+
+// demoTasks returns the sample tasks listed in demo mode
+func demoTasks() []types.Task {
+	active := "active"
+	website := types.Project{ID: 1, Name: "Website Redesign", Status: &active}
+	mobile := types.Project{ID: 2, Name: "Mobile App", Status: &active}
+	return []types.Task{
+		{ID: 101, Name: "Landing page layout", Project: website, Status: &active},
+		{ID: 102, Name: "Accessibility review", Project: website, Status: &active},
+		{ID: 201, Name: "Offline sync", Project: mobile, Status: &active},
+		{ID: 202, Name: "Release notes", Project: mobile, Status: &active},
+	}
+}
+
+// DemoTaskService implements TaskAPI with sample tasks and in-memory work reports
+type DemoTaskService struct {
+	mu      sync.Mutex
+	nextID  int
+	reports map[int]*types.WorkReport
+}
+
+// NewDemoTaskService creates a demo backend with no work reports
+func NewDemoTaskService() *DemoTaskService {
+	return &DemoTaskService{nextID: 1, reports: make(map[int]*types.WorkReport)}
+}
+
+// Ping always succeeds
+func (s *DemoTaskService) Ping(ctx context.Context) error {
+	return nil
+}
+
+// WatchToken does nothing; demo mode has no token
+func (s *DemoTaskService) WatchToken(onChange func(token string)) (stop func(), err error) {
+	return func() {}, nil
+}
+
+// GetUserTasks returns the sample tasks
+func (s *DemoTaskService) GetUserTasks() ([]types.Task, error) {
+	return demoTasks(), nil
+}
+
+// GetUserTasksIfModified returns the sample tasks, always as modified
+func (s *DemoTaskService) GetUserTasksIfModified() ([]types.Task, bool, error) {
+	return demoTasks(), true, nil
+}
+
+// GetProjects reports no projects endpoint, so the projects of the sample tasks are listed
+func (s *DemoTaskService) GetProjects() ([]types.Project, error) {
+	return nil, ErrProjectsUnsupported
+}
+
+// GetServerConfig reports no config endpoint, so local settings apply
+func (s *DemoTaskService) GetServerConfig() (*config.ServerPolicy, error) {
+	return nil, ErrServerConfigUnsupported
+}
+
+// GetOpenWorkReport returns nil; demo reports don't outlive the process
+func (s *DemoTaskService) GetOpenWorkReport() (*types.WorkReport, error) {
+	return nil, nil
+}
+
+// GetCurrentStatus returns the earliest in-memory work report still open
+func (s *DemoTaskService) GetCurrentStatus() (CurrentStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var status CurrentStatus
+	for _, report := range s.reports {
+		if report.EndTime == nil && (status.Report == nil || report.ID < status.Report.ID) {
+			status.Report = report
+		}
+	}
+	if status.Report != nil {
+		copied := *status.Report
+		status.Report = &copied
+	}
+	return status, nil
+}
+
+// StartUserTask opens an in-memory work report
+func (s *DemoTaskService) StartUserTask(projectID, taskID int, description string, startTime string) (*types.WorkReport, error) {
+	start, err := time.Parse(time.RFC3339, startTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start task: %w", err)
+	}
+	report := &types.WorkReport{StartTime: &start, Description: &description}
+	for _, task := range demoTasks() {
+		if task.ID == taskID {
+			report.Task = task
+			report.Project = task.Project
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	report.ID = s.nextID
+	s.nextID++
+	s.reports[report.ID] = report
+	copied := *report
+	return &copied, nil
+}
+
+// StopUserTask sets the end time of an in-memory work report
+func (s *DemoTaskService) StopUserTask(workReportID int, endTime string, description *string) (*types.WorkReport, error) {
+	end, err := time.Parse(time.RFC3339, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stop task: %w", err)
+	}
+	return s.update(workReportID, func(r *types.WorkReport) {
+		r.EndTime = &end
+		if description != nil {
+			r.Description = description
+		}
+	})
+}
+
+// UpdateWorkReport changes the times and, when task is not nil, the task of
+// an in-memory work report
+func (s *DemoTaskService) UpdateWorkReport(workReportID int, task *types.Task, startTime, endTime string) (*types.WorkReport, error) {
+	start, err := time.Parse(time.RFC3339, startTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update work report: %w", err)
+	}
+	end, err := time.Parse(time.RFC3339, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update work report: %w", err)
+	}
+	return s.update(workReportID, func(r *types.WorkReport) {
+		r.StartTime = &start
+		r.EndTime = &end
+		if task != nil {
+			r.Task = *task
+			r.Project = task.Project
+		}
+	})
+}
+
+// UpdateWorkReportDescription changes the description of an in-memory work report
+func (s *DemoTaskService) UpdateWorkReportDescription(workReportID int, description string) (*types.WorkReport, error) {
+	return s.update(workReportID, func(r *types.WorkReport) { r.Description = &description })
+}
+
+// UploadScreenshot accepts the screenshot without sending it anywhere
+func (s *DemoTaskService) UploadScreenshot(workReportID int, filePath, note string) error {
+	log.Printf("Demo mode: not uploading %s to work report %d", filepath.Base(filePath), workReportID)
+	return nil
+}
+
+// update applies change to a work report, returning a copy of the result
+func (s *DemoTaskService) update(workReportID int, change func(*types.WorkReport)) (*types.WorkReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	report, ok := s.reports[workReportID]
+	if !ok {
+		return nil, fmt.Errorf("work report %d not found", workReportID)
+	}
+	change(report)
+	copied := *report
+	return &copied, nil
+}
+
+// demoAuthService implements auth.Service by accepting any credentials
+type demoAuthService struct{}
+
+// Login returns a demo user for any email and password
+func (demoAuthService) Login(email, password string) (*auth.User, error) {
+	if email == "" || password == "" {
+		return nil, nil
+	}
+	return &auth.User{ID: 1, Username: "demo", Email: email, Role: "user", Token: demoToken}, nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDemoMode(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"1", true},
+		{"true", true},
+		{"0", false},
+		{"", false},
+		{"yes please", false},
+	}
+	for _, tt := range tests {
+		t.Setenv(DemoEnv, tt.value)
+		if got := DemoMode(); got != tt.want {
+			t.Errorf("DemoMode() with %s=%q = %v, want %v", DemoEnv, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestDemoLogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		wantUser bool
+	}{
+		{"any credentials", "someone@example.com", "anything", true},
+		{"no password", "someone@example.com", "", false},
+		{"no email", "", "anything", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := demoAuthService{}.Login(tt.email, tt.password)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (user != nil) != tt.wantUser {
+				t.Fatalf("Login() = %+v, want a user %v", user, tt.wantUser)
+			}
+			if user != nil && (user.Email != tt.email || user.Token != demoToken) {
+				t.Errorf("Login() = %+v", user)
+			}
+		})
+	}
+}
+
+func TestDemoWorkReports(t *testing.T) {
+	s := NewDemoTaskService()
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) string { return start.Add(d).Format(time.RFC3339) }
+
+	first, err := s.StartUserTask(1, 101, "first", at(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.StartUserTask(2, 201, "second", at(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Task.Name != "Landing page layout" || second.Project.Name != "Mobile App" {
+		t.Errorf("reports = %+v, %+v, want the sample tasks", first, second)
+	}
+
+	tests := []struct {
+		name       string
+		change     func() error
+		wantStatus int // ID of the open report GetCurrentStatus returns, 0 for none
+	}{
+		{"earliest open report", func() error { return nil }, first.ID},
+		{"after stopping it", func() error {
+			_, err := s.StopUserTask(first.ID, at(time.Hour), nil)
+			return err
+		}, second.ID},
+		{"after stopping all", func() error {
+			_, err := s.StopUserTask(second.ID, at(time.Hour), nil)
+			return err
+		}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.change(); err != nil {
+				t.Fatal(err)
+			}
+			status, err := s.GetCurrentStatus()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := 0
+			if status.Report != nil {
+				got = status.Report.ID
+			}
+			if got != tt.wantStatus {
+				t.Errorf("open report = %d, want %d", got, tt.wantStatus)
+			}
+		})
+	}
+
+	updated, err := s.UpdateWorkReportDescription(first.ID, "renamed")
+	if err != nil || *updated.Description != "renamed" {
+		t.Errorf("UpdateWorkReportDescription() = %+v, %v", updated, err)
+	}
+	if _, err := s.StopUserTask(999, at(0), nil); err == nil {
+		t.Error("stopping an unknown report succeeded")
+	}
+	if _, err := s.StartUserTask(1, 101, "", "not a time"); err == nil {
+		t.Error("starting with an invalid time succeeded")
+	}
+}
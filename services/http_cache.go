@@ -0,0 +1,71 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// cachedResponse is a response body kept with the validators the server sent
+// for it, so a later request can ask whether it changed
+type cachedResponse struct {
+	etag         string
+	lastModified string
+	body         []byte
+}
+
+// GetConditional makes a GET request for endpoint, revalidating the body
+// cached from the previous one: the server's ETag and Last-Modified are sent
+// back as If-None-Match and If-Modified-Since, and on 304 Not Modified the
+// cached body is returned with notModified set. Only responses carrying a
+// validator are cached.
+func (c *ApiClient) GetConditional(endpoint string) (body []byte, notModified bool, err error) {
+	req, err := c.prepareRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	c.mu.Lock()
+	cached, ok := c.cache[endpoint]
+	c.mu.Unlock()
+	if ok {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, false, c.handleUnauthorized()
+	case resp.StatusCode == http.StatusNotModified && ok:
+		io.Copy(io.Discard, resp.Body)
+		return cached.body, true, nil
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, false, newAPIError(resp)
+	}
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	entry := cachedResponse{etag: resp.Header.Get("ETag"), lastModified: resp.Header.Get("Last-Modified"), body: body}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry.etag == "" && entry.lastModified == "" {
+		delete(c.cache, endpoint)
+	} else {
+		if c.cache == nil {
+			c.cache = map[string]cachedResponse{}
+		}
+		c.cache[endpoint] = entry
+	}
+	return body, false, nil
+}
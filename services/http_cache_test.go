@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestGetConditional(t *testing.T) {
+	const lastModified = "Mon, 10 Mar 2025 09:00:00 GMT"
+	tests := []struct {
+		name            string
+		etag            string
+		lastModified    string
+		wantNotModified bool
+	}{
+		{"ETag", `"v1"`, "", true},
+		{"Last-Modified", "", lastModified, true},
+		{"both", `"v1"`, lastModified, true},
+		{"no validator", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				checkHeaders(t, r)
+				inm, ims := r.Header.Get("If-None-Match"), r.Header.Get("If-Modified-Since")
+				if requests == 1 && (inm != "" || ims != "") {
+					t.Errorf("first request revalidates: If-None-Match %q, If-Modified-Since %q", inm, ims)
+				}
+				if requests > 1 && (inm != tt.etag || ims != tt.lastModified) {
+					t.Errorf("If-None-Match %q, If-Modified-Since %q, want %q, %q", inm, ims, tt.etag, tt.lastModified)
+				}
+				if (tt.etag != "" && inm == tt.etag) || (tt.lastModified != "" && ims == tt.lastModified) {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				if tt.etag != "" {
+					w.Header().Set("ETag", tt.etag)
+				}
+				if tt.lastModified != "" {
+					w.Header().Set("Last-Modified", tt.lastModified)
+				}
+				io.WriteString(w, `[{"id": 1}]`)
+			})
+
+			for i, wantNotModified := range []bool{false, tt.wantNotModified} {
+				body, notModified, err := client.GetConditional("/api/tasks/user")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(body) != `[{"id": 1}]` || notModified != wantNotModified {
+					t.Errorf("request %d: GetConditional() = %q, %v, want the list, %v", i+1, body, notModified, wantNotModified)
+				}
+			}
+		})
+	}
+}
+
+func TestGetConditionalForgetsOtherUsers(t *testing.T) {
+	var revalidated []bool
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		revalidated = append(revalidated, r.Header.Get("If-None-Match") != "")
+		w.Header().Set("ETag", `"v1"`)
+		io.WriteString(w, `[]`)
+	})
+
+	for _, token := range []string{testToken, testToken, "other-user"} {
+		client.SetToken(token)
+		if _, _, err := client.GetConditional("/api/tasks/user"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []bool{false, true, false}; !slices.Equal(revalidated, want) {
+		t.Errorf("revalidated = %v, want %v: a new token starts afresh", revalidated, want)
+	}
+}
+
+func TestGetConditionalErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		want   func(error) bool
+	}{
+		{"unauthorized", http.StatusUnauthorized, func(err error) bool { return errors.Is(err, ErrUnauthorized) }},
+		{"server error", http.StatusInternalServerError, func(err error) bool {
+			var apiErr *APIError
+			return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusInternalServerError
+		}},
+		{"not modified, nothing cached", http.StatusNotModified, func(err error) bool {
+			var apiErr *APIError
+			return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotModified
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+			if _, _, err := client.GetConditional("/api/tasks/user"); !tt.want(err) {
+				t.Errorf("GetConditional() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// requestIDHeader carries a per-request ID the server can log, so client and
+// server logs can be matched up during support
+const requestIDHeader = "X-Request-ID"
+
+// newRequestID returns a random (version 4) UUID
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// setRequestID gives req a new request ID header
+func setRequestID(req *http.Request) {
+	req.Header.Set(requestIDHeader, newRequestID())
+}
+
+// do sends req and logs its request ID with the response status. Transport
+// errors include the request ID; APIErrors built from the response carry it too.
+func (c *ApiClient) do(req *http.Request) (*http.Response, error) {
+	id := req.Header.Get(requestIDHeader)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		log.Printf("API %s %s [%s] failed: %v", req.Method, req.URL.Path, id, err)
+		return nil, fmt.Errorf("%w (request ID %s)", err, id)
+	}
+	log.Printf("API %s %s [%s]: %s", req.Method, req.URL.Path, id, resp.Status)
+	return resp, nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+var uuidV4 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNewRequestID(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		id := newRequestID()
+		if !uuidV4.MatchString(id) {
+			t.Fatalf("newRequestID() = %q, want a version 4 UUID", id)
+		}
+		if seen[id] {
+			t.Fatalf("newRequestID() repeated %q", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestAPIErrorMessages(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         APIError
+		wantError   string
+		wantMessage string
+	}{
+		{"status only", APIError{Status: "502 Bad Gateway"},
+			"API call failed with status: 502 Bad Gateway", "502 Bad Gateway"},
+		{"JSON body", APIError{Status: "400 Bad Request", Body: `{"detail": "task is closed"}`, RequestID: "abc"},
+			`API call failed with status: 400 Bad Request, body: {"detail": "task is closed"} (request ID abc)`, "task is closed (request ID abc)"},
+		{"plain body", APIError{Status: "500 Internal Server Error", Body: "broken", RequestID: "abc"},
+			"API call failed with status: 500 Internal Server Error, body: broken (request ID abc)", "broken (request ID abc)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.wantError {
+				t.Errorf("Error() = %q, want %q", got, tt.wantError)
+			}
+			if got := tt.err.UserMessage(); got != tt.wantMessage {
+				t.Errorf("UserMessage() = %q, want %q", got, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestRequestIDInErrors(t *testing.T) {
+	var sent []string
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, r.Header.Get(requestIDHeader))
+		http.Error(w, "broken", http.StatusBadRequest)
+	})
+
+	var errs []error
+	for i := 0; i < 2; i++ {
+		_, err := client.CallAPI("/api/reports", "GET", nil)
+		errs = append(errs, err)
+	}
+	if len(sent) != 2 || sent[0] == sent[1] {
+		t.Fatalf("request IDs = %q, want a new one per request", sent)
+	}
+	for i, err := range errs {
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.RequestID != sent[i] {
+			t.Errorf("error %d = %v, want an APIError with request ID %s", i, err, sent[i])
+		}
+	}
+
+	t.Run("transport error", func(t *testing.T) {
+		t.Setenv(config.HomeEnv, t.TempDir())
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+		_, err := NewApiClientWithHTTPClient(server.URL, server.Client()).CallAPI("/api/reports", "GET", nil)
+		if err == nil || !strings.Contains(err.Error(), "request ID ") {
+			t.Errorf("CallAPI() error = %v, want it to name the request ID", err)
+		}
+	})
+}
@@ -2,7 +2,9 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
@@ -11,6 +13,13 @@ import (
 	"log"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/time-tracker/v2/internal/config"
 	"github.com/time-tracker/v2/internal/types"
@@ -19,20 +28,75 @@ import (
 // TaskService handles task-related operations
 type TaskService struct {
 	apiClient *ApiClient
+
+	mu    sync.Mutex
+	tasks []types.Task // Parsed from the last task list the server sent, for 304 responses
 }
 
-// NewTaskService creates a new instance of TaskService
+// NewTaskService creates a new instance of TaskService for the active server profile
 func NewTaskService() *TaskService {
+	return NewTaskServiceWithClient(NewApiClient(config.ActiveProfile().BaseURL))
+}
+
+// NewTaskServiceWithClient creates a TaskService that uses the given ApiClient
+func NewTaskServiceWithClient(apiClient *ApiClient) *TaskService {
 	return &TaskService{
-		apiClient: NewApiClient(config.API_URL),
+		apiClient: apiClient,
 	}
 }
 
+// Ping checks that the backend is reachable
+func (s *TaskService) Ping(ctx context.Context) error {
+	return s.apiClient.Ping(ctx)
+}
+
+// WatchToken follows changes to the token file; see ApiClient.WatchToken
+func (s *TaskService) WatchToken(onChange func(token string)) (stop func(), err error) {
+	return s.apiClient.WatchToken(onChange)
+}
+
 // GetUserTasks fetches all tasks for the authenticated user
 func (s *TaskService) GetUserTasks() ([]types.Task, error) {
-	response, err := s.apiClient.CallAPIForArray("/api/tasks/user", "GET", nil)
+	tasks, _, err := s.GetUserTasksIfModified()
+	return tasks, err
+}
+
+// GetUserTasksIfModified fetches all tasks for the authenticated user with a
+// conditional request. If the server reports the list unchanged since the
+// last fetch, the tasks parsed then are returned and modified is false.
+func (s *TaskService) GetUserTasksIfModified() (tasks []types.Task, modified bool, err error) {
+	body, notModified, err := s.apiClient.GetConditional("/api/tasks/user")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to fetch tasks: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if notModified && s.tasks != nil {
+		return slices.Clone(s.tasks), false, nil
+	}
+	if err := json.Unmarshal(body, &tasks); err != nil {
+		return nil, false, fmt.Errorf("failed to parse task data: %w", err)
+	}
+	if tasks == nil {
+		tasks = []types.Task{}
+	}
+	s.tasks = tasks
+	return slices.Clone(tasks), true, nil
+}
+
+// ErrProjectsUnsupported is returned when the server has no projects endpoint
+var ErrProjectsUnsupported = errors.New("the server does not list projects")
+
+// GetProjects fetches the projects the authenticated user belongs to
+func (s *TaskService) GetProjects() ([]types.Project, error) {
+	response, err := s.apiClient.CallAPIForArray("/api/projects/user", "GET", nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch tasks: %w", err)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusMethodNotAllowed) {
+			return nil, ErrProjectsUnsupported
+		}
+		return nil, fmt.Errorf("failed to fetch projects: %w", err)
 	}
 
 	jsonData, err := json.Marshal(response)
@@ -40,12 +104,46 @@ func (s *TaskService) GetUserTasks() ([]types.Task, error) {
 		return nil, fmt.Errorf("failed to marshal response: %w", err)
 	}
 
-	var tasks []types.Task
-	if err := json.Unmarshal(jsonData, &tasks); err != nil {
-		return nil, fmt.Errorf("failed to parse task data: %w", err)
+	var projects []types.Project
+	if err := json.Unmarshal(jsonData, &projects); err != nil {
+		return nil, fmt.Errorf("failed to parse project data: %w", err)
 	}
 
-	return tasks, nil
+	return projects, nil
+}
+
+// ErrServerConfigUnsupported is returned when the server has no config endpoint
+var ErrServerConfigUnsupported = errors.New("the server does not provide a config")
+
+// GetServerConfig fetches the policy the server mandates for the authenticated
+// user. Values the app can't apply are dropped.
+func (s *TaskService) GetServerConfig() (*config.ServerPolicy, error) {
+	response, err := s.apiClient.CallAPI("/api/config", "GET", nil)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusMethodNotAllowed) {
+			return nil, ErrServerConfigUnsupported
+		}
+		return nil, fmt.Errorf("failed to fetch server config: %w", err)
+	}
+	if data, ok := response["data"].(map[string]interface{}); ok {
+		response = data
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+
+	var policy config.ServerPolicy
+	if err := json.Unmarshal(jsonData, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse server config: %w", err)
+	}
+	if dropped := policy.Sanitize(); len(dropped) > 0 {
+		log.Printf("Ignoring invalid server config values: %s", strings.Join(dropped, ", "))
+	}
+
+	return &policy, nil
 }
 
 // StartUserTask starts a user task by creating a work report
@@ -62,17 +160,40 @@ func (s *TaskService) StartUserTask(projectID, taskID int, description string, s
 		return nil, fmt.Errorf("failed to start task: %w", err)
 	}
 
-	jsonData, err := json.Marshal(response)
+	return parseWorkReport(response)
+}
+
+// GetOpenWorkReport fetches the authenticated user's work report that has no
+// end time yet, or nil if there is none
+func (s *TaskService) GetOpenWorkReport() (*types.WorkReport, error) {
+	response, err := s.apiClient.CallAPI("/api/work_report/open", "GET", nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal response: %w", err)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch open work report: %w", err)
 	}
-
-	var workReport types.WorkReport
-	if err := json.Unmarshal(jsonData, &workReport); err != nil {
-		return nil, fmt.Errorf("failed to parse work report: %w", err)
+	if data, ok := response["data"]; len(response) == 0 || (ok && data == nil) {
+		return nil, nil
 	}
 
-	return &workReport, nil
+	return parseWorkReport(response)
+}
+
+// CurrentStatus is what the server records the user as working on
+type CurrentStatus struct {
+	Report *types.WorkReport // The open work report, nil when nothing is tracked
+}
+
+// GetCurrentStatus asks the server what the user is tracking, wherever it was
+// started. No open work report is not an error.
+func (s *TaskService) GetCurrentStatus() (CurrentStatus, error) {
+	report, err := s.GetOpenWorkReport()
+	if err != nil {
+		return CurrentStatus{}, err
+	}
+	return CurrentStatus{Report: report}, nil
 }
 
 // StopUserTask stops a user task by updating the work report with an end time
@@ -89,6 +210,57 @@ func (s *TaskService) StopUserTask(workReportID int, endTime string, description
 		return nil, fmt.Errorf("failed to stop task: %w", err)
 	}
 
+	return parseWorkReport(response)
+}
+
+// UpdateWorkReport corrects a closed work report's times and, when task is
+// not nil, moves it to that task
+func (s *TaskService) UpdateWorkReport(workReportID int, task *types.Task, startTime, endTime string) (*types.WorkReport, error) {
+	payload := map[string]interface{}{
+		"start_time": startTime,
+		"end_time":   endTime,
+	}
+	if task != nil {
+		payload["project"] = task.Project.ID
+		payload["task"] = task.ID
+	}
+
+	response, err := s.apiClient.CallAPI(fmt.Sprintf("/api/work_report/%d", workReportID), "PUT", payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update work report: %w", err)
+	}
+
+	return parseWorkReport(response)
+}
+
+// UpdateWorkReportDescription replaces the description of a work report,
+// typically one that has already been closed. When the server refuses the
+// change its message is returned in the error.
+func (s *TaskService) UpdateWorkReportDescription(workReportID int, description string) (*types.WorkReport, error) {
+	payload := map[string]interface{}{
+		"description": description,
+	}
+
+	response, err := s.apiClient.CallAPI(fmt.Sprintf("/api/work_report/%d", workReportID), "PUT", payload)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 {
+			return nil, fmt.Errorf("the server rejected the description: %s", apiErr.UserMessage())
+		}
+		return nil, fmt.Errorf("failed to update work report description: %w", err)
+	}
+
+	return parseWorkReport(response)
+}
+
+// parseWorkReport converts an API response into a WorkReport. Responses wrapped
+// in a {"data": {...}} envelope are unwrapped, and a report without an ID is
+// rejected since every later call (stop, uploads) is keyed on it.
+func parseWorkReport(response map[string]interface{}) (*types.WorkReport, error) {
+	if data, ok := response["data"].(map[string]interface{}); ok {
+		response = data
+	}
+
 	jsonData, err := json.Marshal(response)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal response: %w", err)
@@ -99,72 +271,151 @@ func (s *TaskService) StopUserTask(workReportID int, endTime string, description
 		return nil, fmt.Errorf("failed to parse work report: %w", err)
 	}
 
+	if workReport.ID == 0 {
+		for _, key := range []string{"error", "detail", "message"} {
+			if msg, ok := response[key].(string); ok && msg != "" {
+				return nil, fmt.Errorf("invalid work report response: %s", msg)
+			}
+		}
+		return nil, fmt.Errorf("invalid work report response: missing report ID")
+	}
+
 	return &workReport, nil
 }
 
-// UploadScreenshot uploads a screenshot and webcam image for a specific work report
-func (s *TaskService) UploadScreenshot(workReportID int, screenshotData []byte, filename string) error {
+// Screenshot uploads are retried on network errors and 5xx responses, with
+// the delay doubling after each failed attempt
+const uploadAttempts = 3
+
+// uploadRetryDelay is the wait before the first retry; tests shorten it
+var uploadRetryDelay = 2 * time.Second
+
+// UploadScreenshot uploads a screenshot and webcam image for a specific work report,
+// with note as a form field unless it is empty.
+// The screenshot is streamed from disk, and transient failures are retried.
+func (s *TaskService) UploadScreenshot(workReportID int, filePath, note string) error {
+	delay := uploadRetryDelay
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		retry, err = s.uploadScreenshotOnce(workReportID, filePath, note)
+		if err == nil || !retry || attempt == uploadAttempts {
+			return err
+		}
+		log.Printf("Screenshot upload attempt %d of %d failed, retrying in %s: %v", attempt, uploadAttempts, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// uploadScreenshotOnce makes a single upload attempt, reporting whether a
+// failure is worth retrying
+func (s *TaskService) uploadScreenshotOnce(workReportID int, filePath, note string) (bool, error) {
 	// Construct the API endpoint URL
 	url := fmt.Sprintf("/api/upload_image/%d", workReportID)
 
-	// Prepare the multipart form data
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	// Add the screenshot file part
-	part, err := writer.CreateFormFile("screenshot", filename)
+	file, err := os.Open(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to create form file: %w", err)
+		return false, fmt.Errorf("failed to open screenshot file: %w", err)
 	}
-	_, err = io.Copy(part, bytes.NewReader(screenshotData))
+	defer file.Close()
+	info, err := file.Stat()
 	if err != nil {
-		return fmt.Errorf("failed to copy screenshot data: %w", err)
+		return false, fmt.Errorf("failed to stat screenshot file: %w", err)
 	}
 
-	// Add the webcam image file part
-	webcamPart, err := writer.CreateFormFile("webcam_image", "webcam.png")
+	// Build the multipart framing around the screenshot so the file itself is
+	// streamed from disk rather than buffered in memory
+	framing := &bytes.Buffer{}
+	writer := multipart.NewWriter(framing)
+
+	// Add the screenshot file part, typed by its format
+	partHeader := make(textproto.MIMEHeader)
+	partHeader.Set("Content-Disposition", fmt.Sprintf(`form-data; name="screenshot"; filename="%s"`, filepath.Base(filePath)))
+	partHeader.Set("Content-Type", screenshotContentType(filePath))
+	_, err = writer.CreatePart(partHeader)
 	if err != nil {
-		return fmt.Errorf("failed to create webcam form file: %w", err)
+		return false, fmt.Errorf("failed to create form file: %w", err)
 	}
-	_, err = io.Copy(webcamPart, bytes.NewReader(createBlackPNG()))
-	if err != nil {
-		return fmt.Errorf("failed to copy webcam image data: %w", err)
+	head := append([]byte(nil), framing.Bytes()...)
+	framing.Reset()
+
+	// Add the webcam image file part, unless the server says it isn't required
+	if policy := config.CurrentServerPolicy(); policy == nil || policy.WebcamRequired == nil || *policy.WebcamRequired {
+		webcamPart, err := writer.CreateFormFile("webcam_image", "webcam.png")
+		if err != nil {
+			return false, fmt.Errorf("failed to create webcam form file: %w", err)
+		}
+		settings := config.Current()
+		_, err = io.Copy(webcamPart, bytes.NewReader(createBlackPNG(settings.WebcamWidth, settings.WebcamHeight)))
+		if err != nil {
+			return false, fmt.Errorf("failed to copy webcam image data: %w", err)
+		}
+	}
+
+	if note != "" {
+		if err := writer.WriteField("note", note); err != nil {
+			return false, fmt.Errorf("failed to write note field: %w", err)
+		}
 	}
 
 	// Close the multipart writer
 	err = writer.Close()
 	if err != nil {
-		return fmt.Errorf("failed to close multipart writer: %w", err)
+		return false, fmt.Errorf("failed to close multipart writer: %w", err)
 	}
+	tail := framing.Bytes()
+
+	body := io.MultiReader(bytes.NewReader(head), file, bytes.NewReader(tail))
 
 	// Prepare the request using the new function
 	contentType := writer.FormDataContentType()
 	req, err := s.apiClient.prepareRequestWithBody("POST", url, body, contentType)
 	if err != nil {
-		return fmt.Errorf("failed to prepare request: %w", err)
+		return false, fmt.Errorf("failed to prepare request: %w", err)
 	}
+	req.ContentLength = int64(len(head)) + info.Size() + int64(len(tail))
 
 	// Execute the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.apiClient.do(req)
 	if err != nil {
-		return fmt.Errorf("failed to upload screenshot: %w", err)
+		return true, fmt.Errorf("failed to upload screenshot: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		return false, s.apiClient.handleUnauthorized()
+	}
+
 	// Check the response status code
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		respBody, _ := io.ReadAll(resp.Body) // Read body for error details
-		return fmt.Errorf("screenshot upload failed with status %s: %s", resp.Status, string(respBody))
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("screenshot upload failed: %w", newAPIError(resp))
 	}
 
 	// Screenshot uploaded successfully
-	return nil
+	return false, nil
+}
+
+// screenshotContentType returns the MIME type of a screenshot file from its extension
+func screenshotContentType(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".png":
+		return "image/png"
+	case ".webp":
+		return "image/webp"
+	}
+	return "application/octet-stream"
 }
 
-// createBlackPNG generates a 100x100 all-black PNG image and returns its byte representation
-func createBlackPNG() []byte {
-	const width, height = 100, 100 // Dimensions of the black PNG
+// createBlackPNG generates an all-black PNG image of the given size, falling
+// back to 100x100 for sizes out of range, and returns its byte representation
+func createBlackPNG(width, height int) []byte {
+	if width < 1 || height < 1 || width > config.MaxWebcamDimension || height > config.MaxWebcamDimension {
+		width, height = 100, 100
+	}
 
 	// Create a black image
 	img := image.NewRGBA(image.Rect(0, 0, width, height))
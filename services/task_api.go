@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// TaskAPI is the backend for tasks, work reports and screenshot uploads.
+// TaskService talks to the server; demo mode substitutes a fake.
+type TaskAPI interface {
+	Ping(ctx context.Context) error
+	GetUserTasks() ([]types.Task, error)
+	GetUserTasksIfModified() (tasks []types.Task, modified bool, err error)
+	GetProjects() ([]types.Project, error)
+	GetServerConfig() (*config.ServerPolicy, error)
+	GetOpenWorkReport() (*types.WorkReport, error)
+	GetCurrentStatus() (CurrentStatus, error)
+	StartUserTask(projectID, taskID int, description string, startTime string) (*types.WorkReport, error)
+	StopUserTask(workReportID int, endTime string, description *string) (*types.WorkReport, error)
+	UpdateWorkReport(workReportID int, task *types.Task, startTime, endTime string) (*types.WorkReport, error)
+	UpdateWorkReportDescription(workReportID int, description string) (*types.WorkReport, error)
+	UploadScreenshot(workReportID int, filePath, note string) error
+	WatchToken(onChange func(token string)) (stop func(), err error)
+}
+
+// NewTaskAPI returns the backend for the active server profile, or the demo
+// backend when demo mode is enabled
+func NewTaskAPI() TaskAPI {
+	if DemoMode() {
+		return NewDemoTaskService()
+	}
+	return NewTaskService()
+}
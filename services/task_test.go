@@ -0,0 +1,310 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"image/png"
+	"io"
+	"math/rand"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+func TestParseWorkReport(t *testing.T) {
+	tests := []struct {
+		name     string
+		response map[string]interface{}
+		wantID   int
+		wantErr  string
+	}{
+		{"plain", map[string]interface{}{"id": 12.0, "task": map[string]interface{}{"id": 3.0}}, 12, ""},
+		{"data envelope", map[string]interface{}{"data": map[string]interface{}{"id": 13.0}}, 13, ""},
+		{"server message", map[string]interface{}{"detail": "task is closed"}, 0, "task is closed"},
+		{"missing ID", map[string]interface{}{"description": "x"}, 0, "missing report ID"},
+		{"wrong type", map[string]interface{}{"id": "twelve"}, 0, "failed to parse work report"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := parseWorkReport(tt.response)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseWorkReport() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.ID != tt.wantID {
+				t.Errorf("ID = %d, want %d", report.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestUploadScreenshot(t *testing.T) {
+	saved := uploadRetryDelay
+	uploadRetryDelay = time.Millisecond
+	t.Cleanup(func() { uploadRetryDelay = saved })
+
+	// Large enough that buffering it would show in the allocations
+	const size = 8 << 20
+	data := make([]byte, size)
+	rand.New(rand.NewSource(1)).Read(data)
+	wantSum := sha256.Sum256(data)
+
+	tests := []struct {
+		name         string
+		statuses     []int // Returned in turn, then 200
+		wantAttempts int
+		wantErr      bool
+	}{
+		{"first attempt", nil, 1, false},
+		{"retried after a server error", []int{http.StatusServiceUnavailable}, 2, false},
+		{"client error not retried", []int{http.StatusBadRequest}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			screenshot := filepath.Join(t.TempDir(), "screenshot_20250310_090000.png")
+			if err := os.WriteFile(screenshot, data, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			var mu sync.Mutex
+			attempts := 0
+			client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				attempts++
+				attempt := attempts
+				mu.Unlock()
+				if r.URL.Path != "/api/upload_image/12" {
+					t.Errorf("path = %s", r.URL.Path)
+				}
+				if len(r.TransferEncoding) > 0 || r.ContentLength <= size {
+					t.Errorf("Content-Length = %d, transfer encoding %v, want the length of the whole form", r.ContentLength, r.TransferEncoding)
+				}
+				// Read the form as it arrives, so only the client could buffer it
+				body := &countingReader{r: r.Body}
+				_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+				if err != nil {
+					t.Errorf("Content-Type: %v", err)
+					return
+				}
+				form := multipart.NewReader(body, params["boundary"])
+				for {
+					part, err := form.NextPart()
+					if err == io.EOF {
+						break
+					}
+					if err != nil {
+						t.Errorf("bad form: %v", err)
+						return
+					}
+					switch part.FormName() {
+					case "screenshot":
+						hash := sha256.New()
+						n, _ := io.Copy(hash, part)
+						if n != size || !bytes.Equal(hash.Sum(nil), wantSum[:]) {
+							t.Errorf("screenshot = %d bytes with a different checksum, want the %d-byte file", n, size)
+						}
+						if got := part.Header.Get("Content-Type"); got != "image/png" {
+							t.Errorf("screenshot sent as %s", got)
+						}
+					case "note":
+						if note, _ := io.ReadAll(part); string(note) != "a note" {
+							t.Errorf("note = %q", note)
+						}
+					}
+				}
+				io.Copy(io.Discard, body)
+				if body.n != r.ContentLength {
+					t.Errorf("body is %d bytes, Content-Length says %d", body.n, r.ContentLength)
+				}
+				if attempt <= len(tt.statuses) {
+					w.WriteHeader(tt.statuses[attempt-1])
+				}
+			})
+
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			err := NewTaskServiceWithClient(client).UploadScreenshot(12, screenshot, "a note")
+			runtime.ReadMemStats(&after)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UploadScreenshot() error = %v, want error %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("%d attempts, want %d", attempts, tt.wantAttempts)
+			}
+			if allocated := after.TotalAlloc - before.TotalAlloc; allocated > size/2 {
+				t.Errorf("allocated %d bytes uploading a %d-byte file, want it streamed", allocated, size)
+			}
+		})
+	}
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func TestScreenshotContentType(t *testing.T) {
+	tests := map[string]string{
+		"a.png":  "image/png",
+		"a.JPG":  "image/jpeg",
+		"a.jpeg": "image/jpeg",
+		"a.webp": "image/webp",
+		"a.bmp":  "application/octet-stream",
+	}
+	for path, want := range tests {
+		if got := screenshotContentType(path); got != want {
+			t.Errorf("screenshotContentType(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestCreateBlackPNG(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		wantW, wantH  int
+	}{
+		{"configured size", 320, 240, 320, 240},
+		{"zero falls back", 0, 240, 100, 100},
+		{"too large falls back", config.MaxWebcamDimension + 1, 240, 100, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := png.DecodeConfig(bytes.NewReader(createBlackPNG(tt.width, tt.height)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Width != tt.wantW || cfg.Height != tt.wantH {
+				t.Errorf("createBlackPNG(%d, %d) is %dx%d, want %dx%d", tt.width, tt.height, cfg.Width, cfg.Height, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestGetServerConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		wantErr      error
+		wantInterval int // 0 for none
+		wantMandates bool
+	}{
+		{"plain", http.StatusOK, `{"screenshot_interval_seconds": 300}`, nil, 300, true},
+		{"data envelope", http.StatusOK, `{"data": {"screenshot_interval_seconds": 600}}`, nil, 600, true},
+		{"nothing mandated", http.StatusOK, `{"features": {"beta": true}}`, nil, 0, false},
+		{"invalid value dropped", http.StatusOK, `{"screenshot_interval_seconds": 1}`, nil, 0, false},
+		{"no endpoint", http.StatusNotFound, `not found`, ErrServerConfigUnsupported, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/config" {
+					t.Errorf("path = %s", r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			})
+
+			policy, err := NewTaskServiceWithClient(client).GetServerConfig()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GetServerConfig() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			interval := 0
+			if policy.ScreenshotIntervalSeconds != nil {
+				interval = *policy.ScreenshotIntervalSeconds
+			}
+			if interval != tt.wantInterval || policy.Mandates() != tt.wantMandates {
+				t.Errorf("policy = %+v, want interval %d, mandates %v", policy, tt.wantInterval, tt.wantMandates)
+			}
+		})
+	}
+}
+
+func TestGetUserTasksIfModified(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		io.WriteString(w, `[{"id": 7, "name": "Docs", "project": {"id": 1, "name": "Website"}}]`)
+	})
+	service := NewTaskServiceWithClient(client)
+
+	for i, wantModified := range []bool{true, false} {
+		tasks, modified, err := service.GetUserTasksIfModified()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if modified != wantModified || len(tasks) != 1 || tasks[0].ID != 7 || tasks[0].Project.Name != "Website" {
+			t.Errorf("fetch %d: GetUserTasksIfModified() = %+v, %v, want task 7, modified %v", i+1, tasks, modified, wantModified)
+		}
+	}
+}
+
+func TestUploadScreenshotNote(t *testing.T) {
+	tests := []struct {
+		name     string
+		note     string
+		wantPart bool
+	}{
+		{"with a note", "Reviewing the design", true},
+		{"without", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			screenshot := filepath.Join(t.TempDir(), "screenshot_20250310_090000.png")
+			if err := os.WriteFile(screenshot, []byte("png data"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseMultipartForm(1 << 20); err != nil {
+					t.Errorf("bad form: %v", err)
+					return
+				}
+				notes, ok := r.MultipartForm.Value["note"]
+				if ok != tt.wantPart || (ok && notes[0] != tt.note) {
+					t.Errorf("note part = %q, %v, want %q, %v", notes, ok, tt.note, tt.wantPart)
+				}
+			})
+
+			if err := NewTaskServiceWithClient(client).UploadScreenshot(12, screenshot, tt.note); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/logging"
+)
+
+const tokenFileName = ".token"
+
+// TokenStore persists the auth token on disk
+type TokenStore struct {
+	path string
+}
+
+// NewTokenStore returns a store for the active server profile's token file
+func NewTokenStore() (*TokenStore, error) {
+	return NewTokenStoreForProfile(config.ActiveProfile().Name)
+}
+
+// NewTokenStoreForProfile returns a store for the token file of the named
+// server profile in the data directory
+func NewTokenStoreForProfile(profile string) (*TokenStore, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	return &TokenStore{path: filepath.Join(dir, tokenFileNameFor(profile))}, nil
+}
+
+// tokenFileNameFor returns the token file name for a profile. The production
+// profile keeps the original name so existing logins survive.
+func tokenFileNameFor(profile string) string {
+	if profile == config.DefaultProfileName {
+		return tokenFileName
+	}
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		}
+		return '_'
+	}, profile)
+	return tokenFileName + "-" + safe
+}
+
+// Load reads the stored token
+func (ts *TokenStore) Load() (string, error) {
+	data, err := os.ReadFile(ts.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file %s: %w", ts.path, err)
+	}
+	return string(data), nil
+}
+
+// Save writes the token, readable only by the user. It is written to a
+// temporary file that then replaces the token file, so a watcher never reads
+// it truncated and mistakes that for a logout.
+func (ts *TokenStore) Save(token string) error {
+	f, err := os.CreateTemp(filepath.Dir(ts.path), filepath.Base(ts.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write token file %s: %w", ts.path, err)
+	}
+	_, err = f.WriteString(token)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), ts.path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to write token file %s: %w", ts.path, err)
+	}
+	return nil
+}
+
+// Remove deletes the stored token. A missing file is not an error.
+func (ts *TokenStore) Remove() error {
+	if err := os.Remove(ts.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove token file %s: %w", ts.path, err)
+	}
+	return nil
+}
+
+// Watch calls onChange with the stored token whenever the token file is
+// written, and with "" when it is removed, until stop is called. It runs on
+// a goroutine of its own, and only when the token actually changes.
+func (ts *TokenStore) Watch(onChange func(token string)) (stop func(), err error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch token file: %w", err)
+	}
+	// Watch the directory: the file may not exist yet, and it may be replaced
+	// rather than written in place
+	dir := filepath.Dir(ts.path)
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+
+	last, _ := ts.Load()
+	go func() {
+		defer logging.Recover("token watcher", nil)
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != ts.path {
+					continue
+				}
+				token, err := ts.Load()
+				if err != nil {
+					token = "" // Removed, or unreadable until rewritten
+				}
+				if token == last {
+					continue
+				}
+				last = token
+				onChange(token)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("Token file watcher error: %v", err)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { watcher.Close() }) }, nil
+}
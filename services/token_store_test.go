@@ -0,0 +1,57 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTokenStoreWatchSeesOnlyWholeTokens(t *testing.T) {
+	dir := t.TempDir()
+	ts := &TokenStore{path: filepath.Join(dir, tokenFileName)}
+	if err := ts.Save("first"); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := make(chan string, 16)
+	stop, err := ts.Watch(func(token string) { changes <- token })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	for _, token := range []string{"second", "third"} {
+		if err := ts.Save(token); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case got := <-changes:
+			if got != token {
+				t.Fatalf("onChange(%q), want %q", got, token)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no change seen for %q", token)
+		}
+	}
+
+	if err := ts.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-changes:
+		if got != "" {
+			t.Fatalf("onChange(%q) after removal, want \"\"", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no change seen for the removal")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("%d files left behind, want none", len(entries))
+	}
+}
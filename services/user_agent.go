@@ -0,0 +1,29 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/version"
+)
+
+// DefaultUserAgent names the app, its version and the platform, e.g.
+// "TimeTracker/1.4.0 (linux; amd64)"
+func DefaultUserAgent() string {
+	return fmt.Sprintf("TimeTracker/%s (%s; %s)", version.Version, runtime.GOOS, runtime.GOARCH)
+}
+
+// userAgent returns the configured User-Agent, or the default one
+func userAgent() string {
+	if ua := config.Current().UserAgent; ua != "" {
+		return ua
+	}
+	return DefaultUserAgent()
+}
+
+// setUserAgent sets req's User-Agent header
+func setUserAgent(req *http.Request) {
+	req.Header.Set("User-Agent", userAgent())
+}
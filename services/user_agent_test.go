@@ -0,0 +1,53 @@
+package services
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/time-tracker/v2/internal/config"
+)
+
+func TestDefaultUserAgent(t *testing.T) {
+	if ua := DefaultUserAgent(); !regexp.MustCompile(`^TimeTracker/\S+ \(\w+; \w+\)$`).MatchString(ua) {
+		t.Errorf("DefaultUserAgent() = %q, want the app, version and platform", ua)
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		want       string
+	}{
+		{"default", "", DefaultUserAgent()},
+		{"configured", "AcmeTracker/2.0", "AcmeTracker/2.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := make(chan string, 1)
+			client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				sent <- r.Header.Get("User-Agent")
+				w.Write([]byte(`{}`))
+			})
+			saved := config.Current()
+			if err := config.Update(func(s *config.Settings) { s.UserAgent = tt.configured }); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() {
+				config.Update(func(s *config.Settings) { *s = saved })
+			})
+
+			if got := userAgent(); got != tt.want {
+				t.Errorf("userAgent() = %q, want %q", got, tt.want)
+			}
+			if _, err := client.CallAPI("/api/reports", "GET", nil); err != nil {
+				t.Fatal(err)
+			}
+			if got := <-sent; got != tt.want {
+				t.Errorf("User-Agent sent = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/internal/diagnostics"
+	"github.com/time-tracker/v2/internal/version"
+)
+
+// showAboutDialog shows the version, build and file locations, with a button
+// copying a diagnostics report for support
+func (ui *TaskWindowUI) showAboutDialog() {
+	paths := diagnostics.CurrentPaths()
+	form := widget.NewForm(
+		widget.NewFormItem("Version", widget.NewLabel(version.Version)),
+		widget.NewFormItem("Commit", widget.NewLabel(version.BuildCommit())),
+		widget.NewFormItem("Data", widget.NewLabel(paths.Data)),
+		widget.NewFormItem("Settings", widget.NewLabel(paths.Settings)),
+		widget.NewFormItem("Log", widget.NewLabel(paths.Log)),
+	)
+
+	copied := widget.NewLabel("")
+	copied.Importance = widget.LowImportance
+	copyButton := widget.NewButton("Copy Diagnostics", func() {
+		ui.App.Clipboard().SetContent(diagnostics.Collect())
+		copied.SetText("Copied to the clipboard. Tokens are left out.")
+	})
+
+	content := container.NewVBox(form, copyButton, copied)
+	about := dialog.NewCustom("About Time Tracker", "Close", content, ui.Win)
+	about.Resize(fyne.NewSize(480, 0))
+	about.Show()
+}
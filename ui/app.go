@@ -0,0 +1,239 @@
+package ui
+
+import (
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"github.com/time-tracker/v2/assets"
+	"github.com/time-tracker/v2/internal/auth"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/services"
+)
+
+// AppCoordinator owns the application lifecycle and switches between the
+// login and task windows as often as needed (logout, expired tokens)
+type AppCoordinator struct {
+	App         fyne.App
+	authService auth.Service
+	tokenStore  *services.TokenStore
+
+	loginWin  fyne.Window
+	taskUI    *TaskWindowUI
+	deepLink  *DeepLink // Handed to the task window once it opens, e.g. after logging in
+	autoStart bool      // Start the remembered task once the task window loads; set only for a logged-in launch
+}
+
+// NewAppCoordinator creates a coordinator for the app a, signing in with
+// authService and keeping the session token in tokenStore
+func NewAppCoordinator(a fyne.App, authService auth.Service, tokenStore *services.TokenStore) *AppCoordinator {
+	return &AppCoordinator{
+		App:         a,
+		authService: authService,
+		tokenStore:  tokenStore,
+	}
+}
+
+// SetDeepLink makes the task window act on link once it has loaded its tasks
+func (c *AppCoordinator) SetDeepLink(link *DeepLink) {
+	c.deepLink = link
+}
+
+// Relaunch handles a later launch of the app, handed over by the instance
+// socket: it brings the app forward and acts on rawLink, the timetracker://
+// link it was opened with, unless that is "". It may be called from any goroutine.
+func (c *AppCoordinator) Relaunch(rawLink string) error {
+	var link *DeepLink
+	if rawLink != "" {
+		var err error
+		if link, err = ParseDeepLink(rawLink); err != nil {
+			return err
+		}
+	}
+	fyne.Do(func() {
+		if c.taskUI == nil {
+			if link != nil {
+				c.deepLink = link
+			}
+			if c.loginWin != nil {
+				c.loginWin.Show()
+				c.loginWin.RequestFocus()
+			}
+			return
+		}
+		c.taskUI.Win.Show()
+		c.taskUI.Win.RequestFocus()
+		if link != nil {
+			c.taskUI.openDeepLink(link)
+		}
+	})
+	return nil
+}
+
+// Run shows the task window if a token is stored, otherwise the login
+// window, and blocks in the Fyne event loop until the application quits
+func (c *AppCoordinator) Run() {
+	c.App.Lifecycle().SetOnStopped(func() {
+		// Quitting from the tray menu doesn't close the task window
+		if c.taskUI != nil {
+			saveWindowSize(c.taskUI.Win, config.WindowTask)
+		}
+	})
+	if c.hasToken() {
+		log.Println("Token exists, launching main application.")
+		c.autoStart = config.Current().AutoStartTracking
+		c.ShowTaskWindow()
+	} else {
+		log.Println("Token does not exist, launching login window.")
+		c.ShowLogin()
+	}
+	c.App.Run()
+	log.Println("Application has exited.")
+}
+
+// hasToken reports whether a token is stored
+func (c *AppCoordinator) hasToken() bool {
+	token, err := c.tokenStore.Load()
+	if err != nil {
+		log.Printf("Error loading token: %v", err)
+		return false
+	}
+	return token != ""
+}
+
+// ShowLogin shows the login window. The task window must already be closed.
+func (c *AppCoordinator) ShowLogin() {
+	if c.loginWin != nil {
+		c.loginWin.Show()
+		c.loginWin.RequestFocus()
+		return
+	}
+
+	win := NewLoginWindow(c.App, c.authService, c.onLoginSuccess, c.SwitchProfile)
+	win.SetOnClosed(func() {
+		if c.loginWin != win {
+			return // Replaced after a profile switch
+		}
+		c.loginWin = nil
+		if c.taskUI == nil {
+			// Closed without logging in
+			c.App.Quit()
+		}
+	})
+	c.loginWin = win
+	c.setLoginTray()
+	win.Show()
+}
+
+// setLoginTray replaces the task window's tray menu while logged out
+func (c *AppCoordinator) setLoginTray() {
+	desk, ok := c.App.(desktop.App)
+	if !ok {
+		return
+	}
+	loginMenuItem := fyne.NewMenuItem("Log In", c.ShowLogin)
+	desk.SetSystemTrayMenu(fyne.NewMenu("Time Tracker", loginMenuItem))
+	if iconResource := assets.GetStateIcon(assets.TrayStateStopped); iconResource != nil {
+		desk.SetSystemTrayIcon(iconResource)
+	}
+}
+
+// onLoginSuccess stores the new token and opens the task window
+func (c *AppCoordinator) onLoginSuccess(token string) {
+	log.Println("Login successful, proceeding to main application.")
+	if err := c.tokenStore.Save(token); err != nil {
+		log.Printf("Failed to save token: %v", err)
+	}
+	c.ShowTaskWindow()
+}
+
+// ShowTaskWindow shows the task window, creating it for the stored token if needed
+func (c *AppCoordinator) ShowTaskWindow() {
+	if c.taskUI == nil {
+		log.Println("Showing Task Window...")
+		c.taskUI = NewTaskWindow(c.App)
+		c.taskUI.onLogout = c.Logout
+		c.taskUI.onSwitchProfile = c.SwitchProfile
+		c.taskUI.pendingDeepLink = c.deepLink
+		c.deepLink = nil
+		c.taskUI.autoStartPending = c.autoStart
+		c.autoStart = false
+	}
+	c.taskUI.Win.Show()
+}
+
+// Logout closes the task window, finishing any running session first, then
+// forgets the token and shows the login window
+func (c *AppCoordinator) Logout() {
+	taskUI := c.taskUI
+	if taskUI == nil {
+		c.forgetToken()
+		c.ShowLogin()
+		return
+	}
+	c.taskUI = nil
+	taskUI.Close(func() {
+		c.forgetToken()
+		// Show the login window before closing the last window so the app keeps running
+		c.ShowLogin()
+		taskUI.Win.Close()
+	})
+}
+
+// SwitchProfile makes name the active server profile and reopens the app
+// against it, going straight to the task window if that profile has a stored token
+func (c *AppCoordinator) SwitchProfile(name string) {
+	if err := config.Update(func(s *config.Settings) { s.ActiveProfileName = name }); err != nil {
+		log.Printf("Failed to save active profile: %v", err)
+		return
+	}
+	tokenStore, err := services.NewTokenStore()
+	if err != nil {
+		log.Printf("Failed to locate token file: %v", err)
+		return
+	}
+	log.Printf("Switching to server profile %s", config.ActiveProfile().Name)
+	c.tokenStore = tokenStore
+	c.authService = services.NewAuthService()
+
+	reopen := func() {
+		// The login window holds the previous profile's auth service, so replace it
+		loginWin := c.loginWin
+		c.loginWin = nil
+		if c.hasToken() {
+			c.ShowTaskWindow()
+		} else {
+			c.ShowLogin()
+		}
+		if loginWin != nil {
+			loginWin.Close()
+		}
+	}
+	taskUI := c.taskUI
+	if taskUI == nil {
+		reopen()
+		return
+	}
+	c.taskUI = nil
+	taskUI.Close(func() {
+		reopen()
+		taskUI.Win.Close()
+	})
+}
+
+// windowTitle appends the active server profile to title when more than one is configured
+func windowTitle(title string) string {
+	if services.DemoMode() {
+		title += " — Demo"
+	} else if len(config.Profiles()) > 1 {
+		title += " — " + config.ActiveProfile().Name
+	}
+	return title
+}
+
+// forgetToken removes the stored token
+func (c *AppCoordinator) forgetToken() {
+	if err := c.tokenStore.Remove(); err != nil {
+		log.Printf("Failed to remove token: %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/services"
+)
+
+// newTestCoordinator returns a coordinator without an app, its token store
+// in a data directory of its own holding token if it isn't ""
+func newTestCoordinator(t *testing.T, token string) *AppCoordinator {
+	t.Helper()
+	t.Setenv(config.HomeEnv, t.TempDir())
+	store, err := services.NewTokenStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		if err := store.Save(token); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewAppCoordinator(nil, nil, store)
+}
+
+func TestCoordinatorToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		want  bool
+	}{
+		{"stored", "abc", true},
+		{"none", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCoordinator(t, tt.token)
+			if got := c.hasToken(); got != tt.want {
+				t.Errorf("hasToken() = %v, want %v", got, tt.want)
+			}
+			c.forgetToken() // Logging out, whether or not a token is stored
+			if c.hasToken() {
+				t.Error("hasToken() = true after forgetToken")
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/logging"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// autoStartSeconds is how long the user has to cancel an automatic start
+const autoStartSeconds = 5
+
+// applyLaunchActions runs what was requested for this launch once tasks have
+// loaded: a deep link, or failing that an automatic start of the remembered task
+func (ui *TaskWindowUI) applyLaunchActions() {
+	autoStart := ui.autoStartPending
+	ui.autoStartPending = false
+	ui.launchActionsDone = true
+	if ui.pendingDeepLink != nil {
+		ui.applyDeepLink()
+		return
+	}
+	if autoStart {
+		ui.autoStartLastTask()
+	}
+}
+
+// autoStartLastTask counts down and then starts tracking the remembered task,
+// unless the user cancels. Nothing happens if the task is no longer assigned.
+func (ui *TaskWindowUI) autoStartLastTask() {
+	settings := config.Current()
+	if !settings.AutoStartTracking || !settings.RememberLastTask || ui.isTimerRunning {
+		return
+	}
+	if ui.selectedTask == nil || ui.selectedTask.ID != settings.LastTaskID {
+		log.Printf("Not auto-starting: remembered task %d is no longer available", settings.LastTaskID)
+		return
+	}
+	task := *ui.selectedTask
+	countdownText := func(remaining int) string {
+		return fmt.Sprintf("Auto-starting %q in %ds…", task.Name, remaining)
+	}
+
+	message := widget.NewLabel(countdownText(autoStartSeconds))
+	message.Wrapping = fyne.TextWrapWord
+	// Set once the countdown is over, by either button or by running out
+	finished := false
+	var d *dialog.CustomDialog
+	finish := func(start bool) {
+		if finished {
+			return
+		}
+		finished = true
+		d.Hide()
+		if start {
+			ui.startAutoStartTask(task)
+		} else {
+			log.Println("Auto-start canceled")
+		}
+	}
+	cancelButton := widget.NewButton("Cancel", func() { finish(false) })
+	startButton := widget.NewButton("Start Now", func() { finish(true) })
+	startButton.Importance = widget.HighImportance
+	d = dialog.NewCustomWithoutButtons("Start Tracking", message, ui.Win)
+	d.SetButtons([]fyne.CanvasObject{cancelButton, startButton})
+	d.SetOnClosed(func() { finish(false) })
+	d.Show()
+
+	go func() {
+		defer logging.Recover("auto-start countdown", nil)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for remaining := autoStartSeconds - 1; ; remaining-- {
+			<-ticker.C
+			done := false
+			fyne.DoAndWait(func() {
+				switch {
+				case finished:
+					done = true
+				case remaining > 0:
+					message.SetText(countdownText(remaining))
+				default:
+					done = true
+					finish(true)
+				}
+			})
+			if done {
+				return
+			}
+		}
+	}()
+}
+
+// startAutoStartTask starts tracking task after the countdown, unless the
+// user has meanwhile started a session or picked another task
+func (ui *TaskWindowUI) startAutoStartTask(task types.Task) {
+	if ui.isTimerRunning || ui.selectedTask == nil || ui.selectedTask.ID != task.ID {
+		log.Println("Not auto-starting: the selection changed during the countdown")
+		return
+	}
+	log.Printf("Auto-starting task %d", task.ID)
+	if err := ui.startTracking(); err != nil {
+		dialog.ShowError(fmt.Errorf("failed to start tracking: %w", err), ui.Win)
+	}
+}
@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"runtime"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/core"
+)
+
+// macScreenRecordingSettings opens the Screen Recording privacy pane on macOS
+const macScreenRecordingSettings = "x-apple.systempreferences:com.apple.preference.security?Privacy_ScreenCapture"
+
+// macInputMonitoringSettings opens the Input Monitoring privacy pane on macOS
+const macInputMonitoringSettings = "x-apple.systempreferences:com.apple.preference.security?Privacy_ListenEvent"
+
+// onCaptureFailure is called from the screenshot goroutine after each failed capture
+func (ui *TaskWindowUI) onCaptureFailure(failures int, err error) {
+	if failures < core.MaxCaptureFailures {
+		return
+	}
+	fyne.Do(func() {
+		if ui.captureWarningShown {
+			return
+		}
+		ui.captureWarningShown = true
+		log.Printf("Screenshots unavailable: %v", err)
+		ui.showCapturePermissionDialog(err)
+	})
+}
+
+// showCapturePermissionDialog explains why screenshots can't be taken and how to fix it
+func (ui *TaskWindowUI) showCapturePermissionDialog(err error) {
+	var hint string
+	var link *widget.Hyperlink
+	switch runtime.GOOS {
+	case "darwin":
+		hint = "Grant Time Tracker the Screen Recording permission in System Settings > Privacy & Security, then restart the app."
+		if settingsURL, parseErr := url.Parse(macScreenRecordingSettings); parseErr == nil {
+			link = widget.NewHyperlink("Open Screen Recording settings", settingsURL)
+		}
+	case "linux":
+		hint = "Screen capture isn't permitted in this session. Wayland sessions block it; log in with an X11 session to enable screenshots."
+	default:
+		hint = "Check that the app is allowed to capture the screen."
+	}
+
+	message := widget.NewLabel(fmt.Sprintf(
+		"Screenshots could not be captured and have been paused for this session. Time tracking continues.\n\n%s\n\nError: %v",
+		hint, err))
+	message.Wrapping = fyne.TextWrapWord
+	content := container.NewVBox(message)
+	if link != nil {
+		content.Add(link)
+	}
+
+	d := dialog.NewCustom("Screenshots unavailable", "OK", content, ui.Win)
+	d.Resize(fyne.NewSize(380, 260))
+	d.Show()
+}
+
+// onInputMonitorFailure is called from the input monitor when its hook doesn't start
+func (ui *TaskWindowUI) onInputMonitorFailure(err error) {
+	fyne.Do(func() {
+		if ui.inputWarningShown {
+			return
+		}
+		ui.inputWarningShown = true
+		ui.showInputPermissionDialog(err)
+	})
+}
+
+// showInputPermissionDialog explains why keyboard and mouse activity isn't counted and how to fix it
+func (ui *TaskWindowUI) showInputPermissionDialog(err error) {
+	var hint string
+	var link *widget.Hyperlink
+	switch runtime.GOOS {
+	case "darwin":
+		hint = "Grant Time Tracker the Accessibility and Input Monitoring permissions in System Settings > Privacy & Security, then restart the app."
+		if settingsURL, parseErr := url.Parse(macInputMonitoringSettings); parseErr == nil {
+			link = widget.NewHyperlink("Open Input Monitoring settings", settingsURL)
+		}
+	case "linux":
+		hint = "Input monitoring needs an X11 session. Wayland sessions and headless environments don't allow it."
+	default:
+		hint = "Check that the app is allowed to monitor keyboard and mouse input."
+	}
+
+	message := widget.NewLabel(fmt.Sprintf(
+		"Keyboard and mouse activity can't be counted. Time tracking and screenshots continue.\n\n%s\n\nError: %v",
+		hint, err))
+	message.Wrapping = fyne.TextWrapWord
+	content := container.NewVBox(message)
+	if link != nil {
+		content.Add(link)
+	}
+
+	d := dialog.NewCustom("Input monitoring unavailable", "OK", content, ui.Win)
+	d.Resize(fyne.NewSize(380, 260))
+	d.Show()
+}
@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/core"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/logging"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// newConcurrentSection builds the list of tasks tracked alongside the main
+// one and the button adding them. Both stay hidden unless concurrent tasks
+// are enabled in the settings.
+func (ui *TaskWindowUI) newConcurrentSection() fyne.CanvasObject {
+	ui.concurrentBox = container.NewVBox()
+	ui.addTaskButton = widget.NewButtonWithIcon("Track Another Task...", theme.ContentAddIcon(), ui.showAddTaskDialog)
+	ui.addTaskButton.Disable()
+	if !config.Current().ConcurrentTasks {
+		ui.addTaskButton.Hide()
+	}
+	return container.NewVBox(ui.concurrentBox, ui.addTaskButton)
+}
+
+// showAddTaskDialog lets the user pick a task to track alongside the main one
+func (ui *TaskWindowUI) showAddTaskDialog() {
+	if !ui.isTimerRunning {
+		return
+	}
+	tracked := map[int]bool{}
+	if ui.selectedTask != nil {
+		tracked[ui.selectedTask.ID] = true
+	}
+	for _, ct := range ui.session.ConcurrentTasks() {
+		tracked[ct.Task.ID] = true
+	}
+
+	var options []string
+	optionTasks := map[string]types.Task{}
+	displays := taskDisplays(ui.tasks)
+	for i, task := range ui.tasks {
+		if tracked[task.ID] {
+			continue
+		}
+		options = append(options, displays[i])
+		optionTasks[displays[i]] = task
+	}
+	if len(options) == 0 {
+		dialog.ShowInformation("Track Another Task", "There are no other tasks to track.", ui.Win)
+		return
+	}
+
+	taskSelect := widget.NewSelect(options, nil)
+	taskSelect.PlaceHolder = "Select a task..."
+	items := []*widget.FormItem{widget.NewFormItem("Also track", taskSelect)}
+	dialog.ShowForm("Track Another Task", "Start", "Cancel", items, func(confirmed bool) {
+		if !confirmed || taskSelect.Selected == "" {
+			return
+		}
+		ui.addConcurrentTask(optionTasks[taskSelect.Selected])
+	}, ui.Win)
+}
+
+// addConcurrentTask starts tracking task alongside the main one, opening its
+// work report in the background
+func (ui *TaskWindowUI) addConcurrentTask(task types.Task) {
+	log.Printf("Also tracking task: %s", task.Name)
+	description := core.StartDescription(task)
+	go func() {
+		defer logging.Recover("AddTask", nil)
+		err := ui.session.AddTask(task, description)
+		fyne.Do(func() {
+			if err != nil {
+				log.Printf("Error adding concurrent task: %v", err)
+				dialog.ShowError(fmt.Errorf("failed to start tracking %s: %w", task.Name, err), ui.Win)
+			}
+			ui.rebuildConcurrentTimers()
+		})
+	}()
+}
+
+// stopConcurrentTask stops tracking a concurrent task, closing its work report in the background
+func (ui *TaskWindowUI) stopConcurrentTask(task types.Task) {
+	log.Printf("Stopping concurrent task: %s", task.Name)
+	description := core.StopDescription(task)
+	go func() {
+		defer logging.Recover("RemoveTask", nil)
+		err := ui.session.RemoveTask(task.ID, description)
+		fyne.Do(func() {
+			if err != nil {
+				log.Printf("Error stopping concurrent task: %v", err)
+				dialog.ShowError(fmt.Errorf("failed to stop tracking %s: %w", task.Name, err), ui.Win)
+			}
+			ui.rebuildConcurrentTimers()
+			ui.refreshDailyGoal()
+		})
+	}()
+}
+
+// rebuildConcurrentTimers lists the concurrent tasks, each with its timer and a stop button
+func (ui *TaskWindowUI) rebuildConcurrentTimers() {
+	ui.concurrentBox.RemoveAll()
+	ui.concurrentLabels = map[int]*widget.Label{}
+	for _, ct := range ui.session.ConcurrentTasks() {
+		task := ct.Task
+		label := widget.NewLabel("")
+		ui.concurrentLabels[task.ID] = label
+		stopButton := widget.NewButtonWithIcon("Stop", theme.MediaStopIcon(), func() { ui.stopConcurrentTask(task) })
+		ui.concurrentBox.Add(container.NewBorder(nil, nil, nil, stopButton, label))
+	}
+	ui.updateConcurrentTimers()
+	ui.concurrentBox.Refresh()
+}
+
+// updateConcurrentTimers shows how long each concurrent task has been tracked
+func (ui *TaskWindowUI) updateConcurrentTimers() {
+	for _, ct := range ui.session.ConcurrentTasks() {
+		label := ui.concurrentLabels[ct.Task.ID]
+		if label == nil {
+			continue
+		}
+		elapsed := time.Since(ct.Start)
+		label.SetText(fmt.Sprintf("Also: %s  %02d:%02d:%02d", ct.Task.Name,
+			int(elapsed.Hours()), int(elapsed.Minutes())%60, int(elapsed.Seconds())%60))
+	}
+}
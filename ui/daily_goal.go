@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/logging"
+)
+
+// dailyGoal tracks progress towards the selected task's daily goal
+type dailyGoal struct {
+	bar    *widget.ProgressBar
+	button *widget.Button
+
+	taskID       int           // Task the tracked time below was loaded for
+	trackedToday time.Duration // Saved activities for taskID that started today
+	notifiedFor  string        // "<task ID> <date>" the goal-reached notification was sent for
+}
+
+// newDailyGoalBar creates the progress bar, hidden until a task with a goal is selected
+func (ui *TaskWindowUI) newDailyGoalBar() {
+	ui.goal.bar = widget.NewProgressBar()
+	ui.goal.bar.Hide()
+	ui.goal.button = widget.NewButton("Goal...", ui.showDailyGoalDialog)
+	ui.goal.button.Disable()
+}
+
+// goalMinutes returns the selected task's daily goal, 0 if none is set
+func (ui *TaskWindowUI) goalMinutes() int {
+	if ui.selectedTask == nil {
+		return 0
+	}
+	return config.Current().DailyGoalMinutes[ui.selectedTask.ID]
+}
+
+// refreshDailyGoal reloads today's tracked time for the selected task from the
+// database. Call it whenever the selected task changes or an activity is saved.
+func (ui *TaskWindowUI) refreshDailyGoal() {
+	if ui.selectedTask == nil {
+		ui.goal.button.Disable()
+		ui.goal.bar.Hide()
+		return
+	}
+	ui.goal.button.Enable()
+	if ui.goalMinutes() == 0 {
+		ui.goal.bar.Hide()
+		return
+	}
+
+	task := *ui.selectedTask
+	go func() {
+		defer logging.Recover("refreshDailyGoal", nil)
+		now := time.Now().In(config.DisplayLocation())
+		from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		db := ui.activityTracker.Database
+		var seconds int64
+		err := db.Connect()
+		if err == nil {
+			seconds, err = db.GetTrackedSeconds(task.Name, from, from.AddDate(0, 0, 1))
+		}
+		if err != nil {
+			log.Printf("Error loading today's tracked time: %v", err)
+		}
+		fyne.Do(func() {
+			if ui.selectedTask == nil || ui.selectedTask.ID != task.ID {
+				return // Selection changed while loading
+			}
+			ui.goal.taskID = task.ID
+			ui.goal.trackedToday = time.Duration(seconds) * time.Second
+			ui.updateDailyGoal()
+		})
+	}()
+}
+
+// updateDailyGoal shows today's tracked time plus the running session against
+// the goal, notifying once per task and day when it is reached. It runs on the UI thread.
+func (ui *TaskWindowUI) updateDailyGoal() {
+	goal := time.Duration(ui.goalMinutes()) * time.Minute
+	if goal == 0 || ui.goal.taskID != ui.selectedTask.ID {
+		ui.goal.bar.Hide()
+		return
+	}
+
+	tracked := ui.goal.trackedToday
+	if ui.isTimerRunning {
+		// The running session is only saved when it stops
+		tracked += ui.stopwatch.Elapsed()
+	}
+	ui.goal.bar.Max = goal.Seconds()
+	ui.goal.bar.TextFormatter = func() string {
+		return fmt.Sprintf("%s of %s today", formatGoalDuration(tracked), formatGoalDuration(goal))
+	}
+	ui.goal.bar.SetValue(min(tracked.Seconds(), goal.Seconds()))
+	ui.goal.bar.Show()
+
+	if tracked < goal {
+		return
+	}
+	key := fmt.Sprintf("%d %s", ui.selectedTask.ID, time.Now().In(config.DisplayLocation()).Format(time.DateOnly))
+	if ui.goal.notifiedFor != key {
+		ui.goal.notifiedFor = key
+		ui.notify("Daily goal reached", fmt.Sprintf("You've tracked %s on %s today.", formatGoalDuration(goal), ui.selectedTask.Name))
+	}
+}
+
+// showDailyGoalDialog edits the selected task's daily goal
+func (ui *TaskWindowUI) showDailyGoalDialog() {
+	if ui.selectedTask == nil {
+		return
+	}
+	taskID := ui.selectedTask.ID
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("0 for no goal")
+	if minutes := ui.goalMinutes(); minutes > 0 {
+		entry.SetText(strconv.Itoa(minutes))
+	}
+	items := []*widget.FormItem{widget.NewFormItem("Minutes per day", entry)}
+	dialog.ShowForm("Daily Goal: "+ui.selectedTask.Name, "Save", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		minutes, err := strconv.Atoi(strings.TrimSpace(entry.Text))
+		if strings.TrimSpace(entry.Text) == "" {
+			minutes, err = 0, nil
+		}
+		if err != nil || minutes < 0 || minutes > 24*60 {
+			dialog.ShowError(fmt.Errorf("daily goal must be between 0 and %d minutes", 24*60), ui.Win)
+			return
+		}
+		err = config.Update(func(s *config.Settings) {
+			if minutes == 0 {
+				delete(s.DailyGoalMinutes, taskID)
+				return
+			}
+			if s.DailyGoalMinutes == nil {
+				s.DailyGoalMinutes = map[int]int{}
+			}
+			s.DailyGoalMinutes[taskID] = minutes
+		})
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("failed to save daily goal: %w", err), ui.Win)
+			return
+		}
+		ui.refreshDailyGoal()
+	}, ui.Win)
+}
+
+// formatGoalDuration formats d as hours and minutes, e.g. "1h 05m"
+func formatGoalDuration(d time.Duration) string {
+	return fmt.Sprintf("%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+	"github.com/time-tracker/v2/internal/platform"
+)
+
+// DeepLink is an action requested by opening a timetracker:// URL
+type DeepLink struct {
+	StartTaskID int // Task to select and start tracking
+}
+
+// ParseDeepLink parses a link such as timetracker://start?task=123
+func ParseDeepLink(raw string) (*DeepLink, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid link %q: %w", raw, err)
+	}
+	if u.Scheme != platform.URLScheme {
+		return nil, fmt.Errorf("invalid link %q: scheme must be %s://", raw, platform.URLScheme)
+	}
+	// timetracker://start puts the action in the host, timetracker:start in the opaque part
+	action := u.Host
+	if action == "" {
+		action = strings.Trim(u.Opaque+u.Path, "/")
+	}
+	if action != "start" {
+		return nil, fmt.Errorf("unsupported link action %q", action)
+	}
+	taskID, err := strconv.Atoi(u.Query().Get("task"))
+	if err != nil || taskID <= 0 {
+		return nil, fmt.Errorf("invalid link %q: task must be a task ID", raw)
+	}
+	return &DeepLink{StartTaskID: taskID}, nil
+}
+
+// FindDeepLink returns the first argument that is a timetracker:// URL, as
+// passed by the OS when a link is opened
+func FindDeepLink(args []string) (string, bool) {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, platform.URLScheme+":") {
+			return arg, true
+		}
+	}
+	return "", false
+}
+
+// openDeepLink acts on a link opened while the app runs: at once if the
+// launch actions have run, otherwise in their place. It runs on the UI thread.
+func (ui *TaskWindowUI) openDeepLink(link *DeepLink) {
+	log.Printf("Received link for task %d from a later launch", link.StartTaskID)
+	ui.pendingDeepLink = link
+	if ui.launchActionsDone {
+		ui.applyDeepLink()
+	}
+}
+
+// applyDeepLink starts tracking the task a deep link asked for. It runs on
+// the UI thread once tasks have loaded.
+func (ui *TaskWindowUI) applyDeepLink() {
+	link := ui.pendingDeepLink
+	if link == nil {
+		return
+	}
+	ui.pendingDeepLink = nil
+	if ui.isTimerRunning {
+		dialog.ShowInformation("Start Tracking", fmt.Sprintf("Already tracking %s.", ui.selectedTask.Name), ui.Win)
+		return
+	}
+	if err := ui.startBlocked(); err != nil {
+		dialog.ShowError(fmt.Errorf("failed to start tracking: %w", err), ui.Win)
+		return
+	}
+	if !ui.selectTaskByID(link.StartTaskID) {
+		log.Printf("Deep link task %d not found", link.StartTaskID)
+		dialog.ShowError(fmt.Errorf("the link refers to task %d, which isn't assigned to you", link.StartTaskID), ui.Win)
+		return
+	}
+	log.Printf("Starting task %d from deep link", link.StartTaskID)
+	ui.rememberSelectedTask()
+	ui.refreshDailyGoal()
+	if err := ui.startTracking(); err != nil {
+		dialog.ShowError(fmt.Errorf("failed to start tracking: %w", err), ui.Win)
+	}
+}
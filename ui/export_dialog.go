@@ -0,0 +1,194 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/core"
+	"github.com/time-tracker/v2/internal/config"
+)
+
+const (
+	exportFormatJSON = "JSON"
+	exportFormatCSV  = "CSV"
+
+	exportAllActivities = "All activities"
+	exportTaskTotals    = "Totals per task"
+	exportVerifiable    = "Verifiable log (JSON)"
+
+	dateFormat = "2006-01-02"
+)
+
+// showExportDialog asks which report and format to export, then prompts for a file
+func (ui *TaskWindowUI) showExportDialog() {
+	formatGroup := widget.NewRadioGroup([]string{exportFormatJSON, exportFormatCSV}, nil)
+	formatGroup.SetSelected(exportFormatJSON)
+	formatGroup.Required = true
+	kindGroup := widget.NewRadioGroup([]string{exportAllActivities, exportTaskTotals, exportVerifiable}, nil)
+	kindGroup.SetSelected(exportAllActivities)
+	kindGroup.Required = true
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Report", kindGroup),
+		widget.NewFormItem("Format", formatGroup),
+	}
+	dialog.ShowForm("Export Activities", "Choose File...", "Cancel", items, func(confirmed bool) {
+		if confirmed {
+			ui.saveExport(kindGroup.Selected, formatGroup.Selected)
+		}
+	}, ui.Win)
+}
+
+// saveExport prompts for a destination and writes the chosen export to it
+func (ui *TaskWindowUI) saveExport(kind, format string) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, ui.Win)
+			return
+		}
+		if writer == nil {
+			return // Cancelled
+		}
+		defer writer.Close()
+
+		if err := ui.writeExport(writer, kind, format); err != nil {
+			log.Printf("Export failed: %v", err)
+			dialog.ShowError(fmt.Errorf("export failed: %w", err), ui.Win)
+			return
+		}
+		log.Printf("Exported %s as %s to %s", kind, format, writer.URI().Path())
+	}, ui.Win)
+
+	name := "activities"
+	switch kind {
+	case exportTaskTotals:
+		name = "task_totals"
+	case exportVerifiable:
+		name = "activity_log"
+	}
+	if format == exportFormatCSV && kind != exportVerifiable {
+		save.SetFileName(name + ".csv")
+	} else {
+		save.SetFileName(name + ".json")
+	}
+	save.Show()
+}
+
+// writeExport writes the requested report in the requested format
+func (ui *TaskWindowUI) writeExport(w io.Writer, kind, format string) error {
+	db := ui.activityTracker.Database
+	if err := db.Connect(); err != nil {
+		return err
+	}
+	switch {
+	case kind == exportVerifiable:
+		// Always JSON, which carries the hash chain
+		return db.ExportVerifiable(w)
+	case kind == exportTaskTotals && format == exportFormatCSV:
+		return db.ExportTaskTotalsCSV(w)
+	case kind == exportTaskTotals:
+		return db.ExportTaskTotalsJSON(w)
+	case format == exportFormatCSV:
+		return db.ExportCSV(w)
+	default:
+		return db.ExportJSON(w)
+	}
+}
+
+// showVerifyLogDialog checks the hash chain of a verifiable activity log
+// chosen by the user
+func (ui *TaskWindowUI) showVerifyLogDialog() {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, ui.Win)
+			return
+		}
+		if reader == nil {
+			return // Cancelled
+		}
+		defer reader.Close()
+
+		count, err := core.Verify(reader)
+		if err != nil {
+			log.Printf("Activity log %s failed verification: %v", reader.URI().Path(), err)
+			dialog.ShowError(fmt.Errorf("verification failed: %w", err), ui.Win)
+			return
+		}
+		dialog.ShowInformation("Activity Log Verified",
+			fmt.Sprintf("All %d records match their hashes. The log has not been modified since it was exported.", count), ui.Win)
+	}, ui.Win)
+	open.Show()
+}
+
+// showICSExportDialog asks for a date range and saves those activities as an .ics calendar
+func (ui *TaskWindowUI) showICSExportDialog() {
+	now := time.Now().In(config.DisplayLocation())
+	fromEntry := widget.NewEntry()
+	fromEntry.SetText(now.AddDate(0, 0, -30).Format(dateFormat))
+	toEntry := widget.NewEntry()
+	toEntry.SetText(now.Format(dateFormat))
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("From (YYYY-MM-DD)", fromEntry),
+		widget.NewFormItem("To (YYYY-MM-DD)", toEntry),
+	}
+	dialog.ShowForm("Export to Calendar", "Choose File...", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		from, err := time.ParseInLocation(dateFormat, fromEntry.Text, config.DisplayLocation())
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("invalid start date %q", fromEntry.Text), ui.Win)
+			return
+		}
+		to, err := time.ParseInLocation(dateFormat, toEntry.Text, config.DisplayLocation())
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("invalid end date %q", toEntry.Text), ui.Win)
+			return
+		}
+		// The end date is inclusive
+		to = to.AddDate(0, 0, 1)
+		if !to.After(from) {
+			dialog.ShowError(fmt.Errorf("the end date must not be before the start date"), ui.Win)
+			return
+		}
+		ui.saveICSExport(from, to)
+	}, ui.Win)
+}
+
+// saveICSExport prompts for a destination and writes the activities in [from, to) to it
+func (ui *TaskWindowUI) saveICSExport(from, to time.Time) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, ui.Win)
+			return
+		}
+		if writer == nil {
+			return // Cancelled
+		}
+		defer writer.Close()
+
+		db := ui.activityTracker.Database
+		err = db.Connect()
+		if err == nil {
+			var records []core.ActivityRecord
+			records, err = db.GetActivitiesByDateRange(from, to)
+			if err == nil {
+				err = core.WriteICS(writer, records)
+			}
+		}
+		if err != nil {
+			log.Printf("Calendar export failed: %v", err)
+			dialog.ShowError(fmt.Errorf("calendar export failed: %w", err), ui.Win)
+			return
+		}
+		log.Printf("Exported calendar to %s", writer.URI().Path())
+	}, ui.Win)
+	save.SetFileName("time-tracker.ics")
+	save.Show()
+}
@@ -0,0 +1,308 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/core"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/logging"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// historyTimeFormat is how activity times are shown and edited in the history window
+const historyTimeFormat = "2006-01-02 15:04"
+
+// historyWindow lists past activities so they can be corrected or deleted
+type historyWindow struct {
+	ui  *TaskWindowUI
+	win fyne.Window
+
+	list       *widget.List
+	countLabel *widget.Label
+	records    []core.ActivityRecord // Newest first
+}
+
+// showHistoryWindow opens the activity history window
+func (ui *TaskWindowUI) showHistoryWindow() {
+	h := &historyWindow{ui: ui, win: ui.App.NewWindow("History")}
+
+	h.list = widget.NewList(
+		func() int { return len(h.records) },
+		func() fyne.CanvasObject {
+			uploadButton := widget.NewButton("Re-upload", nil)
+			editButton := widget.NewButton("Edit", nil)
+			deleteButton := widget.NewButton("Delete", nil)
+			deleteButton.Importance = widget.DangerImportance
+			return container.NewHBox(widget.NewLabel(""), layout.NewSpacer(), uploadButton, editButton, deleteButton)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			r := h.records[id]
+			row := item.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(describeActivity(r))
+			uploadButton := row.Objects[2].(*widget.Button)
+			uploadButton.OnTapped = func() { h.confirmReupload(r) }
+			if r.WorkReportID == 0 {
+				uploadButton.Hide()
+			} else {
+				uploadButton.Show()
+			}
+			row.Objects[3].(*widget.Button).OnTapped = func() { h.showEditDialog(r) }
+			row.Objects[4].(*widget.Button).OnTapped = func() { h.confirmDelete(r) }
+		},
+	)
+	h.countLabel = widget.NewLabel("Loading...")
+
+	h.win.SetContent(container.NewBorder(nil, h.countLabel, nil, nil, h.list))
+	restoreWindowSize(h.win, config.WindowHistory, fyne.NewSize(720, 480))
+	h.win.SetCloseIntercept(func() {
+		saveWindowSize(h.win, config.WindowHistory)
+		h.win.Close()
+	})
+	h.win.Show()
+	h.load()
+}
+
+// describeActivity summarizes an activity on one line
+func describeActivity(r core.ActivityRecord) string {
+	loc := config.DisplayLocation()
+	end := "?"
+	if r.EndTime != nil {
+		end = r.EndTime.In(loc).Format("15:04")
+	}
+	d := time.Duration(r.DurationSeconds) * time.Second
+	return fmt.Sprintf("%s  %s–%s  (%s)  %s", r.StartTime.In(loc).Format("2006-01-02"),
+		r.StartTime.In(loc).Format("15:04"), end, formatGoalDuration(d), r.Task)
+}
+
+// load reads the activities from the database, newest first
+func (h *historyWindow) load() {
+	go func() {
+		defer logging.Recover("history", nil)
+		db := h.ui.activityTracker.Database
+		var records []core.ActivityRecord
+		err := db.Connect()
+		if err == nil {
+			records, err = db.GetActivityRecords()
+		}
+		fyne.Do(func() {
+			if err != nil {
+				log.Printf("Error loading activities: %v", err)
+				dialog.ShowError(fmt.Errorf("could not load activities: %w", err), h.win)
+				return
+			}
+			slices.Reverse(records)
+			h.records = records
+			h.list.Refresh()
+			h.countLabel.SetText(fmt.Sprintf("%d activities", len(records)))
+		})
+	}()
+}
+
+// showEditDialog edits an activity's task and times
+func (h *historyWindow) showEditDialog(r core.ActivityRecord) {
+	taskNames := []string{}
+	for _, t := range h.ui.tasks {
+		if !slices.Contains(taskNames, t.Name) {
+			taskNames = append(taskNames, t.Name)
+		}
+	}
+	if !slices.Contains(taskNames, r.Task) {
+		taskNames = append([]string{r.Task}, taskNames...)
+	}
+	taskSelect := widget.NewSelect(taskNames, nil)
+	taskSelect.SetSelected(r.Task)
+
+	startText := r.StartTime.In(config.DisplayLocation()).Format(historyTimeFormat)
+	startEntry := widget.NewEntry()
+	startEntry.SetText(startText)
+	endText := ""
+	if r.EndTime != nil {
+		endText = r.EndTime.In(config.DisplayLocation()).Format(historyTimeFormat)
+	}
+	endEntry := widget.NewEntry()
+	endEntry.SetPlaceHolder("YYYY-MM-DD HH:MM")
+	endEntry.SetText(endText)
+	descriptionEntry := widget.NewMultiLineEntry()
+	descriptionEntry.SetText(r.Description)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Task", taskSelect),
+		widget.NewFormItem("Start", startEntry),
+		widget.NewFormItem("End", endEntry),
+		widget.NewFormItem("Description", descriptionEntry),
+	}
+	dialog.ShowForm("Edit Activity", "Save", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		// Unchanged fields keep their seconds, which the entries don't show
+		start, err := parseHistoryTime(startEntry.Text, startText, r.StartTime)
+		if err != nil {
+			dialog.ShowError(err, h.win)
+			return
+		}
+		var originalEnd time.Time
+		if r.EndTime != nil {
+			originalEnd = *r.EndTime
+		}
+		end, err := parseHistoryTime(endEntry.Text, endText, originalEnd)
+		if err != nil {
+			dialog.ShowError(err, h.win)
+			return
+		}
+		if !end.After(start) {
+			dialog.ShowError(fmt.Errorf("the end time must be after the start time"), h.win)
+			return
+		}
+		if taskSelect.Selected != r.Task || !start.Equal(r.StartTime) || r.EndTime == nil || !end.Equal(*r.EndTime) {
+			h.update(r, taskSelect.Selected, start, end)
+		}
+		if description := strings.TrimSpace(descriptionEntry.Text); description != r.Description {
+			h.updateDescription(r, description)
+		}
+	}, h.win)
+}
+
+// parseHistoryTime parses an edited time, returning original if the text wasn't changed
+func parseHistoryTime(text, originalText string, original time.Time) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	if text == originalText && !original.IsZero() {
+		return original, nil
+	}
+	t, err := time.ParseInLocation(historyTimeFormat, text, config.DisplayLocation())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, use YYYY-MM-DD HH:MM", text)
+	}
+	return t, nil
+}
+
+// update saves an edited activity and, if it has a work report, corrects it on the server too
+func (h *historyWindow) update(r core.ActivityRecord, task string, start, end time.Time) {
+	if err := h.ui.activityTracker.Database.UpdateActivity(r.ID, task, start, end); err != nil {
+		log.Printf("Failed to update activity %d: %v", r.ID, err)
+		dialog.ShowError(err, h.win)
+		return
+	}
+	log.Printf("Updated activity %d", r.ID)
+	h.changed()
+
+	if r.WorkReportID == 0 {
+		return
+	}
+	var newTask *types.Task
+	if task != r.Task {
+		for i := range h.ui.tasks {
+			if h.ui.tasks[i].Name == task {
+				newTask = &h.ui.tasks[i]
+				break
+			}
+		}
+	}
+	go func() {
+		defer logging.Recover("UpdateWorkReport", nil)
+		err := h.ui.taskManager.UpdateWorkReport(int(r.WorkReportID), newTask, start, end)
+		if err != nil {
+			log.Printf("Failed to update work report %d: %v", r.WorkReportID, err)
+			fyne.Do(func() {
+				dialog.ShowError(fmt.Errorf("the activity was saved locally but the server could not be updated: %w", err), h.win)
+			})
+		}
+	}()
+}
+
+// updateDescription saves an activity's description and syncs it to its work report
+func (h *historyWindow) updateDescription(r core.ActivityRecord, description string) {
+	go func() {
+		defer logging.Recover("UpdateDescription", nil)
+		err := h.ui.session.UpdateDescription(r, description)
+		fyne.Do(func() {
+			if err != nil {
+				log.Printf("Failed to update description of activity %d: %v", r.ID, err)
+				dialog.ShowError(fmt.Errorf("the description could not be synced: %w", err), h.win)
+			}
+			h.load()
+		})
+	}()
+}
+
+// confirmReupload uploads the session's local screenshots to its work report again,
+// e.g. after capturing them offline, showing progress and which files failed
+func (h *historyWindow) confirmReupload(r core.ActivityRecord) {
+	message := fmt.Sprintf("Upload the screenshots stored for work report %d again?", r.WorkReportID)
+	dialog.ShowConfirm("Re-upload Session Screenshots", message, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		bar := widget.NewProgressBar()
+		progress := dialog.NewCustomWithoutButtons("Uploading Screenshots", bar, h.win)
+		progress.Show()
+		go func() {
+			defer logging.Recover("ReuploadScreenshots", nil)
+			results, err := h.ui.session.ReuploadScreenshots(int(r.WorkReportID), func(done, total int) {
+				fyne.Do(func() { bar.SetValue(float64(done) / float64(total)) })
+			})
+			fyne.Do(func() {
+				progress.Hide()
+				if err != nil {
+					dialog.ShowError(fmt.Errorf("could not re-upload screenshots: %w", err), h.win)
+					return
+				}
+				dialog.ShowInformation("Re-upload Finished", describeUploadResults(results), h.win)
+			})
+		}()
+	}, h.win)
+}
+
+// describeUploadResults summarizes a batch upload, listing the files that failed
+func describeUploadResults(results []core.UploadResult) string {
+	if len(results) == 0 {
+		return "No screenshots are stored for this session."
+	}
+	var failed []string
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", filepath.Base(result.Path), result.Err))
+		}
+	}
+	summary := fmt.Sprintf("%d of %d screenshots uploaded.", len(results)-len(failed), len(results))
+	if len(failed) == 0 {
+		return summary
+	}
+	return summary + "\n\nFailed:\n" + strings.Join(failed, "\n")
+}
+
+// confirmDelete deletes an activity after confirmation
+func (h *historyWindow) confirmDelete(r core.ActivityRecord) {
+	message := "Delete this activity?"
+	if r.WorkReportID != 0 {
+		message += "\nIts work report stays on the server."
+	}
+	dialog.ShowConfirm("Delete Activity", message, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		if err := h.ui.activityTracker.Database.DeleteActivity(r.ID); err != nil {
+			log.Printf("Failed to delete activity %d: %v", r.ID, err)
+			dialog.ShowError(err, h.win)
+			return
+		}
+		log.Printf("Deleted activity %d", r.ID)
+		h.changed()
+	}, h.win)
+}
+
+// changed reloads the list and the views summarizing tracked time
+func (h *historyWindow) changed() {
+	h.load()
+	h.ui.refreshDailyGoal()
+}
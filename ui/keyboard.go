@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// Keyboard access to the main window. Fyne moves focus with Tab in the order
+// widgets appear in the window, so focus is also handed along as the timer is
+// used: choosing a task focuses Start, starting focuses Stop and stopping
+// focuses Start again, with Space or Enter pressing the focused button.
+var (
+	// timerShortcut starts or stops the timer (Ctrl+Enter, or Cmd+Enter on macOS)
+	timerShortcut = &desktop.CustomShortcut{KeyName: fyne.KeyReturn, Modifier: fyne.KeyModifierShortcutDefault}
+	// refreshShortcut reloads the task list (Ctrl+R, or Cmd+R on macOS)
+	refreshShortcut = &desktop.CustomShortcut{KeyName: fyne.KeyR, Modifier: fyne.KeyModifierShortcutDefault}
+)
+
+// addKeyboardShortcuts registers the main window's shortcuts
+func (ui *TaskWindowUI) addKeyboardShortcuts() {
+	c := ui.Win.Canvas()
+	c.AddShortcut(paletteShortcut, func(fyne.Shortcut) { ui.showTaskPalette() })
+	c.AddShortcut(timerShortcut, func(fyne.Shortcut) { ui.toggleTimer() })
+	c.AddShortcut(refreshShortcut, func(fyne.Shortcut) {
+		if !ui.refreshButton.Disabled() {
+			ui.refresh()
+		}
+	})
+}
+
+// toggleTimer stops the timer if it runs and starts it otherwise
+func (ui *TaskWindowUI) toggleTimer() {
+	if ui.isTimerRunning {
+		ui.stopTimer()
+		return
+	}
+	ui.startTimer()
+}
+
+// focusButton moves keyboard focus to b if it can take it
+func (ui *TaskWindowUI) focusButton(b fyne.Disableable) {
+	if b.Disabled() {
+		return
+	}
+	if f, ok := b.(fyne.Focusable); ok {
+		ui.Win.Canvas().Focus(f)
+	}
+}
@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"github.com/time-tracker/v2/core"
+	"github.com/time-tracker/v2/internal/logging"
+)
+
+// showLegacyImportDialog imports the history of the previous version of the
+// app from a database or CSV file chosen by the user
+func (ui *TaskWindowUI) showLegacyImportDialog() {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, ui.Win)
+			return
+		}
+		if reader == nil {
+			return // Cancelled
+		}
+		uri := reader.URI()
+		reader.Close() // The importer opens the file itself
+
+		go func() {
+			defer logging.Recover("importLegacy", nil)
+			db := ui.activityTracker.Database
+			var result core.LegacyImportResult
+			err := db.Connect()
+			if err == nil {
+				result, err = db.ImportLegacy(uri.Path())
+			}
+			fyne.Do(func() {
+				if err != nil {
+					log.Printf("Importing %s failed: %v", uri.Path(), err)
+					dialog.ShowError(fmt.Errorf("import failed: %w", err), ui.Win)
+					return
+				}
+				dialog.ShowInformation("Import Complete",
+					fmt.Sprintf("Imported %d activities from %s.\nSkipped %d: %d imported before, %d unreadable (see the log).",
+						result.Imported, uri.Name(), result.Skipped(), result.Duplicates, result.Invalid), ui.Win)
+				ui.refreshDailyGoal()
+			})
+		}()
+	}, ui.Win)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".db", ".sqlite", ".sqlite3", ".csv"}))
+	open.Show()
+}
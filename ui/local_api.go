@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/time-tracker/v2/core"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/localapi"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// startLocalAPI starts the local API server if it is enabled in settings
+func (ui *TaskWindowUI) startLocalAPI() {
+	settings := config.Current()
+	if !settings.LocalAPIEnabled {
+		return
+	}
+	if settings.LocalAPIToken == "" {
+		log.Println("Local API is enabled but no token is configured; not starting it")
+		return
+	}
+	server := localapi.NewServer(ui, settings.LocalAPIPort, settings.LocalAPIToken)
+	if err := server.Start(); err != nil {
+		log.Printf("Failed to start local API: %v", err)
+		return
+	}
+	ui.localAPI = server
+}
+
+// Tasks implements localapi.Controller
+func (ui *TaskWindowUI) Tasks() ([]types.Task, error) {
+	return ui.taskManager.GetTasks()
+}
+
+// Start implements localapi.Controller by selecting the task and starting the timer
+func (ui *TaskWindowUI) Start(taskID int) error {
+	var err error
+	fyne.DoAndWait(func() {
+		if err = ui.startBlocked(); err != nil {
+			return
+		}
+		if !ui.selectTaskByID(taskID) {
+			err = fmt.Errorf("task %d not found", taskID)
+			return
+		}
+		ui.rememberSelectedTask()
+		err = ui.startTracking()
+	})
+	return err
+}
+
+// Stop implements localapi.Controller
+func (ui *TaskWindowUI) Stop() error {
+	var err error
+	fyne.DoAndWait(func() {
+		if !ui.isTimerRunning {
+			err = fmt.Errorf("not tracking")
+			return
+		}
+		ui.stopTracking(false)
+	})
+	return err
+}
+
+// Status implements localapi.Controller
+func (ui *TaskWindowUI) Status() localapi.Status {
+	var status localapi.Status
+	fyne.DoAndWait(func() {
+		status.Tracking = ui.isTimerRunning
+		status.ElapsedSeconds = int(ui.stopwatch.Elapsed().Seconds())
+		if ui.isTimerRunning && ui.selectedTask != nil {
+			task := *ui.selectedTask
+			status.Task = &task
+		}
+	})
+	return status
+}
+
+// Metrics implements localapi.Controller with today's and this week's totals
+func (ui *TaskWindowUI) Metrics() (localapi.Metrics, error) {
+	metrics := localapi.Metrics{Status: ui.Status()}
+	db := ui.activityTracker.Database
+	if err := db.Connect(); err != nil {
+		return metrics, err
+	}
+	now := time.Now().In(config.DisplayLocation())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	// Weeks start on Monday
+	week := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	var err error
+	if metrics.Today, err = localTotals(db, today, tomorrow); err != nil {
+		return metrics, err
+	}
+	if metrics.Week, err = localTotals(db, week, tomorrow); err != nil {
+		return metrics, err
+	}
+	return metrics, nil
+}
+
+// localTotals loads the task totals within [from, to) in the local API's shape
+func localTotals(db *core.Database, from, to time.Time) ([]localapi.TaskTotal, error) {
+	totals, err := db.GetTaskTotalsBetween(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tracked time: %w", err)
+	}
+	result := make([]localapi.TaskTotal, len(totals))
+	for i, t := range totals {
+		result[i] = localapi.TaskTotal{Task: t.Task, Sessions: t.Sessions, TrackedSeconds: t.DurationSeconds, Local: t.Local}
+	}
+	return result, nil
+}
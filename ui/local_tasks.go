@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"log"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// newLocalTaskOption is the task picker entry that creates a local task
+const newLocalTaskOption = "+ New local task..."
+
+// loadLocalTasks returns the local-only tasks, or none if they can't be read.
+// It may be called off the UI thread.
+func (ui *TaskWindowUI) loadLocalTasks() []types.Task {
+	db := ui.activityTracker.Database
+	if err := db.Connect(); err != nil {
+		log.Printf("Error loading local tasks: %v", err)
+		return nil
+	}
+	tasks, err := db.GetLocalTasks()
+	if err != nil {
+		log.Printf("Error loading local tasks: %v", err)
+		return nil
+	}
+	return tasks
+}
+
+// showNewLocalTaskDialog asks for a name, then adds and selects a local-only
+// task. Local tasks are tracked and their screenshots kept on this machine,
+// but nothing is sent to the server.
+func (ui *TaskWindowUI) showNewLocalTaskDialog() {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("e.g. Admin, Reading")
+	items := []*widget.FormItem{widget.NewFormItem("Name", nameEntry)}
+	dialog.ShowForm("New Local Task", "Create", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		db := ui.activityTracker.Database
+		err := db.Connect()
+		var task types.Task
+		if err == nil {
+			task, err = db.CreateLocalTask(nameEntry.Text)
+		}
+		if err != nil {
+			log.Printf("Failed to create local task: %v", err)
+			dialog.ShowError(err, ui.Win)
+			return
+		}
+		log.Printf("Created local task %q", task.Name)
+		ui.tasks = append(ui.tasks, task)
+		ui.selectTaskByID(task.ID)
+		ui.rememberSelectedTask()
+		ui.refreshDailyGoal()
+	}, ui.Win)
+}
@@ -1,14 +1,20 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"net/mail"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 	"github.com/time-tracker/v2/internal/auth"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/logging"
+	"github.com/time-tracker/v2/services"
 )
 
 var authService auth.Service
@@ -19,14 +25,15 @@ func SetAuthService(service auth.Service) {
 }
 
 // NewLoginWindow creates and returns the login window.
-// It calls the onSuccess callback with the user's token upon successful login.
-func NewLoginWindow(a fyne.App, service auth.Service, onSuccess func(token string)) fyne.Window {
+// It calls the onSuccess callback with the user's token upon successful login,
+// and onSwitchProfile when another server profile is picked.
+func NewLoginWindow(a fyne.App, service auth.Service, onSuccess func(token string), onSwitchProfile func(name string)) fyne.Window {
 	if service == nil {
 		log.Fatal("Auth service not provided to NewLoginWindow")
 	}
 	authService = service // Keep the package-level variable for consistency if needed elsewhere, or remove if only used here.
 
-	win := a.NewWindow("Login")
+	win := a.NewWindow(windowTitle("Login"))
 
 	emailEntry := widget.NewEntry()
 	emailEntry.SetPlaceHolder("Email")
@@ -36,23 +43,28 @@ func NewLoginWindow(a fyne.App, service auth.Service, onSuccess func(token strin
 
 	statusLabel := widget.NewLabel("") // To show error messages
 
-	loginButton := widget.NewButton("Login", func() {
-		statusLabel.SetText("Logging in...") // Provide feedback
-		email := emailEntry.Text
-		password := passwordEntry.Text
+	progress := widget.NewProgressBarInfinite()
+	progress.Hide()
 
-		if email == "" || password == "" {
-			log.Println("Email and password cannot be empty")
-			statusLabel.SetText("Email and password required.")
-			dialog.ShowError(fmt.Errorf("email and password cannot be empty"), win) // Show dialog too
-			return
-		}
+	// Set when the user closes the window, so a late login response is ignored
+	closed := false
+	win.SetCloseIntercept(func() {
+		closed = true
+		win.Close()
+	})
 
-		// Assume Login returns a user object with a Token field and an error
-		// Adjust this based on the actual signature of authService.Login
-		user, err := authService.Login(email, password)
+	// handleLoginResult reports the outcome of authService.Login on the UI thread
+	handleLoginResult := func(user *auth.User, err error) {
 		if err != nil {
 			log.Printf("Login failed: %v", err)
+			// Prefer the server's own explanation (e.g. "Invalid credentials")
+			var apiErr *services.APIError
+			switch {
+			case errors.Is(err, services.ErrNetworkUnreachable):
+				err = errors.New("cannot reach the server, check your network connection and proxy settings")
+			case errors.As(err, &apiErr):
+				err = errors.New(apiErr.UserMessage())
+			}
 			statusLabel.SetText("Login failed: " + err.Error())
 			dialog.ShowError(err, win) // Show specific error
 			return
@@ -72,13 +84,70 @@ func NewLoginWindow(a fyne.App, service auth.Service, onSuccess func(token strin
 			statusLabel.SetText("Invalid email or password.")
 			dialog.ShowError(fmt.Errorf("invalid email or password"), win)
 		}
+	}
+
+	var loginButton *widget.Button
+	loginButton = widget.NewButton("Login", func() {
+		email := strings.TrimSpace(emailEntry.Text)
+		password := passwordEntry.Text
+
+		if email == "" || password == "" {
+			log.Println("Email and password cannot be empty")
+			statusLabel.SetText("Email and password required.")
+			dialog.ShowError(fmt.Errorf("email and password cannot be empty"), win) // Show dialog too
+			return
+		}
+		if !validEmail(email) {
+			statusLabel.SetText("Please enter a valid email address.")
+			return
+		}
+		emailEntry.SetText(email)
+
+		statusLabel.SetText("Logging in...") // Provide feedback
+		// Prevent double submission while the request is in flight
+		loginButton.Disable()
+		progress.Show()
+
+		go func() {
+			defer logging.Recover("login", func() {
+				fyne.Do(func() {
+					if closed {
+						return
+					}
+					progress.Hide()
+					loginButton.Enable()
+					statusLabel.SetText("Login failed: unexpected error")
+				})
+			})
+			// Assume Login returns a user object with a Token field and an error
+			// Adjust this based on the actual signature of authService.Login
+			user, err := authService.Login(email, password)
+			fyne.Do(func() {
+				if closed {
+					// The window was closed mid-request; nothing left to update
+					log.Println("Login window closed before the login request finished")
+					return
+				}
+				progress.Hide()
+				loginButton.Enable()
+				handleLoginResult(user, err)
+			})
+		}()
+	})
+
+	profileSelect := newProfileSelect(func(name string) {
+		if onSwitchProfile != nil {
+			onSwitchProfile(name)
+		}
 	})
 
 	form := container.NewVBox(
 		widget.NewLabel("Please Log In"),
+		profileSelect,
 		emailEntry,
 		passwordEntry,
 		loginButton,
+		progress,
 		statusLabel, // Add status label to the form
 	)
 
@@ -88,3 +157,35 @@ func NewLoginWindow(a fyne.App, service auth.Service, onSuccess func(token strin
 	win.CenterOnScreen()   // Center the login window
 	return win
 }
+
+// newProfileSelect returns a picker for the configured server profiles, hidden
+// when only production exists. onChanged is called when the user picks another profile.
+func newProfileSelect(onChanged func(name string)) *widget.Select {
+	profiles := config.Profiles()
+	names := make([]string, len(profiles))
+	for i, p := range profiles {
+		names[i] = p.Name
+	}
+	active := config.ActiveProfile().Name
+	profileSelect := widget.NewSelect(names, nil)
+	profileSelect.Selected = active
+	profileSelect.OnChanged = func(name string) {
+		if name != active && onChanged != nil {
+			onChanged(name)
+		}
+	}
+	if len(profiles) < 2 {
+		profileSelect.Hide()
+	}
+	return profileSelect
+}
+
+// validEmail reports whether email is a bare address such as user@example.com
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return false
+	}
+	at := strings.LastIndex(email, "@")
+	return at > 0 && strings.Contains(email[at+1:], ".")
+}
@@ -0,0 +1,124 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/logging"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// checkOpenWorkReport asks the server for a work report left open, e.g. by a
+// crash or on another device, and shows it in the status card. If there is
+// one, it asks the user what to do with it; otherwise the launch actions run.
+// It is called off the UI thread.
+func (ui *TaskWindowUI) checkOpenWorkReport() {
+	status, err := ui.taskManager.CheckServerStatus()
+	if err != nil {
+		log.Printf("Failed to check for an open work report: %v", err)
+	}
+	report := status.Report
+	fyne.Do(func() {
+		ui.showServerStatus(status)
+		if report == nil || ui.isTimerRunning {
+			ui.applyLaunchActions()
+			return
+		}
+		log.Printf("Work report %d was left open on the server", report.ID)
+		ui.dropLaunchActions()
+		ui.showOpenReportDialog(*report)
+	})
+}
+
+// showOpenReportDialog asks whether to resume tracking into report or close it
+func (ui *TaskWindowUI) showOpenReportDialog(report types.WorkReport) {
+	started := "an unknown time"
+	if report.StartTime != nil {
+		started = report.StartTime.In(config.DisplayLocation()).Format("Jan 02, 2006 03:04 PM")
+	}
+	message := widget.NewLabel(fmt.Sprintf(
+		"A work report for %q, started %s, is still open on the server.\n\n"+
+			"Close it out with the time you stopped working, or resume tracking into it now.",
+		report.Task.Name, started))
+	message.Wrapping = fyne.TextWrapWord
+
+	var d *dialog.CustomDialog
+	closeButton := widget.NewButton("Close It Out", func() {
+		d.Hide()
+		ui.showCloseOpenReportDialog(report)
+	})
+	resumeButton := widget.NewButton("Resume", func() {
+		d.Hide()
+		ui.resumeOpenReport(report)
+	})
+	resumeButton.Importance = widget.HighImportance
+
+	d = dialog.NewCustomWithoutButtons("Open Work Report", message, ui.Win)
+	d.SetButtons([]fyne.CanvasObject{closeButton, resumeButton})
+	d.Resize(fyne.NewSize(380, 240))
+	ui.Win.Show()
+	d.Show()
+}
+
+// showCloseOpenReportDialog asks when work on report ended and closes it
+func (ui *TaskWindowUI) showCloseOpenReportDialog(report types.WorkReport) {
+	now := time.Now()
+	nowText := now.In(config.DisplayLocation()).Format(historyTimeFormat)
+	endEntry := widget.NewEntry()
+	endEntry.SetText(nowText)
+	items := []*widget.FormItem{
+		widget.NewFormItem("Ended (YYYY-MM-DD HH:MM)", endEntry),
+	}
+	dialog.ShowForm("Close Work Report", "Close Report", "Back", items, func(confirmed bool) {
+		if !confirmed {
+			ui.showOpenReportDialog(report)
+			return
+		}
+		end, err := parseHistoryTime(endEntry.Text, nowText, now)
+		if err == nil && report.StartTime != nil && end.Before(*report.StartTime) {
+			err = fmt.Errorf("the end time is before the report started")
+		}
+		if err == nil && end.After(time.Now()) {
+			err = fmt.Errorf("the end time is in the future")
+		}
+		if err != nil {
+			dialog.ShowError(err, ui.Win)
+			ui.showCloseOpenReportDialog(report)
+			return
+		}
+		go func() {
+			defer logging.Recover("close open work report", nil)
+			err := ui.taskManager.CloseWorkReportByID(report.ID, end, interruptedDescription)
+			fyne.Do(func() {
+				if err != nil {
+					log.Printf("Failed to close open work report: %v", err)
+					dialog.ShowError(fmt.Errorf("could not close the work report: %w", err), ui.Win)
+					return
+				}
+				ui.serverStatusLabel.Hide()
+			})
+		}()
+	}, ui.Win)
+}
+
+// resumeOpenReport starts tracking report's task, continuing the open report
+func (ui *TaskWindowUI) resumeOpenReport(report types.WorkReport) {
+	if ui.isTimerRunning {
+		return
+	}
+	if !ui.selectTaskByID(report.Task.ID) {
+		dialog.ShowError(fmt.Errorf("task %q is no longer assigned to you", report.Task.Name), ui.Win)
+		ui.showOpenReportDialog(report)
+		return
+	}
+	ui.rememberSelectedTask()
+	ui.refreshDailyGoal()
+	if err := ui.beginTracking(func() error { return ui.session.AdoptWorkReport(report) }); err != nil {
+		dialog.ShowError(fmt.Errorf("failed to resume tracking: %w", err), ui.Win)
+	}
+}
@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/time-tracker/v2/internal/config"
+)
+
+// resetPomodoro starts a fresh focus interval
+func (ui *TaskWindowUI) resetPomodoro() {
+	ui.pomodoroOnBreak = false
+	ui.pomodoroPhaseElapsed = 0
+	ui.pomodorosCompleted = 0
+}
+
+// onPomodoroToggled handles the Pomodoro mode checkbox
+func (ui *TaskWindowUI) onPomodoroToggled(enabled bool) {
+	ui.pomodoroOnBreak = false
+	ui.pomodoroPhaseElapsed = 0
+	if !enabled && ui.isPaused && !ui.lockPaused {
+		ui.resumeTracking()
+	}
+	ui.updateStatusLabel()
+}
+
+// checkPomodoro advances the Pomodoro phase by one tick, switching between
+// focus and break and notifying the user at each boundary. It runs on the UI thread.
+func (ui *TaskWindowUI) checkPomodoro() {
+	if !ui.pomodoroCheck.Checked {
+		return
+	}
+	settings := config.Current()
+	ui.pomodoroPhaseElapsed += time.Second
+
+	if !ui.pomodoroOnBreak {
+		if ui.pomodoroPhaseElapsed < time.Duration(settings.PomodoroFocusMinutes)*time.Minute {
+			return
+		}
+		ui.pomodorosCompleted++
+		ui.pomodoroOnBreak = true
+		ui.pomodoroPhaseElapsed = 0
+		ui.notify("Time for a break", fmt.Sprintf("Focus interval done. Take a %d minute break.", settings.PomodoroBreakMinutes))
+		if settings.PomodoroAutoPause {
+			ui.pauseTracking()
+		}
+		ui.updateStatusLabel()
+		return
+	}
+
+	if ui.pomodoroPhaseElapsed < time.Duration(settings.PomodoroBreakMinutes)*time.Minute {
+		return
+	}
+	ui.pomodoroOnBreak = false
+	ui.pomodoroPhaseElapsed = 0
+	ui.notify("Break over", "Back to focus.")
+	if ui.isPaused && !ui.lockPaused {
+		ui.resumeTracking()
+	}
+	ui.updateStatusLabel()
+}
+
+// pauseTracking suspends screenshots, input monitoring and the timer display.
+// The pause isn't counted as worked time. The tracker is paused in place, so a
+// quick resume can't overtake it.
+func (ui *TaskWindowUI) pauseTracking() {
+	ui.isPaused = true
+	ui.stopwatch.Pause()
+	ui.activityTracker.Pause()
+	ui.updateTrayState()
+	log.Println("Tracking paused")
+}
+
+// resumeTracking undoes pauseTracking
+func (ui *TaskWindowUI) resumeTracking() {
+	ui.isPaused = false
+	ui.stopwatch.Resume()
+	ui.activityTracker.Resume()
+	ui.updateTrayState()
+	log.Println("Tracking resumed")
+	ui.updateStatusLabel()
+}
+
+// notify shows a desktop notification
+func (ui *TaskWindowUI) notify(title, content string) {
+	ui.App.SendNotification(fyne.NewNotification(title, content))
+}
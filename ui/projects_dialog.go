@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// projectOption is how a project is listed in the project dropdown
+func projectOption(project types.Project) string {
+	return fmt.Sprintf("%s (ID: %d)", project.Name, project.ID)
+}
+
+// showProjectsDialog lists the loaded projects with their status, members and
+// assigned tasks, and narrows the task picker to the project picked
+func (ui *TaskWindowUI) showProjectsDialog() {
+	if len(ui.projects) == 0 {
+		dialog.ShowInformation("Projects", "No projects loaded yet.", ui.Win)
+		return
+	}
+	projects := ui.projects
+	taskCounts := map[int]int{}
+	for _, task := range ui.tasks {
+		taskCounts[task.Project.ID]++
+	}
+
+	var d dialog.Dialog
+	list := widget.NewList(
+		func() int { return len(projects) },
+		func() fyne.CanvasObject {
+			return container.NewHBox(widget.NewLabel(""), layout.NewSpacer(), widget.NewButton("Show Tasks", nil))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			p := projects[id]
+			row := item.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(describeProject(p, taskCounts[p.ID]))
+			button := row.Objects[2].(*widget.Button)
+			button.OnTapped = func() {
+				d.Hide()
+				ui.filterByProject(p.ID)
+			}
+			if taskCounts[p.ID] == 0 {
+				button.Disable()
+			} else {
+				button.Enable()
+			}
+		},
+	)
+	d = dialog.NewCustom("Projects", "Close", list, ui.Win)
+	d.Resize(fyne.NewSize(460, 360))
+	d.Show()
+}
+
+// describeProject summarizes a project on one line
+func describeProject(p types.Project, tasks int) string {
+	status := "no status"
+	if p.Status != nil && *p.Status != "" {
+		status = *p.Status
+	}
+	return fmt.Sprintf("%s — %s, %d members, %d tasks", p.Name, status, len(p.Members), tasks)
+}
+
+// filterByProject narrows the task picker to projectID, showing the project
+// dropdown so the filter can be cleared again
+func (ui *TaskWindowUI) filterByProject(projectID int) {
+	for option, id := range ui.projectOptions {
+		if id == projectID {
+			ui.projectSelect.Show()
+			ui.projectSelect.SetSelected(option)
+			return
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/core"
+	"github.com/time-tracker/v2/internal/logging"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// showReassignDialog lets the user move the running session to another task,
+// either entirely or from now on
+func (ui *TaskWindowUI) showReassignDialog() {
+	if !ui.isTimerRunning {
+		return
+	}
+
+	var options []string
+	optionTasks := map[string]types.Task{}
+	displays := taskDisplays(ui.tasks)
+	for i, task := range ui.tasks {
+		if ui.selectedTask != nil && task.ID == ui.selectedTask.ID {
+			continue
+		}
+		options = append(options, displays[i])
+		optionTasks[displays[i]] = task
+	}
+	if len(options) == 0 {
+		dialog.ShowInformation("Reassign Session", "There are no other tasks to reassign to.", ui.Win)
+		return
+	}
+
+	taskSelect := widget.NewSelect(options, nil)
+	taskSelect.PlaceHolder = "Select a task..."
+	splitCheck := widget.NewCheck("Keep the time so far on the current task", nil)
+	items := []*widget.FormItem{
+		widget.NewFormItem("Reassign to", taskSelect),
+		widget.NewFormItem("", splitCheck),
+	}
+	dialog.ShowForm("Reassign Session", "Reassign", "Cancel", items, func(confirmed bool) {
+		if !confirmed || taskSelect.Selected == "" {
+			return
+		}
+		task := optionTasks[taskSelect.Selected]
+		if splitCheck.Checked {
+			ui.switchTask(task)
+			return
+		}
+		ui.reassignSession(task)
+	}, ui.Win)
+}
+
+// reassignSession moves the whole running session to task without stopping
+// the timer, then moves the work report in the background
+func (ui *TaskWindowUI) reassignSession(task types.Task) {
+	if !ui.isTimerRunning {
+		return
+	}
+	log.Printf("Reassigning the session to task: %s", task.Name)
+	stopDescription := fmt.Sprintf("Reassigned to %s", task.Name)
+	startDescription := core.StartDescription(task)
+
+	if err := ui.session.Reassign(task); err != nil {
+		log.Printf("Error reassigning session: %v", err)
+		dialog.ShowError(fmt.Errorf("failed to reassign the session: %w", err), ui.Win)
+		return
+	}
+	ui.keepTrackedTaskSelected()
+	ui.rememberSelectedTask()
+	ui.updateStatusLabel()
+	ui.refreshDailyGoal()
+
+	// Logging out or quitting waits for the handover like a report being closed
+	ui.closingReports.Add(1)
+	go func() {
+		defer ui.closingReports.Done()
+		defer logging.Recover("ReassignWorkReport", nil)
+		if err := ui.session.ReassignWorkReport(stopDescription, startDescription); err != nil {
+			log.Printf("Error reassigning work report: %v", err)
+			fyne.Do(func() {
+				dialog.ShowError(fmt.Errorf("the session was reassigned locally but the server could not be updated: %w", err), ui.Win)
+			})
+		}
+		fyne.Do(ui.updateStatusLabel)
+	}()
+}
@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/core"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/logging"
+)
+
+// interruptedDescription is sent when closing a report left open by a crash
+const interruptedDescription = "Stopped (app closed unexpectedly)"
+
+// checkInterruptedSession looks for a session that was still running when the
+// app last exited and offers to close it out or resume tracking its task.
+// Without a local record of one, the server is asked for a work report left
+// open, e.g. by a forced quit before the checkpoint was written.
+// A pending deep link or auto-start is applied afterwards, unless the user has
+// to decide about the interrupted session first. It must be called on the UI
+// thread once tasks have loaded.
+func (ui *TaskWindowUI) checkInterruptedSession() {
+	go func() {
+		defer logging.Recover("session recovery", nil)
+		cp, err := ui.session.PendingCheckpoint()
+		if err != nil {
+			log.Printf("Failed to check for an interrupted session: %v", err)
+		}
+		if cp == nil {
+			ui.checkOpenWorkReport()
+			return
+		}
+		if cp.ActivitySaved {
+			defer fyne.Do(ui.applyLaunchActions)
+			// Tracking had stopped; only the server report was still open
+			log.Printf("Closing work report %d left open by the last run", cp.WorkReportID)
+			if err := ui.session.CloseCheckpoint(*cp, interruptedDescription); err != nil {
+				log.Printf("Failed to close interrupted work report: %v", err)
+			}
+			return
+		}
+		fyne.Do(func() {
+			ui.dropLaunchActions()
+			ui.showRecoveryDialog(*cp)
+		})
+	}()
+}
+
+// dropLaunchActions forgets a pending deep link and auto-start because an
+// interrupted session needs attention first. It runs on the UI thread.
+func (ui *TaskWindowUI) dropLaunchActions() {
+	if ui.pendingDeepLink != nil {
+		log.Println("Ignoring deep link: an interrupted session needs attention first")
+		ui.pendingDeepLink = nil
+	}
+	ui.autoStartPending = false
+	ui.launchActionsDone = true
+}
+
+// showRecoveryDialog asks what to do with an interrupted session
+func (ui *TaskWindowUI) showRecoveryDialog(cp core.SessionCheckpoint) {
+	message := widget.NewLabel(fmt.Sprintf(
+		"Time Tracker closed while tracking %q.\n\nStarted: %s\nLast active: %s\n\n"+
+			"Close it out to record the session up to when it was last active, or resume tracking the task now.",
+		cp.TaskName,
+		cp.StartTime.In(config.DisplayLocation()).Format("Jan 02, 2006 03:04 PM"),
+		cp.LastSeen.In(config.DisplayLocation()).Format("Jan 02, 2006 03:04 PM")))
+	message.Wrapping = fyne.TextWrapWord
+
+	var d *dialog.CustomDialog
+	closeButton := widget.NewButton("Close It Out", func() {
+		d.Hide()
+		ui.closeInterruptedSession(cp, false)
+	})
+	resumeButton := widget.NewButton("Resume", func() {
+		d.Hide()
+		ui.closeInterruptedSession(cp, true)
+	})
+	resumeButton.Importance = widget.HighImportance
+
+	d = dialog.NewCustomWithoutButtons("Unfinished Session", message, ui.Win)
+	d.SetButtons([]fyne.CanvasObject{closeButton, resumeButton})
+	d.Resize(fyne.NewSize(380, 260))
+	ui.Win.Show()
+	d.Show()
+}
+
+// closeInterruptedSession records the interrupted session and, with resume,
+// starts tracking its task again
+func (ui *TaskWindowUI) closeInterruptedSession(cp core.SessionCheckpoint, resume bool) {
+	go func() {
+		defer logging.Recover("close interrupted session", nil)
+		err := ui.session.CloseCheckpoint(cp, interruptedDescription)
+		fyne.Do(func() {
+			if err != nil {
+				log.Printf("Failed to close interrupted session: %v", err)
+				dialog.ShowError(fmt.Errorf("could not close the interrupted session: %w", err), ui.Win)
+				return
+			}
+			if !resume || ui.isTimerRunning {
+				return
+			}
+			if !ui.selectTaskByID(cp.TaskID) {
+				dialog.ShowError(fmt.Errorf("task %q is no longer available", cp.TaskName), ui.Win)
+				return
+			}
+			if err := ui.startTracking(); err != nil {
+				dialog.ShowError(fmt.Errorf("failed to resume tracking: %w", err), ui.Win)
+			}
+		})
+	}()
+}
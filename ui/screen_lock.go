@@ -0,0 +1,26 @@
+package ui
+
+import "github.com/time-tracker/v2/internal/config"
+
+// onScreenLockChanged shows the lock state in the status and, if configured,
+// pauses tracking until the screen unlocks. It runs on the UI thread.
+func (ui *TaskWindowUI) onScreenLockChanged(locked bool) {
+	if !ui.isTimerRunning {
+		return
+	}
+	ui.screenLocked = locked
+	settings := config.Current()
+	switch {
+	case locked && !ui.isPaused && settings.PauseWhenLocked:
+		ui.lockPaused = true
+		ui.pauseTracking()
+	case !locked && ui.lockPaused:
+		ui.lockPaused = false
+		// A Pomodoro break that began while locked keeps tracking paused
+		if !ui.pomodoroOnBreak || !settings.PomodoroAutoPause {
+			ui.resumeTracking()
+		}
+	}
+	ui.updateTrayState()
+	ui.updateStatusLabel()
+}
@@ -0,0 +1,296 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/core"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/logging"
+)
+
+// galleryPageSize is how many screenshots the gallery shows per page
+const galleryPageSize = 12
+
+// screenshotGallery is a window for browsing, filtering and deleting all screenshots
+type screenshotGallery struct {
+	ui  *TaskWindowUI
+	win fyne.Window
+
+	fromEntry *widget.Entry
+	toEntry   *widget.Entry
+	grid      *fyne.Container
+	pageLabel *widget.Label
+	prev      *widget.Button
+	next      *widget.Button
+
+	all      []core.ScreenshotFile
+	filtered []core.ScreenshotFile
+	page     int
+}
+
+// showScreenshotGallery opens the screenshot gallery window
+func (ui *TaskWindowUI) showScreenshotGallery() {
+	g := &screenshotGallery{ui: ui, win: ui.App.NewWindow("Screenshots")}
+
+	g.fromEntry = widget.NewEntry()
+	g.fromEntry.SetPlaceHolder("From (YYYY-MM-DD)")
+	g.toEntry = widget.NewEntry()
+	g.toEntry.SetPlaceHolder("To (YYYY-MM-DD)")
+	filterButton := widget.NewButton("Filter", g.applyFilter)
+	clearButton := widget.NewButton("Clear", func() {
+		g.fromEntry.SetText("")
+		g.toEntry.SetText("")
+		g.applyFilter()
+	})
+	filterBar := container.NewGridWithColumns(4, g.fromEntry, g.toEntry, filterButton, clearButton)
+	deleteOlderButton := widget.NewButton("Delete Older...", g.showDeleteOlderDialog)
+	deleteOlderButton.Importance = widget.DangerImportance
+	filterBar = container.NewBorder(nil, nil, nil, deleteOlderButton, filterBar)
+
+	g.grid = container.NewGridWrap(fyne.NewSize(150, 230))
+	g.pageLabel = widget.NewLabel("")
+	g.prev = widget.NewButton("Previous", func() { g.showPage(g.page - 1) })
+	g.next = widget.NewButton("Next", func() { g.showPage(g.page + 1) })
+	pager := container.NewHBox(g.prev, g.pageLabel, g.next)
+
+	g.win.SetContent(container.NewBorder(filterBar, container.NewCenter(pager), nil, nil, container.NewVScroll(g.grid)))
+	restoreWindowSize(g.win, config.WindowGallery, fyne.NewSize(660, 520))
+	g.win.SetCloseIntercept(func() {
+		saveWindowSize(g.win, config.WindowGallery)
+		g.win.Close()
+	})
+	g.win.Show()
+	g.load()
+}
+
+// load reads the screenshot directory and shows the first page
+func (g *screenshotGallery) load() {
+	g.pageLabel.SetText("Loading...")
+	go func() {
+		defer logging.Recover("screenshot gallery", nil)
+		screenshots, err := core.ListScreenshots()
+		if err == nil {
+			g.ui.loadScreenshotDetails(screenshots)
+		}
+		fyne.Do(func() {
+			if err != nil {
+				log.Printf("Error loading screenshots: %v", err)
+				dialog.ShowError(fmt.Errorf("could not load screenshots: %w", err), g.win)
+				return
+			}
+			g.all = screenshots
+			g.applyFilter()
+		})
+	}()
+}
+
+// applyFilter keeps the screenshots captured within the entered dates (both inclusive)
+func (g *screenshotGallery) applyFilter() {
+	from, ok := g.parseDate(g.fromEntry.Text)
+	if !ok {
+		return
+	}
+	to, ok := g.parseDate(g.toEntry.Text)
+	if !ok {
+		return
+	}
+	if !to.IsZero() {
+		to = to.AddDate(0, 0, 1)
+	}
+
+	g.filtered = g.filtered[:0]
+	for _, s := range g.all {
+		if !from.IsZero() && s.Time.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !s.Time.Before(to) {
+			continue
+		}
+		g.filtered = append(g.filtered, s)
+	}
+	g.showPage(0)
+}
+
+// parseDate parses an optional date entry, showing an error if it is invalid
+func (g *screenshotGallery) parseDate(text string) (time.Time, bool) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return time.Time{}, true
+	}
+	t, err := time.ParseInLocation(dateFormat, text, config.DisplayLocation())
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("invalid date %q, use YYYY-MM-DD", text), g.win)
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// showPage shows the given page of the filtered screenshots
+func (g *screenshotGallery) showPage(page int) {
+	pages := (len(g.filtered) + galleryPageSize - 1) / galleryPageSize
+	if page >= pages {
+		page = pages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+	g.page = page
+
+	g.grid.RemoveAll()
+	start := page * galleryPageSize
+	end := min(start+galleryPageSize, len(g.filtered))
+	for _, s := range g.filtered[start:end] {
+		g.grid.Add(g.newItem(s))
+	}
+	g.grid.Refresh()
+
+	if pages == 0 {
+		g.pageLabel.SetText("No screenshots")
+	} else {
+		g.pageLabel.SetText(fmt.Sprintf("Page %d of %d (%d screenshots)", page+1, pages, len(g.filtered)))
+	}
+	if page > 0 {
+		g.prev.Enable()
+	} else {
+		g.prev.Disable()
+	}
+	if page < pages-1 {
+		g.next.Enable()
+	} else {
+		g.next.Disable()
+	}
+}
+
+// newItem shows a thumbnail with note and delete buttons
+func (g *screenshotGallery) newItem(s core.ScreenshotFile) fyne.CanvasObject {
+	noteButton := widget.NewButton("Note...", func() { g.showNoteDialog(s) })
+	deleteButton := widget.NewButton("Delete", func() {
+		dialog.ShowConfirm("Delete Screenshot", "Delete this screenshot permanently?", func(confirmed bool) {
+			if confirmed {
+				g.delete(s)
+			}
+		}, g.win)
+	})
+	deleteButton.Importance = widget.DangerImportance
+	return container.NewVBox(g.ui.newScreenshotThumbnail(s), container.NewGridWithColumns(2, noteButton, deleteButton))
+}
+
+// showNoteDialog edits the note on a screenshot; clearing the text removes it
+func (g *screenshotGallery) showNoteDialog(s core.ScreenshotFile) {
+	entry := widget.NewMultiLineEntry()
+	entry.SetPlaceHolder("What was happening in this screenshot")
+	entry.SetText(s.Note)
+	entry.SetMinRowsVisible(4)
+	title := "Note: " + s.Time.In(config.DisplayLocation()).Format("Jan 02, 2006 03:04 PM")
+	d := dialog.NewForm(title, "Save", "Cancel", []*widget.FormItem{widget.NewFormItem("Note", entry)}, func(ok bool) {
+		if ok {
+			g.saveNote(s.Path, strings.TrimSpace(entry.Text))
+		}
+	}, g.win)
+	d.Resize(fyne.NewSize(420, 0))
+	d.Show()
+}
+
+// saveNote stores a screenshot's note and refreshes the gallery and the main window's list
+func (g *screenshotGallery) saveNote(path, note string) {
+	db := g.ui.activityTracker.Database
+	err := db.Connect()
+	if err == nil {
+		err = db.SetScreenshotNote(path, note)
+	}
+	if err != nil {
+		log.Printf("Failed to save note on screenshot %s: %v", path, err)
+		dialog.ShowError(err, g.win)
+		return
+	}
+	for i := range g.all {
+		if g.all[i].Path == path {
+			g.all[i].Note = note
+			break
+		}
+	}
+	page := g.page
+	g.applyFilter()
+	g.showPage(page)
+	g.ui.updateScreenshotsList()
+}
+
+// delete removes a screenshot and refreshes the gallery and the main window's list
+func (g *screenshotGallery) delete(s core.ScreenshotFile) {
+	if err := g.ui.activityTracker.ScreenshotManager.DeleteScreenshot(s.Path); err != nil {
+		log.Printf("Failed to delete screenshot %s: %v", s.Path, err)
+		dialog.ShowError(err, g.win)
+		return
+	}
+	for i := range g.all {
+		if g.all[i].Path == s.Path {
+			g.all = append(g.all[:i], g.all[i+1:]...)
+			break
+		}
+	}
+	page := g.page
+	g.applyFilter()
+	g.showPage(page)
+	g.ui.updateScreenshotsList()
+}
+
+// showDeleteOlderDialog asks for a cutoff date and deletes every screenshot
+// captured before it, regardless of the gallery's filter
+func (g *screenshotGallery) showDeleteOlderDialog() {
+	dateEntry := widget.NewEntry()
+	dateEntry.SetText(time.Now().In(config.DisplayLocation()).AddDate(0, -1, 0).Format(dateFormat))
+	items := []*widget.FormItem{widget.NewFormItem("Before (YYYY-MM-DD)", dateEntry)}
+	dialog.ShowForm("Delete Older Screenshots", "Delete", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		before, ok := g.parseDate(dateEntry.Text)
+		if !ok || before.IsZero() {
+			return
+		}
+		count := 0
+		for _, s := range g.all {
+			if s.Time.Before(before) {
+				count++
+			}
+		}
+		if count == 0 {
+			dialog.ShowInformation("Delete Older Screenshots", "No screenshots were captured before that date.", g.win)
+			return
+		}
+		message := fmt.Sprintf("Permanently delete %d screenshots captured before %s?", count, before.Format(dateFormat))
+		dialog.ShowConfirm("Delete Older Screenshots", message, func(confirmed bool) {
+			if confirmed {
+				g.deleteBefore(before)
+			}
+		}, g.win)
+	}, g.win)
+}
+
+// deleteBefore removes the screenshots captured before the cutoff, reports
+// the space freed and reloads the gallery
+func (g *screenshotGallery) deleteBefore(before time.Time) {
+	go func() {
+		defer logging.Recover("delete screenshots", nil)
+		deleted, freed, err := g.ui.activityTracker.ScreenshotManager.DeleteRange(before)
+		fyne.Do(func() {
+			if err != nil {
+				log.Printf("Failed to delete screenshots before %s: %v", before, err)
+				dialog.ShowError(fmt.Errorf("could not delete screenshots: %w", err), g.win)
+				return
+			}
+			log.Printf("Deleted %d screenshots captured before %s (%d bytes)", deleted, before, freed)
+			dialog.ShowInformation("Delete Older Screenshots",
+				fmt.Sprintf("Deleted %d screenshots, freeing %.1f MB.", deleted, float64(freed)/(1024*1024)), g.win)
+			g.load()
+			g.ui.updateScreenshotsList()
+		})
+	}()
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/logging"
+)
+
+// startScreenshotRefresh reloads the recent screenshots panel at the
+// configured interval while tracking, showing captures whose events were
+// dropped. A refresh already running is replaced. It runs on the UI thread.
+func (ui *TaskWindowUI) startScreenshotRefresh() {
+	ui.stopScreenshotRefresh()
+	seconds := config.Current().ScreenshotPanelRefreshSeconds
+	if seconds <= 0 {
+		return
+	}
+	stop := make(chan struct{})
+	ui.stopPanelRefresh = stop
+	ticker := time.NewTicker(time.Duration(seconds) * time.Second)
+	go func() {
+		defer logging.Recover("screenshot panel refresh", nil)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fyne.Do(func() {
+					if ui.isTimerRunning {
+						ui.updateScreenshotsList()
+					}
+				})
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopScreenshotRefresh ends the periodic panel refresh, if one is running.
+// It runs on the UI thread.
+func (ui *TaskWindowUI) stopScreenshotRefresh() {
+	if ui.stopPanelRefresh != nil {
+		close(ui.stopPanelRefresh)
+		ui.stopPanelRefresh = nil
+	}
+}
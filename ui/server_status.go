@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/core"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/logging"
+)
+
+// serverStatusInterval is how often the server's status is checked while tracking
+const serverStatusInterval = 5 * time.Minute
+
+// newServerStatusLabel creates the status card's line for what the server
+// records, hidden while it agrees with this app
+func (ui *TaskWindowUI) newServerStatusLabel() *widget.Label {
+	ui.serverStatusLabel = widget.NewLabel("")
+	ui.serverStatusLabel.Alignment = fyne.TextAlignCenter
+	ui.serverStatusLabel.Wrapping = fyne.TextWrapWord
+	ui.serverStatusLabel.Importance = widget.WarningImportance
+	ui.serverStatusLabel.Hide()
+	return ui.serverStatusLabel
+}
+
+// checkServerStatus asks the server what the user is tracking and shows any
+// conflict with this app. Failures are only logged; the connection line
+// already shows when the server is unreachable.
+func (ui *TaskWindowUI) checkServerStatus() {
+	go func() {
+		defer logging.Recover("checkServerStatus", nil)
+		status, err := ui.taskManager.CheckServerStatus()
+		if err != nil {
+			log.Printf("Failed to check the server's status: %v", err)
+			return
+		}
+		fyne.Do(func() { ui.showServerStatus(status) })
+	}()
+}
+
+// showServerStatus shows status in the status card. It runs on the UI thread.
+func (ui *TaskWindowUI) showServerStatus(status core.ServerStatus) {
+	text := ui.serverStatusText(status)
+	if text == "" {
+		ui.serverStatusLabel.Hide()
+		return
+	}
+	if status.Conflict != core.StatusInSync {
+		log.Printf("Server status conflict: %s", text)
+	}
+	ui.serverStatusLabel.SetText(text)
+	ui.serverStatusLabel.Show()
+}
+
+// serverStatusText describes how the server's status differs from this app's,
+// "" if it doesn't
+func (ui *TaskWindowUI) serverStatusText(status core.ServerStatus) string {
+	switch status.Conflict {
+	case core.StatusOpenElsewhere:
+		since := ""
+		if start := status.Report.StartTime; start != nil {
+			since = " since " + start.In(config.DisplayLocation()).Format("Jan 02 15:04")
+		}
+		if ui.isTimerRunning {
+			return fmt.Sprintf("Conflict: the server is tracking %q%s, not this session. It may have been started on another device.",
+				status.Report.Task.Name, since)
+		}
+		return fmt.Sprintf("Open on the server: %q%s, started elsewhere or left open", status.Report.Task.Name, since)
+	case core.StatusClosedElsewhere:
+		if !ui.isTimerRunning {
+			return "" // Checked just before this app stopped
+		}
+		return "Conflict: the server has no open work report for this session. It may have been stopped on another device."
+	}
+	return ""
+}
+
+// startServerStatusChecks checks the server's status periodically while
+// tracking. Checks already running are replaced. It runs on the UI thread.
+func (ui *TaskWindowUI) startServerStatusChecks() {
+	ui.stopServerStatusChecks()
+	stop := make(chan struct{})
+	ui.stopStatusChecks = stop
+	ticker := time.NewTicker(serverStatusInterval)
+	go func() {
+		defer logging.Recover("server status checks", nil)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ui.checkServerStatus()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopServerStatusChecks ends the periodic checks, if they run. It runs on
+// the UI thread.
+func (ui *TaskWindowUI) stopServerStatusChecks() {
+	if ui.stopStatusChecks != nil {
+		close(ui.stopStatusChecks)
+		ui.stopStatusChecks = nil
+	}
+}
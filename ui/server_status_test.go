@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/time-tracker/v2/core"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+func TestServerStatusText(t *testing.T) {
+	updateSettings(t, func(s *config.Settings) { s.DisplayTimezone = "UTC" })
+	start := time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC)
+	elsewhere := &types.WorkReport{ID: 5, StartTime: &start, Task: types.Task{Name: "Docs"}}
+	unstarted := &types.WorkReport{ID: 5, Task: types.Task{Name: "Docs"}}
+
+	tests := []struct {
+		name    string
+		running bool
+		status  core.ServerStatus
+		want    []string // Substrings, none for no text
+	}{
+		{"in sync", true, core.ServerStatus{Report: elsewhere}, nil},
+		{"open elsewhere while tracking", true, core.ServerStatus{Report: elsewhere, Conflict: core.StatusOpenElsewhere},
+			[]string{"Conflict", `"Docs"`, "since Mar 10 09:30", "another device"}},
+		{"open elsewhere while idle", false, core.ServerStatus{Report: elsewhere, Conflict: core.StatusOpenElsewhere},
+			[]string{"Open on the server", `"Docs"`, "since Mar 10 09:30"}},
+		{"open elsewhere, no start time", false, core.ServerStatus{Report: unstarted, Conflict: core.StatusOpenElsewhere},
+			[]string{`Open on the server: "Docs", started`}},
+		{"closed elsewhere while tracking", true, core.ServerStatus{Conflict: core.StatusClosedElsewhere},
+			[]string{"Conflict", "no open work report"}},
+		{"closed elsewhere after stopping", false, core.ServerStatus{Conflict: core.StatusClosedElsewhere}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ui := &TaskWindowUI{isTimerRunning: tt.running}
+			got := ui.serverStatusText(tt.status)
+			if (got == "") != (len(tt.want) == 0) {
+				t.Fatalf("serverStatusText() = %q, want text %v", got, len(tt.want) > 0)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("serverStatusText() = %q, want it to contain %q", got, want)
+				}
+			}
+		})
+	}
+}
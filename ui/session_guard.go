@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/time-tracker/v2/internal/config"
+)
+
+// hintDuration is how long a timer hint stays visible
+const hintDuration = 4 * time.Second
+
+// sessionTooShort reports whether the running session is shorter than the
+// configured minimum, in which case a manual stop or switch is ignored as an
+// accidental click and a hint explains why
+func (ui *TaskWindowUI) sessionTooShort(action string) bool {
+	minimum := time.Duration(config.Current().MinSessionSeconds) * time.Second
+	elapsed := ui.stopwatch.Elapsed()
+	remaining := minSessionRemaining(elapsed, minimum)
+	if remaining == 0 {
+		return false
+	}
+	log.Printf("Ignoring %s after %s, sessions must last at least %s", action, elapsed.Round(time.Second), minimum)
+	ui.showTimerHint(fmt.Sprintf("Sessions must last at least %s — try again in %s", minimum, remaining.Round(time.Second)))
+	return true
+}
+
+// minSessionRemaining returns how much longer a session that has run for
+// elapsed must run to reach minimum, or 0 if it has (or there is no minimum)
+func minSessionRemaining(elapsed, minimum time.Duration) time.Duration {
+	if minimum <= 0 || elapsed >= minimum {
+		return 0
+	}
+	return minimum - elapsed
+}
+
+// sessionTooLong stops the session, as if the user had, once it has run for
+// the configured maximum, so a forgotten timer doesn't run overnight. The
+// session ends at the maximum, however late the check runs, so time after it
+// isn't recorded. It reports whether the session was stopped and runs on the
+// UI thread.
+func (ui *TaskWindowUI) sessionTooLong() bool {
+	maximum := time.Duration(config.Current().MaxSessionHours) * time.Hour
+	start := ui.session.StartedAt()
+	if start.IsZero() {
+		return false
+	}
+	elapsed := ui.now().Sub(start)
+	if !maxSessionReached(elapsed, maximum) {
+		return false
+	}
+	task := "the task"
+	if ui.selectedTask != nil {
+		task = ui.selectedTask.Name
+	}
+	log.Printf("Stopping %s after %s, the maximum session length (it ran %s)", task, maximum, elapsed.Round(time.Second))
+	end := start.Add(maximum)
+	ui.endTracking(func() error { return ui.session.StopAt(end) }, false)
+	message := fmt.Sprintf("Tracking %s stopped automatically after %s. The session was saved.", task, maximum)
+	ui.notify("Timer stopped", message)
+	ui.showTimerHint(message)
+	return true
+}
+
+// maxSessionReached reports whether a session that has run for elapsed has
+// reached maximum (never, if there is no maximum)
+func maxSessionReached(elapsed, maximum time.Duration) bool {
+	return maximum > 0 && elapsed >= maximum
+}
+
+// showTimerHint shows text under the timer buttons for hintDuration
+func (ui *TaskWindowUI) showTimerHint(text string) {
+	ui.timerHint.SetText(text)
+	ui.timerHint.Show()
+	time.AfterFunc(hintDuration, func() {
+		fyne.Do(func() {
+			if ui.timerHint.Text == text {
+				ui.timerHint.Hide()
+			}
+		})
+	})
+}
@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/types"
+	"github.com/time-tracker/v2/services"
+)
+
+func TestMinSessionRemaining(t *testing.T) {
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		minimum time.Duration
+		want    time.Duration
+	}{
+		{"too short", 3 * time.Second, 10 * time.Second, 7 * time.Second},
+		{"just started", 0, 10 * time.Second, 10 * time.Second},
+		{"exactly the minimum", 10 * time.Second, 10 * time.Second, 0},
+		{"long enough", time.Minute, 10 * time.Second, 0},
+		{"no minimum", time.Second, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := minSessionRemaining(tt.elapsed, tt.minimum); got != tt.want {
+				t.Errorf("minSessionRemaining(%s, %s) = %s, want %s", tt.elapsed, tt.minimum, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxSessionReached(t *testing.T) {
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		maximum time.Duration
+		want    bool
+	}{
+		{"under the maximum", 7 * time.Hour, 8 * time.Hour, false},
+		{"exactly the maximum", 8 * time.Hour, 8 * time.Hour, true},
+		{"over the maximum", 9 * time.Hour, 8 * time.Hour, true},
+		{"no maximum", 100 * time.Hour, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maxSessionReached(tt.elapsed, tt.maximum); got != tt.want {
+				t.Errorf("maxSessionReached(%s, %s) = %v, want %v", tt.elapsed, tt.maximum, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTickStopsAtMaximum(t *testing.T) {
+	updateSettings(t, func(s *config.Settings) { s.MaxSessionHours = 8 })
+	t.Setenv(services.DemoEnv, "1")
+	a := test.NewTempApp(t)
+	a.Settings().SetTheme(theme.DefaultTheme()) // The test theme lacks some of the fonts the window uses
+	ui := NewTaskWindow(a)
+	ui.selectedTask = &types.Task{ID: 101, Name: "Landing page layout", Project: types.Project{ID: 1, Name: "Website Redesign"}}
+	if err := ui.startTracking(); err != nil {
+		t.Fatal(err)
+	}
+	start := ui.session.StartedAt()
+	maximum := 8 * time.Hour
+
+	ui.now = func() time.Time { return start.Add(maximum - time.Minute) }
+	ui.tick()
+	if !ui.isTimerRunning {
+		t.Fatal("stopped before the maximum")
+	}
+
+	// The computer slept through the maximum, so the first tick after it is late
+	ui.now = func() time.Time { return start.Add(maximum + 3*time.Hour) }
+	ui.tick()
+	if ui.isTimerRunning {
+		t.Fatal("still running after the maximum")
+	}
+	ui.closingReports.Wait()
+	summary := ui.activityTracker.LastSummary()
+	if summary == nil {
+		t.Fatal("no session summary")
+	}
+	if want := start.Add(maximum); !summary.Record.EndTime.Equal(want) {
+		t.Errorf("session ended at %s, want %s", summary.Record.EndTime, want)
+	}
+	if got := time.Duration(summary.Record.DurationSeconds) * time.Second; got != maximum {
+		t.Errorf("recorded %s, want %s", got, maximum)
+	}
+}
@@ -0,0 +1,625 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/core"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/localapi"
+	"github.com/time-tracker/v2/internal/logging"
+	"github.com/time-tracker/v2/internal/platform"
+	"github.com/time-tracker/v2/services"
+)
+
+// NewSettingsWindow creates the settings window. onSwitchProfile is called
+// after saving if another server profile was picked.
+func NewSettingsWindow(a fyne.App, onSwitchProfile func(name string)) fyne.Window {
+	win := a.NewWindow("Settings")
+	settings := config.Current()
+
+	logSizeEntry := widget.NewEntry()
+	logSizeEntry.SetText(strconv.Itoa(settings.LogMaxSizeMB))
+	logBackupsEntry := widget.NewEntry()
+	logBackupsEntry.SetText(strconv.Itoa(settings.LogMaxBackups))
+
+	openLogsButton := widget.NewButton("Open Log Folder", func() {
+		openLogFolder(a, win)
+	})
+
+	inputKeysCheck := widget.NewCheck("Include the keys typed", nil)
+	inputKeysCheck.SetChecked(settings.InputDebugKeys)
+	inputLogCheck := widget.NewCheck("Record raw keyboard and mouse events each session (debugging)", func(on bool) {
+		if on {
+			inputKeysCheck.Enable()
+		} else {
+			inputKeysCheck.Disable()
+		}
+	})
+	inputLogCheck.SetChecked(settings.InputDebugLog)
+	if !settings.InputDebugLog {
+		inputKeysCheck.Disable()
+	}
+
+	logForm := widget.NewForm(
+		widget.NewFormItem("Max log size (MB)", logSizeEntry),
+		widget.NewFormItem("Log files to keep", logBackupsEntry),
+	)
+	logCard := widget.NewCard("Logging", "Changes apply on next launch; input recording from the next session",
+		container.NewVBox(logForm, inputLogCheck, inputKeysCheck, openLogsButton))
+
+	apiEnabledCheck := widget.NewCheck("Enable local API (localhost only)", nil)
+	apiEnabledCheck.SetChecked(settings.LocalAPIEnabled)
+	apiPortEntry := widget.NewEntry()
+	apiPortEntry.SetText(strconv.Itoa(settings.LocalAPIPort))
+	apiTokenEntry := widget.NewEntry()
+	apiTokenEntry.SetText(settings.LocalAPIToken)
+	apiTokenEntry.Disable()
+	regenerateButton := widget.NewButton("Generate New Token", func() {
+		token, err := localapi.GenerateToken()
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		apiTokenEntry.SetText(token)
+	})
+	apiForm := widget.NewForm(
+		widget.NewFormItem("Port", apiPortEntry),
+		widget.NewFormItem("Token", apiTokenEntry),
+	)
+	apiCard := widget.NewCard("Local API", "Changes apply on next launch", container.NewVBox(apiEnabledCheck, apiForm, regenerateButton))
+
+	proxyEntry := widget.NewEntry()
+	proxyEntry.SetPlaceHolder("http://proxy.example.com:8080")
+	proxyEntry.SetText(settings.ProxyURL)
+	insecureCheck := widget.NewCheck("Skip TLS certificate verification (insecure)", nil)
+	insecureCheck.SetChecked(settings.InsecureSkipVerify)
+	userAgentEntry := widget.NewEntry()
+	userAgentEntry.SetPlaceHolder(services.DefaultUserAgent())
+	userAgentEntry.SetText(settings.UserAgent)
+	uploadConcurrencyEntry := widget.NewEntry()
+	uploadConcurrencyEntry.SetText(strconv.Itoa(settings.UploadConcurrency))
+	networkForm := widget.NewForm(
+		widget.NewFormItem("Proxy URL", proxyEntry),
+		widget.NewFormItem("User-Agent", userAgentEntry),
+		widget.NewFormItem(fmt.Sprintf("Parallel uploads (1-%d)", config.MaxUploadConcurrency), uploadConcurrencyEntry),
+	)
+	activeProfile := config.ActiveProfile().Name
+	profileSelect := newProfileSelect(nil)
+	if len(config.Profiles()) > 1 {
+		networkForm.Append("Server", profileSelect)
+	}
+	networkCard := widget.NewCard("Network", "Leave the proxy empty to use HTTP_PROXY/HTTPS_PROXY. Changes apply on next launch",
+		container.NewVBox(networkForm, widget.NewLabel("Advanced"), insecureCheck))
+
+	blurSelect := widget.NewSelect([]string{config.BlurOff, config.BlurLight, config.BlurHeavy}, nil)
+	blurSelect.SetSelected(settings.ScreenshotBlur)
+	skipDuplicatesCheck := widget.NewCheck("Don't upload near-identical screenshots", nil)
+	skipDuplicatesCheck.SetChecked(settings.SkipDuplicateScreenshots)
+	confirmUploadsCheck := widget.NewCheck("Ask before uploading each session's screenshots", nil)
+	confirmUploadsCheck.SetChecked(settings.ConfirmScreenshotUploads)
+	uploadNotesCheck := widget.NewCheck("Upload screenshot notes with the screenshots", nil)
+	uploadNotesCheck.SetChecked(settings.UploadScreenshotNotes)
+	duplicateThresholdEntry := widget.NewEntry()
+	duplicateThresholdEntry.SetText(strconv.Itoa(settings.DuplicateThreshold))
+	retentionDaysEntry := widget.NewEntry()
+	retentionDaysEntry.SetText(strconv.Itoa(settings.ScreenshotRetentionDays))
+	jitterEntry := widget.NewEntry()
+	jitterEntry.SetText(strconv.Itoa(settings.ScreenshotJitterPercent))
+	graceEntry := widget.NewEntry()
+	graceEntry.SetText(strconv.Itoa(settings.ScreenshotGraceSeconds))
+	adaptiveCheck := widget.NewCheck("Take screenshots less often while activity is low", nil)
+	adaptiveCheck.SetChecked(settings.AdaptiveScreenshotInterval)
+	lowActivityEntry := widget.NewEntry()
+	lowActivityEntry.SetText(strconv.Itoa(settings.LowActivityPercent))
+	lowIntervalMinEntry := widget.NewEntry()
+	lowIntervalMinEntry.SetText(strconv.Itoa(settings.LowActivityMinIntervalMinutes))
+	lowIntervalMaxEntry := widget.NewEntry()
+	lowIntervalMaxEntry.SetText(strconv.Itoa(settings.LowActivityMaxIntervalMinutes))
+	panelRefreshEntry := widget.NewEntry()
+	panelRefreshEntry.SetText(strconv.Itoa(settings.ScreenshotPanelRefreshSeconds))
+	maxSizeEntry := widget.NewEntry()
+	maxSizeEntry.SetText(strconv.Itoa(settings.ScreenshotMaxSizeMB))
+	qualityEntry := widget.NewEntry()
+	qualityEntry.SetText(strconv.Itoa(settings.ScreenshotQuality))
+	formatSelect := widget.NewSelect([]string{config.FormatPNG, config.FormatJPEG, config.FormatWebP}, func(format string) {
+		if format == config.FormatJPEG || format == config.FormatWebP {
+			qualityEntry.Enable()
+		} else {
+			qualityEntry.Disable()
+		}
+	})
+	formatSelect.SetSelected(settings.ScreenshotFormat)
+	resolutionSelect := widget.NewSelect([]string{config.ResolutionPhysical, config.ResolutionLogical}, nil)
+	resolutionSelect.SetSelected(settings.ScreenshotResolution)
+	captureModeSelect := widget.NewSelect([]string{config.CaptureModeInterval, config.CaptureModeStartStop, config.CaptureModeManual}, nil)
+	captureModeSelect.SetSelected(settings.ScreenshotCaptureMode)
+	scopeSelect := widget.NewSelect([]string{config.CaptureScopeFullScreen, config.CaptureScopeActiveWindow}, nil)
+	scopeSelect.SetSelected(settings.ScreenshotScope)
+	maxDimensionEntry := widget.NewEntry()
+	maxDimensionEntry.SetText(strconv.Itoa(settings.ScreenshotMaxDimension))
+	watermarkCheck := widget.NewCheck("Stamp the capture time on screenshots", nil)
+	watermarkCheck.SetChecked(settings.Watermark)
+	watermarkUserCheck := widget.NewCheck("Include user name", nil)
+	watermarkUserCheck.SetChecked(settings.WatermarkUser)
+	watermarkTaskCheck := widget.NewCheck("Include task", nil)
+	watermarkTaskCheck.SetChecked(settings.WatermarkTask)
+	watermarkPositionSelect := widget.NewSelect([]string{config.WatermarkTopLeft, config.WatermarkTopRight, config.WatermarkBottomLeft, config.WatermarkBottomRight}, nil)
+	watermarkPositionSelect.SetSelected(settings.WatermarkPosition)
+	watermarkOpacityEntry := widget.NewEntry()
+	watermarkOpacityEntry.SetText(strconv.Itoa(settings.WatermarkOpacity))
+	busyRateEntry := widget.NewEntry()
+	busyRateEntry.SetText(strconv.Itoa(settings.BusyEventsPerMinute))
+	webcamWidthEntry := widget.NewEntry()
+	webcamWidthEntry.SetText(strconv.Itoa(settings.WebcamWidth))
+	webcamHeightEntry := widget.NewEntry()
+	webcamHeightEntry.SetText(strconv.Itoa(settings.WebcamHeight))
+	screenshotDirEntry := widget.NewEntry()
+	screenshotDirEntry.SetPlaceHolder("Default (screenshots in the data folder)")
+	if dataDir, err := config.DataDir(); err == nil {
+		screenshotDirEntry.SetPlaceHolder("Default (" + filepath.Join(dataDir, "screenshots") + ")")
+	}
+	screenshotDirEntry.SetText(settings.ScreenshotDir)
+	browseDirButton := widget.NewButton("Browse...", func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			if uri != nil {
+				screenshotDirEntry.SetText(uri.Path())
+			}
+		}, win)
+	})
+	screenshotForm := widget.NewForm(
+		widget.NewFormItem("Folder", container.NewBorder(nil, nil, nil, browseDirButton, screenshotDirEntry)),
+		widget.NewFormItem("Capture", captureModeSelect),
+		widget.NewFormItem("Capture area", scopeSelect),
+		widget.NewFormItem("Interval randomness (%)", jitterEntry),
+		widget.NewFormItem("First capture after (s, 0 = interval)", graceEntry),
+		widget.NewFormItem("", adaptiveCheck),
+		widget.NewFormItem("Low activity below (%)", lowActivityEntry),
+		widget.NewFormItem("Low activity interval (min to max minutes)", container.NewGridWithColumns(2, lowIntervalMinEntry, lowIntervalMaxEntry)),
+		widget.NewFormItem("Refresh recent screenshots (s, 0 = off)", panelRefreshEntry),
+		widget.NewFormItem("Privacy blur", blurSelect),
+		widget.NewFormItem("Format", formatSelect),
+		widget.NewFormItem("JPEG/WebP quality (1-100)", qualityEntry),
+		widget.NewFormItem("HiDPI resolution", resolutionSelect),
+		widget.NewFormItem("Max size (px, 0 = full)", maxDimensionEntry),
+		widget.NewFormItem("Watermark", watermarkCheck),
+		widget.NewFormItem("", container.NewHBox(watermarkUserCheck, watermarkTaskCheck)),
+		widget.NewFormItem("Watermark corner", watermarkPositionSelect),
+		widget.NewFormItem(fmt.Sprintf("Watermark opacity (%d-100%%)", config.MinWatermarkOpacity), watermarkOpacityEntry),
+		widget.NewFormItem("Busy input (events/min)", busyRateEntry),
+		widget.NewFormItem("Webcam image (width x height)", container.NewGridWithColumns(2, webcamWidthEntry, webcamHeightEntry)),
+		widget.NewFormItem("", confirmUploadsCheck),
+		widget.NewFormItem("", skipDuplicatesCheck),
+		widget.NewFormItem("", uploadNotesCheck),
+		widget.NewFormItem("Similarity threshold (0-64)", duplicateThresholdEntry),
+		widget.NewFormItem("Delete after (days, 0 = never)", retentionDaysEntry),
+		widget.NewFormItem("Folder size cap (MB, 0 = none)", maxSizeEntry),
+	)
+	screenshotCard := widget.NewCard("Screenshots", "", screenshotForm)
+
+	trackWindowCheck := widget.NewCheck("Record which applications are used while tracking", nil)
+	trackWindowCheck.SetChecked(settings.TrackActiveWindow)
+	detectLockCheck := widget.NewCheck("Skip screenshots while the screen is locked", nil)
+	detectLockCheck.SetChecked(settings.DetectScreenLock)
+	pauseWhenLockedCheck := widget.NewCheck("Also pause the timer while the screen is locked", nil)
+	pauseWhenLockedCheck.SetChecked(settings.PauseWhenLocked)
+	privacyCard := widget.NewCard("Application Usage",
+		"Stored locally. macOS asks for Automation permission; Linux needs X11 and xprop, and loginctl for lock detection",
+		container.NewVBox(trackWindowCheck, detectLockCheck, pauseWhenLockedCheck))
+
+	autostartEnabled, err := platform.AutostartEnabled()
+	if err != nil {
+		log.Printf("Failed to read autostart state: %v", err)
+	}
+	autostartCheck := widget.NewCheck("Start Time Tracker on login", nil)
+	autostartCheck.SetChecked(autostartEnabled)
+	stopSummaryCheck := widget.NewCheck("Show a session summary when the timer stops", nil)
+	stopSummaryCheck.SetChecked(settings.ShowStopSummary)
+	timezoneEntry := widget.NewEntry()
+	timezoneEntry.SetPlaceHolder("System zone, or e.g. Europe/Berlin")
+	timezoneEntry.SetText(settings.DisplayTimezone)
+	minSessionEntry := widget.NewEntry()
+	minSessionEntry.SetText(strconv.Itoa(settings.MinSessionSeconds))
+	maxSessionEntry := widget.NewEntry()
+	maxSessionEntry.SetText(strconv.Itoa(settings.MaxSessionHours))
+	idleEntry := widget.NewEntry()
+	idleEntry.SetText(strconv.Itoa(settings.IdleThresholdMinutes))
+	countIdleCheck := widget.NewCheck("Count idle time as worked", nil)
+	countIdleCheck.SetChecked(settings.CountIdleAsWorked)
+	concurrentCheck := widget.NewCheck("Allow tracking several tasks at once", nil)
+	concurrentCheck.SetChecked(settings.ConcurrentTasks)
+	allocationSelect := widget.NewSelect([]string{config.AllocationEqual, config.AllocationFull}, nil)
+	allocationSelect.SetSelected(settings.TimeAllocation)
+	generalForm := widget.NewForm(
+		widget.NewFormItem("Time zone", timezoneEntry),
+		widget.NewFormItem("Minimum session (s, 0 = off)", minSessionEntry),
+		widget.NewFormItem("Stop sessions after (h, 0 = never)", maxSessionEntry),
+		widget.NewFormItem("Idle after (minutes, 0 = off)", idleEntry),
+	)
+	allocationForm := widget.NewForm(widget.NewFormItem("Time for tasks tracked together", allocationSelect))
+	generalCard := widget.NewCard("General", "", container.NewVBox(autostartCheck, stopSummaryCheck, generalForm, countIdleCheck,
+		concurrentCheck, allocationForm))
+
+	groupByProjectCheck := widget.NewCheck("Pick a project before picking a task", nil)
+	groupByProjectCheck.SetChecked(settings.GroupTasksByProject)
+	rememberTaskCheck := widget.NewCheck("Remember the selected task across restarts", nil)
+	rememberTaskCheck.SetChecked(settings.RememberLastTask)
+	autoStartTrackingCheck := widget.NewCheck("Start tracking the remembered task on launch", nil)
+	autoStartTrackingCheck.SetChecked(settings.AutoStartTracking)
+	taskFormatEntry := widget.NewEntry()
+	taskFormatEntry.SetPlaceHolder(config.DefaultTaskDisplayFormat)
+	taskFormatEntry.SetText(settings.TaskDisplayFormat)
+	taskFormatForm := widget.NewForm(widget.NewFormItem("Task label", taskFormatEntry))
+	taskCard := widget.NewCard("Task Selection", "Changes apply on next launch. Labels may use {name}, {id}, {project} and {status}",
+		container.NewVBox(groupByProjectCheck, rememberTaskCheck, autoStartTrackingCheck, taskFormatForm))
+
+	startDescriptionEntry := widget.NewEntry()
+	startDescriptionEntry.SetPlaceHolder(config.DefaultStartDescription)
+	startDescriptionEntry.SetText(settings.StartDescriptionTemplate)
+	stopDescriptionEntry := widget.NewEntry()
+	stopDescriptionEntry.SetPlaceHolder(config.DefaultStopDescription)
+	stopDescriptionEntry.SetText(settings.StopDescriptionTemplate)
+	descriptionForm := widget.NewForm(
+		widget.NewFormItem("On start", startDescriptionEntry),
+		widget.NewFormItem("On stop", stopDescriptionEntry),
+	)
+	descriptionCard := widget.NewCard("Work Report Descriptions",
+		"May use {task}, {id}, {project}, {status}, {date} and {time}", descriptionForm)
+
+	focusEntry := widget.NewEntry()
+	focusEntry.SetText(strconv.Itoa(settings.PomodoroFocusMinutes))
+	breakEntry := widget.NewEntry()
+	breakEntry.SetText(strconv.Itoa(settings.PomodoroBreakMinutes))
+	autoPauseCheck := widget.NewCheck("Pause tracking during breaks", nil)
+	autoPauseCheck.SetChecked(settings.PomodoroAutoPause)
+	pomodoroForm := widget.NewForm(
+		widget.NewFormItem("Focus (minutes)", focusEntry),
+		widget.NewFormItem("Break (minutes)", breakEntry),
+		widget.NewFormItem("", autoPauseCheck),
+	)
+	pomodoroCard := widget.NewCard("Pomodoro", "", pomodoroForm)
+
+	// Settings the server mandates are shown but can't be changed
+	if policy := config.CurrentServerPolicy(); policy.Mandates() {
+		screenshotCard.SetSubTitle("Some settings are set by your organization's server")
+		if policy.ScreenshotCaptureMode != nil {
+			captureModeSelect.Disable()
+		}
+		if policy.ScreenshotBlur != nil {
+			blurSelect.Disable()
+		}
+		if policy.ConfirmScreenshotUploads != nil {
+			confirmUploadsCheck.Disable()
+		}
+		if policy.IdleThresholdMinutes != nil {
+			idleEntry.Disable()
+		}
+		if policy.CountIdleAsWorked != nil {
+			countIdleCheck.Disable()
+		}
+	}
+
+	saveButton := widget.NewButton("Save", func() {
+		logSize, err := strconv.Atoi(logSizeEntry.Text)
+		if err != nil || logSize <= 0 {
+			dialog.ShowError(fmt.Errorf("max log size must be a positive number"), win)
+			return
+		}
+		logBackups, err := strconv.Atoi(logBackupsEntry.Text)
+		if err != nil || logBackups < 0 {
+			dialog.ShowError(fmt.Errorf("log files to keep must be zero or more"), win)
+			return
+		}
+
+		apiPort, err := strconv.Atoi(apiPortEntry.Text)
+		if err != nil || apiPort <= 0 || apiPort > 65535 {
+			dialog.ShowError(fmt.Errorf("local API port must be between 1 and 65535"), win)
+			return
+		}
+		proxyURL := strings.TrimSpace(proxyEntry.Text)
+		if proxyURL != "" {
+			parsed, err := url.Parse(proxyURL)
+			if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+				dialog.ShowError(fmt.Errorf("proxy URL must look like http://host:port"), win)
+				return
+			}
+		}
+		userAgent := strings.TrimSpace(userAgentEntry.Text)
+		if strings.ContainsFunc(userAgent, unicode.IsControl) {
+			dialog.ShowError(fmt.Errorf("User-Agent can't contain control characters"), win)
+			return
+		}
+		duplicateThreshold, err := strconv.Atoi(duplicateThresholdEntry.Text)
+		if err != nil || duplicateThreshold < 0 || duplicateThreshold > 64 {
+			dialog.ShowError(fmt.Errorf("similarity threshold must be between 0 and 64"), win)
+			return
+		}
+		jitter, err := strconv.Atoi(jitterEntry.Text)
+		if err != nil || jitter < 0 || jitter > core.MaxScreenshotJitterPercent {
+			dialog.ShowError(fmt.Errorf("interval randomness must be between 0 and %d", core.MaxScreenshotJitterPercent), win)
+			return
+		}
+		grace, err := strconv.Atoi(graceEntry.Text)
+		if err != nil || grace < 0 || grace > 3600 {
+			dialog.ShowError(fmt.Errorf("first capture delay must be between 0 and 3600 seconds"), win)
+			return
+		}
+		lowActivity, err := strconv.Atoi(lowActivityEntry.Text)
+		if err != nil || lowActivity < 1 || lowActivity > 100 {
+			dialog.ShowError(fmt.Errorf("low activity threshold must be between 1 and 100%%"), win)
+			return
+		}
+		lowIntervalMin, minErr := strconv.Atoi(lowIntervalMinEntry.Text)
+		lowIntervalMax, maxErr := strconv.Atoi(lowIntervalMaxEntry.Text)
+		if minErr != nil || maxErr != nil || lowIntervalMin < 1 || lowIntervalMax < lowIntervalMin || lowIntervalMax > 240 {
+			dialog.ShowError(fmt.Errorf("low activity intervals must be between 1 and 240 minutes, the first no longer than the second"), win)
+			return
+		}
+		panelRefresh, err := strconv.Atoi(panelRefreshEntry.Text)
+		if err != nil || panelRefresh != 0 && (panelRefresh < 10 || panelRefresh > 3600) {
+			dialog.ShowError(fmt.Errorf("screenshot refresh must be 0 or between 10 and 3600 seconds"), win)
+			return
+		}
+		minSession, err := strconv.Atoi(minSessionEntry.Text)
+		if err != nil || minSession < 0 || minSession > 3600 {
+			dialog.ShowError(fmt.Errorf("minimum session must be between 0 and 3600 seconds"), win)
+			return
+		}
+		maxSession, err := strconv.Atoi(maxSessionEntry.Text)
+		if err != nil || maxSession < 0 || maxSession > 24 {
+			dialog.ShowError(fmt.Errorf("maximum session must be between 0 and 24 hours"), win)
+			return
+		}
+		idleMinutes, err := strconv.Atoi(idleEntry.Text)
+		if err != nil || idleMinutes < 0 || idleMinutes > 240 {
+			dialog.ShowError(fmt.Errorf("idle threshold must be between 0 and 240 minutes"), win)
+			return
+		}
+		taskFormat := strings.TrimSpace(taskFormatEntry.Text)
+		if taskFormat == "" {
+			taskFormat = config.DefaultTaskDisplayFormat
+		}
+		if !strings.Contains(taskFormat, "{name}") {
+			dialog.ShowError(fmt.Errorf("the task label must include {name}"), win)
+			return
+		}
+		startDescription := strings.TrimSpace(startDescriptionEntry.Text)
+		if startDescription == "" {
+			startDescription = config.DefaultStartDescription
+		}
+		stopDescription := strings.TrimSpace(stopDescriptionEntry.Text)
+		if stopDescription == "" {
+			stopDescription = config.DefaultStopDescription
+		}
+		timezone := strings.TrimSpace(timezoneEntry.Text)
+		if timezone != "" {
+			if _, err := time.LoadLocation(timezone); err != nil {
+				dialog.ShowError(fmt.Errorf("unknown time zone %q, use a name such as Europe/Berlin", timezone), win)
+				return
+			}
+		}
+		uploadConcurrency, err := strconv.Atoi(uploadConcurrencyEntry.Text)
+		if err != nil || uploadConcurrency < 1 || uploadConcurrency > config.MaxUploadConcurrency {
+			dialog.ShowError(fmt.Errorf("parallel uploads must be between 1 and %d", config.MaxUploadConcurrency), win)
+			return
+		}
+		quality, err := strconv.Atoi(qualityEntry.Text)
+		if err != nil || quality < config.MinScreenshotQuality || quality > config.MaxScreenshotQuality {
+			dialog.ShowError(fmt.Errorf("JPEG/WebP quality must be between %d and %d", config.MinScreenshotQuality, config.MaxScreenshotQuality), win)
+			return
+		}
+		busyRate, err := strconv.Atoi(busyRateEntry.Text)
+		if err != nil || busyRate < 1 {
+			dialog.ShowError(fmt.Errorf("busy input rate must be at least 1 event per minute"), win)
+			return
+		}
+		webcamWidth, widthErr := strconv.Atoi(webcamWidthEntry.Text)
+		webcamHeight, heightErr := strconv.Atoi(webcamHeightEntry.Text)
+		if widthErr != nil || heightErr != nil || webcamWidth < 1 || webcamHeight < 1 ||
+			webcamWidth > config.MaxWebcamDimension || webcamHeight > config.MaxWebcamDimension {
+			dialog.ShowError(fmt.Errorf("webcam image width and height must be between 1 and %d", config.MaxWebcamDimension), win)
+			return
+		}
+		maxDimension, err := strconv.Atoi(maxDimensionEntry.Text)
+		if err != nil || maxDimension < 0 {
+			dialog.ShowError(fmt.Errorf("max screenshot size must be zero or more"), win)
+			return
+		}
+		watermarkOpacity, err := strconv.Atoi(watermarkOpacityEntry.Text)
+		if err != nil || watermarkOpacity < config.MinWatermarkOpacity || watermarkOpacity > 100 {
+			dialog.ShowError(fmt.Errorf("watermark opacity must be between %d and 100", config.MinWatermarkOpacity), win)
+			return
+		}
+		retentionDays, err := strconv.Atoi(retentionDaysEntry.Text)
+		if err != nil || retentionDays < 0 {
+			dialog.ShowError(fmt.Errorf("retention days must be zero or more"), win)
+			return
+		}
+		maxSize, err := strconv.Atoi(maxSizeEntry.Text)
+		if err != nil || maxSize < 0 {
+			dialog.ShowError(fmt.Errorf("folder size cap must be zero or more"), win)
+			return
+		}
+		screenshotDir := strings.TrimSpace(screenshotDirEntry.Text)
+		if screenshotDir != "" {
+			if !filepath.IsAbs(screenshotDir) {
+				dialog.ShowError(fmt.Errorf("screenshot folder must be an absolute path"), win)
+				return
+			}
+			if err := config.CheckWritableDir(screenshotDir); err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+		}
+		focusMinutes, err := strconv.Atoi(focusEntry.Text)
+		if err != nil || focusMinutes <= 0 {
+			dialog.ShowError(fmt.Errorf("focus minutes must be a positive number"), win)
+			return
+		}
+		breakMinutes, err := strconv.Atoi(breakEntry.Text)
+		if err != nil || breakMinutes <= 0 {
+			dialog.ShowError(fmt.Errorf("break minutes must be a positive number"), win)
+			return
+		}
+		apiToken := apiTokenEntry.Text
+		if apiEnabledCheck.Checked && apiToken == "" {
+			apiToken, err = localapi.GenerateToken()
+			if err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+		}
+
+		if autostartCheck.Checked != autostartEnabled {
+			if err := platform.SetAutostart(autostartCheck.Checked); err != nil {
+				log.Printf("Failed to update autostart: %v", err)
+				dialog.ShowError(fmt.Errorf("failed to update start on login: %w", err), win)
+				return
+			}
+			autostartEnabled = autostartCheck.Checked
+		}
+
+		oldScreenshotDir, oldDirErr := config.ScreenshotDir()
+		err = config.Update(func(s *config.Settings) {
+			s.LogMaxSizeMB = logSize
+			s.LogMaxBackups = logBackups
+			s.InputDebugLog = inputLogCheck.Checked
+			s.InputDebugKeys = inputKeysCheck.Checked
+			s.LocalAPIEnabled = apiEnabledCheck.Checked
+			s.LocalAPIPort = apiPort
+			s.LocalAPIToken = apiToken
+			s.ProxyURL = proxyURL
+			s.UserAgent = userAgent
+			s.UploadConcurrency = uploadConcurrency
+			s.InsecureSkipVerify = insecureCheck.Checked
+			s.ScreenshotBlur = blurSelect.Selected
+			s.ScreenshotFormat = formatSelect.Selected
+			s.ScreenshotQuality = quality
+			s.ScreenshotMaxDimension = maxDimension
+			s.Watermark = watermarkCheck.Checked
+			s.WatermarkUser = watermarkUserCheck.Checked
+			s.WatermarkTask = watermarkTaskCheck.Checked
+			s.WatermarkPosition = watermarkPositionSelect.Selected
+			s.WatermarkOpacity = watermarkOpacity
+			s.BusyEventsPerMinute = busyRate
+			s.WebcamWidth = webcamWidth
+			s.WebcamHeight = webcamHeight
+			s.ScreenshotResolution = resolutionSelect.Selected
+			s.ScreenshotCaptureMode = captureModeSelect.Selected
+			s.ScreenshotScope = scopeSelect.Selected
+			s.SkipDuplicateScreenshots = skipDuplicatesCheck.Checked
+			s.ConfirmScreenshotUploads = confirmUploadsCheck.Checked
+			s.UploadScreenshotNotes = uploadNotesCheck.Checked
+			s.DuplicateThreshold = duplicateThreshold
+			s.GroupTasksByProject = groupByProjectCheck.Checked
+			s.RememberLastTask = rememberTaskCheck.Checked
+			s.AutoStartTracking = autoStartTrackingCheck.Checked
+			s.TaskDisplayFormat = taskFormat
+			s.StartDescriptionTemplate = startDescription
+			s.StopDescriptionTemplate = stopDescription
+			s.MinSessionSeconds = minSession
+			s.MaxSessionHours = maxSession
+			s.IdleThresholdMinutes = idleMinutes
+			s.CountIdleAsWorked = countIdleCheck.Checked
+			s.ConcurrentTasks = concurrentCheck.Checked
+			s.TimeAllocation = allocationSelect.Selected
+			s.ScreenshotRetentionDays = retentionDays
+			s.ScreenshotMaxSizeMB = maxSize
+			s.ScreenshotDir = screenshotDir
+			s.ScreenshotJitterPercent = jitter
+			s.ScreenshotGraceSeconds = grace
+			s.AdaptiveScreenshotInterval = adaptiveCheck.Checked
+			s.LowActivityPercent = lowActivity
+			s.LowActivityMinIntervalMinutes = lowIntervalMin
+			s.LowActivityMaxIntervalMinutes = lowIntervalMax
+			s.ScreenshotPanelRefreshSeconds = panelRefresh
+			s.PomodoroFocusMinutes = focusMinutes
+			s.PomodoroBreakMinutes = breakMinutes
+			s.PomodoroAutoPause = autoPauseCheck.Checked
+			s.ShowStopSummary = stopSummaryCheck.Checked
+			s.DisplayTimezone = timezone
+			s.TrackActiveWindow = trackWindowCheck.Checked
+			s.DetectScreenLock = detectLockCheck.Checked
+			s.PauseWhenLocked = pauseWhenLockedCheck.Checked
+		})
+		if err != nil {
+			log.Printf("Failed to save settings: %v", err)
+			dialog.ShowError(fmt.Errorf("failed to save settings: %w", err), win)
+			return
+		}
+		log.Println("Settings saved")
+		if profileSelect.Selected != activeProfile && onSwitchProfile != nil {
+			onSwitchProfile(profileSelect.Selected)
+		}
+
+		newScreenshotDir, err := config.ScreenshotDir()
+		if oldDirErr != nil || err != nil || filepath.Clean(oldScreenshotDir) == filepath.Clean(newScreenshotDir) {
+			win.Close()
+			return
+		}
+		dialog.ShowConfirm("Move Screenshots", "Move existing screenshots to the new folder?", func(move bool) {
+			if !move {
+				win.Close()
+				return
+			}
+			go func() {
+				defer logging.Recover("move screenshots", nil)
+				moved, err := core.MoveScreenshots(oldScreenshotDir, newScreenshotDir)
+				fyne.Do(func() {
+					if err != nil {
+						log.Printf("Failed to move screenshots: %v", err)
+						dialog.ShowError(fmt.Errorf("moved %d screenshots before failing: %w", moved, err), win)
+						return
+					}
+					log.Printf("Moved %d screenshots to %s", moved, newScreenshotDir)
+					win.Close()
+				})
+			}()
+		}, win)
+	})
+
+	cards := container.NewVScroll(container.NewVBox(generalCard, taskCard, descriptionCard, pomodoroCard, screenshotCard, privacyCard, networkCard, apiCard, logCard))
+	win.SetContent(container.NewBorder(nil, saveButton, nil, nil, cards))
+	win.Resize(fyne.NewSize(420, 560))
+	win.CenterOnScreen()
+	return win
+}
+
+// openLogFolder opens the directory containing the log files
+func openLogFolder(a fyne.App, win fyne.Window) {
+	go func() {
+		dir, err := logging.Dir()
+		fyne.Do(func() {
+			if err != nil {
+				log.Printf("Failed to get log folder: %v", err)
+				dialog.ShowError(fmt.Errorf("could not locate log folder: %w", err), win)
+				return
+			}
+			parsedURL, err := url.Parse(storage.NewFileURI(dir).String())
+			if err != nil {
+				log.Printf("Failed to parse log folder URI %s: %v", dir, err)
+				dialog.ShowError(fmt.Errorf("invalid log folder path"), win)
+				return
+			}
+			if err := a.OpenURL(parsedURL); err != nil {
+				log.Printf("Failed to open log folder %s: %v", dir, err)
+				dialog.ShowError(fmt.Errorf("could not open file explorer: %w", err), win)
+			}
+		})
+	}()
+}
@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/core"
+)
+
+// errStopSummaryOpen is returned when starting a session while the last one's
+// summary is still open, as its work report hasn't been closed yet
+var errStopSummaryOpen = errors.New("the last session's summary is still open; send or dismiss it first")
+
+// showStopSummary shows what the stopped session recorded and lets the user
+// edit the stop description, pre-filled with defaultDescription.
+// closeReport is called exactly once with the description to send, whether
+// the dialog is saved or dismissed; dismissing sends defaultDescription. The
+// returned func sends the description typed so far and closes the dialog, for
+// when the app quits or logs out with it still open.
+func (ui *TaskWindowUI) showStopSummary(summary core.SessionSummary, defaultDescription string, closeReport func(description string)) func() {
+	record := summary.Record
+	duration := time.Duration(record.DurationSeconds) * time.Second
+	durationText := fmt.Sprintf("%02d:%02d:%02d", int(duration.Hours()), int(duration.Minutes())%60, int(duration.Seconds())%60)
+
+	descriptionEntry := widget.NewMultiLineEntry()
+	descriptionEntry.SetText(defaultDescription)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Task", widget.NewLabel(record.Task)),
+		widget.NewFormItem("Duration", widget.NewLabel(durationText)),
+		widget.NewFormItem("Screenshots", widget.NewLabel(strconv.Itoa(summary.Screenshots))),
+		widget.NewFormItem("Keyboard events", widget.NewLabel(strconv.FormatInt(record.KeyboardEventCount, 10))),
+		widget.NewFormItem("Mouse events", widget.NewLabel(strconv.FormatInt(record.MouseEventCount, 10))),
+	}
+	if record.IdleSeconds > 0 {
+		idle := time.Duration(record.IdleSeconds) * time.Second
+		items = append(items, widget.NewFormItem("Idle", widget.NewLabel(idle.String())))
+	}
+	if record.TopApps != "" {
+		items = append(items, widget.NewFormItem("Top apps", widget.NewLabel(record.TopApps)))
+	}
+	items = append(items, widget.NewFormItem("Description", descriptionEntry))
+	closed := false
+	finish := func(send bool) {
+		if closed {
+			return
+		}
+		closed = true
+		description := strings.TrimSpace(descriptionEntry.Text)
+		if !send || description == "" {
+			description = defaultDescription
+		}
+		closeReport(description)
+	}
+	d := dialog.NewForm("Session Summary", "Send", "Dismiss", items, finish, ui.Win)
+	d.Show()
+	return func() {
+		finish(true)
+		d.Hide()
+	}
+}
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/core"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+func TestStartWhileSummaryOpen(t *testing.T) {
+	task := types.Task{ID: 7, Name: "Docs"}
+	closed := false
+	// No session: starting must be refused before anything is tracked
+	ui := &TaskWindowUI{
+		selectedTask: &task,
+		pendingClose: func() { closed = true },
+	}
+
+	if err := ui.startTracking(); !errors.Is(err, errStopSummaryOpen) {
+		t.Fatalf("startTracking() = %v, want %v", err, errStopSummaryOpen)
+	}
+	if ui.isTimerRunning {
+		t.Error("timer running after a refused start")
+	}
+	if closed {
+		t.Error("the stopped session's report was closed by the refused start")
+	}
+
+	ui.pendingClose = nil
+	if err := ui.startBlocked(); err != nil {
+		t.Errorf("startBlocked() = %v once the summary is closed, want nil", err)
+	}
+}
+
+func TestCloseWithSummaryOpen(t *testing.T) {
+	tests := []struct {
+		name  string
+		typed string
+		want  string
+	}{
+		{"typed description", "Wrote the intro", "Wrote the intro"},
+		{"cleared description", "  ", "Stopped Docs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := test.NewTempApp(t)
+			a.Settings().SetTheme(theme.DefaultTheme()) // The test theme lacks some of the fonts the window uses
+			ui := &TaskWindowUI{Win: a.NewWindow("Tasks")}
+			var sent []string
+			closeNow := ui.showStopSummary(core.SessionSummary{Record: core.ActivityRecord{Task: "Docs"}}, "Stopped Docs",
+				func(description string) { sent = append(sent, description) })
+
+			var entry *widget.Entry
+			for _, o := range test.LaidOutObjects(ui.Win.Canvas().Overlays().Top()) {
+				if e, ok := o.(*widget.Entry); ok && e.MultiLine {
+					entry = e
+				}
+			}
+			if entry == nil {
+				t.Fatal("summary has no description entry")
+			}
+			entry.SetText(tt.typed)
+
+			closeNow() // Quitting or logging out
+			if len(sent) != 1 || sent[0] != tt.want {
+				t.Errorf("report closed with %q, want once with %q", sent, tt.want)
+			}
+			if ui.Win.Canvas().Overlays().Top() != nil {
+				t.Error("summary still shown")
+			}
+		})
+	}
+}
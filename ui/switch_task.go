@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/core"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/logging"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// showSwitchTaskDialog lets the user pick another task to switch to while the timer runs
+func (ui *TaskWindowUI) showSwitchTaskDialog() {
+	if !ui.isTimerRunning || ui.sessionTooShort("switch") {
+		return
+	}
+
+	var options []string
+	optionTasks := map[string]types.Task{}
+	displays := taskDisplays(ui.tasks)
+	for i, task := range ui.tasks {
+		if ui.selectedTask != nil && task.ID == ui.selectedTask.ID {
+			continue
+		}
+		option := displays[i]
+		options = append(options, option)
+		optionTasks[option] = task
+	}
+	if len(options) == 0 {
+		dialog.ShowInformation("Switch Task", "There are no other tasks to switch to.", ui.Win)
+		return
+	}
+
+	taskSelect := widget.NewSelect(options, nil)
+	taskSelect.PlaceHolder = "Select a task..."
+	items := []*widget.FormItem{widget.NewFormItem("Switch to", taskSelect)}
+	dialog.ShowForm("Switch Task", "Switch", "Cancel", items, func(confirmed bool) {
+		if !confirmed || taskSelect.Selected == "" {
+			return
+		}
+		ui.switchTask(optionTasks[taskSelect.Selected])
+	}, ui.Win)
+}
+
+// switchTask stops the current session and starts one for task in a single step
+func (ui *TaskWindowUI) switchTask(task types.Task) {
+	if !ui.isTimerRunning {
+		return
+	}
+	log.Printf("Switching tracking to task: %s", task.Name)
+	stopDescription := config.DefaultStopDescription
+	if ui.selectedTask != nil {
+		stopDescription = core.StopDescription(*ui.selectedTask)
+	}
+	startDescription := core.StartDescription(task)
+
+	if err := ui.session.Switch(task); err != nil {
+		log.Printf("Error switching task: %v", err)
+		dialog.ShowError(fmt.Errorf("failed to switch task: %w", err), ui.Win)
+		if errors.Is(err, core.ErrSwitchStopped) {
+			// The session already stopped, so only the UI and report remain
+			ui.endTracking(func() error { return nil }, false)
+		}
+		return
+	}
+
+	ui.keepTrackedTaskSelected()
+	ui.rememberSelectedTask()
+	ui.stopwatch.Start()
+	if ui.isPaused {
+		ui.stopwatch.Pause()
+	}
+	ui.updateTimerDisplay()
+	ui.updateStatusLabel()
+	ui.updateScreenshotsList()
+	ui.refreshDailyGoal()
+
+	// Logging out or quitting waits for the handover like a report being closed
+	ui.closingReports.Add(1)
+	go func() {
+		defer ui.closingReports.Done()
+		defer logging.Recover("SwitchWorkReport", nil)
+		if err := ui.session.SwitchWorkReport(stopDescription, startDescription); err != nil {
+			log.Printf("Error switching work report: %v", err)
+			fyne.Do(func() {
+				dialog.ShowError(fmt.Errorf("the task was switched locally but the server could not be updated: %w", err), ui.Win)
+			})
+		}
+		fyne.Do(ui.updateStatusLabel)
+		ui.refreshSyncBadge()
+	}()
+}
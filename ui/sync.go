@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/internal/logging"
+)
+
+// newSyncRow builds the pending sync badge and its Sync Now button, hidden
+// while every session is synced with the server
+func (ui *TaskWindowUI) newSyncRow() fyne.CanvasObject {
+	ui.syncLabel = widget.NewLabel("")
+	ui.syncLabel.Importance = widget.WarningImportance
+	ui.syncButton = widget.NewButton("Sync Now", ui.syncNow)
+	ui.syncRow = container.NewCenter(container.NewHBox(ui.syncLabel, ui.syncButton))
+	ui.syncRow.Hide()
+	return ui.syncRow
+}
+
+// refreshSyncBadge recounts the sessions not yet synced, off the UI thread
+func (ui *TaskWindowUI) refreshSyncBadge() {
+	go func() {
+		defer logging.Recover("refreshSyncBadge", nil)
+		db := ui.activityTracker.Database
+		if err := db.Connect(); err != nil {
+			log.Printf("Error counting unsynced sessions: %v", err)
+			return
+		}
+		count, err := db.CountUnsynced()
+		if err != nil {
+			log.Printf("Error counting unsynced sessions: %v", err)
+			return
+		}
+		fyne.Do(func() { ui.setSyncBadge(count) })
+	}()
+}
+
+// setSyncBadge shows how many sessions are pending sync
+func (ui *TaskWindowUI) setSyncBadge(count int) {
+	if count == 0 {
+		ui.syncRow.Hide()
+		return
+	}
+	if count == 1 {
+		ui.syncLabel.SetText("1 session pending sync")
+	} else {
+		ui.syncLabel.SetText(fmt.Sprintf("%d sessions pending sync", count))
+	}
+	ui.syncRow.Show()
+}
+
+// syncNow reconciles the saved sessions with the server and reports the outcome
+func (ui *TaskWindowUI) syncNow() {
+	ui.syncButton.Disable()
+	go func() {
+		defer logging.Recover("Sync", nil)
+		result, err := ui.session.Sync()
+		fyne.Do(func() {
+			ui.syncButton.Enable()
+			ui.setSyncBadge(result.Remaining)
+			if err != nil {
+				log.Printf("Error syncing sessions: %v", err)
+				dialog.ShowError(fmt.Errorf("some sessions could not be synced: %w", err), ui.Win)
+				return
+			}
+			message := fmt.Sprintf("Synced %d sessions with the server.", result.Repaired)
+			if result.Remaining > 0 {
+				message += fmt.Sprintf(" %d were left for later; the log says why.", result.Remaining)
+			}
+			if report := result.UnclosedReport; report != nil {
+				message += fmt.Sprintf("\n\nWork report %d for %s is still open on the server but wasn't recorded here. Close it on the server if it is not in use elsewhere.",
+					report.ID, report.Task.Name)
+			}
+			dialog.ShowInformation("Sync", message, ui.Win)
+		})
+	}()
+}
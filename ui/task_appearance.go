@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// maxTaskIconRunes bounds a task icon, leaving room for emoji sequences
+const maxTaskIconRunes = 8
+
+// taskAppearanceUI shows the selected and the tracked task's color
+type taskAppearanceUI struct {
+	button       *widget.Button
+	taskSwatch   *canvas.Rectangle // Beside the task dropdown
+	statusSwatch *canvas.Rectangle // Beside the status
+}
+
+// newTaskAppearance creates the appearance button and the color swatches,
+// hidden until a task with a color is selected or tracked
+func (ui *TaskWindowUI) newTaskAppearance() {
+	ui.appearance.button = widget.NewButton("Style...", ui.showTaskAppearanceDialog)
+	ui.appearance.button.Disable()
+	ui.appearance.taskSwatch = newSwatch()
+	ui.appearance.statusSwatch = newSwatch()
+}
+
+func newSwatch() *canvas.Rectangle {
+	swatch := canvas.NewRectangle(color.Transparent)
+	swatch.SetMinSize(fyne.NewSize(12, 12))
+	swatch.CornerRadius = 3
+	swatch.Hide()
+	return swatch
+}
+
+// taskAppearance returns task's configured appearance, the zero value if none
+func taskAppearance(task types.Task) config.TaskAppearance {
+	return config.Current().TaskAppearances[task.ID]
+}
+
+// taskTitle is task's name preceded by its icon, if it has one
+func taskTitle(task types.Task) string {
+	if icon := taskAppearance(task).Icon; icon != "" {
+		return icon + " " + task.Name
+	}
+	return task.Name
+}
+
+// parseHexColor parses a "#rrggbb" color
+func parseHexColor(s string) (color.Color, bool) {
+	var r, g, b uint8
+	if len(s) != 7 {
+		return nil, false
+	}
+	if n, err := fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b); err != nil || n != 3 {
+		return nil, false
+	}
+	return color.NRGBA{R: r, G: g, B: b, A: 0xff}, true
+}
+
+// hexColor formats c as "#rrggbb", dropping any transparency
+func hexColor(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B)
+}
+
+// setSwatch shows the color of task on swatch, hiding it for no task or color
+func setSwatch(swatch *canvas.Rectangle, task *types.Task) {
+	if task == nil {
+		swatch.Hide()
+		return
+	}
+	c, ok := parseHexColor(taskAppearance(*task).Color)
+	if !ok {
+		swatch.Hide()
+		return
+	}
+	swatch.FillColor = c
+	swatch.Show()
+	swatch.Refresh()
+}
+
+// refreshTaskAppearance shows the selected task's color and enables styling
+// it. Call it whenever the selected task changes.
+func (ui *TaskWindowUI) refreshTaskAppearance() {
+	if ui.selectedTask == nil {
+		ui.appearance.button.Disable()
+	} else {
+		ui.appearance.button.Enable()
+	}
+	setSwatch(ui.appearance.taskSwatch, ui.selectedTask)
+}
+
+// showTaskAppearanceDialog edits the selected task's color and icon
+func (ui *TaskWindowUI) showTaskAppearanceDialog() {
+	if ui.selectedTask == nil {
+		return
+	}
+	task := *ui.selectedTask
+	appearance := taskAppearance(task)
+
+	iconEntry := widget.NewEntry()
+	iconEntry.SetPlaceHolder("An emoji, or empty for none")
+	iconEntry.SetText(appearance.Icon)
+
+	chosen := appearance.Color
+	preview := canvas.NewRectangle(color.Transparent)
+	preview.SetMinSize(fyne.NewSize(24, 24))
+	preview.CornerRadius = 3
+	showChosen := func() {
+		preview.FillColor = color.Transparent
+		if c, ok := parseHexColor(chosen); ok {
+			preview.FillColor = c
+		}
+		preview.Refresh()
+	}
+	showChosen()
+	pickButton := widget.NewButton("Choose...", func() {
+		dialog.NewColorPicker("Task Color", "Pick a color for "+task.Name, func(c color.Color) {
+			chosen = hexColor(c)
+			showChosen()
+		}, ui.Win).Show()
+	})
+	noneButton := widget.NewButton("None", func() {
+		chosen = ""
+		showChosen()
+	})
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Icon", iconEntry),
+		widget.NewFormItem("Color", container.NewHBox(preview, pickButton, noneButton)),
+	}
+	dialog.ShowForm("Appearance: "+task.Name, "Save", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		icon := strings.TrimSpace(iconEntry.Text)
+		if utf8.RuneCountInString(icon) > maxTaskIconRunes || strings.ContainsFunc(icon, unicode.IsControl) {
+			dialog.ShowError(fmt.Errorf("the icon must be at most %d characters", maxTaskIconRunes), ui.Win)
+			return
+		}
+		updated := config.TaskAppearance{Color: chosen, Icon: icon}
+		err := config.Update(func(s *config.Settings) {
+			if updated == (config.TaskAppearance{}) {
+				delete(s.TaskAppearances, task.ID)
+				return
+			}
+			if s.TaskAppearances == nil {
+				s.TaskAppearances = map[int]config.TaskAppearance{}
+			}
+			s.TaskAppearances[task.ID] = updated
+		})
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("failed to save task appearance: %w", err), ui.Win)
+			return
+		}
+		ui.setTaskOptions()
+		ui.updateStatusLabel()
+	}, ui.Win)
+}
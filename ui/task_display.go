@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// localTaskPrefix marks local-only tasks in the task pickers
+const localTaskPrefix = "[Local] "
+
+// formatTaskDisplay renders a task for the task pickers using the configured
+// TaskDisplayFormat, replacing {name}, {id}, {project} and {status}, after the
+// task's icon if it has one. Local tasks have no meaningful ID or project, so
+// they are shown by name.
+func formatTaskDisplay(task types.Task) string {
+	display := formatTaskText(task)
+	if icon := taskAppearance(task).Icon; icon != "" {
+		return icon + " " + display
+	}
+	return display
+}
+
+// formatTaskText is formatTaskDisplay without the icon
+func formatTaskText(task types.Task) string {
+	if task.Local {
+		return localTaskPrefix + task.Name
+	}
+	status := ""
+	if task.Status != nil {
+		status = *task.Status
+	}
+	format := config.Current().TaskDisplayFormat
+	if format == "" {
+		format = config.DefaultTaskDisplayFormat
+	}
+	return strings.NewReplacer(
+		"{name}", task.Name,
+		"{id}", strconv.Itoa(task.ID),
+		"{project}", task.Project.Name,
+		"{status}", status,
+	).Replace(format)
+}
+
+// taskDisplays formats every task with formatTaskDisplay. Tasks that would
+// otherwise look identical (e.g. when the format hides IDs) get their ID
+// appended, so a display string always identifies one task.
+func taskDisplays(tasks []types.Task) []string {
+	displays := make([]string, len(tasks))
+	counts := map[string]int{}
+	for i, task := range tasks {
+		displays[i] = formatTaskDisplay(task)
+		counts[displays[i]]++
+	}
+	for i, task := range tasks {
+		if counts[displays[i]] > 1 {
+			displays[i] = fmt.Sprintf("%s #%d", displays[i], task.ID)
+		}
+	}
+	return displays
+}
@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// updateSettings applies fn to the settings for the rest of the test, saving
+// them in a data directory of the test's own
+func updateSettings(t *testing.T, fn func(s *config.Settings)) {
+	t.Helper()
+	t.Setenv(config.HomeEnv, t.TempDir())
+	saved := config.Current()
+	if err := config.Update(fn); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		config.Update(func(s *config.Settings) { *s = saved })
+	})
+}
+
+func TestFormatTaskDisplay(t *testing.T) {
+	open := "open"
+	task := types.Task{ID: 7, Name: "Docs", Project: types.Project{Name: "Website"}, Status: &open}
+
+	tests := []struct {
+		name   string
+		format string
+		task   types.Task
+		icon   string
+		want   string
+	}{
+		{"default format", "", task, "", "Docs (ID: 7, Project: Website)"},
+		{"custom format", "{project} / {name} [{status}]", task, "", "Website / Docs [open]"},
+		{"no status", "{name} {status}", types.Task{ID: 7, Name: "Docs"}, "", "Docs "},
+		{"icon", "{name}", task, "📝", "📝 Docs"},
+		{"local task", "{name} #{id}", types.Task{Name: "Errands", Local: true}, "", localTaskPrefix + "Errands"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateSettings(t, func(s *config.Settings) {
+				s.TaskDisplayFormat = tt.format
+				s.TaskAppearances = map[int]config.TaskAppearance{tt.task.ID: {Icon: tt.icon}}
+			})
+			if got := formatTaskDisplay(tt.task); got != tt.want {
+				t.Errorf("formatTaskDisplay() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaskDisplaysUnique(t *testing.T) {
+	tasks := []types.Task{
+		{ID: 1, Name: "Review"},
+		{ID: 2, Name: "Review"},
+		{ID: 3, Name: "Deploy"},
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		want   []string
+	}{
+		{"IDs shown", "{name} {id}", []string{"Review 1", "Review 2", "Deploy 3"}},
+		{"IDs hidden", "{name}", []string{"Review #1", "Review #2", "Deploy"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateSettings(t, func(s *config.Settings) { s.TaskDisplayFormat = tt.format })
+			if got := taskDisplays(tasks); !slices.Equal(got, tt.want) {
+				t.Errorf("taskDisplays() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+// paletteShortcut opens the task palette (Ctrl+K, or Cmd+K on macOS)
+var paletteShortcut = &desktop.CustomShortcut{KeyName: fyne.KeyK, Modifier: fyne.KeyModifierShortcutDefault}
+
+// paletteResults is how many matches the task palette lists
+const paletteResults = 10
+
+// fuzzyScore reports whether every character of query appears in text in
+// order, ignoring case, and how well it matches: higher is better.
+// Consecutive characters, matches at the start of a word and matches near the
+// start of text score higher, so "tt" ranks "Time Tracker" above "fitted".
+func fuzzyScore(query, text string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(text))
+	if len(q) == 0 {
+		return 0, true
+	}
+	score := 0
+	qi := 0
+	last := -1
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+		score++
+		if last >= 0 && ti == last+1 {
+			score += 5 // Consecutive
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]) {
+			score += 6 // Start of a word, outweighing consecutive characters
+		}
+		if last >= 0 {
+			score -= min(ti-last-1, 3) // Gap since the previous match
+		}
+		last = ti
+		qi++
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+	if strings.HasPrefix(string(t), string(q)) {
+		score += 10
+	}
+	return score, true
+}
+
+// rankTasks returns the tasks matching query by name or project, best first.
+// Ties keep the tasks' order. An empty query matches every task.
+func rankTasks(query string, tasks []types.Task) []types.Task {
+	type match struct {
+		task  types.Task
+		score int
+	}
+	var matches []match
+	for _, task := range tasks {
+		best, ok := fuzzyScore(query, task.Name)
+		if score, projectOK := fuzzyScore(query, task.Project.Name+" "+task.Name); projectOK && (!ok || score > best) {
+			best, ok = score, true
+		}
+		if ok {
+			matches = append(matches, match{task, best})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	ranked := make([]types.Task, len(matches))
+	for i, m := range matches {
+		ranked[i] = m.task
+	}
+	return ranked
+}
+
+// showTaskPalette opens a search box over the tasks. Enter starts the best
+// match, or switches to it while the timer runs; clicking a result does the same.
+func (ui *TaskWindowUI) showTaskPalette() {
+	if len(ui.tasks) == 0 {
+		dialog.ShowInformation("Find Task", "There are no tasks to search yet.", ui.Win)
+		return
+	}
+	var candidates []types.Task
+	for _, task := range ui.tasks {
+		if ui.isTimerRunning && ui.selectedTask != nil && task.ID == ui.selectedTask.ID {
+			continue // Already tracking it
+		}
+		candidates = append(candidates, task)
+	}
+
+	var results []types.Task
+	var palette *dialog.CustomDialog
+	choose := func(task types.Task) {
+		palette.Hide()
+		ui.activatePaletteTask(task)
+	}
+
+	list := widget.NewList(
+		func() int { return len(results) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(formatTaskDisplay(results[id]))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		if id < len(results) {
+			choose(results[id])
+		}
+	}
+
+	search := widget.NewEntry()
+	search.SetPlaceHolder("Type to search tasks and projects...")
+	search.OnChanged = func(query string) {
+		results = rankTasks(query, candidates)
+		if len(results) > paletteResults {
+			results = results[:paletteResults]
+		}
+		list.UnselectAll()
+		list.Refresh()
+	}
+	search.OnSubmitted = func(string) {
+		if len(results) > 0 {
+			choose(results[0])
+		}
+	}
+	search.OnChanged("")
+
+	action := "Start"
+	if ui.isTimerRunning {
+		action = "Switch to"
+	}
+	hint := widget.NewLabel(action + " a task: press Enter for the first match")
+	hint.Importance = widget.LowImportance
+	content := container.NewBorder(container.NewVBox(search, hint), nil, nil, nil, list)
+
+	palette = dialog.NewCustom("Find Task", "Cancel", content, ui.Win)
+	palette.Resize(fyne.NewSize(420, 380))
+	palette.Show()
+	ui.Win.Canvas().Focus(search)
+}
+
+// activatePaletteTask starts tracking task, or switches to it if the timer is running
+func (ui *TaskWindowUI) activatePaletteTask(task types.Task) {
+	if ui.isTimerRunning {
+		if ui.sessionTooShort("switch") {
+			return
+		}
+		ui.switchTask(task)
+		return
+	}
+	if !ui.selectTaskByID(task.ID) {
+		log.Printf("Task %d is no longer in the task list", task.ID)
+		return
+	}
+	ui.rememberSelectedTask()
+	ui.refreshDailyGoal()
+	if err := ui.startTracking(); err != nil {
+		dialog.ShowError(fmt.Errorf("failed to start tracking: %w", err), ui.Win)
+	}
+}
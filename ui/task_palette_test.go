@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/time-tracker/v2/internal/types"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		query, text string
+		want        bool
+	}{
+		{"", "Anything", true},
+		{"tt", "Time Tracker", true},
+		{"TIME", "time tracker", true},
+		{"tmtr", "Time Tracker", true},
+		{"ak", "Time Tracker", true},
+		{"xyz", "Time Tracker", false},
+		{"rekcart", "Time Tracker", false}, // Out of order
+	}
+	for _, tt := range tests {
+		if _, got := fuzzyScore(tt.query, tt.text); got != tt.want {
+			t.Errorf("fuzzyScore(%q, %q) matches = %v, want %v", tt.query, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestFuzzyScoreRanking(t *testing.T) {
+	tests := []struct {
+		query         string
+		better, worse string
+	}{
+		{"tt", "Time Tracker", "fitted"},              // Word starts
+		{"log", "Login form", "Blog layout"},          // Prefix
+		{"page", "Landing page", "Pause agenda"},      // Consecutive
+		{"rel", "Release notes", "Tracker telemetry"}, // Near the start
+	}
+	for _, tt := range tests {
+		better, ok1 := fuzzyScore(tt.query, tt.better)
+		worse, ok2 := fuzzyScore(tt.query, tt.worse)
+		if !ok1 || !ok2 || better <= worse {
+			t.Errorf("fuzzyScore(%q): %q = %d, %q = %d, want the first higher", tt.query, tt.better, better, tt.worse, worse)
+		}
+	}
+}
+
+func TestRankTasks(t *testing.T) {
+	website := types.Project{Name: "Website"}
+	tasks := []types.Task{
+		{ID: 1, Name: "Fix login bug", Project: website},
+		{ID: 2, Name: "Release notes", Project: types.Project{Name: "Mobile App"}},
+		{ID: 3, Name: "Landing page", Project: website},
+	}
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"", []int{1, 2, 3}},
+		{"notes", []int{2}},
+		{"web", []int{1, 3}}, // By project, ties in order
+		{"land", []int{3}},
+		{"zzz", []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got := rankTasks(tt.query, tasks)
+			ids := []int{}
+			for _, task := range got {
+				ids = append(ids, task.ID)
+			}
+			if len(ids) != len(tt.want) {
+				t.Fatalf("rankTasks(%q) = %v, want %v", tt.query, ids, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Fatalf("rankTasks(%q) = %v, want %v", tt.query, ids, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -1,13 +1,13 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/url"
-	"os"
-	"path/filepath"
 	"sort"
-	"strings"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -21,34 +21,91 @@ import (
 	"fyne.io/fyne/v2/widget"
 	"github.com/time-tracker/v2/assets"
 	"github.com/time-tracker/v2/core"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/localapi"
+	"github.com/time-tracker/v2/internal/logging"
 	"github.com/time-tracker/v2/internal/types"
+	"github.com/time-tracker/v2/services"
+	_ "golang.org/x/image/webp" // Thumbnails of WebP screenshots
 )
 
+const allProjectsOption = "All projects"
+
 // TaskWindowUI holds the Fyne UI elements corresponding to the Python TaskWindow
 
 type TaskWindowUI struct {
 	App fyne.App
 	Win fyne.Window
 
-	taskSelect       *widget.Select
-	refreshButton    *widget.Button
-	timerLabel       *widget.Label
-	startButton      *widget.Button
-	stopButton       *widget.Button
-	statusLabel      *widget.Label
-	screenshotsBox   *fyne.Container
-	openFolderButton *widget.Button
+	taskSelect        *widget.Select
+	projectSelect     *widget.Select
+	refreshButton     *widget.Button
+	timerLabel        *widget.Label
+	startButton       *widget.Button
+	stopButton        *widget.Button
+	switchButton      *widget.Button
+	reassignButton    *widget.Button
+	timerHint         *widget.Label // Briefly explains ignored timer actions
+	captureNowButton  *widget.Button
+	addTaskButton     *widget.Button        // Adds a concurrent task
+	concurrentBox     *fyne.Container       // One row per concurrent task
+	concurrentLabels  map[int]*widget.Label // Concurrent task timers, by task ID
+	statusLabel       *widget.Label
+	serverStatusLabel *widget.Label // Where the server's status differs from this app's
+	stopStatusChecks  chan struct{} // Ends the periodic server status checks; nil when none run
+	connectionLabel   *widget.Label
+	syncLabel         *widget.Label  // How many sessions are pending sync
+	syncButton        *widget.Button // Sync Now
+	syncRow           *fyne.Container
+	screenshotsBox    *fyne.Container
+	thumbnailCount    int           // Thumbnails in screenshotsBox; 0 while it shows a placeholder message
+	stopPanelRefresh  chan struct{} // Ends the periodic screenshots panel refresh; nil when none runs
+	openFolderButton  *widget.Button
 
 	ticker         *time.Ticker
 	stopTicker     chan bool
-	elapsedTime    time.Duration
+	stopwatch      *core.Stopwatch  // Tracked time, excluding pauses
+	now            func() time.Time // Replaceable clock for the maximum session length
 	isTimerRunning bool
+	isPaused       bool // Tracking is suspended (e.g. during a Pomodoro break)
+	screenLocked   bool // The screen is locked while tracking
+	lockPaused     bool // Tracking was paused because the screen locked
+	trayState      assets.TrayState
+
+	pomodoroCheck        *widget.Check
+	pomodoroOnBreak      bool
+	pomodoroPhaseElapsed time.Duration
+	pomodorosCompleted   int
+
+	goal       dailyGoal
+	appearance taskAppearanceUI
+
+	captureWarningShown bool
+	inputWarningShown   bool
+	recoveryChecked     bool      // Looked for an interrupted session after the first task load
+	pendingDeepLink     *DeepLink // Applied once tasks have loaded and recovery was checked
+	autoStartPending    bool      // Auto-start the remembered task once tasks have loaded and recovery was checked
+	launchActionsDone   bool      // The deep link and auto-start were applied or dropped; later links apply at once
 
 	tasks           []types.Task
+	projects        []types.Project
 	selectedTask    *types.Task
-	screenshotDir   string
+	projectFilter   int            // Project ID the task list is filtered to, 0 for all
+	projectOptions  map[string]int // Project select option -> project ID
 	taskManager     *core.TaskManager
 	activityTracker *core.ActivityTracker
+	session         *core.Session
+	localAPI        *localapi.Server
+	connectivity    *core.ConnectivityMonitor
+	unsubscribe     func()         // Ends the tracker event subscription
+	stopTokenWatch  func()         // Ends reloading the token when the token file changes
+	connectionItem  *fyne.MenuItem // Tray menu entry showing the backend state
+	trayMenu        *fyne.Menu
+
+	closingReports  sync.WaitGroup    // Work reports still being closed or handed over on the server
+	pendingClose    func()            // Closes the stopped session's report with the summary's description while the summary is open; nil otherwise
+	onLogout        func()            // Set by AppCoordinator; also called when the token is rejected
+	onSwitchProfile func(name string) // Set by AppCoordinator
 }
 
 // NewTaskWindow creates and initializes the Fyne UI
@@ -56,10 +113,11 @@ func NewTaskWindow(a fyne.App) *TaskWindowUI {
 	ui := &TaskWindowUI{
 		App:        a,
 		stopTicker: make(chan bool),
+		stopwatch:  core.NewStopwatch(),
+		now:        time.Now,
 	}
-	ui.Win = a.NewWindow("Go Time Tracker")
-	ui.Win.Resize(fyne.NewSize(400, 560))
-	ui.Win.SetFixedSize(true)
+	ui.Win = a.NewWindow(windowTitle("Go Time Tracker"))
+	restoreWindowSize(ui.Win, config.WindowTask, fyne.NewSize(400, 630))
 
 	iconResource := assets.GetClockResource()
 	if iconResource == nil {
@@ -68,19 +126,43 @@ func NewTaskWindow(a fyne.App) *TaskWindowUI {
 		ui.Win.SetIcon(iconResource)
 	}
 	ui.taskManager = core.NewTaskManager()
-	homeDir, _ := os.UserHomeDir()
-	ui.screenshotDir = filepath.Join(homeDir, ".time-tracker", "screenshots")
-	os.MkdirAll(ui.screenshotDir, os.ModePerm)
+	screenshotDir, err := config.ScreenshotDir()
+	if err != nil {
+		log.Printf("Failed to prepare screenshot directory: %v", err)
+	}
 
-	ui.activityTracker = core.NewActivityTracker(ui.screenshotDir, ui.taskManager)
+	ui.activityTracker = core.NewActivityTracker(screenshotDir, ui.taskManager)
+	ui.session = core.NewSession(ui.taskManager, ui.activityTracker)
+	ui.activityTracker.ScreenshotManager.StartRetention()
+	ui.activityTracker.ScreenshotManager.SetCaptureFailureCallback(ui.onCaptureFailure)
+	ui.activityTracker.InputMonitor.SetStartFailureCallback(ui.onInputMonitorFailure)
 	ui.setupUI()
+	ui.watchTrackerEvents()
 	ui.loadTasks()
 
 	ui.Win.SetCloseIntercept(func() {
+		saveWindowSize(ui.Win, config.WindowTask)
 		ui.Win.Hide()
 	})
 
 	ui.setupSystemTray()
+	ui.startLocalAPI()
+	ui.connectivity = core.NewConnectivityMonitor(ui.taskManager.Ping)
+	ui.connectivity.SetChangeCallback(func(online bool) {
+		fyne.Do(func() { ui.setConnectionState(online) })
+		if online {
+			ui.refreshServerPolicy()
+			ui.checkServerStatus()
+		}
+	})
+	ui.connectivity.Start()
+	go ui.refreshServerPolicy()
+	ui.refreshSyncBadge()
+	if stop, err := ui.taskManager.WatchToken(ui.onTokenChanged); err != nil {
+		log.Printf("Not watching the token file: %v", err)
+	} else {
+		ui.stopTokenWatch = stop
+	}
 
 	return ui
 }
@@ -88,18 +170,43 @@ func NewTaskWindow(a fyne.App) *TaskWindowUI {
 // setupUI creates the main layout and widgets
 func (ui *TaskWindowUI) setupUI() {
 	ui.taskSelect = widget.NewSelect([]string{"Loading tasks..."}, func(s string) {
-		for i := range ui.tasks {
-			taskDisplay := fmt.Sprintf("%s (ID: %d, Project: %s)", ui.tasks[i].Name, ui.tasks[i].ID, ui.tasks[i].Project.Name)
+		if s == newLocalTaskOption {
+			ui.setTaskOptions() // Put the previous selection back
+			ui.showNewLocalTaskDialog()
+			return
+		}
+		for i, taskDisplay := range taskDisplays(ui.tasks) {
 			if taskDisplay == s {
 				ui.selectedTask = &ui.tasks[i]
 				log.Printf("Selected task: %s (ID: %d)", ui.selectedTask.Name, ui.selectedTask.ID)
+				ui.rememberSelectedTask()
+				ui.refreshDailyGoal()
+				ui.refreshTaskAppearance()
+				ui.focusButton(ui.startButton)
 				break
 			}
 		}
 	})
-	ui.refreshButton = widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), ui.loadTasks)
-	taskSelectionLayout := container.NewBorder(nil, nil, nil, ui.refreshButton, ui.taskSelect)
-	taskCard := widget.NewCard("Task Selection", "", taskSelectionLayout)
+	// Icon buttons carry text too, as it is all screen readers and keyboard users get
+	ui.refreshButton = widget.NewButtonWithIcon("Refresh", theme.ViewRefreshIcon(), ui.refresh)
+	ui.newDailyGoalBar()
+	ui.newTaskAppearance()
+	projectsButton := widget.NewButtonWithIcon("Projects", theme.FolderOpenIcon(), ui.showProjectsDialog)
+	taskSelectionLayout := container.NewBorder(nil, nil, container.NewCenter(ui.appearance.taskSwatch),
+		container.NewHBox(ui.goal.button, ui.appearance.button, projectsButton, ui.refreshButton), ui.taskSelect)
+
+	// Optional first step: narrow the task list down to one project
+	ui.projectSelect = widget.NewSelect([]string{allProjectsOption}, func(s string) {
+		ui.projectFilter = ui.projectOptions[s]
+		ui.setTaskOptions()
+	})
+	ui.projectSelect.Selected = allProjectsOption
+	if !config.Current().GroupTasksByProject {
+		// Shown when a project is picked from the projects dialog
+		ui.projectSelect.Hide()
+	}
+	taskSelectionLayout = container.NewVBox(ui.projectSelect, taskSelectionLayout)
+	taskCard := widget.NewCard("Task Selection", "", container.NewVBox(taskSelectionLayout, ui.goal.bar))
 
 	ui.timerLabel = widget.NewLabel("00:00:00")
 	ui.timerLabel.Alignment = fyne.TextAlignCenter
@@ -109,20 +216,39 @@ func (ui *TaskWindowUI) setupUI() {
 	ui.startButton = widget.NewButton("Start Timer", ui.startTimer)
 	ui.stopButton = widget.NewButton("Stop Timer", ui.stopTimer)
 	ui.stopButton.Disable()
-	timerButtons := container.NewGridWithColumns(2, ui.startButton, ui.stopButton)
-	timerLayout := container.NewVBox(ui.timerLabel, timerButtons)
+	ui.switchButton = widget.NewButton("Switch Task", ui.showSwitchTaskDialog)
+	ui.switchButton.Disable()
+	ui.reassignButton = widget.NewButton("Reassign Session", ui.showReassignDialog)
+	ui.reassignButton.Disable()
+	timerButtons := container.NewVBox(container.NewGridWithColumns(2, ui.startButton, ui.stopButton),
+		container.NewGridWithColumns(2, ui.switchButton, ui.reassignButton))
+	ui.timerHint = widget.NewLabel("")
+	ui.timerHint.Alignment = fyne.TextAlignCenter
+	ui.timerHint.Wrapping = fyne.TextWrapWord
+	ui.timerHint.Importance = widget.LowImportance
+	ui.timerHint.Hide()
+	ui.pomodoroCheck = widget.NewCheck("Pomodoro mode", ui.onPomodoroToggled)
+	timerLayout := container.NewVBox(ui.timerLabel, timerButtons, ui.newConcurrentSection(), ui.timerHint, ui.pomodoroCheck)
 	timerCard := widget.NewCard("Timer Controls", "", timerLayout)
 
 	ui.statusLabel = widget.NewLabel("No task active")
 	ui.statusLabel.Alignment = fyne.TextAlignCenter
-	statusCard := widget.NewCard("Current Status", "", container.NewCenter(ui.statusLabel))
+	ui.connectionLabel = widget.NewLabel("")
+	ui.connectionLabel.Alignment = fyne.TextAlignCenter
+	ui.setConnectionLabel(true)
+	statusCard := widget.NewCard("Current Status", "", container.NewVBox(container.NewCenter(container.NewHBox(container.NewCenter(ui.appearance.statusSwatch), ui.statusLabel)), ui.newServerStatusLabel(), ui.connectionLabel, ui.newSyncRow()))
 
 	ui.screenshotsBox = container.NewHBox()
 	scrollContainer := container.NewHScroll(ui.screenshotsBox)
 	scrollContainer.SetMinSize(fyne.NewSize(380, 120))
 
 	ui.openFolderButton = widget.NewButton("Open Screenshots Folder", ui.openScreenshotsFolder)
-	screenshotLayout := container.NewVBox(scrollContainer, ui.openFolderButton)
+	ui.captureNowButton = widget.NewButton("Capture Now", ui.captureNow)
+	ui.captureNowButton.Disable()
+	browseButton := widget.NewButton("Browse All...", ui.showScreenshotGallery)
+	screenshotLayout := container.NewVBox(scrollContainer,
+		container.NewGridWithColumns(2, ui.captureNowButton, ui.openFolderButton),
+		browseButton)
 	screenshotCard := widget.NewCard("Recent Screenshots", "", screenshotLayout)
 	ui.updateScreenshotsList()
 
@@ -134,6 +260,13 @@ func (ui *TaskWindowUI) setupUI() {
 		layout.NewSpacer(),
 	)
 	ui.Win.SetContent(content)
+	ui.addKeyboardShortcuts()
+}
+
+// refresh reloads the task list and checks the server's status
+func (ui *TaskWindowUI) refresh() {
+	ui.loadTasks()
+	ui.checkServerStatus()
 }
 
 // loadTasks fetches tasks (placeholder) and updates the dropdown
@@ -142,41 +275,182 @@ func (ui *TaskWindowUI) loadTasks() {
 	ui.refreshButton.Disable()
 	ui.taskSelect.PlaceHolder = "Refreshing..."
 	ui.taskSelect.Refresh()
+	loaded := len(ui.tasks) > 0
 
 	go func() {
+		defer logging.Recover("loadTasks", func() {
+			fyne.Do(func() {
+				ui.taskSelect.PlaceHolder = "Error loading tasks"
+				ui.enableTaskSelection()
+				ui.taskSelect.Refresh()
+			})
+		})
 		time.Sleep(500 * time.Millisecond)
-		tasks, err := ui.taskManager.GetTasks()
+		tasks, modified, err := ui.taskManager.GetTasksIfModified()
+		if err == nil && !modified && loaded {
+			// The server's list is unchanged, so the dropdown already shows it
+			fyne.Do(func() {
+				ui.setTaskOptions()
+				ui.enableTaskSelection()
+				log.Println("Tasks unchanged")
+			})
+			return
+		}
+		var projects []types.Project
+		if err == nil {
+			var projectsErr error
+			if projects, projectsErr = ui.taskManager.GetProjects(); projectsErr != nil {
+				log.Printf("Error loading projects, listing those of assigned tasks: %v", projectsErr)
+				projects = core.ProjectsFromTasks(tasks)
+			}
+			tasks = append(tasks, ui.loadLocalTasks()...)
+		}
 		fyne.Do(func() {
 			if err != nil {
 				log.Printf("Error loading tasks: %v", err)
 				ui.taskSelect.PlaceHolder = "Error loading tasks"
 				ui.taskSelect.Refresh()
+				ui.checkUnauthorized(err)
 				return
 			}
 			ui.tasks = tasks
-			taskDisplays := make([]string, len(ui.tasks))
-			for i, task := range ui.tasks {
-				taskDisplays[i] = fmt.Sprintf("%s (ID: %d, Project: %s)", task.Name, task.ID, task.Project.Name)
-			}
-
-			if len(taskDisplays) == 0 {
-				taskDisplays = []string{"No tasks found"}
-				ui.taskSelect.PlaceHolder = "No tasks found"
-			} else {
-				ui.taskSelect.PlaceHolder = "Select a task..."
-			}
-
-			ui.taskSelect.Options = taskDisplays
-			ui.taskSelect.ClearSelected()
+			ui.projects = projects
 			ui.selectedTask = nil
-			ui.taskSelect.Enable()
-			ui.refreshButton.Enable()
+			if !ui.keepTrackedTaskSelected() {
+				if settings := config.Current(); settings.RememberLastTask && settings.LastTaskID != 0 {
+					// Only pre-select; tracking is never started here
+					ui.selectTaskByID(settings.LastTaskID)
+				}
+			}
+			ui.setProjectOptions()
+			ui.setTaskOptions()
+			ui.refreshDailyGoal()
+			ui.enableTaskSelection()
 			ui.taskSelect.Refresh()
 			log.Println("Tasks refreshed")
+			if !ui.recoveryChecked {
+				ui.recoveryChecked = true
+				ui.checkInterruptedSession()
+			}
 		})
 	}()
 }
 
+// keepTrackedTaskSelected selects the task being tracked, reporting whether
+// the timer runs. The tracked task is locked while it does: a refresh keeps it
+// selected even if the server no longer lists it.
+func (ui *TaskWindowUI) keepTrackedTaskSelected() bool {
+	task := ui.session.Task()
+	if !ui.isTimerRunning || task == nil {
+		return false
+	}
+	if !ui.selectTaskByID(task.ID) {
+		log.Printf("Tracked task %d is no longer in the task list, keeping it selected", task.ID)
+		ui.selectedTask = task
+		ui.setTaskOptions()
+	}
+	return true
+}
+
+// enableTaskSelection re-enables choosing and refreshing tasks, unless the
+// timer runs and the tracked task is locked
+func (ui *TaskWindowUI) enableTaskSelection() {
+	if ui.isTimerRunning {
+		return
+	}
+	ui.taskSelect.Enable()
+	ui.refreshButton.Enable()
+}
+
+// selectTaskByID selects the loaded task with the given ID, reporting whether it exists
+func (ui *TaskWindowUI) selectTaskByID(id int) bool {
+	for i := range ui.tasks {
+		if ui.tasks[i].ID == id {
+			ui.selectedTask = &ui.tasks[i]
+			ui.setTaskOptions()
+			return true
+		}
+	}
+	return false
+}
+
+// rememberSelectedTask persists the selected task so it can be restored on the next launch
+func (ui *TaskWindowUI) rememberSelectedTask() {
+	if ui.selectedTask == nil || !config.Current().RememberLastTask {
+		return
+	}
+	id := ui.selectedTask.ID
+	if err := config.Update(func(s *config.Settings) { s.LastTaskID = id }); err != nil {
+		log.Printf("Failed to remember selected task: %v", err)
+	}
+}
+
+// setProjectOptions fills the project dropdown from the loaded projects
+func (ui *TaskWindowUI) setProjectOptions() {
+	ui.projectOptions = map[string]int{allProjectsOption: 0}
+	options := []string{allProjectsOption}
+	filterStillExists := false
+	for _, project := range ui.projects {
+		option := projectOption(project)
+		if _, seen := ui.projectOptions[option]; seen {
+			continue
+		}
+		ui.projectOptions[option] = project.ID
+		options = append(options, option)
+		if project.ID == ui.projectFilter {
+			filterStillExists = true
+		}
+	}
+	sort.Strings(options[1:])
+
+	ui.projectSelect.Options = options
+	if !filterStillExists {
+		ui.projectFilter = 0
+		ui.projectSelect.Selected = allProjectsOption
+	}
+	ui.projectSelect.Refresh()
+}
+
+// setTaskOptions fills the task dropdown with the tasks of the chosen project
+// (or all tasks). The selected task is kept even when it's filtered out, so
+// browsing other projects doesn't lose the selection.
+func (ui *TaskWindowUI) setTaskOptions() {
+	options, selectedDisplay, placeHolder := taskOptions(ui.tasks, ui.projectFilter, ui.selectedTask)
+	ui.taskSelect.PlaceHolder = placeHolder
+	ui.taskSelect.Options = append(options, newLocalTaskOption)
+	ui.taskSelect.Selected = selectedDisplay
+	ui.taskSelect.Refresh()
+	ui.refreshTaskAppearance()
+}
+
+// taskOptions returns the task dropdown's options for the tasks of project
+// (all tasks if 0), the option of selected ("" if it isn't among them, e.g.
+// a tracked task the server no longer lists) and the placeholder to show
+func taskOptions(tasks []types.Task, project int, selected *types.Task) (options []string, selectedDisplay, placeHolder string) {
+	displays := taskDisplays(tasks)
+	for i, task := range tasks {
+		if project != 0 && task.Project.ID != project {
+			continue
+		}
+		options = append(options, displays[i])
+		if selected != nil && selected.ID == task.ID {
+			selectedDisplay = displays[i]
+		}
+	}
+
+	switch {
+	case len(tasks) == 0:
+		placeHolder = "No tasks found"
+	case len(options) == 0:
+		placeHolder = "No tasks in this project"
+	case selected != nil && selectedDisplay == "":
+		placeHolder = "Selected: " + selected.Name
+	default:
+		placeHolder = "Select a task..."
+	}
+	return options, selectedDisplay, placeHolder
+}
+
 // startTimer handles the start button click
 func (ui *TaskWindowUI) startTimer() {
 	if ui.selectedTask == nil {
@@ -187,29 +461,69 @@ func (ui *TaskWindowUI) startTimer() {
 		return
 	}
 
+	if err := ui.startTracking(); err != nil {
+		dialog.ShowError(fmt.Errorf("failed to start tracking: %w", err), ui.Win)
+	}
+}
+
+// startTracking starts the timer and activity tracking for the selected task
+// and opens a work report for it. It must be called on the UI thread.
+func (ui *TaskWindowUI) startTracking() error {
+	if ui.selectedTask == nil {
+		return errors.New("no task selected")
+	}
+	startDescription := core.StartDescription(*ui.selectedTask)
+	return ui.beginTracking(func() error { return ui.session.OpenWorkReport(startDescription) })
+}
+
+// beginTracking starts the timer and activity tracking for the selected task.
+// openReport is then called in the background to set up the server work
+// report. It must be called on the UI thread.
+func (ui *TaskWindowUI) beginTracking(openReport func() error) error {
+	if err := ui.startBlocked(); err != nil {
+		return err
+	}
+
 	log.Printf("Starting timer and activity tracking for task: %s", ui.selectedTask.Name)
 
-	err := ui.activityTracker.StartTracking(ui.selectedTask.Name)
+	err := ui.session.Start(*ui.selectedTask)
 	if err != nil {
 		log.Printf("Error starting activity tracker: %v", err)
-		dialog.ShowError(fmt.Errorf("failed to start tracking: %w", err), ui.Win)
-		return
+		return err
 	}
 
 	ui.isTimerRunning = true
-	ui.elapsedTime = 0
+	ui.isPaused = false
+	ui.screenLocked = false
+	ui.lockPaused = false
+	ui.stopwatch.Start()
+	ui.resetPomodoro()
 	ui.ticker = time.NewTicker(1 * time.Second)
 	ui.stopTicker = make(chan bool)
-	ui.taskManager.SetActiveTask(*ui.selectedTask)
-	go ui.taskManager.UserStartTask(ui.selectedTask.Project.ID, *ui.selectedTask, "Started")
 	go func() {
+		defer logging.Recover("OpenWorkReport", nil)
+		if err := openReport(); err != nil {
+			log.Printf("Error opening work report: %v", err)
+			fyne.Do(func() { ui.checkUnauthorized(err) })
+			return
+		}
+		fyne.Do(ui.updateStatusLabel)
+	}()
+	// The loop uses its own ticker and stop channel, not the fields, which the
+	// next session replaces while this goroutine may still be exiting
+	ticker, stopTicker := ui.ticker, ui.stopTicker
+	go func() {
+		// A panic here would leave the timer frozen, so stop the session cleanly
+		defer logging.Recover("timer loop", func() {
+			ticker.Stop()
+			fyne.Do(func() { ui.stopTracking(false) })
+		})
 		for {
 			select {
-			case <-ui.ticker.C:
-				ui.elapsedTime += time.Second
-				ui.updateTimerDisplay()
-			case <-ui.stopTicker:
-				ui.ticker.Stop()
+			case <-ticker.C:
+				fyne.Do(ui.tick)
+			case <-stopTicker:
+				ticker.Stop()
 				log.Println("Timer stopped goroutine exiting.")
 				return
 			}
@@ -217,155 +531,232 @@ func (ui *TaskWindowUI) startTimer() {
 	}()
 
 	ui.updateUIForStart()
+	return nil
+}
+
+// startBlocked returns why a session can't start now, or nil if it can. While
+// the stop summary is open the stopped session's report is still the
+// TaskManager's, so starting would open a new one in its place.
+func (ui *TaskWindowUI) startBlocked() error {
+	if ui.isTimerRunning {
+		return fmt.Errorf("already tracking %s", ui.selectedTask.Name)
+	}
+	if ui.pendingClose != nil {
+		return errStopSummaryOpen
+	}
+	return nil
 }
 
 // stopTimer handles the stop button click
 func (ui *TaskWindowUI) stopTimer() {
+	if !ui.isTimerRunning || ui.sessionTooShort("stop") {
+		return
+	}
+	ui.stopTracking(config.Current().ShowStopSummary)
+}
+
+// stopTracking stops the timer and activity tracking. With showSummary the
+// work report is closed once the user has reviewed the session summary.
+func (ui *TaskWindowUI) stopTracking(showSummary bool) {
 	if !ui.isTimerRunning {
 		return
 	}
+	ui.endTracking(ui.session.Stop, showSummary)
+}
+
+// endTracking resets the timer after stopping the session with stop, then
+// closes the work report as stopTracking describes
+func (ui *TaskWindowUI) endTracking(stop func() error, showSummary bool) {
+	stopDescription := config.DefaultStopDescription
+	if ui.selectedTask != nil {
+		stopDescription = core.StopDescription(*ui.selectedTask)
+	}
 
 	// Prevent multiple stop actions.
 	ui.isTimerRunning = false
+	ui.isPaused = false
+	ui.screenLocked = false
+	ui.lockPaused = false
+	ui.stopwatch.Reset()
 
 	log.Println("Stopping timer and activity tracking")
 
-	err := ui.activityTracker.StopTracking()
+	err := stop()
 	if err != nil {
 		log.Printf("Error stopping activity tracker: %v", err)
 		dialog.ShowError(fmt.Errorf("failed to properly stop tracking session: %w", err), ui.Win)
 	}
-	go ui.taskManager.UserStopTask("Stopped")
+	ui.closingReports.Add(1)
+	var closeOnce sync.Once
+	closeReport := func(description string) {
+		closeOnce.Do(func() {
+			ui.pendingClose = nil
+			go func() {
+				defer ui.closingReports.Done()
+				defer logging.Recover("CloseWorkReport", nil)
+				if err := ui.session.CloseWorkReport(description); err != nil {
+					log.Printf("Error closing work report: %v", err)
+				}
+				ui.refreshSyncBadge()
+			}()
+		})
+	}
+	if summary := ui.activityTracker.LastSummary(); showSummary && err == nil && summary != nil {
+		ui.pendingClose = ui.showStopSummary(*summary, stopDescription, closeReport)
+	} else {
+		closeReport(stopDescription)
+	}
 
+	stopTicker := ui.stopTicker
 	go func() {
-		if ui.ticker != nil {
-			// Safely close ui.stopTicker to avoid double-close panics.
+		if stopTicker != nil {
+			// Safely close stopTicker to avoid double-close panics.
 			defer func() {
 				if r := recover(); r != nil {
 					log.Println("Recovered from closing ui.stopTicker:", r)
 				}
 			}()
-			close(ui.stopTicker)
+			close(stopTicker)
 		}
 		fyne.Do(func() {
 			ui.updateUIForStop()
 			ui.timerLabel.SetText("00:00:00")
 			ui.updateScreenshotsList()
+			// The stopped session is now saved in the database
+			ui.refreshDailyGoal()
 		})
 	}()
 }
 
-// updateTimerDisplay updates the timer label text
+// tick advances the timer by one second. It runs on the UI thread.
+func (ui *TaskWindowUI) tick() {
+	if !ui.isTimerRunning {
+		return
+	}
+	if ui.sessionTooLong() {
+		return
+	}
+	ui.updateTrayState()
+	if !ui.isPaused {
+		ui.updateTimerDisplay()
+		ui.updateDailyGoal()
+	}
+	ui.updateConcurrentTimers()
+	ui.checkPomodoro()
+}
+
+// updateTimerDisplay shows the stopwatch's elapsed time. It is recomputed from
+// the clock each time, so a missed tick only delays the display.
 func (ui *TaskWindowUI) updateTimerDisplay() {
-	hours := int(ui.elapsedTime.Hours())
-	minutes := int(ui.elapsedTime.Minutes()) % 60
-	seconds := int(ui.elapsedTime.Seconds()) % 60
-	fyne.Do(func() {
-		ui.timerLabel.SetText(fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds))
-	})
+	elapsed := ui.stopwatch.Elapsed()
+	hours := int(elapsed.Hours())
+	minutes := int(elapsed.Minutes()) % 60
+	seconds := int(elapsed.Seconds()) % 60
+	ui.timerLabel.SetText(fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds))
 }
 
 // updateUIForStart adjusts widget states when timer starts
 func (ui *TaskWindowUI) updateUIForStart() {
 	ui.startButton.Disable()
 	ui.stopButton.Enable()
+	ui.focusButton(ui.stopButton)
+	ui.startScreenshotRefresh()
+	ui.serverStatusLabel.Hide()
+	ui.startServerStatusChecks()
+	ui.switchButton.Enable()
+	ui.reassignButton.Enable()
+	ui.captureNowButton.Enable()
+	if config.Current().ConcurrentTasks {
+		ui.addTaskButton.Show()
+		ui.addTaskButton.Enable()
+	} else {
+		ui.addTaskButton.Hide()
+	}
+	ui.updateTrayState()
 	ui.taskSelect.Disable()
 	ui.refreshButton.Disable()
-	if ui.selectedTask != nil {
-		ui.statusLabel.SetText(fmt.Sprintf("Tracking: %s", ui.selectedTask.Name))
-	} else {
-		ui.statusLabel.SetText("Tracking: Unknown Task")
+	ui.updateStatusLabel()
+}
+
+// updateStatusLabel shows the tracked task and, once it has been created, the open work report
+func (ui *TaskWindowUI) updateStatusLabel() {
+	task := ui.session.Task()
+	if !ui.isTimerRunning || task == nil {
+		return
+	}
+	setSwatch(ui.appearance.statusSwatch, task)
+	status := fmt.Sprintf("Tracking: %s", taskTitle(*task))
+	switch {
+	case ui.lockPaused:
+		status = "Screen locked — paused — " + status
+	case ui.isPaused:
+		status = "On break — " + status
+	case ui.screenLocked:
+		status = "Screen locked — " + status
 	}
+	if report := ui.taskManager.GetWorkReport(); report != nil {
+		if report.StartTime != nil {
+			status += fmt.Sprintf("\nReport #%d since %s", report.ID, report.StartTime.In(config.DisplayLocation()).Format("15:04"))
+		} else {
+			status += fmt.Sprintf("\nReport #%d", report.ID)
+		}
+	}
+	if ui.pomodoroCheck.Checked {
+		status += fmt.Sprintf("\nPomodoros completed: %d", ui.pomodorosCompleted)
+	}
+	ui.statusLabel.SetText(status)
 }
 
 // updateUIForStop adjusts widget states when timer stops
 func (ui *TaskWindowUI) updateUIForStop() {
+	ui.updateTrayState()
 	ui.startButton.Enable()
 	ui.stopButton.Disable()
+	ui.focusButton(ui.startButton)
+	ui.stopScreenshotRefresh()
+	ui.serverStatusLabel.Hide()
+	ui.stopServerStatusChecks()
+	ui.switchButton.Disable()
+	ui.reassignButton.Disable()
+	ui.captureNowButton.Disable()
+	ui.addTaskButton.Disable()
+	ui.rebuildConcurrentTimers()
 	ui.taskSelect.Enable()
 	ui.refreshButton.Enable()
 	ui.statusLabel.SetText("No task active")
+	ui.appearance.statusSwatch.Hide()
 }
 
+// maxThumbnails is how many of the latest screenshots the window shows
+const maxThumbnails = 5
+
 // updateScreenshotsList loads recent screenshots and displays them
 func (ui *TaskWindowUI) updateScreenshotsList() {
 	ui.screenshotsBox.RemoveAll()
+	ui.thumbnailCount = 0
 
 	go func() {
-		files, err := os.ReadDir(ui.screenshotDir)
+		screenshots, err := core.ListScreenshots()
+		if err == nil {
+			ui.loadScreenshotDetails(screenshots)
+		}
 		fyne.Do(func() {
 			if err != nil {
 				log.Printf("Error reading screenshot dir: %v", err)
-				ui.screenshotsBox.Add(widget.NewLabel("Error loading screenshots"))
+				ui.screenshotsBox.Add(widget.NewLabel("Screenshots folder unavailable."))
 				ui.screenshotsBox.Refresh()
 				return
 			}
 
-			type fileInfo struct {
-				path    string
-				modTime time.Time
-			}
-			var screenshots []fileInfo
-
-			for _, file := range files {
-				if !file.IsDir() && strings.HasPrefix(file.Name(), "screenshot_") && strings.HasSuffix(file.Name(), ".png") {
-					info, err := file.Info()
-					if err == nil {
-						screenshots = append(screenshots, fileInfo{
-							path:    filepath.Join(ui.screenshotDir, file.Name()),
-							modTime: info.ModTime(),
-						})
-					}
-				}
-			}
-
-			sort.Slice(screenshots, func(i, j int) bool {
-				return screenshots[i].modTime.After(screenshots[j].modTime)
-			})
-
-			limit := 5
-			if len(screenshots) < limit {
-				limit = len(screenshots)
-			}
-
+			// A capture may have been added while the list was loading
+			ui.screenshotsBox.RemoveAll()
+			limit := min(len(screenshots), maxThumbnails)
+			ui.thumbnailCount = limit
 			if limit == 0 {
 				ui.screenshotsBox.Add(widget.NewLabel("No screenshots yet."))
 			} else {
 				for i := 0; i < limit; i++ {
-					ssPath := screenshots[i].path
-
-					timestampStr := "Unknown time"
-					nameOnly := strings.TrimSuffix(filepath.Base(ssPath), ".png")
-					parts := strings.Split(nameOnly, "_")
-					if len(parts) == 3 {
-						ts, err := time.Parse("20060102_150405", parts[1]+"_"+parts[2])
-						if err == nil {
-							timestampStr = ts.Format("Jan 02, 2006 03:04 PM")
-						}
-					}
-
-					img := canvas.NewImageFromFile(ssPath)
-					if img == nil {
-						log.Printf("Warning: Failed to load image %s", ssPath)
-						img = canvas.NewImageFromResource(theme.BrokenImageIcon())
-					}
-					img.FillMode = canvas.ImageFillContain
-					img.SetMinSize(fyne.NewSize(100, 100))
-
-					imgButton := widget.NewButton("", func() { ui.openScreenshotPreview(ssPath) })
-					imgButton.Importance = widget.LowImportance
-					clickableImage := container.NewStack(imgButton, img)
-
-					timestampLabel := widget.NewLabel(timestampStr)
-					timestampLabel.Wrapping = fyne.TextWrapOff
-					timestampLabel.Alignment = fyne.TextAlignCenter
-					timestampLabel.Importance = widget.LowImportance
-
-					screenshotItem := container.New(layout.NewVBoxLayout(),
-						clickableImage,
-						timestampLabel,
-					)
-					ui.screenshotsBox.Add(screenshotItem)
+					ui.screenshotsBox.Add(ui.newScreenshotThumbnail(screenshots[i]))
 				}
 			}
 
@@ -374,6 +765,113 @@ func (ui *TaskWindowUI) updateScreenshotsList() {
 	}()
 }
 
+// addScreenshotThumbnail shows a newly captured screenshot at the front of the
+// list without reloading the others, dropping the oldest past maxThumbnails
+func (ui *TaskWindowUI) addScreenshotThumbnail(path string) {
+	screenshot := core.ScreenshotFile{Path: path, Time: time.Now()}
+	if t, ok := core.ParseScreenshotTime(path); ok {
+		screenshot.Time = t
+	}
+	go func() {
+		defer logging.Recover("screenshot thumbnail", nil)
+		screenshots := []core.ScreenshotFile{screenshot}
+		ui.loadScreenshotDetails(screenshots)
+		fyne.Do(func() {
+			objects := prependThumbnail(ui.screenshotsBox.Objects, ui.thumbnailCount, ui.newScreenshotThumbnail(screenshots[0]))
+			ui.screenshotsBox.Objects = objects
+			ui.thumbnailCount = len(objects)
+			ui.screenshotsBox.Refresh()
+		})
+	}()
+}
+
+// prependThumbnail puts thumbnail in front of the count thumbnails in
+// objects, dropping the oldest past maxThumbnails. With no thumbnails,
+// objects holds a placeholder message, which is dropped.
+func prependThumbnail(objects []fyne.CanvasObject, count int, thumbnail fyne.CanvasObject) []fyne.CanvasObject {
+	if count == 0 {
+		objects = nil
+	}
+	objects = append([]fyne.CanvasObject{thumbnail}, objects...)
+	return objects[:min(len(objects), maxThumbnails)]
+}
+
+// newScreenshotThumbnail shows a screenshot and its capture time; clicking it opens the file
+func (ui *TaskWindowUI) newScreenshotThumbnail(screenshot core.ScreenshotFile) fyne.CanvasObject {
+	ssPath := screenshot.Path
+
+	img := canvas.NewImageFromFile(ssPath)
+	if img == nil {
+		log.Printf("Warning: Failed to load image %s", ssPath)
+		img = canvas.NewImageFromResource(theme.BrokenImageIcon())
+	}
+	img.FillMode = canvas.ImageFillContain
+	img.SetMinSize(fyne.NewSize(100, 100))
+
+	// The text sits under the image and names the button for keyboard users. It
+	// is kept short as it sets the thumbnail's width; the time label follows it.
+	imgButton := widget.NewButton("Open screenshot", func() { ui.openScreenshotPreview(ssPath) })
+	imgButton.Importance = widget.LowImportance
+	clickableImage := container.NewStack(imgButton, img)
+
+	label := screenshot.Time.In(config.DisplayLocation()).Format("Jan 02, 2006 03:04 PM")
+	if s := screenshot.Scale; s != nil && s.Factor != 1 {
+		// Captured on a HiDPI display
+		label += fmt.Sprintf(" @%gx", math.Round(s.Factor*100)/100)
+	}
+	timestampLabel := widget.NewLabel(label)
+	timestampLabel.Wrapping = fyne.TextWrapOff
+	timestampLabel.Alignment = fyne.TextAlignCenter
+	timestampLabel.Importance = widget.LowImportance
+
+	items := []fyne.CanvasObject{clickableImage, timestampLabel}
+	if screenshot.Note != "" {
+		// Marks the screenshot as annotated, with the start of the note
+		noteLabel := widget.NewLabelWithStyle("✎ "+screenshot.Note, fyne.TextAlignCenter, fyne.TextStyle{Italic: true})
+		noteLabel.Truncation = fyne.TextTruncateEllipsis
+		items = append(items, noteLabel)
+	}
+	if level := screenshot.ActivityLevel; level != nil {
+		activityBar := widget.NewProgressBar()
+		activityBar.TextFormatter = func() string { return fmt.Sprintf("Activity %d%%", *level) }
+		activityBar.SetValue(float64(*level) / 100)
+		items = append(items, activityBar)
+	}
+	return container.New(layout.NewVBoxLayout(), items...)
+}
+
+// loadScreenshotDetails adds the recorded display scaling, activity level and
+// note to screenshots. Failures are only logged since these are informational.
+func (ui *TaskWindowUI) loadScreenshotDetails(screenshots []core.ScreenshotFile) {
+	db := ui.activityTracker.Database
+	err := db.Connect()
+	if err == nil {
+		err = db.LoadScreenshotDetails(screenshots)
+	}
+	if err != nil {
+		log.Printf("Error loading screenshot details: %v", err)
+	}
+}
+
+// captureNow takes a screenshot outside the random schedule and refreshes the list
+func (ui *TaskWindowUI) captureNow() {
+	ui.captureNowButton.Disable()
+	go func() {
+		defer logging.Recover("capture now", nil)
+		_, err := ui.activityTracker.ScreenshotManager.CaptureNow()
+		fyne.Do(func() {
+			if ui.isTimerRunning {
+				ui.captureNowButton.Enable()
+			}
+			if err != nil {
+				log.Printf("Capture now failed: %v", err)
+				dialog.ShowError(fmt.Errorf("could not capture screenshot: %w", err), ui.Win)
+			}
+			// On success the screenshot list is refreshed by the capture event
+		})
+	}()
+}
+
 // openScreenshotPreview opens a specific screenshot file
 func (ui *TaskWindowUI) openScreenshotPreview(path string) {
 	go func() {
@@ -397,7 +895,15 @@ func (ui *TaskWindowUI) openScreenshotPreview(path string) {
 // openScreenshotsFolder opens the directory containing screenshots
 func (ui *TaskWindowUI) openScreenshotsFolder() {
 	go func() {
-		uri := storage.NewFileURI(ui.screenshotDir)
+		screenshotDir, err := config.ScreenshotDir()
+		if err != nil {
+			fyne.Do(func() {
+				log.Printf("Failed to get screenshot folder: %v", err)
+				dialog.ShowError(fmt.Errorf("could not locate screenshot folder: %w", err), ui.Win)
+			})
+			return
+		}
+		uri := storage.NewFileURI(screenshotDir)
 		parsedURL, err := url.Parse(uri.String())
 		fyne.Do(func() {
 			if err != nil {
@@ -407,7 +913,7 @@ func (ui *TaskWindowUI) openScreenshotsFolder() {
 			}
 			err = ui.App.OpenURL(parsedURL)
 			if err != nil {
-				log.Printf("Failed to open screenshot folder %s: %v", ui.screenshotDir, err)
+				log.Printf("Failed to open screenshot folder %s: %v", screenshotDir, err)
 				dialog.ShowError(fmt.Errorf("could not open file explorer: %w", err), ui.Win)
 			}
 		})
@@ -422,23 +928,246 @@ func (ui *TaskWindowUI) setupSystemTray() {
 			ui.Win.RequestFocus()
 		})
 
-		menu := fyne.NewMenu("Time Tracker", showMenuItem)
+		settingsMenuItem := fyne.NewMenuItem("Settings", func() {
+			NewSettingsWindow(ui.App, ui.switchProfile).Show()
+		})
+
+		exportMenuItem := fyne.NewMenuItem("Export Activities...", func() {
+			ui.Win.Show()
+			ui.showExportDialog()
+		})
+
+		importMenuItem := fyne.NewMenuItem("Import Previous Version History...", func() {
+			ui.Win.Show()
+			ui.showLegacyImportDialog()
+		})
+
+		historyMenuItem := fyne.NewMenuItem("History...", func() {
+			ui.showHistoryWindow()
+		})
+
+		calendarMenuItem := fyne.NewMenuItem("Export to Calendar...", func() {
+			ui.Win.Show()
+			ui.showICSExportDialog()
+		})
+
+		verifyMenuItem := fyne.NewMenuItem("Verify Activity Log...", func() {
+			ui.Win.Show()
+			ui.showVerifyLogDialog()
+		})
+
+		aboutMenuItem := fyne.NewMenuItem("About", func() {
+			ui.Win.Show()
+			ui.showAboutDialog()
+		})
+
+		logoutMenuItem := fyne.NewMenuItem("Log Out", func() {
+			ui.Win.Show()
+			ui.logout()
+		})
+
+		syncMenuItem := fyne.NewMenuItem("Sync Now", func() {
+			ui.Win.Show()
+			ui.syncNow()
+		})
+
+		ui.connectionItem = fyne.NewMenuItem(connectionText(true), nil)
+		ui.connectionItem.Disabled = true
+
+		menu := fyne.NewMenu("Time Tracker", showMenuItem, historyMenuItem, exportMenuItem, calendarMenuItem, importMenuItem, verifyMenuItem, settingsMenuItem,
+			fyne.NewMenuItemSeparator(), ui.connectionItem, syncMenuItem, aboutMenuItem, logoutMenuItem)
 		desk.SetSystemTrayMenu(menu)
+		ui.trayMenu = menu
 
-		iconResource := assets.GetClockResource()
-		if iconResource == nil {
-			log.Printf("Error loading system tray icon from embedded resources")
-		} else {
-			desk.SetSystemTrayIcon(iconResource)
-		}
+		ui.updateTrayState()
 	} else {
 		log.Println("System tray not supported on this platform.")
 	}
 }
 
-// Run starts the Fyne application event loop
-func (ui *TaskWindowUI) Run() {
-	ui.Win.Show()
-	ui.App.Run()
-	log.Println("Application finished.")
+// updateTrayState shows the tray icon for the tracking state: idle while
+// paused, locked or without input for the idle threshold. It runs on the UI
+// thread.
+func (ui *TaskWindowUI) updateTrayState() {
+	state := assets.TrayStateStopped
+	switch {
+	case !ui.isTimerRunning:
+	case ui.isPaused || ui.screenLocked || ui.activityTracker.Idle():
+		state = assets.TrayStateIdle
+	default:
+		state = assets.TrayStateRunning
+	}
+	if state != ui.trayState {
+		ui.trayState = state
+		ui.setTrayState(state)
+	}
+}
+
+// setTrayState shows the tray icon variant for state
+func (ui *TaskWindowUI) setTrayState(state assets.TrayState) {
+	desk, ok := ui.App.(desktop.App)
+	if !ok {
+		return
+	}
+	iconResource := assets.GetStateIcon(state)
+	if iconResource == nil {
+		log.Printf("Error loading system tray icon from embedded resources")
+		return
+	}
+	desk.SetSystemTrayIcon(iconResource)
+}
+
+// watchTrackerEvents keeps the window in step with the activity tracker,
+// e.g. showing screenshots as the scheduler takes them
+func (ui *TaskWindowUI) watchTrackerEvents() {
+	events, unsubscribe := ui.activityTracker.Subscribe()
+	ui.unsubscribe = unsubscribe
+	go func() {
+		defer logging.Recover("tracker events", nil)
+		for ev := range events {
+			switch ev.Type {
+			case core.EventScreenshotCaptured:
+				ui.addScreenshotThumbnail(ev.ScreenshotPath)
+			case core.EventUploadConsentRequested:
+				fyne.Do(ui.showUploadConsentDialog)
+			case core.EventScreenLocked:
+				fyne.Do(func() { ui.onScreenLockChanged(true) })
+			case core.EventScreenUnlocked:
+				fyne.Do(func() { ui.onScreenLockChanged(false) })
+			case core.EventLocalHistoryUnavailable:
+				err := ev.Err
+				fyne.Do(func() {
+					dialog.ShowInformation("Local History Unavailable",
+						fmt.Sprintf("Sessions can't be saved on this computer, so they won't appear in the history or exports. "+
+							"Tracking and screenshot uploads continue.\n\n%v", err), ui.Win)
+				})
+			}
+		}
+	}()
+}
+
+// setConnectionState shows whether the backend is reachable in the window and
+// the tray menu. It runs on the UI thread.
+func (ui *TaskWindowUI) setConnectionState(online bool) {
+	ui.setConnectionLabel(online)
+	if ui.connectionItem != nil {
+		ui.connectionItem.Label = connectionText(online)
+		ui.trayMenu.Refresh()
+	}
+}
+
+// onTokenChanged reconnects with a token written by a login elsewhere, e.g.
+// in another instance. It runs on the token watcher's goroutine.
+func (ui *TaskWindowUI) onTokenChanged(token string) {
+	if token == "" {
+		// The next request is rejected and checkUnauthorized logs out
+		log.Println("Token file removed")
+		return
+	}
+	log.Println("Token file changed, reconnecting with the new token")
+	ui.refreshServerPolicy()
+	ui.checkServerStatus()
+	fyne.Do(ui.loadTasks)
+}
+
+// refreshServerPolicy fetches and applies the server's mandated settings. It
+// blocks, so call it off the UI thread.
+func (ui *TaskWindowUI) refreshServerPolicy() {
+	defer logging.Recover("refreshServerPolicy", nil)
+	if err := ui.taskManager.RefreshServerPolicy(); err != nil {
+		log.Printf("Error refreshing server config, keeping the cached one: %v", err)
+	}
+}
+
+// setConnectionLabel updates the connection line in the status card
+func (ui *TaskWindowUI) setConnectionLabel(online bool) {
+	ui.connectionLabel.SetText(connectionText(online))
+	if online {
+		ui.connectionLabel.Importance = widget.SuccessImportance
+	} else {
+		ui.connectionLabel.Importance = widget.DangerImportance
+	}
+	ui.connectionLabel.Refresh()
+}
+
+// connectionText describes the backend state
+func connectionText(online bool) string {
+	if online {
+		return "● Server online"
+	}
+	return "● Server offline"
+}
+
+// logout asks for confirmation if a session is running, then hands over to onLogout
+func (ui *TaskWindowUI) logout() {
+	if ui.onLogout == nil {
+		return
+	}
+	if !ui.isTimerRunning {
+		ui.onLogout()
+		return
+	}
+	dialog.ShowConfirm("Log Out", "Stop tracking and log out?", func(confirmed bool) {
+		if confirmed {
+			ui.onLogout()
+		}
+	}, ui.Win)
+}
+
+// switchProfile reopens the app against another server profile, confirming
+// first if tracking would be stopped
+func (ui *TaskWindowUI) switchProfile(name string) {
+	if ui.onSwitchProfile == nil {
+		return
+	}
+	if !ui.isTimerRunning {
+		ui.onSwitchProfile(name)
+		return
+	}
+	dialog.ShowConfirm("Switch Server", "Stop tracking and switch to "+name+"?", func(confirmed bool) {
+		if confirmed {
+			ui.onSwitchProfile(name)
+		}
+	}, ui.Win)
+}
+
+// checkUnauthorized sends the user back to the login window when err shows
+// the server rejected the token. It must be called on the UI thread.
+func (ui *TaskWindowUI) checkUnauthorized(err error) {
+	if !errors.Is(err, services.ErrUnauthorized) || ui.onLogout == nil {
+		return
+	}
+	log.Println("Token rejected, returning to login")
+	ui.onLogout()
+}
+
+// Close stops any running session, the local API and background cleanup,
+// then calls done on the UI thread once pending work reports are closed.
+// The window itself is left for the caller to close.
+func (ui *TaskWindowUI) Close(done func()) {
+	saveWindowSize(ui.Win, config.WindowTask)
+	ui.stopTracking(false)
+	if ui.pendingClose != nil {
+		// The summary is still open; the report ends when tracking stopped
+		log.Println("Closing the stopped session's work report with the description typed so far")
+		ui.pendingClose()
+	}
+	if ui.localAPI != nil {
+		if err := ui.localAPI.Shutdown(); err != nil {
+			log.Printf("Failed to stop local API: %v", err)
+		}
+		ui.localAPI = nil
+	}
+	ui.activityTracker.ScreenshotManager.StopRetention()
+	ui.stopScreenshotRefresh()
+	ui.stopServerStatusChecks()
+	ui.connectivity.Stop()
+	if ui.stopTokenWatch != nil {
+		ui.stopTokenWatch()
+	}
+	ui.unsubscribe()
+	go func() {
+		ui.closingReports.Wait()
+		fyne.Do(done)
+	}()
 }
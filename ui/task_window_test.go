@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"github.com/time-tracker/v2/internal/config"
+	"github.com/time-tracker/v2/internal/types"
+)
+
+func TestPrependThumbnail(t *testing.T) {
+	thumbnails := make([]fyne.CanvasObject, maxThumbnails+1)
+	for i := range thumbnails {
+		thumbnails[i] = canvas.NewRectangle(nil)
+	}
+	placeholder := canvas.NewText("No screenshots yet", nil)
+	added := thumbnails[0]
+
+	tests := []struct {
+		name    string
+		objects []fyne.CanvasObject
+		count   int
+		want    []fyne.CanvasObject
+	}{
+		{"replaces the placeholder", []fyne.CanvasObject{placeholder}, 0, []fyne.CanvasObject{added}},
+		{"in front of the others", thumbnails[1:3], 2, thumbnails[:3]},
+		{"oldest dropped when full", thumbnails[1:], maxThumbnails, thumbnails[:maxThumbnails]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := slices.Clone(tt.objects)
+			got := prependThumbnail(objects, tt.count, added)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("prependThumbnail() = %d objects, want %d in order", len(got), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestTaskOptions(t *testing.T) {
+	updateSettings(t, func(s *config.Settings) { s.TaskDisplayFormat = "{name}" })
+	docs := types.Task{ID: 7, Name: "Docs", Project: types.Project{ID: 1}}
+	tests := types.Task{ID: 8, Name: "Tests", Project: types.Project{ID: 1}}
+	logo := types.Task{ID: 9, Name: "Logo", Project: types.Project{ID: 2}}
+	all := []types.Task{docs, tests, logo}
+
+	cases := []struct {
+		name            string
+		tasks           []types.Task
+		project         int
+		selected        *types.Task
+		wantOptions     []string
+		wantSelected    string
+		wantPlaceHolder string
+	}{
+		{"nothing loaded", nil, 0, nil, nil, "", "No tasks found"},
+		{"all projects", all, 0, nil, []string{"Docs", "Tests", "Logo"}, "", "Select a task..."},
+		{"one project", all, 2, nil, []string{"Logo"}, "", "Select a task..."},
+		{"empty project", all, 3, nil, nil, "", "No tasks in this project"},
+		{"selected listed", all, 0, &tests, []string{"Docs", "Tests", "Logo"}, "Tests", "Select a task..."},
+		{"selected filtered out", all, 2, &docs, []string{"Logo"}, "", "Selected: Docs"},
+		{"tracked task no longer listed", []types.Task{tests, logo}, 0, &docs, []string{"Tests", "Logo"}, "", "Selected: Docs"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			options, selected, placeHolder := taskOptions(tt.tasks, tt.project, tt.selected)
+			if !slices.Equal(options, tt.wantOptions) {
+				t.Errorf("options = %q, want %q", options, tt.wantOptions)
+			}
+			if selected != tt.wantSelected {
+				t.Errorf("selected = %q, want %q", selected, tt.wantSelected)
+			}
+			if placeHolder != tt.wantPlaceHolder {
+				t.Errorf("placeholder = %q, want %q", placeHolder, tt.wantPlaceHolder)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2/dialog"
+	"github.com/time-tracker/v2/core"
+)
+
+// showUploadConsentDialog asks whether this session's screenshots may be
+// uploaded. Without an answer they are kept local after core.UploadConsentTimeout.
+func (ui *TaskWindowUI) showUploadConsentDialog() {
+	message := fmt.Sprintf("Upload screenshots for this session?\n\nDeclined screenshots are only saved on this computer.\n"+
+		"Without an answer within %s they are kept local.", core.UploadConsentTimeout)
+	consent := dialog.NewConfirm("Upload Screenshots", message, func(allowed bool) {
+		ui.activityTracker.ScreenshotManager.SetUploadConsent(allowed)
+	}, ui.Win)
+	consent.SetConfirmText("Upload")
+	consent.SetDismissText("Keep Local")
+	consent.Show()
+	ui.Win.RequestFocus()
+}
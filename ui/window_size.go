@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"log"
+
+	"fyne.io/fyne/v2"
+	"github.com/time-tracker/v2/internal/config"
+)
+
+// maxWindowDimension bounds restored sizes, so a size saved on a large
+// monitor can't leave a window unusably big after a monitor change. Fyne
+// doesn't expose window positions or screen bounds, so only the size is kept.
+const maxWindowDimension = 2560
+
+// restoreWindowSize resizes win to the size saved under name, or to fallback.
+// Saved sizes are clamped between fallback's minimum and maxWindowDimension.
+func restoreWindowSize(win fyne.Window, name string, fallback fyne.Size) {
+	size := fallback
+	if saved, ok := config.Current().WindowSizes[name]; ok {
+		size = fyne.NewSize(
+			min(max(saved.Width, fallback.Width/2), maxWindowDimension),
+			min(max(saved.Height, fallback.Height/2), maxWindowDimension))
+	}
+	win.Resize(size)
+}
+
+// saveWindowSize stores win's current size under name
+func saveWindowSize(win fyne.Window, name string) {
+	size := win.Canvas().Size()
+	if size.Width <= 0 || size.Height <= 0 {
+		return
+	}
+	if saved, ok := config.Current().WindowSizes[name]; ok && saved.Width == size.Width && saved.Height == size.Height {
+		return
+	}
+	err := config.Update(func(s *config.Settings) {
+		if s.WindowSizes == nil {
+			s.WindowSizes = map[string]config.WindowSize{}
+		}
+		s.WindowSizes[name] = config.WindowSize{Width: size.Width, Height: size.Height}
+	})
+	if err != nil {
+		log.Printf("Failed to save %s window size: %v", name, err)
+	}
+}